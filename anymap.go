@@ -0,0 +1,150 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Returns v, which must be a struct, as a generic map.
+//
+// Named fields and the entries of v.Overflow are merged into a single map, in
+// the same way that MarshalJSON merges them into a single JSON object. Nested
+// values are map[string]interface{}, []interface{}, string, bool, nil or
+// json.Number, so that numbers are not rounded on the way through.
+func ToAnyMap(v interface{}) (map[string]interface{}, error) {
+	data, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{})
+	if err := decodeUsingNumber(data, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Parses the generic map m into the struct pointed to by v.
+//
+// This behaves like UnmarshalJSON on the JSON encoding of m: keys matching
+// named fields are decoded into those fields, and everything else is put in
+// v.Overflow.
+func FromAnyMap(m map[string]interface{}, v interface{}) error {
+	return DecodeMap(m, v)
+}
+
+// Parses m, a document that has already been decoded by another library,
+// such as a Firestore or DynamoDB SDK or a configuration loader, into v with
+// the same overflow semantics as UnmarshalJSON, and with opts.
+//
+// Values in m are anything that encoding/json can marshal, such as
+// time.Time or []byte. Maps with interface{} keys, as produced by some YAML
+// libraries, are accepted too; their keys are formatted with fmt.Sprint.
+//
+// v is a pointer to a struct with an Overflow field, or a pointer to a
+// wrapper type with its own UnmarshalJSON method, in which case opts are
+// ignored.
+func DecodeMap(m map[string]interface{}, v interface{}, opts ...Option) error {
+	data, err := json.Marshal(normalizeMapKeys(m))
+	if err != nil {
+		return err
+	}
+
+	if u, ok := v.(json.Unmarshaler); ok {
+		return u.UnmarshalJSON(data)
+	}
+	return UnmarshalJSON(data, v, opts...)
+}
+
+// Returns v as a generic map, for handing to libraries that take pre-decoded
+// documents. This is the reverse of DecodeMap.
+//
+// Unlike ToAnyMap, numbers are int64 if they are integers that fit, and
+// float64 otherwise, as such libraries expect, and v may be a wrapper type
+// with its own MarshalJSON method.
+func EncodeMap(v interface{}) (map[string]interface{}, error) {
+	var data []byte
+	var err error
+	if m, ok := v.(json.Marshaler); ok {
+		data, err = m.MarshalJSON()
+	} else {
+		data, err = MarshalJSON(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]interface{})
+	if err := decodeUsingNumber(data, &m); err != nil {
+		return nil, err
+	}
+
+	converted, err := convertNumbers(m)
+	if err != nil {
+		return nil, err
+	}
+	return converted.(map[string]interface{}), nil
+}
+
+// Returns v with every map[interface{}]interface{} replaced by a
+// map[string]interface{}, which encoding/json can marshal.
+func normalizeMapKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			normalized[k] = normalizeMapKeys(value)
+		}
+		return normalized
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			normalized[fmt.Sprint(k)] = normalizeMapKeys(value)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, value := range v {
+			normalized[i] = normalizeMapKeys(value)
+		}
+		return normalized
+	}
+	return v
+}
+
+// Returns v with every json.Number replaced by an int64 or a float64.
+func convertNumbers(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			converted, err := convertNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = converted
+		}
+	case []interface{}:
+		for i, value := range v {
+			converted, err := convertNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+	case json.Number:
+		if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return n, nil
+		}
+		return strconv.ParseFloat(string(v), 64)
+	}
+	return v, nil
+}
+
+func decodeUsingNumber(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
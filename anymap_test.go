@@ -0,0 +1,121 @@
+package j2n
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToAnyMapMergesNamedAndOverflowFields(t *testing.T) {
+	p := PersonData{}
+	p.Name = "Bert"
+	p.Overflow = make(map[string]*json.RawMessage)
+
+	ageJSON := json.RawMessage(`99999999999999999999`)
+	p.Overflow["age"] = &ageJSON
+
+	m, err := ToAnyMap(&p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]interface{}{
+		"name": "Bert",
+		"age":  json.Number("99999999999999999999"),
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, m)
+	}
+}
+
+func TestFromAnyMapPutsUnknownKeysInOverflow(t *testing.T) {
+	m := map[string]interface{}{
+		"name": "Bert",
+		"pets": []interface{}{"Tiddles"},
+	}
+
+	p := PersonData{}
+	if err := FromAnyMap(m, &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+
+	expectedPets := `["Tiddles"]`
+	if string(*p.Overflow["pets"]) != expectedPets {
+		t.Fatalf("Expected '%s', got '%s'", expectedPets, *p.Overflow["pets"])
+	}
+
+	if _, ok := p.Overflow["name"]; ok {
+		t.Fatal("Expected 'name' to be absent from Overflow")
+	}
+}
+
+func TestDecodeMapAcceptsPreDecodedValues(t *testing.T) {
+	m := map[string]interface{}{
+		"name":    "Bert",
+		"created": time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		"labels":  map[interface{}]interface{}{"team": "a", 1: true},
+		"count":   int64(3),
+	}
+
+	p := PersonData{}
+	calls := 0
+	err := DecodeMap(m, &p, WithAfterUnmarshal(func(v interface{}) error {
+		calls++
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" || calls != 1 {
+		t.Fatalf("Expected 'Bert' and the options applied, got '%s' and %d calls", p.Name, calls)
+	}
+
+	expected := map[string]string{
+		"created": `"2024-05-01T12:00:00Z"`,
+		"labels":  `{"1":true,"team":"a"}`,
+		"count":   `3`,
+	}
+	for k, value := range expected {
+		if p.Overflow[k] == nil || string(*p.Overflow[k]) != value {
+			t.Fatalf("Expected %s '%s', got '%v'", k, value, p.Overflow[k])
+		}
+	}
+}
+
+func TestDecodeMapWithWrapper(t *testing.T) {
+	person := Person{}
+	if err := DecodeMap(map[string]interface{}{"name": "Bert", "age": 29}, &person); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if person.Name != "Bert" || person.Overflow["age"] == nil {
+		t.Fatalf("Expected 'Bert' with 'age' in Overflow, got '%+v'", person)
+	}
+}
+
+func TestEncodeMapConvertsNumbers(t *testing.T) {
+	person := Person{}
+	if err := json.Unmarshal([]byte(`{"name":"Bert","age":29,"height":1.8,"scores":[1,2.5]}`), &person); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	m, err := EncodeMap(person)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":   "Bert",
+		"age":    int64(29),
+		"height": 1.8,
+		"scores": []interface{}{int64(1), 2.5},
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, m)
+	}
+}
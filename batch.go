@@ -0,0 +1,278 @@
+package j2n
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// RecordErrorPolicy controls what RunBatch does when the function it runs
+// fails on a record, or the record cannot be decoded.
+type RecordErrorPolicy int
+
+const (
+	// AbortOnError stops at the first failing record. This is the default.
+	AbortOnError RecordErrorPolicy = iota
+
+	// SkipErrors leaves failing records out of the output, and counts them.
+	SkipErrors
+
+	// CollectErrors leaves failing records out of the output, and returns
+	// them all as RecordErrors at the end.
+	CollectErrors
+)
+
+// Batch configures RunBatch.
+type Batch struct {
+	// The number of records transformed at once. Zero means
+	// runtime.GOMAXPROCS(0). Records are read at most four times
+	// this many ahead of the oldest record not yet written, so that one
+	// slow record holds back the reader rather than filling memory with
+	// the results after it.
+	Concurrency int
+
+	OnError RecordErrorPolicy
+
+	// Progress, if not nil, is called every ProgressInterval records, which
+	// defaults to 10000, and once at the end. It is called from the
+	// goroutine that called RunBatch.
+	Progress         func(p Progress)
+	ProgressInterval int
+
+	// The offset in the original input at which the reader starts, when
+	// resuming from the Offset of an earlier run
+	Offset int64
+
+	// Options for decoding and encoding each record
+	Options []Option
+}
+
+// Progress reports how far RunBatch has got.
+type Progress struct {
+	Records int
+	Written int
+	Skipped int
+	Failed  int
+
+	// The offset in the input up to which every record has been processed,
+	// and its result written. A run that was interrupted can be resumed by
+	// seeking the input to this offset and setting Batch.Offset to it.
+	Offset int64
+}
+
+// RecordError is a record that RunBatch could not process.
+type RecordError struct {
+	// The number of the record in this run, counting from 0, and the offset
+	// in the input at which it starts
+	Record int
+	Offset int64
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("Record %d at offset %d: %s", e.Record, e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// RecordErrors are the failing records collected under CollectErrors, in
+// input order.
+type RecordErrors []*RecordError
+
+func (e RecordErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d records failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Returns the failures, so that errors.Is and errors.As can match each one.
+func (e RecordErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// The number of records per worker that may be read but not yet written
+const batchWindow = 4
+
+type batchJob struct {
+	index      int
+	raw        []byte
+	start, end int64
+}
+
+type batchResult struct {
+	batchJob
+	data []byte
+	err  error
+}
+
+// Runs fn over every record of the NDJSON stream r, like Transform, using
+// several goroutines, and writes the results to w as NDJSON in input order.
+// This is for reprocessing exports too large to be restarted from the
+// beginning after a failure.
+//
+// RunBatch returns the progress made, with an error if the run stopped, or
+// with RecordErrors if records failed under CollectErrors. A syntax error
+// in the input always stops the run, as the records after it cannot be
+// found.
+func RunBatch[T any](r io.Reader, w io.Writer, fn func(*T) error, batch Batch) (Progress, error) {
+	progress := Progress{Offset: batch.Offset}
+
+	records, err := newRecordReader(r)
+	if err != nil {
+		return progress, err
+	}
+	if records.array {
+		return progress, errors.New("RunBatch requires NDJSON input")
+	}
+
+	workers := batch.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	interval := batch.ProgressInterval
+	if interval <= 0 {
+		interval = 10000
+	}
+
+	jobs := make(chan batchJob, workers)
+	results := make(chan batchResult, workers)
+	done := make(chan struct{})
+
+	// A slot is taken for each record read, and given back once it has
+	// been handled in order
+	window := make(chan struct{}, workers*batchWindow)
+
+	// Set by the reader before it closes jobs, so it can be read once
+	// results is closed
+	var readErr error
+	go func() {
+		defer close(jobs)
+		start := batch.Offset
+		for i := 0; ; i++ {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+
+			raw, end, err := records.next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = &RecordError{Record: i, Offset: start, Err: err}
+				return
+			}
+
+			select {
+			case jobs <- batchJob{index: i, raw: raw, start: start, end: batch.Offset + end}:
+			case <-done:
+				return
+			}
+			start = batch.Offset + end
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				data, err := transformRecord(job.raw, fn, batch.Options)
+				select {
+				case results <- batchResult{batchJob: job, data: data, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results arrive in any order, and are handled in input order
+	output := newRecordWriter(w, false)
+	pending := make(map[int]batchResult)
+	next := 0
+	var collected RecordErrors
+	var failure error
+
+handling:
+	for result := range results {
+		pending[result.index] = result
+		for {
+			result, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+
+			progress.Records++
+			switch {
+			case errors.Is(result.err, ErrSkip):
+				progress.Skipped++
+			case result.err != nil:
+				progress.Failed++
+				recordErr := &RecordError{Record: result.index, Offset: result.start, Err: result.err}
+				switch batch.OnError {
+				case SkipErrors:
+				case CollectErrors:
+					collected = append(collected, recordErr)
+				default:
+					failure = recordErr
+					break handling
+				}
+			default:
+				if err := output.write(result.data); err != nil {
+					failure = err
+					break handling
+				}
+				progress.Written++
+			}
+			progress.Offset = result.end
+
+			if batch.Progress != nil && progress.Records%interval == 0 {
+				batch.Progress(progress)
+			}
+		}
+	}
+
+	// Stop the reader and the workers, and wait for them
+	close(done)
+	for range results {
+	}
+	if failure == nil {
+		failure = readErr
+	}
+
+	if batch.Progress != nil {
+		batch.Progress(progress)
+	}
+	if failure != nil {
+		return progress, failure
+	}
+	if len(collected) > 0 {
+		return progress, collected
+	}
+	return progress, nil
+}
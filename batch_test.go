@@ -0,0 +1,136 @@
+package j2n
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBatchKeepsOrder(t *testing.T) {
+	var input, expected strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&input, "{\"name\":\"n%d\"}\n", i)
+		fmt.Fprintf(&expected, "{\"name\":\"N%d\"}\n", i)
+	}
+
+	var output bytes.Buffer
+	var reports []Progress
+	progress, err := RunBatch(strings.NewReader(input.String()), &output, upperCaseNames, Batch{
+		Concurrency:      8,
+		Progress:         func(p Progress) { reports = append(reports, p) },
+		ProgressInterval: 50,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if output.String() != expected.String() {
+		t.Fatalf("Expected '%s', got '%s'", expected.String(), output.String())
+	}
+	if progress.Records != 200 || progress.Written != 200 || progress.Offset != int64(input.Len()-1) {
+		t.Fatalf("Expected 200 records up to offset %d, got %+v", input.Len()-1, progress)
+	}
+	if len(reports) != 5 || reports[0].Records != 50 {
+		t.Fatalf("Expected 5 progress reports, got %+v", reports)
+	}
+}
+
+func TestRunBatchErrorPolicies(t *testing.T) {
+	input := "{\"name\":\"bert\"}\n{\"name\":3}\n{\"name\":\"skip\"}\n{\"name\":\"ernie\"}\n"
+
+	var output bytes.Buffer
+	progress, err := RunBatch(strings.NewReader(input), &output, upperCaseNames, Batch{OnError: SkipErrors})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := "{\"name\":\"BERT\"}\n{\"name\":\"ERNIE\"}\n"
+	if output.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output.String())
+	}
+	if progress.Failed != 1 || progress.Skipped != 1 || progress.Written != 2 {
+		t.Fatalf("Expected 1 failed, 1 skipped and 2 written, got %+v", progress)
+	}
+
+	output.Reset()
+	_, err = RunBatch(strings.NewReader(input), &output, upperCaseNames, Batch{OnError: CollectErrors})
+	var collected RecordErrors
+	if !errors.As(err, &collected) || len(collected) != 1 || collected[0].Record != 1 || collected[0].Offset != 15 {
+		t.Fatalf("Expected record 1 at offset 15 to be collected, got '%v'", err)
+	}
+	if output.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output.String())
+	}
+
+	output.Reset()
+	progress, err = RunBatch(strings.NewReader(input), &output, upperCaseNames, Batch{Concurrency: 4})
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || recordErr.Record != 1 {
+		t.Fatalf("Expected the run to stop at record 1, got '%v'", err)
+	}
+	if output.String() != "{\"name\":\"BERT\"}\n" || progress.Offset != 15 {
+		t.Fatalf("Expected only record 0 to be written, got '%s' and %+v", output.String(), progress)
+	}
+}
+
+func TestRunBatchBoundsRecordsAhead(t *testing.T) {
+	var input strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&input, "{\"name\":\"n%d\"}\n", i)
+	}
+
+	// The first record is held up until the others have had time to run
+	release := make(chan struct{})
+	var started atomic.Int32
+	hold := func(p *OverflowPersonData) error {
+		started.Add(1)
+		if p.Name == "n0" {
+			<-release
+		}
+		return nil
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if n := started.Load(); n > 2*batchWindow {
+			t.Errorf("Expected at most %d records to start, got %d", 2*batchWindow, n)
+		}
+		close(release)
+	}()
+
+	progress, err := RunBatch(strings.NewReader(input.String()), &bytes.Buffer{}, hold, Batch{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if progress.Written != 100 {
+		t.Fatalf("Expected 100 records to be written, got %+v", progress)
+	}
+}
+
+func TestRunBatchResumes(t *testing.T) {
+	input := "{\"name\":\"bert\"}\n{\"name\":3}\n{\"name\":\"ernie\"}\n"
+
+	progress, err := RunBatch(strings.NewReader(input), &bytes.Buffer{}, upperCaseNames, Batch{})
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	// Resume after the failing record, as if it had been fixed by hand
+	resume := progress.Offset + int64(len("\n{\"name\":3}"))
+	var output bytes.Buffer
+	progress, err = RunBatch(strings.NewReader(input[resume:]), &output, upperCaseNames, Batch{Offset: resume})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if output.String() != "{\"name\":\"ERNIE\"}\n" || progress.Offset != int64(len(input)-1) {
+		t.Fatalf("Expected ERNIE up to offset %d, got '%s' and %+v", len(input)-1, output.String(), progress)
+	}
+}
+
+func TestRunBatchRejectsArrays(t *testing.T) {
+	_, err := RunBatch(strings.NewReader(`[{"name":"bert"}]`), &bytes.Buffer{}, upperCaseNames, Batch{})
+	if err == nil {
+		t.Fatalf("Expected an error for array input")
+	}
+}
@@ -0,0 +1,143 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Builder constructs a JSON object from a struct, raw fragments and ad-hoc
+// keys, following the rules of MarshalJSON: the named fields of the struct
+// are kept apart from everything else, which is treated as overflow. It is
+// created with Build, and its methods can be chained:
+//
+//	data, err := j2n.Build().
+//		SetNamed(cat).
+//		Set("x-trace", traceID).
+//		MergeRaw(extensions).
+//		Marshal()
+//
+// The first error stops the chain, and is returned by Marshal.
+type Builder struct {
+	opts     []Option
+	named    map[string]*json.RawMessage
+	overflow map[string]*json.RawMessage
+	err      error
+}
+
+// Returns an empty Builder. opts apply to the structs given to SetNamed, as
+// they would to MarshalJSON.
+func Build(opts ...Option) *Builder {
+	return &Builder{
+		opts:     opts,
+		named:    make(map[string]*json.RawMessage),
+		overflow: make(map[string]*json.RawMessage),
+	}
+}
+
+// Adds the named fields of v, a struct with an Overflow field, and the
+// entries of its Overflow. Named fields replace those of earlier calls.
+func (b *Builder) SetNamed(v interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	config := newConfig(v, b.opts)
+	named, err := config.encodeNamedFields(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	for k, raw := range named {
+		b.named[k] = raw
+	}
+
+	overflow, err := getOverflowMap(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	if overflow, err = config.encodeOverflow(overflow); err != nil {
+		b.err = err
+		return b
+	}
+	for k, raw := range overflow {
+		b.overflow[k] = raw
+	}
+	return b
+}
+
+// Sets the overflow key to the encoding of value, replacing any earlier
+// value.
+func (b *Builder) Set(key string, value interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		b.err = &OverflowError{Key: key, Err: err}
+		return b
+	}
+	b.overflow[key] = (*json.RawMessage)(&raw)
+	return b
+}
+
+// Sets the overflow key to a copy of raw, which must be valid JSON.
+func (b *Builder) SetRaw(key string, raw json.RawMessage) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	if !json.Valid(raw) {
+		b.err = fmt.Errorf("Invalid JSON for overflow key '%s'", printableKey(key))
+		return b
+	}
+	copied := append(json.RawMessage(nil), raw...)
+	b.overflow[key] = &copied
+	return b
+}
+
+// Adds the members of the JSON object raw as overflow, replacing any
+// earlier values for the same keys. A null raw adds nothing.
+func (b *Builder) MergeRaw(raw json.RawMessage) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	var members map[string]*json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		b.err = err
+		return b
+	}
+	for k, value := range members {
+		b.overflow[k] = value
+	}
+	return b
+}
+
+// Removes the overflow key, if it has been set.
+func (b *Builder) Delete(key string) *Builder {
+	delete(b.overflow, key)
+	return b
+}
+
+// Returns the encoding of the object, with its keys sorted. As with
+// MarshalJSON, it is an error for an overflow key to be that of a named
+// field.
+func (b *Builder) Marshal() ([]byte, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	result := make(map[string]*json.RawMessage, len(b.named)+len(b.overflow))
+	for k, raw := range b.named {
+		result[k] = raw
+	}
+	for _, k := range Overflow(b.overflow).sortedKeys() {
+		if _, ok := result[k]; ok {
+			return nil, fmt.Errorf("Named field present in overflow: '%s'", printableKey(k))
+		}
+		result[k] = b.overflow[k]
+	}
+	return json.Marshal(result)
+}
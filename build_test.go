@@ -0,0 +1,43 @@
+package j2n
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	person := OverflowPersonData{Name: "bert", Overflow: newTestOverflow(map[string]string{"age": "3"})}
+
+	data, err := Build().
+		SetNamed(person).
+		Set("x-trace", "abc").
+		MergeRaw(json.RawMessage(`{"age":4,"colour":"yellow"}`)).
+		SetRaw("tags", json.RawMessage(`["a"]`)).
+		Marshal()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"age":4,"colour":"yellow","name":"bert","tags":["a"],"x-trace":"abc"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+func TestBuildRejectsNamedFieldsInOverflow(t *testing.T) {
+	_, err := Build().SetNamed(OverflowPersonData{Name: "bert"}).Set("name", "ernie").Marshal()
+	expected := "Named field present in overflow: 'name'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestBuildKeepsFirstError(t *testing.T) {
+	_, err := Build().MergeRaw(json.RawMessage(`[1]`)).SetRaw("a", json.RawMessage(`{`)).Marshal()
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if _, ok := err.(*json.UnmarshalTypeError); !ok {
+		t.Fatalf("Expected the error from MergeRaw, got '%s'", err)
+	}
+}
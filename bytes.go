@@ -0,0 +1,99 @@
+package j2n
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The encodings of []byte fields that can be set in their j2n tag:
+//
+//	Signature []byte `json:"sig" j2n:"bytes=base64url"`
+//
+// base64 is the encoding/json default, and base64url uses the URL-safe
+// alphabet; both are written with padding, and read with or without it.
+// base64raw and base64rawurl are written without padding. hex is written in
+// lower case, and read in either case. raw holds a JSON value as it is, like
+// json.RawMessage.
+var bytesEncodings = map[string]bool{
+	"base64":       true,
+	"base64url":    true,
+	"base64raw":    true,
+	"base64rawurl": true,
+	"hex":          true,
+	"raw":          true,
+}
+
+type bytesCodec struct {
+	encoding string
+}
+
+func newBytesCodec(t reflect.Type, encoding string) (fieldCodec, error) {
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return nil, fmt.Errorf("bytes requires a []byte field, not %s", t)
+	}
+	if !bytesEncodings[encoding] {
+		return nil, fmt.Errorf("Unknown bytes encoding '%s'", encoding)
+	}
+	return bytesCodec{encoding: encoding}, nil
+}
+
+func (b bytesCodec) decode(raw json.RawMessage, value reflect.Value) error {
+	if b.encoding == "raw" {
+		value.SetBytes(append([]byte(nil), raw...))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return fmt.Errorf("Expected a %s string", b.encoding)
+	}
+
+	var decoded []byte
+	var err error
+	switch b.encoding {
+	case "hex":
+		decoded, err = hex.DecodeString(s)
+	case "base64url", "base64rawurl":
+		decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	default:
+		decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %s", b.encoding, err)
+	}
+	value.SetBytes(decoded)
+	return nil
+}
+
+func (b bytesCodec) encode(value reflect.Value) ([]byte, error) {
+	data := value.Bytes()
+	if data == nil {
+		return []byte("null"), nil
+	}
+	if b.encoding == "raw" {
+		if !json.Valid(data) {
+			return nil, errors.New("Invalid JSON in raw bytes")
+		}
+		return data, nil
+	}
+
+	var s string
+	switch b.encoding {
+	case "hex":
+		s = hex.EncodeToString(data)
+	case "base64url":
+		s = base64.URLEncoding.EncodeToString(data)
+	case "base64raw":
+		s = base64.RawStdEncoding.EncodeToString(data)
+	case "base64rawurl":
+		s = base64.RawURLEncoding.EncodeToString(data)
+	default:
+		s = base64.StdEncoding.EncodeToString(data)
+	}
+	return json.Marshal(s)
+}
@@ -0,0 +1,59 @@
+package j2n
+
+import (
+	"testing"
+)
+
+type BytesData struct {
+	Std      []byte   `json:"std"`
+	URL      []byte   `json:"url" j2n:"bytes=base64url"`
+	RawURL   []byte   `json:"rawURL" j2n:"bytes=base64rawurl"`
+	Hex      []byte   `json:"hex,omitempty" j2n:"bytes=hex"`
+	Raw      []byte   `json:"raw" j2n:"bytes=raw"`
+	Ptr      *[]byte  `json:"ptr" j2n:"bytes=hex"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestBytesEncodings(t *testing.T) {
+	input := `{"std":"+/8=","url":"-_8","rawURL":"-_8=","hex":"FF00","raw":{"a": [1]},"ptr":"0a","other":1}`
+
+	data := BytesData{}
+	if err := UnmarshalJSON([]byte(input), &data); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(data.Std) != "\xfb\xff" || string(data.URL) != "\xfb\xff" || string(data.RawURL) != "\xfb\xff" {
+		t.Fatalf("Expected base64 variants to decode, got %v, %v and %v", data.Std, data.URL, data.RawURL)
+	}
+	if string(data.Hex) != "\xff\x00" || string(data.Raw) != `{"a": [1]}` || data.Ptr == nil || string(*data.Ptr) != "\n" {
+		t.Fatalf("Expected hex, raw and pointer fields to decode, got %+v", data)
+	}
+	if len(data.Overflow) != 1 {
+		t.Fatalf("Expected only 'other' in overflow, got %v", data.Overflow)
+	}
+
+	output, err := MarshalJSON(&data)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"hex":"ff00","other":1,"ptr":"0a","raw":{"a":[1]},"rawURL":"-_8","std":"+/8=","url":"-_8="}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestBytesEncodingErrors(t *testing.T) {
+	data := BytesData{}
+	err := UnmarshalJSON([]byte(`{"hex":"xyz"}`), &data)
+	if err == nil || err.Error() != "Cannot decode field 'hex': Invalid hex: encoding/hex: invalid byte: U+0078 'x'" {
+		t.Fatalf("Expected an invalid hex error, got '%v'", err)
+	}
+
+	type BadTagData struct {
+		Name     string   `json:"name" j2n:"bytes=hex"`
+		Overflow Overflow `json:"-"`
+	}
+	err = UnmarshalJSON([]byte(`{}`), &BadTagData{})
+	if err == nil || err.Error() != "Invalid j2n tag on field 'name': bytes requires a []byte field, not string" {
+		t.Fatalf("Expected an invalid tag error, got '%v'", err)
+	}
+}
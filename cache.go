@@ -0,0 +1,132 @@
+package j2n
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// MarshalCache memoizes the result of MarshalJSON for a wrapper type that is
+// serialized far more often than it changes. The wrapper holds a pointer to
+// it, so that its MarshalJSON method keeps the value receiver that j2n
+// wrappers need, and UnmarshalJSON allocates it:
+//
+//	type Cat struct {
+//		CatData
+//		cache *j2n.MarshalCache
+//	}
+//
+//	func (c Cat) MarshalJSON() ([]byte, error) {
+//		return c.cache.Marshal(c.CatData)
+//	}
+//
+//	func (c *Cat) UnmarshalJSON(data []byte) error {
+//		if c.cache == nil {
+//			c.cache = new(j2n.MarshalCache)
+//		}
+//		return j2n.UnmarshalJSON(data, &c.CatData)
+//	}
+//
+// The cache tracks changes itself: it keeps a deep copy of the value it
+// encoded, and encodes again once the value differs from it, as compared by
+// reflect.DeepEqual, whether a named field or an Overflow entry changed.
+// Comparing is much cheaper than encoding, but not free, so the cache only
+// pays for values that are encoded many times between changes. Values
+// reached through unexported pointers are not copied, so changes made
+// through them are only seen after Invalidate.
+//
+// A nil *MarshalCache encodes every time, so wrappers made without
+// UnmarshalJSON still encode correctly. Copies of a wrapper share its
+// cache, which stays correct as they diverge. It is safe for concurrent
+// use.
+type MarshalCache struct {
+	current atomic.Pointer[cachedEncoding]
+}
+
+type cachedEncoding struct {
+	snapshot interface{}
+	data     []byte
+}
+
+// Returns the cached encoding if v is unchanged since it was made, or else
+// the result of MarshalJSON(v), which is cached if it succeeds. The result
+// is shared between callers and must not be modified.
+func (c *MarshalCache) Marshal(v interface{}) ([]byte, error) {
+	if c == nil {
+		return MarshalJSON(v)
+	}
+	if cached := c.current.Load(); cached != nil && reflect.DeepEqual(cached.snapshot, v) {
+		return cached.data, nil
+	}
+
+	data, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// encoding/json has rejected cycles, so the copy terminates
+	c.current.Store(&cachedEncoding{snapshot: snapshotValue(reflect.ValueOf(v)).Interface(), data: data})
+	return data, nil
+}
+
+// Discards the cached encoding, so that the next call to Marshal encodes
+// the value again.
+func (c *MarshalCache) Invalidate() {
+	if c != nil {
+		c.current.Store(nil)
+	}
+}
+
+// Returns a deep copy of v that shares no exported memory with it.
+// Unexported fields are copied as they are.
+func snapshotValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(snapshotValue(v.Elem()))
+		return copied
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(snapshotValue(v.Elem()))
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			copied.SetMapIndex(iter.Key(), snapshotValue(iter.Value()))
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(snapshotValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(snapshotValue(v.Index(i)))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(snapshotValue(v.Field(i)))
+			}
+		}
+		return copied
+	}
+	return v
+}
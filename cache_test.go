@@ -0,0 +1,107 @@
+package j2n
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type CachedPerson struct {
+	PersonData
+	cache *MarshalCache
+}
+
+func (p CachedPerson) MarshalJSON() ([]byte, error) {
+	return p.cache.Marshal(p.PersonData)
+}
+
+func (p *CachedPerson) UnmarshalJSON(data []byte) error {
+	if p.cache == nil {
+		p.cache = new(MarshalCache)
+	}
+	return UnmarshalJSON(data, &p.PersonData)
+}
+
+func TestMarshalCacheReusesEncodingUntilChanged(t *testing.T) {
+	p := CachedPerson{}
+	if err := json.Unmarshal([]byte(`{"name":"Bert","age":29}`), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// Marshaled by value, as the wrapper usually is
+	first, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"age":29,"name":"Bert"}`
+	if string(first) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, first)
+	}
+
+	cached, err := p.cache.Marshal(p.PersonData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	again, _ := p.cache.Marshal(p.PersonData)
+	if &cached[0] != &again[0] {
+		t.Fatalf("Expected the cached encoding to be reused")
+	}
+
+	// Changes to named fields and to Overflow entries are seen without
+	// Invalidate
+	p.Name = "Ernie"
+	changed, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"age":29,"name":"Ernie"}`
+	if string(changed) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, changed)
+	}
+
+	*p.Overflow["age"] = json.RawMessage(`30`)
+	changed, err = json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"age":30,"name":"Ernie"}`
+	if string(changed) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, changed)
+	}
+}
+
+func TestMarshalCacheNilEncodesEveryTime(t *testing.T) {
+	age := json.RawMessage(`29`)
+	p := CachedPerson{PersonData: PersonData{Name: "Bert", Overflow: map[string]*json.RawMessage{"age": &age}}}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"age":29,"name":"Bert"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+
+	var c *MarshalCache
+	c.Invalidate()
+}
+
+func TestMarshalCacheDoesNotCacheErrors(t *testing.T) {
+	c := MarshalCache{}
+	if _, err := c.Marshal(3); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	data, err := c.Marshal(PersonData{Name: "Bert"})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(data) != `{"name":"Bert"}` {
+		t.Fatalf("Expected '%s', got '%s'", `{"name":"Bert"}`, data)
+	}
+
+	c.Invalidate()
+	if c.current.Load() != nil {
+		t.Fatalf("Expected Invalidate to discard the encoding")
+	}
+}
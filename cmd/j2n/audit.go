@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ygt/j2n"
+	"github.com/ygt/j2n/j2ndrift"
+	"github.com/ygt/j2n/j2nyaml"
+)
+
+func runAudit(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("j2n audit", flag.ContinueOnError)
+	schemaPath := flags.String("schema", "", "JSON Schema describing the type, as JSON or YAML")
+	ref := flags.String("ref", "", "JSON Pointer to the schema within the file, such as #/components/schemas/Pet")
+	name := flags.String("name", "", "type name for the report (default the schema file name)")
+	format := flags.String("format", "text", "report format, text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *schemaPath == "" {
+		flags.Usage()
+		return fmt.Errorf("-schema is required")
+	}
+
+	keys, err := schemaKeys(*schemaPath, *ref)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(*schemaPath), filepath.Ext(*schemaPath))
+	}
+
+	detector := j2ndrift.NewDetector()
+	structType := auditType(keys)
+
+	audit := func(r io.Reader, source string) error {
+		decoder := json.NewDecoder(r)
+		for i := 1; ; i++ {
+			var document json.RawMessage
+			if err := decoder.Decode(&document); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("%s: document %d: %s", source, i, err)
+			}
+
+			v := reflect.New(structType).Interface()
+			if err := j2n.UnmarshalJSON(document, v); err != nil {
+				return fmt.Errorf("%s: document %d: %s", source, i, err)
+			}
+			detector.ObserveAs(*name, v)
+		}
+	}
+
+	if flags.NArg() == 0 {
+		if err := audit(stdin, "stdin"); err != nil {
+			return err
+		}
+	}
+	for _, path := range flags.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = audit(f, path)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	report := detector.Report()
+	if report[*name] == nil {
+		report[*name] = &j2ndrift.TypeReport{Keys: map[string]*j2ndrift.KeyReport{}}
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "text":
+		return writeAuditText(stdout, report, *name)
+	}
+	return fmt.Errorf("Unknown format '%s'", *format)
+}
+
+// Returns the top-level property names of the schema at ref in the file.
+func schemaKeys(path, ref string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		if data, err = j2nyaml.ToJSON(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var node interface{}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("Invalid schema: %s", err)
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, _ := node.(map[string]interface{})
+		if node = object[token]; node == nil {
+			return nil, fmt.Errorf("Reference '%s' not found", ref)
+		}
+	}
+
+	schema, _ := node.(map[string]interface{})
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Schema has no properties")
+	}
+
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Returns a struct type with a json.RawMessage field for each key, and an
+// Overflow field, built at run time for j2n to parse into.
+func auditType(keys []string) reflect.Type {
+	rawType := reflect.TypeOf(json.RawMessage(nil))
+	fields := make([]reflect.StructField, 0, len(keys)+1)
+
+	for i, k := range keys {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: rawType,
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:%q`, k+",omitempty")),
+		})
+	}
+	fields = append(fields, reflect.StructField{
+		Name: "Overflow",
+		Type: reflect.TypeOf(map[string]*json.RawMessage(nil)),
+		Tag:  `json:"-"`,
+	})
+
+	return reflect.StructOf(fields)
+}
+
+func writeAuditText(w io.Writer, report j2ndrift.Report, name string) error {
+	typeReport := report[name]
+	fmt.Fprintf(w, "%d documents, %d unknown keys\n", typeReport.Documents, len(typeReport.Keys))
+	if len(typeReport.Keys) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nKEY\tCOUNT\tPERCENT\tEXAMPLES")
+	for _, k := range report.Candidates(name) {
+		key := typeReport.Keys[k]
+		examples := make([]string, len(key.Examples))
+		for i, example := range key.Examples {
+			var compact bytes.Buffer
+			if json.Compact(&compact, example) != nil {
+				compact.Write(example)
+			}
+			examples[i] = compact.String()
+		}
+		percent := 100 * float64(key.Count) / float64(typeReport.Documents)
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%s\n", k, key.Count, percent, strings.Join(examples, ", "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestSchema(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "pet.json")
+	schema := `{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"}}}`
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return path
+}
+
+const testCorpus = `{"name":"Rex","age":3,"colour":"brown"}
+{"name":"Tom","colour":"grey","microchip":"123"}
+{"name":"Kit","age":1}
+`
+
+func TestAuditReportsUnknownKeysAsText(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{"audit", "-schema", writeTestSchema(t)}, strings.NewReader(testCorpus), &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `3 documents, 2 unknown keys
+
+KEY        COUNT  PERCENT  EXAMPLES
+colour     2      66.7%    "brown", "grey"
+microchip  1      33.3%    "123"
+`
+	if out.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out.String())
+	}
+}
+
+func TestAuditReportsUnknownKeysAsJSON(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{"audit", "-schema", writeTestSchema(t), "-name", "Pet", "-format", "json"}, strings.NewReader(testCorpus), &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var report map[string]struct {
+		Documents int `json:"documents"`
+		Keys      map[string]struct {
+			Count int `json:"count"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	pet := report["Pet"]
+	if pet.Documents != 3 || pet.Keys["colour"].Count != 2 || len(pet.Keys) != 2 {
+		t.Fatalf("Expected report for 'Pet', got '%s'", out.String())
+	}
+}
+
+func TestAuditReturnsErrorOnInvalidDocument(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{"audit", "-schema", writeTestSchema(t)}, strings.NewReader(`{"name":"Rex"} {`), &out)
+	if err == nil || !strings.Contains(err.Error(), "document 2") {
+		t.Fatalf("Expected error for document 2, got '%v'", err)
+	}
+}
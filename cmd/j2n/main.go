@@ -0,0 +1,52 @@
+// Command j2n works with JSON documents from the command line, using the
+// j2n packages, for those who would rather not write Go.
+//
+// Usage:
+//
+//	j2n audit -schema pet.schema.json [-ref pointer] [-name Pet] [-format text|json] [files...]
+//	j2n merge [-arrays replace|append|index] [-key field] [-indent] base.json overlay.json...
+//
+// The audit subcommand streams a corpus of JSON documents through the type
+// described by a JSON Schema, such as one written by j2n.Schema or an
+// OpenAPI component, and reports the top-level keys that the type does not
+// name: how many documents contained each, when it was first seen, and up to
+// three example values. Each file may hold several documents, one after
+// another, as in JSON Lines. With no files, documents are read from standard
+// input.
+//
+// Go programs can produce the same report for their own types with the
+// j2ndrift package.
+//
+// The merge subcommand deep merges each overlay into the base document in
+// turn, with j2n.MergeJSON, and writes the result to standard output. Every
+// key is kept, whether or not any schema knows about it, and a null in an
+// overlay removes a key. Arrays are replaced by default; -arrays chooses
+// another strategy, and -key merges arrays of objects by the given field.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "j2n: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Expected a subcommand: audit or merge")
+	}
+
+	switch args[0] {
+	case "audit":
+		return runAudit(args[1:], stdin, stdout)
+	case "merge":
+		return runMerge(args[1:], stdout)
+	}
+	return fmt.Errorf("Unknown subcommand '%s'", args[0])
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ygt/j2n"
+)
+
+var arrayStrategies = map[string]j2n.ArrayStrategy{
+	"replace": j2n.ReplaceArrays,
+	"append":  j2n.AppendArrays,
+	"index":   j2n.MergeArraysByIndex,
+}
+
+func runMerge(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("j2n merge", flag.ContinueOnError)
+	arrays := flags.String("arrays", "replace", "array merge strategy: replace, append or index")
+	key := flags.String("key", "", "merge arrays of objects by this field")
+	indent := flags.Bool("indent", false, "indent the output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return fmt.Errorf("Expected a base document and at least one overlay")
+	}
+
+	strategy, ok := arrayStrategies[*arrays]
+	if !ok {
+		return fmt.Errorf("Unknown array strategy '%s'", *arrays)
+	}
+
+	opts := []j2n.MergeOption{j2n.WithArrayStrategy(strategy)}
+	if *key != "" {
+		opts = append(opts, j2n.WithArrayMergeKey(*key))
+	}
+
+	merged, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range flags.Args()[1:] {
+		overlay, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if merged, err = j2n.MergeJSON(merged, overlay, opts...); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	if *indent {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, merged, "", "  "); err != nil {
+			return err
+		}
+		merged = indented.Bytes()
+	}
+
+	_, err = fmt.Fprintf(stdout, "%s\n", merged)
+	return err
+}
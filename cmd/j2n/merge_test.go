@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	return dir
+}
+
+func TestMergeAppliesOverlaysInOrder(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"base.json": `{"name":"app","replicas":1,"x-team":"core","ports":[{"name":"http","port":80}]}`,
+		"prod.json": `{"replicas":3,"ports":[{"name":"http","port":8080},{"name":"metrics","port":9090}]}`,
+		"eu.json":   `{"region":"eu","x-team":null}`,
+	})
+
+	var out bytes.Buffer
+	err := run([]string{"merge", "-key", "name",
+		filepath.Join(dir, "base.json"), filepath.Join(dir, "prod.json"), filepath.Join(dir, "eu.json")}, nil, &out)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"name":"app","ports":[{"name":"http","port":8080},{"name":"metrics","port":9090}],"region":"eu","replicas":3}` + "\n"
+	if out.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out.String())
+	}
+}
+
+func TestMergeReturnsErrorOnUnknownStrategy(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"a.json": `{}`, "b.json": `{}`})
+
+	var out bytes.Buffer
+	err := run([]string{"merge", "-arrays", "zip", filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}, nil, &out)
+	if err == nil {
+		t.Fatal("Expected error merging with unknown array strategy")
+	}
+}
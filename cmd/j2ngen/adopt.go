@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+// An edit replaces the bytes of a file between start and end with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// An adoptFile is a Go file of the package being rewritten, with the edits
+// to make to it.
+type adoptFile struct {
+	path    string
+	fset    *token.FileSet
+	file    *ast.File
+	source  []byte
+	edits   []edit
+	imports []string
+}
+
+// An adoptedType is a struct that is renamed to its data struct name and
+// replaced by a wrapper.
+type adoptedType struct {
+	data string
+	spec *ast.TypeSpec
+}
+
+func runAdopt(args []string) error {
+	flags := flag.NewFlagSet("j2ngen adopt", flag.ContinueOnError)
+	types := flags.String("type", "", "comma-separated list of struct names (default all structs with json tags)")
+	suffix := flags.String("suffix", "Data", "suffix appended to each struct name to name its data struct")
+	dryRun := flags.Bool("n", false, "list the files that would change without writing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	var names []string
+	if *types != "" {
+		for _, name := range strings.Split(*types, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, dir := range dirs {
+		changed, err := adoptPackage(dir, names, *suffix, found)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range changed {
+			fmt.Fprintln(os.Stdout, f.path)
+			if *dryRun {
+				continue
+			}
+			source, err := f.apply()
+			if err != nil {
+				return fmt.Errorf("%s: %s", f.path, err)
+			}
+			if err := os.WriteFile(f.path, source, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			return fmt.Errorf("Struct '%s' not found in %s", name, strings.Join(dirs, ", "))
+		}
+	}
+	return nil
+}
+
+// Plans the rewrite of the structs named by names in the package in dir, or
+// of every struct with json tags if names is empty, and returns the files
+// that change. Each struct found is recorded in found.
+func adoptPackage(dir string, names []string, suffix string, found map[string]bool) ([]*adoptFile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*adoptFile
+	var packageName string
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, source, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.HasSuffix(path, "_test.go") {
+			packageName = file.Name.Name
+		}
+		files = append(files, &adoptFile{path: path, fset: fset, file: file, source: source})
+	}
+
+	// Test files of an external test package see the structs under the
+	// package name, and are left alone
+	var pkgFiles []*adoptFile
+	for _, f := range files {
+		if f.file.Name.Name == packageName {
+			pkgFiles = append(pkgFiles, f)
+		}
+	}
+
+	declared := declaredNames(pkgFiles)
+	methods := jsonMethods(pkgFiles)
+	adopted := make(map[string]*adoptedType)
+
+	for _, f := range pkgFiles {
+		if strings.HasSuffix(f.path, "_test.go") {
+			continue
+		}
+
+		for _, decl := range f.file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			var wrappers bytes.Buffer
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				name := ts.Name.Name
+
+				if len(names) > 0 {
+					if !contains(names, name) {
+						continue
+					}
+					found[name] = true
+					if !ok || ts.TypeParams != nil {
+						return nil, fmt.Errorf("'%s' is not a struct", name)
+					}
+					if methods[name] {
+						return nil, fmt.Errorf("'%s' already has its own JSON methods", name)
+					}
+				} else if !ok || ts.TypeParams != nil || methods[name] || strings.HasSuffix(name, suffix) || !hasJSONTags(st) {
+					continue
+				}
+
+				data := name + suffix
+				if declared[data] {
+					return nil, fmt.Errorf("Cannot rename '%s' to '%s', which is already declared", name, data)
+				}
+
+				if err := f.addOverflowField(st, name); err != nil {
+					return nil, err
+				}
+				f.replace(ts.Name.Pos(), ts.Name.End(), data)
+				writeWrapper(&wrappers, name, data)
+				adopted[name] = &adoptedType{data: data, spec: ts}
+			}
+
+			if wrappers.Len() > 0 {
+				f.insert(gen.End(), "\n"+wrappers.String())
+				f.imports = append(f.imports, "github.com/ygt/j2n")
+			}
+		}
+	}
+
+	var changed []*adoptFile
+	for _, f := range pkgFiles {
+		f.wrapLiterals(adopted)
+		if len(f.edits) > 0 {
+			changed = append(changed, f)
+		}
+	}
+	return changed, nil
+}
+
+// Returns the names declared at the top level of the package.
+func declaredNames(files []*adoptFile) map[string]bool {
+	declared := make(map[string]bool)
+	for _, f := range files {
+		for name := range f.file.Scope.Objects {
+			declared[name] = true
+		}
+	}
+	return declared
+}
+
+// Returns the names of the types that declare MarshalJSON or UnmarshalJSON
+// methods, which cannot be wrapped without changing their encoding.
+func jsonMethods(files []*adoptFile) map[string]bool {
+	methods := make(map[string]bool)
+	for _, f := range files {
+		for _, decl := range f.file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || (fn.Name.Name != "MarshalJSON" && fn.Name.Name != "UnmarshalJSON") {
+				continue
+			}
+
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); ok {
+				methods[ident.Name] = true
+			}
+		}
+	}
+	return methods
+}
+
+func hasJSONTags(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if field.Tag != nil && strings.Contains(field.Tag.Value, `json:"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the Overflow field of st, planning to add one if it is missing.
+func (f *adoptFile) addOverflowField(st *ast.StructType, name string) error {
+	s := &sourceStruct{path: f.path, file: f.file, fset: f.fset, fields: st.Fields}
+	if overflowField(s) != nil {
+		return checkOverflowField(s, name)
+	}
+
+	field := fmt.Sprintf("\tOverflow map[string]*%s.RawMessage `json:\"-\"`\n", importName(f.file, "encoding/json"))
+	if f.fset.Position(st.Fields.Closing).Line == f.fset.Position(st.Fields.Opening).Line {
+		field = "\n" + field
+	}
+	f.insert(st.Fields.Closing, field)
+	f.imports = append(f.imports, "encoding/json")
+	return nil
+}
+
+// Plans the rewrite of the composite literals of adopted types, which can no
+// longer set the fields of the data struct directly: Cat{Name: "Tom"}
+// becomes Cat{CatData: CatData{Name: "Tom"}}. This includes literals whose
+// type is elided inside a slice, array or map literal.
+func (f *adoptFile) wrapLiterals(adopted map[string]*adoptedType) {
+	if len(adopted) == 0 {
+		return
+	}
+
+	ast.Inspect(f.file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		if t := f.adoptedType(lit.Type, adopted); t != nil {
+			f.wrapLiteral(lit, t)
+			return true
+		}
+
+		var elem ast.Expr
+		switch typ := lit.Type.(type) {
+		case *ast.ArrayType:
+			elem = typ.Elt
+		case *ast.MapType:
+			elem = typ.Value
+		default:
+			return true
+		}
+		if star, ok := elem.(*ast.StarExpr); ok {
+			elem = star.X
+		}
+
+		t := f.adoptedType(elem, adopted)
+		if t == nil {
+			return true
+		}
+		for _, e := range lit.Elts {
+			if kv, ok := e.(*ast.KeyValueExpr); ok {
+				e = kv.Value
+			}
+			if inner, ok := e.(*ast.CompositeLit); ok && inner.Type == nil {
+				f.wrapLiteral(inner, t)
+			}
+		}
+		return true
+	})
+}
+
+// Returns the adopted type that expr names, unless the name refers to
+// something declared in a narrower scope.
+func (f *adoptFile) adoptedType(expr ast.Expr, adopted map[string]*adoptedType) *adoptedType {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+
+	t := adopted[ident.Name]
+	if t == nil || (ident.Obj != nil && ident.Obj.Decl != t.spec) {
+		return nil
+	}
+	return t
+}
+
+func (f *adoptFile) wrapLiteral(lit *ast.CompositeLit, t *adoptedType) {
+	if len(lit.Elts) == 0 {
+		return
+	}
+
+	open := t.data + "{"
+	if _, keyed := lit.Elts[0].(*ast.KeyValueExpr); keyed {
+		open = t.data + ": " + open
+	}
+	f.insert(lit.Lbrace+1, open)
+	f.insert(lit.Rbrace, "}")
+}
+
+func (f *adoptFile) insert(pos token.Pos, text string) {
+	f.replace(pos, pos, text)
+}
+
+func (f *adoptFile) replace(start, end token.Pos, text string) {
+	f.edits = append(f.edits, edit{
+		start: f.fset.Position(start).Offset,
+		end:   f.fset.Position(end).Offset,
+		text:  text,
+	})
+}
+
+// Returns the source of f with its edits made and imports added, formatted.
+// Edits at the same offset are made in the order they were planned.
+func (f *adoptFile) apply() ([]byte, error) {
+	edits := append([]edit(nil), f.edits...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+
+	var updated bytes.Buffer
+	offset := 0
+	for _, e := range edits {
+		updated.Write(f.source[offset:e.start])
+		updated.WriteString(e.text)
+		offset = e.end
+	}
+	updated.Write(f.source[offset:])
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, f.path, updated.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range f.imports {
+		astutil.AddImport(fset, file, path)
+	}
+
+	var source bytes.Buffer
+	if err := format.Node(&source, fset, file); err != nil {
+		return nil, err
+	}
+	return imports.Process(f.path, source.Bytes(), &imports.Options{Comments: true, TabIndent: true, TabWidth: 8, FormatOnly: true})
+}
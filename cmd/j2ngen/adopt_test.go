@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAdoptRewritesStructsAndLiterals(t *testing.T) {
+	dir := writeTestPackage(t, `package pets
+
+// Cat is a cat.
+type Cat struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+type counter struct{ n int }
+
+func (c Cat) Greeting() string {
+	return "Hello, " + c.Name
+}
+`)
+	err := os.WriteFile(filepath.Join(dir, "cat_test.go"), []byte(`package pets
+
+var cats = []Cat{{Name: "Tom"}, {Name: "Felix"}}
+
+var tom = &Cat{Name: "Tom"}
+
+var empty = Cat{}
+`), 0644)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if err := run([]string{"adopt", dir}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, "cat.go"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, expected := range []string{
+		"import (\n\t\"encoding/json\"\n\n\t\"github.com/ygt/j2n\"\n)",
+		"// Cat is a cat.\ntype CatData struct {\n\tName     string                      `json:\"name\"`\n\tOverflow map[string]*json.RawMessage `json:\"-\"`\n}",
+		"type Cat struct {\n\tCatData\n}",
+		"func (c *Cat) UnmarshalJSON(data []byte) error {\n\treturn j2n.UnmarshalJSON(data, &c.CatData)\n}",
+		"type counter struct{ n int }",
+		"func (c Cat) Greeting() string",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf("Expected '%s' in output, got '%s'", expected, source)
+		}
+	}
+
+	tests, err := os.ReadFile(filepath.Join(dir, "cat_test.go"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `package pets
+
+var cats = []Cat{{CatData: CatData{Name: "Tom"}}, {CatData: CatData{Name: "Felix"}}}
+
+var tom = &Cat{CatData: CatData{Name: "Tom"}}
+
+var empty = Cat{}
+`
+	if string(tests) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, tests)
+	}
+}
+
+func TestAdoptRejectsStructsWithJSONMethods(t *testing.T) {
+	dir := writeTestPackage(t, `package pets
+
+type Cat struct {
+	Name string `+"`json:\"name\"`"+`
+}
+
+func (c Cat) MarshalJSON() ([]byte, error) {
+	return nil, nil
+}
+`)
+
+	if err := run([]string{"adopt", "-type", "Cat", dir}); err == nil {
+		t.Fatal("Expected error adopting struct with its own MarshalJSON")
+	}
+
+	if err := run([]string{"adopt", "-type", "Dog", dir}); err == nil {
+		t.Fatal("Expected error adopting missing struct")
+	}
+}
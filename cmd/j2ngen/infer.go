@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func runInfer(args []string) error {
+	flags := flag.NewFlagSet("j2ngen infer", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the generated type")
+	pkg := flags.String("package", "main", "package name of the generated file")
+	output := flags.String("output", "", "output file name (default standard output)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 || *name == "" {
+		flags.Usage()
+		return fmt.Errorf("Expected -name and at least one sample file")
+	}
+
+	var samples []interface{}
+	for _, path := range flags.Args() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		documents, err := readSamples(data)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		samples = append(samples, documents...)
+	}
+
+	source, err := generateFromSamples(samples, *name, *pkg)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, source)
+}
+
+// Returns the documents in data, which may hold several concatenated JSON
+// documents. A top-level array is taken to be a list of samples.
+func readSamples(data []byte) ([]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var samples []interface{}
+	for {
+		var document interface{}
+		if err := decoder.Decode(&document); err == io.EOF {
+			return samples, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		if array, ok := document.([]interface{}); ok {
+			samples = append(samples, array...)
+		} else {
+			samples = append(samples, document)
+		}
+	}
+}
+
+// Returns the source of a Go file with types inferred from the samples,
+// the root type being called name.
+func generateFromSamples(samples []interface{}, name, pkg string) ([]byte, error) {
+	schema, err := json.Marshal(inferSchema(samples))
+	if err != nil {
+		return nil, err
+	}
+	return generateFromSchema(schema, "", name, pkg)
+}
+
+// Returns a JSON Schema that every one of values matches. Values of
+// different types are left untyped, except that integers and other numbers
+// unify to numbers, and null makes the type nullable. An object's
+// properties are required if every sample has them.
+func inferSchema(values []interface{}) map[string]interface{} {
+	types := make(map[string]bool)
+	nullable := false
+	allDates := true
+	var objects []map[string]interface{}
+	var items []interface{}
+
+	for _, value := range values {
+		switch value := value.(type) {
+		case nil:
+			nullable = true
+		case bool:
+			types["boolean"] = true
+		case json.Number:
+			if strings.ContainsAny(string(value), ".eE") {
+				types["number"] = true
+			} else {
+				types["integer"] = true
+			}
+		case string:
+			types["string"] = true
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				allDates = false
+			}
+		case []interface{}:
+			types["array"] = true
+			items = append(items, value...)
+		case map[string]interface{}:
+			types["object"] = true
+			objects = append(objects, value)
+		}
+	}
+
+	if types["integer"] && types["number"] {
+		delete(types, "integer")
+	}
+
+	schema := make(map[string]interface{})
+	if len(types) != 1 {
+		return schema
+	}
+
+	var typ string
+	for t := range types {
+		typ = t
+	}
+
+	if nullable {
+		schema["type"] = []string{typ, "null"}
+	} else {
+		schema["type"] = typ
+	}
+
+	switch typ {
+	case "string":
+		if allDates {
+			schema["format"] = "date-time"
+		}
+	case "array":
+		if len(items) > 0 {
+			schema["items"] = inferSchema(items)
+		}
+	case "object":
+		properties, required := inferProperties(objects)
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+
+	return schema
+}
+
+func inferProperties(objects []map[string]interface{}) (map[string]interface{}, []string) {
+	values := make(map[string][]interface{})
+	for _, object := range objects {
+		for k, v := range object {
+			values[k] = append(values[k], v)
+		}
+	}
+
+	properties := make(map[string]interface{}, len(values))
+	var required []string
+	for k, v := range values {
+		properties[k] = inferSchema(v)
+		if len(v) == len(objects) {
+			required = append(required, k)
+		}
+	}
+	sort.Strings(required)
+
+	return properties, required
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateFromSamplesUnifiesTypes(t *testing.T) {
+	samples, err := readSamples([]byte(`
+		{"id":1,"total":10,"created":"2024-01-02T03:04:05Z","note":null,"extra":"x","customer":{"name":"Bert"}}
+		[{"id":2,"total":9.5,"created":"2024-01-03T00:00:00Z","note":"gift","extra":3,"customer":{"name":"Ernie","vip":true}}]
+	`))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(samples) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(samples))
+	}
+
+	source, err := generateFromSamples(samples, "Order", "orders")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, expected := range []string{
+		"\tCreated  time.Time                   `json:\"created\"`",
+		"\tCustomer OrderCustomer               `json:\"customer\"`",
+		"\tExtra    json.RawMessage             `json:\"extra\"`",
+		"\tID       int64                       `json:\"id\"`",
+		"\tNote     *string                     `json:\"note\"`",
+		"\tTotal    float64                     `json:\"total\"`",
+		"\tName     string                      `json:\"name\"`",
+		"\tVip      bool                        `json:\"vip,omitempty\"`",
+		"type Order struct {\n\tOrderData\n}",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf("Expected '%s' in output, got '%s'", expected, source)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Command j2ngen generates the boilerplate for j2n structs.
+//
+// Given the name of a data struct, it writes the companion wrapper type with
+// UnmarshalJSON and MarshalJSON methods that forward to j2n, as described in
+// the j2n package documentation. It is intended for use with go:generate:
+//
+//	//go:generate j2ngen -type=CatData,DogData
+//
+// generates Cat and Dog in catdata_j2n.go. If a data struct has no Overflow
+// field, one is added to its source file.
+//
+// With the schema subcommand, it instead generates structs from a JSON
+// Schema, which may be a component of a JSON or YAML OpenAPI document:
+//
+//	j2ngen schema -name Pet -ref '#/components/schemas/Pet' -output pet.go openapi.yaml
+//
+// Objects that allow additional properties become j2n data structs with
+// wrapper types; properties that are not required are tagged omitempty, and
+// referenced schemas become types named after their last path segment.
+//
+// With the infer subcommand, it proposes structs from sample documents,
+// unifying the type of each field across the samples:
+//
+//	j2ngen infer -name Order -output order.go samples/*.json
+//
+// Each file may hold several documents, or an array of them. Fields present
+// in every sample are required, fields that are sometimes null become
+// pointers, and fields whose type varies are left as json.RawMessage.
+//
+// With the adopt subcommand, it rewrites existing packages of plain
+// encoding/json structs into the j2n pattern, in place, in the manner of go
+// fix:
+//
+//	j2ngen adopt -type Cat,Dog ./pets
+//
+// Each struct is renamed with the suffix, as CatData, and given an Overflow
+// field, and a wrapper type with the original name is declared after it, so
+// that existing references keep compiling. Composite literals of the struct
+// in the same package are rewritten to set the embedded data struct. Without
+// -type, every struct with json tags and no JSON methods of its own is
+// adopted; -n lists the files that would change without writing them.
+//
+// Flags:
+//
+//	-type    comma-separated list of data struct names (required)
+//	-suffix  suffix removed from each data struct name to name its wrapper
+//	         (default "Data")
+//	-output  output file name (default <first type>_j2n.go)
+//	-dir     package directory (default ".")
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "j2ngen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "schema":
+			return runSchema(args[1:])
+		case "infer":
+			return runInfer(args[1:])
+		case "adopt":
+			return runAdopt(args[1:])
+		}
+	}
+
+	flags := flag.NewFlagSet("j2ngen", flag.ContinueOnError)
+	types := flags.String("type", "", "comma-separated list of data struct names")
+	suffix := flags.String("suffix", "Data", "suffix removed from each data struct name to name its wrapper")
+	output := flags.String("output", "", "output file name")
+	dir := flags.String("dir", ".", "package directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *types == "" {
+		flags.Usage()
+		return fmt.Errorf("-type is required")
+	}
+
+	return generateWrappers(*dir, strings.Split(*types, ","), *suffix, *output)
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ygt/j2n/j2nyaml"
+)
+
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("j2ngen schema", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the generated type for the root schema")
+	ref := flags.String("ref", "", "JSON Pointer to the schema within the file, such as #/components/schemas/Cat")
+	pkg := flags.String("package", "main", "package name of the generated file")
+	output := flags.String("output", "", "output file name (default standard output)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 || *name == "" {
+		flags.Usage()
+		return fmt.Errorf("Expected -name and a single schema file")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if ext := filepath.Ext(flags.Arg(0)); ext == ".yaml" || ext == ".yml" {
+		if data, err = j2nyaml.ToJSON(data); err != nil {
+			return err
+		}
+	}
+
+	source, err := generateFromSchema(data, *ref, *name, *pkg)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, source)
+}
+
+func writeOutput(output string, source []byte) error {
+	if output == "" {
+		_, err := os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(output, source, 0644)
+}
+
+// A schemaGenerator emits Go types for a JSON Schema and the schemas it
+// references.
+type schemaGenerator struct {
+	document map[string]interface{}
+	used     map[string]bool
+	refs     map[string]string
+	imports  map[string]bool
+	types    bytes.Buffer
+}
+
+// Returns the source of a Go file with types for the schema at ref within
+// the JSON-encoded document, the root type being called name.
+func generateFromSchema(data []byte, ref, name, pkg string) ([]byte, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("Invalid schema: %s", err)
+	}
+
+	g := &schemaGenerator{
+		document: document,
+		used:     make(map[string]bool),
+		refs:     make(map[string]string),
+		imports:  make(map[string]bool),
+	}
+
+	if ref == "" {
+		ref = "#"
+	}
+	schema, err := g.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	g.refs[ref] = name
+	if _, err := g.namedType(schema, name); err != nil {
+		return nil, err
+	}
+
+	return g.source(pkg)
+}
+
+func (g *schemaGenerator) source(pkg string) ([]byte, error) {
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by j2ngen; DO NOT EDIT.\n\npackage %s\n", pkg)
+
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		// Standard library packages first, as goimports groups them
+		sort.SliceStable(paths, func(i, j int) bool {
+			return !strings.Contains(paths[i], ".") && strings.Contains(paths[j], ".")
+		})
+
+		source.WriteString("\nimport (\n")
+		for i, path := range paths {
+			if i > 0 && strings.Contains(path, ".") && !strings.Contains(paths[i-1], ".") {
+				source.WriteString("\n")
+			}
+			fmt.Fprintf(&source, "\t%q\n", path)
+		}
+		source.WriteString(")\n")
+	}
+
+	source.Write(g.types.Bytes())
+	return format.Source(source.Bytes())
+}
+
+// Returns the schema at the local reference ref, such as "#/$defs/Address".
+func (g *schemaGenerator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("Only local references are supported, got '%s'", ref)
+	}
+
+	var node interface{} = g.document
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Reference '%s' not found", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("Reference '%s' not found", ref)
+		}
+	}
+
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Reference '%s' is not a schema", ref)
+	}
+	return schema, nil
+}
+
+// Returns the Go type for schema, emitting a declaration called name if the
+// schema is an object with properties.
+func (g *schemaGenerator) namedType(schema map[string]interface{}, name string) (string, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		if typeName, ok := g.refs[ref]; ok {
+			return typeName, nil
+		}
+
+		target, err := g.resolve(ref)
+		if err != nil {
+			return "", err
+		}
+		typeName := exportedName(ref[strings.LastIndex(ref, "/")+1:])
+		g.refs[ref] = typeName
+		return g.namedType(target, typeName)
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged, err := g.mergeAllOf(schema, allOf)
+		if err != nil {
+			return "", err
+		}
+		return g.namedType(merged, name)
+	}
+
+	if _, ok := schema["oneOf"]; ok {
+		return g.rawType(), nil
+	}
+	if _, ok := schema["anyOf"]; ok {
+		return g.rawType(), nil
+	}
+
+	typ, nullable := schemaType(schema)
+	goType, err := g.baseType(schema, typ, name)
+	if err != nil {
+		return "", err
+	}
+
+	if nullable && goType != "json.RawMessage" && !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") {
+		return "*" + goType, nil
+	}
+	return goType, nil
+}
+
+func (g *schemaGenerator) baseType(schema map[string]interface{}, typ, name string) (string, error) {
+	switch typ {
+	case "string":
+		if schema["format"] == "date-time" {
+			g.imports["time"] = true
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return "[]" + g.rawType(), nil
+		}
+		item, err := g.namedType(items, name+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object":
+		if _, ok := schema["properties"].(map[string]interface{}); ok {
+			return g.objectType(schema, name)
+		}
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			value, err := g.namedType(additional, name+"Value")
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		return g.rawType(), nil
+	}
+
+	return g.rawType(), nil
+}
+
+// Emits a struct for an object schema with properties. Unless the schema
+// forbids additional properties, the struct is a j2n data struct with an
+// Overflow field and a wrapper type called name, which is returned.
+func (g *schemaGenerator) objectType(schema map[string]interface{}, name string) (string, error) {
+	name = uniqueName(name, g.used)
+	properties := schema["properties"].(map[string]interface{})
+
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, key := range list {
+			if key, ok := key.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type structField struct {
+		name, typ, tag, description string
+	}
+	var fields []structField
+	fieldNames := map[string]bool{"Overflow": true}
+
+	for _, key := range keys {
+		property, _ := properties[key].(map[string]interface{})
+		fieldName := uniqueName(exportedName(key), fieldNames)
+
+		typ, err := g.namedType(property, name+fieldName)
+		if err != nil {
+			return "", err
+		}
+
+		// Optional structs are pointers, as omitempty does not omit them
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+			if typ == "time.Time" || (typ != "" && unicode.IsUpper([]rune(typ)[0])) {
+				typ = "*" + typ
+			}
+		}
+		description, _ := property["description"].(string)
+		fields = append(fields, structField{fieldName, typ, tag, description})
+	}
+
+	overflow := schema["additionalProperties"] != false
+	structName := name
+	if overflow {
+		structName = uniqueName(name+"Data", g.used)
+		g.imports["encoding/json"] = true
+		g.imports["github.com/ygt/j2n"] = true
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\n")
+	if description, ok := schema["description"].(string); ok {
+		writeComment(&b, "", description)
+	}
+	fmt.Fprintf(&b, "type %s struct {\n", structName)
+	for _, f := range fields {
+		if f.description != "" {
+			writeComment(&b, "\t", f.description)
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", f.name, f.typ, f.tag)
+	}
+	if overflow {
+		b.WriteString("\tOverflow map[string]*json.RawMessage `json:\"-\"`\n")
+	}
+	b.WriteString("}\n")
+
+	if overflow {
+		writeWrapper(&b, name, structName)
+	}
+
+	g.types.Write(b.Bytes())
+	return name, nil
+}
+
+// Returns the merge of the object schemas in allOf, with the other keywords
+// of schema.
+func (g *schemaGenerator) mergeAllOf(schema map[string]interface{}, allOf []interface{}) (map[string]interface{}, error) {
+	merged := map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	var required []interface{}
+
+	parts := []interface{}{schema}
+	parts = append(parts, allOf...)
+	for _, part := range parts {
+		part, _ := part.(map[string]interface{})
+		if ref, ok := part["$ref"].(string); ok {
+			var err error
+			if part, err = g.resolve(ref); err != nil {
+				return nil, err
+			}
+		}
+
+		for k, v := range part {
+			switch k {
+			case "allOf", "$ref":
+			case "properties":
+				for key, property := range v.(map[string]interface{}) {
+					merged["properties"].(map[string]interface{})[key] = property
+				}
+			case "required":
+				list, _ := v.([]interface{})
+				required = append(required, list...)
+			default:
+				merged[k] = v
+			}
+		}
+	}
+
+	merged["required"] = required
+	return merged, nil
+}
+
+func (g *schemaGenerator) rawType() string {
+	g.imports["encoding/json"] = true
+	return "json.RawMessage"
+}
+
+// Returns the JSON type of schema and whether it also allows null.
+func schemaType(schema map[string]interface{}) (string, bool) {
+	nullable := schema["nullable"] == true
+
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ, nullable
+	case []interface{}:
+		var nonNull []string
+		for _, t := range typ {
+			if t == "null" {
+				nullable = true
+			} else if t, ok := t.(string); ok {
+				nonNull = append(nonNull, t)
+			}
+		}
+		if len(nonNull) == 1 {
+			return nonNull[0], nullable
+		}
+		return "", nullable
+	}
+
+	if _, ok := schema["properties"]; ok {
+		return "object", nullable
+	}
+	return "", nullable
+}
+
+func writeComment(b *bytes.Buffer, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimSpace(line))
+	}
+}
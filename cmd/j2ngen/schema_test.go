@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testOpenAPI = `{
+	"components": {
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"description": "A pet in the store.",
+				"required": ["id", "name"],
+				"properties": {
+					"id": {"type": "integer"},
+					"name": {"type": "string"},
+					"born": {"type": "string", "format": "date-time"},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"owner": {"$ref": "#/components/schemas/Owner"},
+					"nickname": {"type": ["string", "null"]}
+				}
+			},
+			"Owner": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"email_address": {"type": "string", "description": "Where to send receipts."}
+				}
+			}
+		}
+	}
+}`
+
+func TestGenerateFromSchemaEmitsStructsAndWrappers(t *testing.T) {
+	source, err := generateFromSchema([]byte(testOpenAPI), "#/components/schemas/Pet", "Pet", "store")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, expected := range []string{
+		"package store",
+		"\"github.com/ygt/j2n\"",
+		"\"time\"",
+		"// A pet in the store.\ntype PetData struct {",
+		"\tBorn     *time.Time                  `json:\"born,omitempty\"`",
+		"\tID       int64                       `json:\"id\"`",
+		"\tName     string                      `json:\"name\"`",
+		"\tNickname *string                     `json:\"nickname,omitempty\"`",
+		"\tOwner    *Owner                      `json:\"owner,omitempty\"`",
+		"\tTags     []string                    `json:\"tags,omitempty\"`",
+		"\tOverflow map[string]*json.RawMessage `json:\"-\"`",
+		"type Pet struct {\n\tPetData\n}",
+		"type Owner struct {\n\t// Where to send receipts.\n\tEmailAddress string `json:\"email_address,omitempty\"`\n}",
+	} {
+		if !strings.Contains(string(source), expected) {
+			t.Fatalf("Expected '%s' in output, got '%s'", expected, source)
+		}
+	}
+
+	if strings.Contains(string(source), "OwnerData") {
+		t.Fatalf("Expected no data struct for closed object, got '%s'", source)
+	}
+}
+
+func TestGenerateFromSchemaReturnsErrorOnMissingReference(t *testing.T) {
+	_, err := generateFromSchema([]byte(`{"properties":{"a":{"$ref":"#/$defs/Missing"}}}`), "", "Thing", "main")
+	if err == nil {
+		t.Fatal("Expected error generating from schema with missing reference")
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const overflowFieldType = "map[string]*json.RawMessage"
+
+// A sourceStruct is a struct type declaration found in a package directory.
+type sourceStruct struct {
+	path   string
+	file   *ast.File
+	fset   *token.FileSet
+	fields *ast.FieldList
+}
+
+// Writes wrapper types for the data structs named by types in the package
+// in dir, adding Overflow fields to the structs where they are missing.
+func generateWrappers(dir string, types []string, suffix, output string) error {
+	var packageName string
+	var wrappers bytes.Buffer
+
+	for _, name := range types {
+		name = strings.TrimSpace(name)
+		s, pkg, err := findStruct(dir, name)
+		if err != nil {
+			return err
+		}
+		packageName = pkg
+
+		if err := ensureOverflowField(s, name); err != nil {
+			return err
+		}
+
+		wrapper := strings.TrimSuffix(name, suffix)
+		if wrapper == name || wrapper == "" {
+			return fmt.Errorf("Cannot name wrapper for '%s', which does not end in '%s'", name, suffix)
+		}
+		writeWrapper(&wrappers, wrapper, name)
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "// Code generated by j2ngen; DO NOT EDIT.\n\npackage %s\n\n", packageName)
+	fmt.Fprintf(&source, "import \"github.com/ygt/j2n\"\n")
+	source.Write(wrappers.Bytes())
+
+	formatted, err := format.Source(source.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = strings.ToLower(strings.TrimSpace(types[0])) + "_j2n.go"
+	}
+	return os.WriteFile(filepath.Join(dir, output), formatted, 0644)
+}
+
+func writeWrapper(b *bytes.Buffer, wrapper, data string) {
+	first, _ := utf8.DecodeRuneInString(wrapper)
+	receiver := string(unicode.ToLower(first))
+
+	fmt.Fprintf(b, `
+// %[1]s wraps %[2]s, keeping any JSON fields it does not name in Overflow.
+type %[1]s struct {
+	%[2]s
+}
+
+func (%[3]s *%[1]s) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &%[3]s.%[2]s)
+}
+
+func (%[3]s %[1]s) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(%[3]s.%[2]s)
+}
+`, wrapper, data, receiver)
+}
+
+// Returns the struct named name in the non-test Go files of dir, and the
+// name of their package.
+func findStruct(dir, name string) (*sourceStruct, string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return nil, "", fmt.Errorf("'%s' is not a struct", name)
+				}
+				return &sourceStruct{path: path, file: file, fset: fset, fields: st.Fields}, file.Name.Name, nil
+			}
+		}
+	}
+
+	return nil, "", fmt.Errorf("Struct '%s' not found in %s", name, dir)
+}
+
+// Checks the Overflow field of s, adding it to the source file if it is
+// missing.
+func ensureOverflowField(s *sourceStruct, name string) error {
+	if overflowField(s) != nil {
+		return checkOverflowField(s, name)
+	}
+
+	source, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+
+	jsonName := importName(s.file, "encoding/json")
+	field := fmt.Sprintf("\tOverflow map[string]*%s.RawMessage `json:\"-\"`\n", jsonName)
+	closing := s.fset.Position(s.fields.Closing).Offset
+
+	var updated bytes.Buffer
+	if jsonName == "json" && !hasImport(s.file, "encoding/json") {
+		end := s.fset.Position(s.file.Name.End()).Offset
+		updated.Write(source[:end])
+		updated.WriteString("\n\nimport \"encoding/json\"\n")
+		updated.Write(source[end:closing])
+	} else {
+		updated.Write(source[:closing])
+	}
+	updated.WriteString(field)
+	updated.Write(source[closing:])
+
+	formatted, err := format.Source(updated.Bytes())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, formatted, 0644)
+}
+
+func overflowField(s *sourceStruct) *ast.Field {
+	for _, f := range s.fields.List {
+		for _, ident := range f.Names {
+			if ident.Name == "Overflow" {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// Checks that the Overflow field of s has a type and tag that j2n accepts.
+func checkOverflowField(s *sourceStruct, name string) error {
+	f := overflowField(s)
+
+	var typ bytes.Buffer
+	format.Node(&typ, s.fset, f.Type)
+	if typ.String() != overflowFieldType && typ.String() != "j2n.Overflow" {
+		return fmt.Errorf("Overflow field of '%s' must be of type %s or j2n.Overflow", name, overflowFieldType)
+	}
+	if f.Tag == nil || f.Tag.Value != "`json:\"-\"`" {
+		return fmt.Errorf("Overflow field of '%s' must have the tag `json:\"-\"`", name)
+	}
+	return nil
+}
+
+func hasImport(file *ast.File, path string) bool {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the name under which file imports path, or the package's own name
+// if it is not imported.
+func importName(file *ast.File, path string) string {
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path && spec.Name != nil {
+			return spec.Name.Name
+		}
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestPackage(t *testing.T, source string) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cat.go"), []byte(source), 0644); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return dir
+}
+
+func TestGenerateWrappersWritesForwardingMethods(t *testing.T) {
+	dir := writeTestPackage(t, `package pets
+
+import "encoding/json"
+
+type CatData struct {
+	Name     string                      `+"`json:\"name\"`"+`
+	Overflow map[string]*json.RawMessage `+"`json:\"-\"`"+`
+}
+`)
+
+	if err := run([]string{"-dir", dir, "-type", "CatData"}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(dir, "catdata_j2n.go"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, expected := range []string{
+		"// Code generated by j2ngen; DO NOT EDIT.",
+		"package pets",
+		"type Cat struct {\n\tCatData\n}",
+		"func (c *Cat) UnmarshalJSON(data []byte) error {\n\treturn j2n.UnmarshalJSON(data, &c.CatData)\n}",
+		"func (c Cat) MarshalJSON() ([]byte, error) {\n\treturn j2n.MarshalJSON(c.CatData)\n}",
+	} {
+		if !strings.Contains(string(generated), expected) {
+			t.Fatalf("Expected '%s' in output, got '%s'", expected, generated)
+		}
+	}
+}
+
+func TestGenerateWrappersAddsMissingOverflowField(t *testing.T) {
+	dir := writeTestPackage(t, `package pets
+
+// CatData is a cat.
+type CatData struct {
+	Name string `+"`json:\"name\"`"+` // the cat's name
+}
+`)
+
+	if err := run([]string{"-dir", dir, "-type", "CatData", "-output", "gen.go"}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	source, err := os.ReadFile(filepath.Join(dir, "cat.go"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `package pets
+
+import "encoding/json"
+
+// CatData is a cat.
+type CatData struct {
+	Name     string                      ` + "`json:\"name\"`" + ` // the cat's name
+	Overflow map[string]*json.RawMessage ` + "`json:\"-\"`" + `
+}
+`
+	if string(source) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, source)
+	}
+}
+
+func TestGenerateWrappersRejectsWrongOverflowField(t *testing.T) {
+	dir := writeTestPackage(t, `package pets
+
+type CatData struct {
+	Overflow map[string]string `+"`json:\"-\"`"+`
+}
+`)
+
+	if err := run([]string{"-dir", dir, "-type", "CatData"}); err == nil {
+		t.Fatal("Expected error generating wrapper for struct with wrong Overflow type")
+	}
+}
@@ -0,0 +1,15 @@
+// Command j2nvet runs the j2ncheck analyzer, which checks the structs passed
+// to j2n at compile time. It can be run on its own or through go vet:
+//
+//	j2nvet ./...
+//	go vet -vettool=$(which j2nvet) ./...
+package main
+
+import (
+	"github.com/ygt/j2n/j2ncheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(j2ncheck.Analyzer)
+}
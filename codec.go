@@ -0,0 +1,253 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// A fieldCodec decodes and encodes the values of a named field in place of
+// encoding/json, for settings in its j2n tag or for its type. value is
+// never a pointer: the codecs of pointer fields are given the element.
+type fieldCodec interface {
+	decode(raw json.RawMessage, value reflect.Value) error
+	encode(value reflect.Value) ([]byte, error)
+}
+
+type codecField struct {
+	field
+	codec fieldCodec
+}
+
+// Returns the named fields of v that have a codec, or an error if a j2n tag
+// is invalid.
+func (c *config) codecFields(v interface{}) ([]codecField, error) {
+	var fields []codecField
+	for _, f := range namedFields(structType(v)) {
+		codec, err := c.codecFor(f)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid j2n tag on field '%s': %s", printableKey(f.name), err)
+		}
+		if codec != nil {
+			fields = append(fields, codecField{field: f, codec: codec})
+		}
+	}
+	return fields, nil
+}
+
+// Returns an Option that decodes and encodes the named fields of type T, or
+// of type *T, with decode and encode in place of encoding/json, for types
+// whose JSON form in the documents at hand differs from their own:
+//
+//	j2n.Configure(JobData{}, j2n.WithTypeCodec(parseInterval, formatInterval))
+//
+// decode is not called for null, which sets the field to its zero value.
+// Settings in the j2n tag of a field take precedence.
+func WithTypeCodec[T any](decode func(raw json.RawMessage) (T, error), encode func(v T) ([]byte, error)) Option {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	codec := typeCodec{
+		decodeValue: func(raw json.RawMessage) (reflect.Value, error) {
+			v, err := decode(raw)
+			return reflect.ValueOf(&v).Elem(), err
+		},
+		encodeValue: func(value reflect.Value) ([]byte, error) {
+			return encode(value.Interface().(T))
+		},
+	}
+
+	return func(c *config) {
+		if c.typeCodecs == nil {
+			c.typeCodecs = make(map[reflect.Type]fieldCodec)
+		}
+		c.typeCodecs[t] = codec
+	}
+}
+
+type typeCodec struct {
+	decodeValue func(raw json.RawMessage) (reflect.Value, error)
+	encodeValue func(value reflect.Value) ([]byte, error)
+}
+
+func (c typeCodec) decode(raw json.RawMessage, value reflect.Value) error {
+	decoded, err := c.decodeValue(raw)
+	if err != nil {
+		return err
+	}
+	value.Set(decoded)
+	return nil
+}
+
+func (c typeCodec) encode(value reflect.Value) ([]byte, error) {
+	data, err := c.encodeValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("Invalid JSON from the codec for %s", value.Type())
+	}
+	return data, nil
+}
+
+// Returns the codec for f, or nil if it is left to encoding/json.
+func (c *config) codecFor(f field) (fieldCodec, error) {
+	t := f.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	settings := j2nTag(f.tag)
+	if encoding, ok := settings["bytes"]; ok {
+		return newBytesCodec(t, encoding)
+	}
+	if layout, ok := settings["layout"]; ok {
+		return newTimeCodec(t, layout)
+	}
+	return c.typeCodecs[t], nil
+}
+
+// A codecDecoding holds the values of codec fields taken out of a document,
+// to be decoded once encoding/json has decoded the rest of it.
+type codecDecoding struct {
+	fields []codecField
+	raws   []json.RawMessage
+}
+
+// Returns data with the values of the codec fields of v replaced by null,
+// so that encoding/json does not try to decode them, and the values taken
+// out. data must be valid JSON.
+func (c *config) takeCodecFields(v interface{}, data []byte) ([]byte, *codecDecoding, error) {
+	fields, err := c.codecFields(v)
+	if err != nil || len(fields) == 0 {
+		return data, nil, err
+	}
+	start := skipSpace(data, 0)
+	if start >= len(data) || data[start] != '{' {
+		return data, nil, nil
+	}
+
+	members, _, err := objectMembers(data, start)
+	if err != nil {
+		return nil, nil, err
+	}
+	isCodecKey := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		isCodecKey[f.name] = true
+	}
+
+	// Keys are matched to fields as encoding/json matches them, and every
+	// duplicate is replaced, and the last one decoded
+	t := structType(v)
+	last := make(map[string]json.RawMessage)
+	var taken []member
+	for _, m := range members {
+		if f, ok := namedField(t, m.key); ok && isCodecKey[f.name] {
+			last[f.name] = json.RawMessage(data[m.valueStart:m.end])
+			taken = append(taken, m)
+		}
+	}
+	if len(taken) == 0 {
+		return data, nil, nil
+	}
+
+	decoding := &codecDecoding{}
+	for _, f := range fields {
+		if raw, ok := last[f.name]; ok {
+			decoding.fields = append(decoding.fields, f)
+			decoding.raws = append(decoding.raws, raw)
+		}
+	}
+
+	var replaced bytes.Buffer
+	offset := 0
+	for _, m := range taken {
+		replaced.Write(data[offset:m.valueStart])
+		replaced.WriteString("null")
+		offset = m.end
+	}
+	replaced.Write(data[offset:])
+	return replaced.Bytes(), decoding, nil
+}
+
+// Decodes the values taken out by takeCodecFields into v.
+func (d *codecDecoding) apply(v interface{}) error {
+	if d == nil {
+		return nil
+	}
+
+	value, _ := structValue(v)
+	for i, f := range d.fields {
+		target, err := value.FieldByIndexErr(f.index)
+		if err != nil {
+			return fmt.Errorf("Cannot decode field '%s': %s", printableKey(f.name), err)
+		}
+		if err := decodeCodecField(f.codec, d.raws[i], target); err != nil {
+			return fmt.Errorf("Cannot decode field '%s': %s", printableKey(f.name), err)
+		}
+	}
+	return nil
+}
+
+func decodeCodecField(codec fieldCodec, raw json.RawMessage, target reflect.Value) error {
+	if bytes.Equal(raw, []byte("null")) {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	if target.Kind() != reflect.Ptr {
+		return codec.decode(raw, target)
+	}
+
+	allocated := reflect.New(target.Type().Elem())
+	if err := codec.decode(raw, allocated.Elem()); err != nil {
+		return err
+	}
+	target.Set(allocated)
+	return nil
+}
+
+// Replaces the encodings of the codec fields of v in result, the encoding
+// of its named fields. Fields that encoding/json left out stay out.
+func (c *config) encodeCodecFields(v interface{}, result map[string]*json.RawMessage) error {
+	fields, err := c.codecFields(v)
+	if err != nil || len(fields) == 0 {
+		return err
+	}
+
+	value, _ := structValue(v)
+	for _, f := range fields {
+		if _, ok := result[f.name]; !ok {
+			continue
+		}
+		source, err := value.FieldByIndexErr(f.index)
+		if err != nil {
+			continue
+		}
+
+		var encoded []byte
+		if source.Kind() == reflect.Ptr && source.IsNil() {
+			encoded = []byte("null")
+		} else if encoded, err = f.codec.encode(reflect.Indirect(source)); err != nil {
+			return fmt.Errorf("Cannot encode field '%s': %s", printableKey(f.name), err)
+		}
+		result[f.name] = (*json.RawMessage)(&encoded)
+	}
+	return nil
+}
+
+// Returns the encoding of the named fields of v as MarshalJSON outputs
+// them, as a map.
+func (c *config) encodeNamedFields(v interface{}) (map[string]*json.RawMessage, error) {
+	namedFieldsJSON, err := marshalNamedFields(v)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*json.RawMessage)
+	if err := json.Unmarshal(namedFieldsJSON, &result); err != nil {
+		return nil, err
+	}
+	if err := c.encodeCodecFields(v, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
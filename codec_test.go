@@ -0,0 +1,69 @@
+package j2n
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type Colour string
+
+type CodecData struct {
+	Colour   Colour   `json:"colour"`
+	Name     string   `json:"name"`
+	Overflow Overflow `json:"-"`
+}
+
+func upperCaseColours() Option {
+	return WithTypeCodec(
+		func(raw json.RawMessage) (Colour, error) {
+			var s string
+			err := json.Unmarshal(raw, &s)
+			return Colour(strings.ToUpper(s)), err
+		},
+		func(c Colour) ([]byte, error) {
+			return json.Marshal(strings.ToLower(string(c)))
+		},
+	)
+}
+
+func TestTypeCodec(t *testing.T) {
+	data := CodecData{}
+	err := UnmarshalJSON([]byte(`{"colour":"red","name":"bert","colour":"yellow","x":1}`), &data, upperCaseColours())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Colour != "YELLOW" || data.Name != "bert" || len(data.Overflow) != 1 {
+		t.Fatalf("Expected the last colour to be decoded, got %+v", data)
+	}
+
+	output, err := MarshalJSON(&data, upperCaseColours())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"colour":"yellow","name":"bert","x":1}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestTypeCodecMatchesKeysCaseInsensitively(t *testing.T) {
+	data := CodecData{}
+	err := UnmarshalJSON([]byte(`{"Colour":"red","COLOUR":"blue"}`), &data, upperCaseColours())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Colour != "BLUE" {
+		t.Fatalf("Expected the last colour to be decoded with the codec, got '%s'", data.Colour)
+	}
+}
+
+func TestTypeCodecNull(t *testing.T) {
+	data := CodecData{Colour: "RED"}
+	if err := UnmarshalJSON([]byte(`{"colour":null}`), &data, upperCaseColours()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Colour != "" {
+		t.Fatalf("Expected null to clear the field, got '%s'", data.Colour)
+	}
+}
@@ -0,0 +1,86 @@
+package j2n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns an Option that collects the failures of overflow validators,
+// including the types declared with WithOverflowType, rather than stopping
+// at the first one. Every entry is checked, and v is populated as usual:
+// the failing entries are left in Overflow as raw bytes for later
+// inspection. UnmarshalJSON then returns the failures as OverflowErrors, so
+// the caller decides whether the document is usable:
+//
+//	err := j2n.UnmarshalJSON(data, &cat, j2n.WithCollectOverflowErrors())
+//	var invalid j2n.OverflowErrors
+//	if errors.As(err, &invalid) {
+//		log.Printf("Ignoring %v", invalid.Keys())
+//	} else if err != nil {
+//		return err
+//	}
+//
+// Any other error still aborts the call, and is returned instead.
+func WithCollectOverflowErrors() Option {
+	return func(c *config) {
+		c.collectOverflowErrors = true
+	}
+}
+
+// OverflowErrors is a list of overflow entries that could not be decoded or
+// validated, in key order. It is returned by UnmarshalJSON with
+// WithCollectOverflowErrors, and can be built with Add when reading entries
+// with the typed getters:
+//
+//	var errs j2n.OverflowErrors
+//	age, _, err := cat.Overflow.GetInt64("age")
+//	errs.Add("age", err)
+//	born, _, err := cat.Overflow.GetTime("born")
+//	errs.Add("born", err)
+//	if err := errs.Err(); err != nil { ... }
+type OverflowErrors []*OverflowError
+
+// Appends an *OverflowError for key if err is not nil.
+func (e *OverflowErrors) Add(key string, err error) {
+	if err != nil {
+		*e = append(*e, &OverflowError{Key: key, Err: err})
+	}
+}
+
+// Returns e as an error, or nil if it is empty.
+func (e OverflowErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Returns the keys of the failing entries.
+func (e OverflowErrors) Keys() []string {
+	keys := make([]string, len(e))
+	for i, err := range e {
+		keys[i] = err.Key
+	}
+	return keys
+}
+
+func (e OverflowErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid overflow fields: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Returns the failures, so that errors.Is and errors.As can match each one.
+func (e OverflowErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
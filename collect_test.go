@@ -0,0 +1,72 @@
+package j2n
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithCollectOverflowErrorsChecksEveryEntry(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":"old","tags":"a","city":"Paris"}`), &p,
+		WithCollectOverflowErrors(),
+		WithOverflowType("age", int64(0)),
+		WithOverflowType("tags", []string(nil)),
+		WithOverflowType("city", ""),
+	)
+
+	var invalid OverflowErrors
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Expected OverflowErrors, got '%v'", err)
+	}
+	if !reflect.DeepEqual(invalid.Keys(), []string{"age", "tags"}) {
+		t.Fatalf("Expected '[age tags]', got '%v'", invalid.Keys())
+	}
+
+	// The struct is populated, and the failing entries are kept raw
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+	if string(*p.Overflow["age"]) != `"old"` {
+		t.Fatalf("Expected '\"old\"', got '%s'", *p.Overflow["age"])
+	}
+	if _, ok := p.Overflow["city"]; !ok {
+		t.Fatalf("Expected 'city' in overflow")
+	}
+}
+
+func TestWithCollectOverflowErrorsReturnsNilWithoutFailures(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p,
+		WithCollectOverflowErrors(),
+		WithOverflowType("age", int64(0)),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestOverflowErrorsAddCollectsGetterErrors(t *testing.T) {
+	p := OverflowPersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","age":"old","height":1.8}`), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var errs OverflowErrors
+	_, _, err := p.Overflow.GetInt64("age")
+	errs.Add("age", err)
+	height, _, err := p.Overflow.GetFloat("height")
+	errs.Add("height", err)
+
+	if height != 1.8 {
+		t.Fatalf("Expected '1.8', got '%v'", height)
+	}
+	if !reflect.DeepEqual(errs.Keys(), []string{"age"}) {
+		t.Fatalf("Expected '[age]', got '%v'", errs.Keys())
+	}
+
+	var overflowErr *OverflowError
+	if !errors.As(errs.Err(), &overflowErr) || overflowErr.Key != "age" {
+		t.Fatalf("Expected overflow error for 'age', got '%v'", errs.Err())
+	}
+}
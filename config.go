@@ -0,0 +1,274 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Source is a layer of configuration for LoadConfig, made with Defaults,
+// File, OptionalFile, Env or Patch.
+type Source struct {
+	// The kind of source: "default", "file", "env" or "patch"
+	Kind string
+
+	// Where the source came from, such as the path of a file
+	Name string
+
+	// Returns the source as a JSON object, given the type being loaded
+	load func(t reflect.Type) ([]byte, error)
+}
+
+// Returns a Source holding the encoding of v, a struct with the default
+// settings, encoded with MarshalJSON if it has an Overflow field.
+func Defaults(v interface{}) Source {
+	return Source{Kind: "default", Name: "defaults", load: func(reflect.Type) ([]byte, error) {
+		if m, ok := v.(json.Marshaler); ok {
+			return m.MarshalJSON()
+		}
+		if _, err := getOverflowFieldValue(v); err == nil {
+			return MarshalJSON(v)
+		}
+		return json.Marshal(v)
+	}}
+}
+
+// Returns a Source that reads the file at path, which holds a JSON object,
+// optionally with comments and trailing commas as in JSONC.
+func File(path string) Source {
+	return Source{Kind: "file", Name: path, load: func(reflect.Type) ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data, _, err = stripJSONC(data, false)
+		return data, err
+	}}
+}
+
+// Returns a Source like File that is empty if the file does not exist.
+func OptionalFile(path string) Source {
+	source := File(path)
+	load := source.load
+	source.load = func(t reflect.Type) ([]byte, error) {
+		data, err := load(t)
+		if errors.Is(err, os.ErrNotExist) {
+			return []byte("{}"), nil
+		}
+		return data, err
+	}
+	return source
+}
+
+// Returns a Source that reads the environment variables whose names start
+// with prefix. The rest of a name is the key, with "__" separating nested
+// keys: with the prefix "APP_", APP_DATABASE__MAX_CONNS sets the key
+// max_conns in the object database.
+//
+// Keys are matched to named fields case-insensitively, as encoding/json
+// matches them, and are otherwise lower case, so that unknown keys land in
+// Overflow. A value is used as a string if the field it sets is a string,
+// and otherwise as JSON if it is valid JSON, so that numbers, booleans,
+// arrays and objects can be set.
+func Env(prefix string) Source {
+	return Source{Kind: "env", Name: "environment", load: func(t reflect.Type) ([]byte, error) {
+		return envDocument(t, prefix, os.Environ())
+	}}
+}
+
+// Returns a Source holding the JSON object data, such as overrides given
+// on the command line. name says where it came from.
+func Patch(name string, data []byte) Source {
+	return Source{Kind: "patch", Name: name, load: func(reflect.Type) ([]byte, error) {
+		return data, nil
+	}}
+}
+
+// Loads configuration into v, a pointer to a struct with an Overflow field
+// or to a wrapper type with its own UnmarshalJSON method, from sources, for
+// programs configured by defaults, files and the environment:
+//
+//	err := j2n.LoadConfig(&config, []j2n.Source{
+//		j2n.Defaults(defaultConfig),
+//		j2n.File("/etc/app/config.json"),
+//		j2n.OptionalFile("/etc/app/config.local.json"),
+//		j2n.Env("APP_"),
+//	})
+//
+// Each source is merged over the ones before it as by MergeJSON, so a null
+// removes a key, and the result is parsed with UnmarshalJSON and opts.
+// Unknown keys from every source are kept in Overflow, so that settings for
+// newer versions of a program survive being loaded by older ones.
+// WithProvenance records which source set each value.
+//
+// An *OverflowError, such as for an unknown key under RejectUnknown, names
+// the source that set the key, and its position if it is a file or patch:
+//
+//	Invalid overflow field 'retrys' in /etc/app/config.json at line 42, column 3: Unknown field (did you mean 'retries'?)
+//
+// Other validation errors are not given positions, even with
+// WithErrorPositions, as they are found in the merged document.
+func LoadConfig(v interface{}, sources []Source, opts ...Option) error {
+	merged, loaded, err := mergeSources(structType(v), sources, newConfig(v, opts).provenance)
+	if err != nil {
+		return err
+	}
+
+	if u, ok := v.(json.Unmarshaler); ok {
+		err = u.UnmarshalJSON(merged)
+	} else {
+		// Positions in the merged document would mean nothing to the
+		// operator
+		err = UnmarshalJSON(merged, v, append(opts[:len(opts):len(opts)], func(c *config) {
+			c.errorPositions = false
+		})...)
+	}
+	if err != nil {
+		locateInSources(err, loaded)
+	}
+	return err
+}
+
+// A loadedSource is a Source and the JSON object it loaded.
+type loadedSource struct {
+	Source
+	data []byte
+}
+
+func mergeSources(t reflect.Type, sources []Source, provenance *Provenance) ([]byte, []loadedSource, error) {
+	if provenance != nil {
+		provenance.sources = nil
+	}
+
+	merged := []byte("{}")
+	loaded := make([]loadedSource, 0, len(sources))
+	for _, source := range sources {
+		data, err := source.load(t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Cannot load %s: %w", source.Name, err)
+		}
+		if !isObject(data) {
+			return nil, nil, fmt.Errorf("Cannot load %s: Expected a JSON object", source.Name)
+		}
+		if merged, err = MergeJSON(merged, data); err != nil {
+			return nil, nil, fmt.Errorf("Cannot load %s: %w", source.Name, err)
+		}
+		if provenance != nil {
+			var overlay map[string]interface{}
+			if err := decodeUsingNumber(data, &overlay); err != nil {
+				return nil, nil, fmt.Errorf("Cannot load %s: %w", source.Name, err)
+			}
+			provenance.record(source, "", overlay)
+		}
+		loaded = append(loaded, loadedSource{Source: source, data: data})
+	}
+	return merged, loaded, nil
+}
+
+// Sets the Source of each overflow error in err to the last of loaded to
+// set its key, and its Position to that of the key in a file or patch.
+// Other sources are generated, so positions in them mean nothing.
+func locateInSources(err error, loaded []loadedSource) {
+	positions := make([]map[string]Position, len(loaded))
+	eachOverflowError(err, func(e *OverflowError) {
+		for i := len(loaded) - 1; i >= 0; i-- {
+			if positions[i] == nil {
+				positions[i], _ = keyPositions(loaded[i].data)
+			}
+			p, ok := positions[i][e.Key]
+			if !ok {
+				continue
+			}
+			e.Source, e.Position = loaded[i].Name, Position{}
+			if loaded[i].Kind == "file" || loaded[i].Kind == "patch" {
+				e.Position = p
+			}
+			return
+		}
+	})
+}
+
+// Returns the JSON object set by the variables in environ with the given
+// prefix, for the struct type t.
+func envDocument(t reflect.Type, prefix string, environ []string) ([]byte, error) {
+	sort.Strings(environ)
+
+	document := make(map[string]interface{})
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		segments := strings.Split(name[len(prefix):], "__")
+
+		keys, leaf := envKeys(t, segments)
+		if keys == nil {
+			continue
+		}
+
+		parent := document
+		for _, k := range keys[:len(keys)-1] {
+			child, ok := parent[k].(map[string]interface{})
+			if !ok {
+				if _, set := parent[k]; set {
+					return nil, fmt.Errorf("Environment variable %s sets a key inside a value that is already set", name)
+				}
+				child = make(map[string]interface{})
+				parent[k] = child
+			}
+			parent = child
+		}
+		last := keys[len(keys)-1]
+		if _, set := parent[last].(map[string]interface{}); set {
+			return nil, fmt.Errorf("Environment variable %s replaces an object that is already set", name)
+		}
+		parent[last] = envValue(value, leaf)
+	}
+	return json.Marshal(document)
+}
+
+// Returns the keys named by segments in the struct type t, and the type of
+// the value they lead to, if it is known.
+func envKeys(t reflect.Type, segments []string) ([]string, reflect.Type) {
+	keys := make([]string, len(segments))
+	for i, segment := range segments {
+		if segment == "" {
+			return nil, nil
+		}
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		keys[i] = strings.ToLower(segment)
+		switch {
+		case t != nil && t.Kind() == reflect.Struct:
+			if f, ok := namedField(t, segment); ok {
+				keys[i] = f.name
+				t = f.typ
+			} else {
+				t = nil
+			}
+		case t != nil && t.Kind() == reflect.Map:
+			t = t.Elem()
+		default:
+			t = nil
+		}
+	}
+	return keys, t
+}
+
+func envValue(value string, t reflect.Type) json.RawMessage {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if (t == nil || t.Kind() != reflect.String) && json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+
+	quoted, _ := json.Marshal(value)
+	return quoted
+}
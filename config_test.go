@@ -0,0 +1,109 @@
+package j2n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type ServerConfig struct {
+	Host     string         `json:"host"`
+	Port     int            `json:"port"`
+	Database DatabaseConfig `json:"database"`
+	Tags     []string       `json:"tags"`
+	Overflow Overflow       `json:"-"`
+}
+
+type DatabaseConfig struct {
+	URL      string `json:"url"`
+	MaxConns int    `json:"maxConns"`
+}
+
+func writeConfigFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfigFile(t, `{
+		// Set by the operator
+		"port": 9000,
+		"database": {"url": "postgres://db", "poolMode": "session"},
+		"featureX": true,
+	}`)
+	t.Setenv("APP_HOST", "0.0.0.0")
+	t.Setenv("APP_DATABASE__MAXCONNS", "20")
+	t.Setenv("APP_DATABASE__URL", "123")
+	t.Setenv("APP_NEW_THING", `{"a":1}`)
+	t.Setenv("OTHER_PORT", "1")
+
+	config := ServerConfig{}
+	err := LoadConfig(&config, []Source{
+		Defaults(ServerConfig{Host: "localhost", Port: 8080, Tags: []string{"a"}}),
+		File(path),
+		OptionalFile(filepath.Join(t.TempDir(), "missing.json")),
+		Env("APP_"),
+		Patch("flags", []byte(`{"tags":["b"]}`)),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if config.Host != "0.0.0.0" || config.Port != 9000 || config.Database.MaxConns != 20 || config.Database.URL != "123" {
+		t.Fatalf("Expected every layer to apply, got %+v", config)
+	}
+	if len(config.Tags) != 1 || config.Tags[0] != "b" {
+		t.Fatalf("Expected the patch to replace the tags, got %v", config.Tags)
+	}
+
+	output, err := MarshalJSON(&config)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"database":{"url":"123","maxConns":20},"featureX":true,"host":"0.0.0.0","new_thing":{"a":1},"port":9000,"tags":["b"]}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	err := LoadConfig(&ServerConfig{}, []Source{File(missing)})
+	if err == nil || !strings.HasPrefix(err.Error(), "Cannot load "+missing+": ") {
+		t.Fatalf("Expected an error naming the file, got '%v'", err)
+	}
+
+	err = LoadConfig(&ServerConfig{}, []Source{Patch("flags", []byte(`[1]`))})
+	if err == nil || err.Error() != "Cannot load flags: Expected a JSON object" {
+		t.Fatalf("Expected an error for a non-object, got '%v'", err)
+	}
+}
+
+func TestLoadConfigLocatesUnknownKeys(t *testing.T) {
+	path := writeConfigFile(t, `{
+		// Set by the operator
+		"port": 9000,
+		"hots": "0.0.0.0"
+	}`)
+
+	err := LoadConfig(&ServerConfig{}, []Source{
+		Defaults(ServerConfig{Host: "localhost"}),
+		File(path),
+	}, WithUnknownFields(RejectUnknown))
+	expected := "Invalid overflow field 'hots' in " + path + " at line 4, column 3: Unknown field (did you mean 'host'?)"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	// Keys from the environment have no position
+	t.Setenv("APP_PORTS", "80")
+	err = LoadConfig(&ServerConfig{}, []Source{File(path), Env("APP_")}, WithExtensionsOnly(), WithCollectOverflowErrors())
+	expected = "Invalid overflow field 'ports' in environment: Unknown field, only 'x-' extensions are allowed"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
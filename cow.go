@@ -0,0 +1,136 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// COWOverflow is an OverflowStore whose snapshots are taken in constant
+// time, by sharing the entries until either side changes them, at which
+// point the side making the change takes a private copy. This makes it
+// cheap to hand a large document over for asynchronous processing:
+//
+//	type EventData struct {
+//		Kind     string           `json:"kind"`
+//		Overflow *j2n.COWOverflow `json:"-"`
+//	}
+//
+//	snapshot := event.Overflow.Snapshot()
+//	go process(snapshot)
+//
+// A COWOverflow is not safe for concurrent use, but a snapshot can be used
+// by another goroutine while the original is used and changed by the
+// goroutine that took it. The zero value is empty and ready to use.
+type COWOverflow struct {
+	entries Overflow
+
+	// Whether entries may be shared with another COWOverflow
+	shared bool
+	frozen bool
+}
+
+// Returns the entries, without copying them.
+func (c *COWOverflow) OverflowEntries() map[string]*json.RawMessage {
+	return c.entries
+}
+
+func (c *COWOverflow) ReplaceOverflow(m map[string]*json.RawMessage) error {
+	if c.frozen {
+		return ErrFrozen
+	}
+	c.entries = m
+	c.shared = false
+	return nil
+}
+
+// Makes c read-only. Its snapshots are not.
+func (c *COWOverflow) Freeze() {
+	// The entries of a frozen store are always shared, so that Snapshot
+	// does not write to it, and cached instances can be thawed concurrently
+	c.frozen = true
+	c.shared = true
+}
+
+// Reports whether c is read-only.
+func (c *COWOverflow) Frozen() bool {
+	return c.frozen
+}
+
+// Returns a COWOverflow with the same entries, in constant time. The
+// snapshot is not frozen, even if c is. Snapshots of a frozen COWOverflow
+// may be taken concurrently.
+func (c *COWOverflow) Snapshot() *COWOverflow {
+	if !c.shared {
+		c.shared = true
+	}
+	return &COWOverflow{entries: c.entries, shared: true}
+}
+
+// Returns the entries, for reading with the typed getters. They may be
+// shared with snapshots, so they must not be modified.
+func (c *COWOverflow) Overflow() Overflow {
+	return c.entries
+}
+
+// Returns the raw value at key, which must not be modified.
+func (c *COWOverflow) Get(key string) (json.RawMessage, bool) {
+	raw, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return rawOrNull(raw), true
+}
+
+// Returns the number of entries.
+func (c *COWOverflow) Len() int {
+	return len(c.entries)
+}
+
+// Sets the value at key to a copy of raw, which must be valid JSON.
+func (c *COWOverflow) Set(key string, raw json.RawMessage) error {
+	if !json.Valid(raw) {
+		return fmt.Errorf("Invalid JSON for overflow key '%s'", printableKey(key))
+	}
+	if c.frozen {
+		return ErrFrozen
+	}
+	copied := append(json.RawMessage(nil), raw...)
+
+	c.own()
+	c.entries[key] = &copied
+	return nil
+}
+
+// Removes the value at key, if there is one.
+func (c *COWOverflow) Delete(key string) error {
+	if c.frozen {
+		return ErrFrozen
+	}
+	if _, ok := c.entries[key]; !ok {
+		return nil
+	}
+
+	c.own()
+	delete(c.entries, key)
+	return nil
+}
+
+// Takes a private copy of the entries if they may be shared. The raw
+// values are never modified in place, so they stay shared.
+func (c *COWOverflow) own() {
+	if c.entries == nil {
+		c.entries = make(Overflow)
+		c.shared = false
+		return
+	}
+	if !c.shared {
+		return
+	}
+
+	entries := make(Overflow, len(c.entries))
+	for k, raw := range c.entries {
+		entries[k] = raw
+	}
+	c.entries = entries
+	c.shared = false
+}
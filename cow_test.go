@@ -0,0 +1,66 @@
+package j2n
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type COWEventData struct {
+	Kind     string       `json:"kind"`
+	Overflow *COWOverflow `json:"-"`
+}
+
+func TestCOWOverflowSnapshotsAreIndependent(t *testing.T) {
+	e := COWEventData{}
+	if err := UnmarshalJSON([]byte(`{"kind":"push","a":1,"b":2}`), &e); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	snapshot := e.Overflow.Snapshot()
+	if reflect.ValueOf(snapshot.Overflow()).Pointer() != reflect.ValueOf(e.Overflow.Overflow()).Pointer() {
+		t.Fatalf("Expected the snapshot to share the entries")
+	}
+
+	if err := e.Overflow.Set("a", json.RawMessage(`10`)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := snapshot.Delete("b"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if raw, _ := snapshot.Get("a"); string(raw) != "1" {
+		t.Fatalf("Expected '1', got '%s'", raw)
+	}
+	if _, ok := e.Overflow.Get("b"); !ok {
+		t.Fatalf("Expected 'b' to be kept in the original")
+	}
+
+	data, err := MarshalJSON(e)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"a":10,"b":2,"kind":"push"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+
+	data, err = MarshalJSON(COWEventData{Kind: "push", Overflow: snapshot})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"a":1,"kind":"push"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+func TestCOWOverflowZeroValue(t *testing.T) {
+	c := COWOverflow{}
+	if err := c.Set("a", json.RawMessage(`"x"`)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if value, _, _ := c.Overflow().GetString("a"); value != "x" {
+		t.Fatalf("Expected 'x', got '%s'", value)
+	}
+}
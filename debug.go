@@ -0,0 +1,127 @@
+package j2n
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// DebugSink is given the intermediate state of every call to UnmarshalJSON
+// that it is registered for, to troubleshoot fields that end up in the wrong
+// place. DebugRecorder keeps the last few captures of each type.
+//
+// Capture may be called concurrently by concurrent calls to UnmarshalJSON.
+type DebugSink interface {
+	Capture(c DebugCapture)
+}
+
+// DebugCapture is the intermediate state of one call to UnmarshalJSON.
+type DebugCapture struct {
+	// The name of the struct type being parsed
+	Type string
+	Time time.Time
+
+	// The members of the document that were parsed into named fields, as
+	// they encode once parsed, and the members left in Overflow. Both are
+	// nil if the call failed before the document was split.
+	Named    map[string]json.RawMessage
+	Overflow map[string]json.RawMessage
+
+	Err error
+}
+
+// Returns an Option that gives sink the intermediate state of each call,
+// whether or not it succeeds. Nothing is captured for types without a sink,
+// so a sink can be registered with Configure for just the type under
+// investigation, without turning on verbose logging everywhere:
+//
+//	recorder := j2n.NewDebugRecorder(20)
+//	j2n.Configure(CatData{}, j2n.WithDebugSink(recorder))
+//	...
+//	captures := recorder.Captures(CatData{})
+func WithDebugSink(sink DebugSink) Option {
+	return func(c *config) {
+		c.debugSinks = append(c.debugSinks, sink)
+	}
+}
+
+func (c *config) capture(v interface{}, named, overflow map[string]*json.RawMessage, err error) error {
+	if len(c.debugSinks) == 0 {
+		return nil
+	}
+
+	// Until the document is split, overflow holds all of it
+	if named == nil {
+		overflow = nil
+	}
+
+	capture := DebugCapture{
+		Type:     structType(v).String(),
+		Time:     time.Now(),
+		Named:    rawMap(named),
+		Overflow: rawMap(overflow),
+		Err:      err,
+	}
+	for _, sink := range c.debugSinks {
+		err := callHook("DebugSink", "", func() error {
+			sink.Capture(capture)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns a copy of m that does not share memory with the document.
+func rawMap(m map[string]*json.RawMessage) map[string]json.RawMessage {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]json.RawMessage, len(m))
+	for k, raw := range m {
+		result[k] = append(json.RawMessage(nil), rawOrNull(raw)...)
+	}
+	return result
+}
+
+// DebugRecorder is a DebugSink that keeps the last captures of each type.
+// It is safe for concurrent use.
+type DebugRecorder struct {
+	size int
+
+	mutex    sync.Mutex
+	captures map[string][]DebugCapture
+}
+
+// Returns a DebugRecorder that keeps the last size captures of each type.
+func NewDebugRecorder(size int) *DebugRecorder {
+	return &DebugRecorder{size: size, captures: make(map[string][]DebugCapture)}
+}
+
+// Records c, discarding the oldest capture of its type if there are already
+// as many as the recorder keeps.
+func (r *DebugRecorder) Capture(c DebugCapture) {
+	if r.size <= 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	captures := r.captures[c.Type]
+	if len(captures) >= r.size {
+		captures = append(captures[:0:0], captures[len(captures)-r.size+1:]...)
+	}
+	r.captures[c.Type] = append(captures, c)
+}
+
+// Returns the captures recorded for the type of v, which is a struct or a
+// pointer to one, oldest first.
+func (r *DebugRecorder) Captures(v interface{}) []DebugCapture {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]DebugCapture(nil), r.captures[structType(v).String()]...)
+}
@@ -0,0 +1,63 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestDebugRecorderKeepsLastCapturesPerType(t *testing.T) {
+	recorder := NewDebugRecorder(2)
+
+	for _, doc := range []string{`{"name":"A"}`, `{"name":"B","x":1}`, `{"name":"C","y":2}`} {
+		p := OverflowPersonData{}
+		if err := UnmarshalJSON([]byte(doc), &p, WithDebugSink(recorder)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &PersonData{}, WithDebugSink(recorder)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	captures := recorder.Captures(&OverflowPersonData{})
+	if len(captures) != 2 {
+		t.Fatalf("Expected 2 captures, got %d", len(captures))
+	}
+	if string(captures[0].Named["name"]) != `"B"` || string(captures[0].Overflow["x"]) != "1" {
+		t.Fatalf("Expected the capture of 'B', got '%v'", captures[0])
+	}
+	if string(captures[1].Named["name"]) != `"C"` || string(captures[1].Overflow["y"]) != "2" {
+		t.Fatalf("Expected the capture of 'C', got '%v'", captures[1])
+	}
+
+	if n := len(recorder.Captures(PersonData{})); n != 1 {
+		t.Fatalf("Expected 1 capture, got %d", n)
+	}
+}
+
+func TestDebugSinkCapturesFailures(t *testing.T) {
+	recorder := NewDebugRecorder(1)
+
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":"old"}`), &p,
+		WithDebugSink(recorder),
+		WithOverflowType("age", int64(0)),
+	)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	captures := recorder.Captures(p)
+	if len(captures) != 1 || captures[0].Err != err {
+		t.Fatalf("Expected a capture of '%s', got '%v'", err, captures)
+	}
+	if string(captures[0].Overflow["age"]) != `"old"` {
+		t.Fatalf("Expected '\"old\"', got '%s'", captures[0].Overflow["age"])
+	}
+
+	if err := UnmarshalJSON([]byte(`[]`), &p, WithDebugSink(recorder)); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	captures = recorder.Captures(p)
+	if captures[0].Named != nil || captures[0].Overflow != nil {
+		t.Fatalf("Expected no maps, got '%v'", captures[0])
+	}
+}
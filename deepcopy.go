@@ -0,0 +1,40 @@
+package j2n
+
+import (
+	"encoding/json"
+)
+
+// Copies the entries of in into out, which is replaced with a new map, so
+// that the two share no memory. It has the signature that controller-gen and
+// deepcopy-gen look for, so structs with an Overflow field of this type can
+// be used in Kubernetes API types and have their deep copy functions
+// generated as usual.
+func (in Overflow) DeepCopyInto(out *Overflow) {
+	if in == nil {
+		*out = nil
+		return
+	}
+
+	copied := make(Overflow, len(in))
+	for k, raw := range in {
+		if raw == nil {
+			copied[k] = nil
+			continue
+		}
+		value := make(json.RawMessage, len(*raw))
+		copy(value, *raw)
+		copied[k] = &value
+	}
+	*out = copied
+}
+
+// Returns a copy of o that shares no memory with it.
+func (in Overflow) DeepCopy() Overflow {
+	if in == nil {
+		return nil
+	}
+
+	var out Overflow
+	in.DeepCopyInto(&out)
+	return out
+}
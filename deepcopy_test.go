@@ -0,0 +1,33 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestDeepCopySharesNoMemory(t *testing.T) {
+	o := newTestOverflow(map[string]string{"age": `29`})
+	o["nil"] = nil
+
+	copied := o.DeepCopy()
+	(*o["age"])[0] = '3'
+	o["new"] = nil
+
+	if string(*copied["age"]) != `29` {
+		t.Fatalf("Expected '29', got '%s'", *copied["age"])
+	}
+
+	if _, ok := copied["nil"]; !ok || copied["nil"] != nil {
+		t.Fatalf("Expected nil entry to be copied, got '%v'", copied)
+	}
+
+	if _, ok := copied["new"]; ok {
+		t.Fatal("Expected copy to be unaffected by later additions")
+	}
+}
+
+func TestDeepCopyOfNilIsNil(t *testing.T) {
+	var o Overflow
+	if o.DeepCopy() != nil {
+		t.Fatal("Expected copy of nil overflow to be nil")
+	}
+}
@@ -0,0 +1,110 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Moves the named field with the JSON key key out of the struct pointed to
+// by v and into its Overflow, under the same key, for downgrading documents
+// for consumers that predate the field. The field is set to its zero value,
+// and its encoding is stored in Overflow, so that MarshalJSON produces the
+// same member as before.
+//
+// The field must be omitted from the encoding once it is zero, by tagging it
+// omitempty or omitzero; otherwise it would clash with the overflow entry,
+// and Demote returns an error without changing v.
+func Demote(v interface{}, key string) error {
+	value, f, err := namedFieldOf(v, key, "Demote")
+	if err != nil {
+		return err
+	}
+	target, err := value.FieldByIndexErr(f.index)
+	if err != nil {
+		return fmt.Errorf("Cannot demote '%s': %s", printableKey(key), err)
+	}
+
+	encoded, err := newConfig(v, nil).encodeNamedFields(v)
+	if err != nil {
+		return err
+	}
+	raw, ok := encoded[key]
+	if !ok {
+		// Omitted because it is empty, so there is nothing to keep
+		return nil
+	}
+
+	previous := reflect.New(target.Type()).Elem()
+	previous.Set(target)
+	target.Set(reflect.Zero(target.Type()))
+
+	err = func() error {
+		namedFieldsJSON, err := marshalNamedFields(v)
+		if err != nil {
+			return err
+		}
+		var encoded map[string]*json.RawMessage
+		if err := json.Unmarshal(namedFieldsJSON, &encoded); err != nil {
+			return err
+		}
+		if _, ok := encoded[key]; ok {
+			return fmt.Errorf("Cannot demote '%s': it is encoded even when zero, so it must be tagged omitempty or omitzero", printableKey(key))
+		}
+
+		return updateOverflow(v, func(overflow map[string]*json.RawMessage) {
+			overflow[key] = raw
+		})
+	}()
+	if err != nil {
+		target.Set(previous)
+	}
+	return err
+}
+
+// Returns the struct pointed to by v, and its named field with exactly the
+// JSON key key. fn names the caller, for errors.
+func namedFieldOf(v interface{}, key, fn string) (reflect.Value, field, error) {
+	value, ok := structValue(v)
+	if !ok || !value.CanAddr() {
+		return reflect.Value{}, field{}, fmt.Errorf("%s requires a pointer to a struct", fn)
+	}
+
+	for _, f := range namedFields(value.Type()) {
+		if f.name == key {
+			return value, f, nil
+		}
+	}
+	return reflect.Value{}, field{}, fmt.Errorf("No named field '%s'", printableKey(key))
+}
+
+// Calls fn with the overflow entries of v to change them, allocating the
+// map if it is nil. An OverflowStore is given a copy of its entries, and
+// then the changed copy, so that it can refuse the change.
+func updateOverflow(v interface{}, fn func(overflow map[string]*json.RawMessage)) error {
+	value, err := getOverflowFieldValue(v)
+	if err != nil {
+		return err
+	}
+
+	if value.Kind() != reflect.Ptr {
+		if value.IsNil() {
+			if _, _, err := resetOverflowMap(v); err != nil {
+				return err
+			}
+		}
+		fn(value.Convert(rawMapType).Interface().(map[string]*json.RawMessage))
+		return nil
+	}
+
+	if value.IsNil() {
+		value.Set(reflect.New(value.Type().Elem()))
+	}
+	store := value.Interface().(OverflowStore)
+	overflow := make(map[string]*json.RawMessage)
+	for k, raw := range store.OverflowEntries() {
+		overflow[k] = raw
+	}
+	fn(overflow)
+	return store.ReplaceOverflow(overflow)
+}
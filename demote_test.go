@@ -0,0 +1,76 @@
+package j2n
+
+import (
+	"testing"
+)
+
+type NicknameData struct {
+	Name     string   `json:"name"`
+	Nickname string   `json:"nickname,omitempty"`
+	Age      int      `json:"age"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestDemote(t *testing.T) {
+	data := NicknameData{Name: "bert", Nickname: "b", Age: 3}
+	if err := Demote(&data, "nickname"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Nickname != "" {
+		t.Fatalf("Expected the field to be cleared, got '%s'", data.Nickname)
+	}
+
+	output, err := MarshalJSON(&data)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"age":3,"name":"bert","nickname":"b"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+	if raw, _, _ := data.Overflow.GetString("nickname"); raw != "b" {
+		t.Fatalf("Expected 'b' in overflow, got '%s'", raw)
+	}
+}
+
+func TestDemoteStore(t *testing.T) {
+	type StoreNicknameData struct {
+		Nickname string        `json:"nickname,omitempty"`
+		Overflow *SyncOverflow `json:"-"`
+	}
+
+	data := StoreNicknameData{Nickname: "b"}
+	if err := Demote(&data, "nickname"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if raw, ok := data.Overflow.Get("nickname"); !ok || string(raw) != `"b"` {
+		t.Fatalf("Expected '\"b\"' in overflow, got '%s'", raw)
+	}
+
+	data.Nickname = "c"
+	data.Overflow.Freeze()
+	if err := Demote(&data, "nickname"); err != ErrFrozen {
+		t.Fatalf("Expected ErrFrozen, got '%v'", err)
+	}
+	if data.Nickname != "c" {
+		t.Fatalf("Expected the field to be restored, got '%s'", data.Nickname)
+	}
+}
+
+func TestDemoteErrors(t *testing.T) {
+	data := NicknameData{Age: 3}
+	expected := "Cannot demote 'age': it is encoded even when zero, so it must be tagged omitempty or omitzero"
+	if err := Demote(&data, "age"); err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+	if data.Age != 3 || data.Overflow != nil {
+		t.Fatalf("Expected the struct to be unchanged, got %+v", data)
+	}
+
+	if err := Demote(&data, "colour"); err == nil || err.Error() != "No named field 'colour'" {
+		t.Fatalf("Expected an error for a missing field, got '%v'", err)
+	}
+	if err := Demote(data, "age"); err == nil {
+		t.Fatalf("Expected an error for a struct that is not a pointer")
+	}
+}
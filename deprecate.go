@@ -0,0 +1,82 @@
+package j2n
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Deprecation reports a deprecated key found in a document.
+type Deprecation struct {
+	// Type is the name of the struct type being parsed.
+	Type string
+
+	// Key is the deprecated key, and Replacement the key that clients
+	// should send instead, which may be "".
+	Key         string
+	Replacement string
+}
+
+// Returns an Option that marks key as deprecated in favour of replacement.
+// Whenever the key is present in a document, as a named field or in
+// Overflow, a successful UnmarshalJSON reports it to the function given with
+// WithDeprecationWarning, or logs a warning to the Logger for warnings (see
+// WithWarningLogger).
+//
+// Deprecations are normally registered for a type with Configure:
+//
+//	j2n.Configure(CatData{},
+//		j2n.WithDeprecatedKey("fullname", "name"),
+//		j2n.WithDeprecatedKey("colour", ""),
+//	)
+func WithDeprecatedKey(key, replacement string) Option {
+	return func(c *config) {
+		if c.deprecations == nil {
+			c.deprecations = make(map[string]string)
+		}
+		c.deprecations[key] = replacement
+	}
+}
+
+// Returns an Option that reports deprecated keys to warn instead of logging
+// them.
+func WithDeprecationWarning(warn func(d Deprecation)) Option {
+	return func(c *config) {
+		c.warnDeprecated = warn
+	}
+}
+
+// Returns the deprecated keys present in the top level of a document, in
+// key order.
+func (c *config) deprecatedKeys(document map[string]*json.RawMessage) []string {
+	var keys []string
+	for k := range c.deprecations {
+		if _, ok := document[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *config) reportDeprecations(v interface{}, keys []string) error {
+	for _, k := range keys {
+		d := Deprecation{Type: structType(v).String(), Key: k, Replacement: c.deprecations[k]}
+
+		var err error
+		if c.warnDeprecated != nil {
+			err = callHook("WithDeprecationWarning", k, func() error {
+				c.warnDeprecated(d)
+				return nil
+			})
+		} else {
+			err = callHook("Logger", k, func() error {
+				c.warningLogger().Warn("Deprecated JSON field", "type", d.Type, "key", d.Key, "replacement", d.Replacement)
+				return nil
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithDeprecatedKeyReportsNamedAndOverflowKeys(t *testing.T) {
+	p := PersonData{}
+
+	var reported []Deprecation
+	err := UnmarshalJSON([]byte(`{"name":"Bert","fullname":"Bert Smith","age":29}`), &p,
+		WithDeprecatedKey("name", "displayName"),
+		WithDeprecatedKey("fullname", ""),
+		WithDeprecatedKey("colour", "color"),
+		WithDeprecationWarning(func(d Deprecation) {
+			reported = append(reported, d)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := []Deprecation{
+		{Type: "j2n.PersonData", Key: "fullname", Replacement: ""},
+		{Type: "j2n.PersonData", Key: "name", Replacement: "displayName"},
+	}
+	if !reflect.DeepEqual(reported, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, reported)
+	}
+
+	if p.Name != "Bert" || p.Overflow["fullname"] == nil {
+		t.Fatalf("Expected deprecated keys to still be parsed, got '%+v'", p)
+	}
+}
+
+func TestWithDeprecatedKeyDoesNotReportOnError(t *testing.T) {
+	p := PersonData{}
+
+	reported := false
+	err := UnmarshalJSON([]byte(`{"name":3}`), &p,
+		WithDeprecatedKey("name", "displayName"),
+		WithDeprecationWarning(func(d Deprecation) {
+			reported = true
+		}),
+	)
+	if err == nil || reported {
+		t.Fatalf("Expected error and no report, got '%v' and %t", err, reported)
+	}
+}
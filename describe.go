@@ -0,0 +1,235 @@
+package j2n
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Descriptor is a machine-readable description of what UnmarshalJSON will
+// consume for a struct type: the keys it parses into named fields, and what
+// it does with any other key. It marshals to JSON for use by external
+// tooling, such as documentation generators and API gateways.
+type Descriptor struct {
+	Type     string             `json:"type"`
+	Fields   []FieldDescriptor  `json:"fields"`
+	Overflow OverflowDescriptor `json:"overflow"`
+	Options  OptionsDescriptor  `json:"options"`
+}
+
+// FieldDescriptor describes a named field of a struct.
+type FieldDescriptor struct {
+	// The JSON key
+	Key string `json:"key"`
+
+	// The path of the Go field, such as Address.City for a field of an
+	// embedded struct, and its type
+	Field  string `json:"field"`
+	GoType string `json:"goType"`
+
+	// The full struct tag
+	Tag string `json:"tag,omitempty"`
+
+	OmitEmpty bool `json:"omitEmpty,omitempty"`
+	OmitZero  bool `json:"omitZero,omitempty"`
+	String    bool `json:"string,omitempty"`
+}
+
+// OverflowDescriptor describes how keys that are not named fields are
+// handled.
+type OverflowDescriptor struct {
+	// The type of the Overflow field
+	GoType string `json:"goType"`
+
+	// The UnknownFieldPolicy, as given by its String method
+	UnknownFields string `json:"unknownFields"`
+
+	// Whether only "x-" extensions are allowed, as set by WithExtensionsOnly
+	ExtensionsOnly bool `json:"extensionsOnly,omitempty"`
+
+	// The patterns set by WithOverflowKeyPattern and WithOverflowKeyFilter
+	KeyPatterns []KeyPatternDescriptor `json:"keyPatterns,omitempty"`
+
+	// The Go types expected for keys by WithOverflowType
+	Types map[string]string `json:"types,omitempty"`
+
+	// The deprecated keys set by WithDeprecatedKey, with their replacements
+	Deprecated map[string]string `json:"deprecated,omitempty"`
+
+	// The number of validators set by WithOverflowValidator
+	Validators int `json:"validators,omitempty"`
+}
+
+// KeyPatternDescriptor describes a pattern that overflow keys must match.
+// Action is "reject" for WithOverflowKeyPattern or "drop" for
+// WithOverflowKeyFilter.
+type KeyPatternDescriptor struct {
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+}
+
+// OptionsDescriptor describes the options that apply to the whole document.
+// Options that take functions can only be counted.
+type OptionsDescriptor struct {
+	Migrations     []MigrationsDescriptor `json:"migrations,omitempty"`
+	Rules          []RuleDescriptor       `json:"rules,omitempty"`
+	Validators     int                    `json:"validators,omitempty"`
+	AfterUnmarshal int                    `json:"afterUnmarshal,omitempty"`
+}
+
+// MigrationsDescriptor describes a set of Migrations by their version key
+// and the versions each step migrates between.
+type MigrationsDescriptor struct {
+	VersionKey string                `json:"versionKey"`
+	Steps      []MigrationDescriptor `json:"steps"`
+}
+
+// MigrationDescriptor describes a step registered with Migrations.Register.
+type MigrationDescriptor struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RuleDescriptor describes a Rule by the name of the function that made it,
+// such as "requiredIf", and its arguments.
+type RuleDescriptor struct {
+	Kind   string        `json:"kind"`
+	Keys   []string      `json:"keys"`
+	Values []interface{} `json:"values,omitempty"`
+}
+
+// Returns a description of the struct type of v as UnmarshalJSON sees it,
+// with the options registered for the type with Configure followed by opts.
+// v must be a struct with an 'Overflow' field, or a pointer to one.
+func Describe(v interface{}, opts ...Option) (*Descriptor, error) {
+	overflow, err := getOverflowFieldValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	t := structType(v)
+	c := newConfig(v, opts)
+
+	d := &Descriptor{
+		Type:   t.String(),
+		Fields: []FieldDescriptor{},
+		Overflow: OverflowDescriptor{
+			GoType:         overflow.Type().String(),
+			UnknownFields:  c.unknownFields.String(),
+			ExtensionsOnly: c.extensionsOnly,
+			Validators:     len(c.overflowValidators) - c.describedValidators,
+		},
+		Options: OptionsDescriptor{
+			Validators:     len(c.validators),
+			AfterUnmarshal: len(c.afterUnmarshal),
+		},
+	}
+
+	for _, f := range namedFields(t) {
+		d.Fields = append(d.Fields, FieldDescriptor{
+			Key:       f.name,
+			Field:     fieldPath(t, f.index),
+			GoType:    f.typ.String(),
+			Tag:       string(f.tag),
+			OmitEmpty: f.omitEmpty,
+			OmitZero:  f.omitZero,
+			String:    f.quoted,
+		})
+	}
+
+	for _, p := range c.keyPatterns {
+		action := "reject"
+		if p.drop {
+			action = "drop"
+		}
+		d.Overflow.KeyPatterns = append(d.Overflow.KeyPatterns, KeyPatternDescriptor{Pattern: p.pattern.String(), Action: action})
+	}
+
+	if len(c.overflowTypes) > 0 {
+		d.Overflow.Types = make(map[string]string, len(c.overflowTypes))
+		for k, t := range c.overflowTypes {
+			d.Overflow.Types[k] = t.String()
+		}
+	}
+
+	if len(c.deprecations) > 0 {
+		d.Overflow.Deprecated = make(map[string]string, len(c.deprecations))
+		for k, replacement := range c.deprecations {
+			d.Overflow.Deprecated[k] = replacement
+		}
+	}
+
+	for _, m := range c.migrations {
+		d.Options.Migrations = append(d.Options.Migrations, m.describe())
+	}
+
+	for _, r := range c.rules {
+		d.Options.Rules = append(d.Options.Rules, RuleDescriptor{Kind: r.kind, Keys: r.keys, Values: r.values})
+	}
+
+	return d, nil
+}
+
+// Returns descriptions of every type with options registered by Configure,
+// sorted by type name.
+func DescribeRegistered() ([]*Descriptor, error) {
+	var types []reflect.Type
+	typeOptionRegistry.Range(func(key, value interface{}) bool {
+		types = append(types, key.(reflect.Type))
+		return true
+	})
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].String() < types[j].String()
+	})
+
+	descriptors := make([]*Descriptor, 0, len(types))
+	for _, t := range types {
+		d, err := Describe(reflect.New(t).Interface())
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, d)
+	}
+	return descriptors, nil
+}
+
+// Returns the name of the UnknownFieldPolicy in lower case, such as
+// "reject".
+func (p UnknownFieldPolicy) String() string {
+	switch p {
+	case AllowUnknown:
+		return "allow"
+	case WarnUnknown:
+		return "warn"
+	case CollectUnknown:
+		return "collect"
+	case RejectUnknown:
+		return "reject"
+	}
+	return "unknown"
+}
+
+func (m *Migrations) describe() MigrationsDescriptor {
+	d := MigrationsDescriptor{VersionKey: m.versionKey, Steps: []MigrationDescriptor{}}
+	for from, step := range m.steps {
+		d.Steps = append(d.Steps, MigrationDescriptor{From: from, To: step.to})
+	}
+	sort.Slice(d.Steps, func(i, j int) bool {
+		return d.Steps[i].From < d.Steps[j].From
+	})
+	return d
+}
+
+// Returns the dotted path of Go field names to the field at index.
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, n := range index {
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		f := t.Field(n)
+		names[i] = f.Name
+		t = f.Type
+	}
+	return strings.Join(names, ".")
+}
@@ -0,0 +1,77 @@
+package j2n
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+)
+
+type DescribedAddress struct {
+	City string `json:"city"`
+}
+
+type DescribedData struct {
+	DescribedAddress
+	Name     string   `json:"name"`
+	Age      int      `json:"age,omitempty,string"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestDescribeListsFieldsAndOptions(t *testing.T) {
+	Configure(DescribedData{}, WithUnknownFields(WarnUnknown), WithDeprecatedKey("fullname", "name"))
+	defer Configure(DescribedData{})
+
+	d, err := Describe(&DescribedData{},
+		WithOverflowKeyFilter(regexp.MustCompile(`^x-`)),
+		WithOverflowType("x-rank", 0),
+		WithOverflowValidator(func(key string, raw json.RawMessage) error { return nil }),
+		WithMigrations(NewMigrations("v").Register("2", "3", Rename("a", "b")).Register("1", "2", Rename("b", "c"))),
+		WithRules(RequiredIf("age", "name", "Bert"), AtLeastOneOf("name", "city")),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"type":"j2n.DescribedData","fields":[` +
+		`{"key":"city","field":"DescribedAddress.City","goType":"string","tag":"json:\"city\""},` +
+		`{"key":"name","field":"Name","goType":"string","tag":"json:\"name\""},` +
+		`{"key":"age","field":"Age","goType":"int","tag":"json:\"age,omitempty,string\"","omitEmpty":true,"string":true}],` +
+		`"overflow":{"goType":"j2n.Overflow","unknownFields":"warn","keyPatterns":[{"pattern":"^x-","action":"drop"}],` +
+		`"types":{"x-rank":"int"},"deprecated":{"fullname":"name"},"validators":1},` +
+		`"options":{"migrations":[{"versionKey":"v","steps":[{"from":"1","to":"2"},{"from":"2","to":"3"}]}],` +
+		`"rules":[{"kind":"requiredIf","keys":["age","name"],"values":["Bert"]},{"kind":"atLeastOneOf","keys":["name","city"]}]}}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+func TestDescribeRegisteredIncludesConfiguredTypes(t *testing.T) {
+	Configure(DescribedData{}, WithExtensionsOnly())
+	defer Configure(DescribedData{})
+
+	descriptors, err := DescribeRegistered()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, d := range descriptors {
+		if d.Type == "j2n.DescribedData" {
+			if !d.Overflow.ExtensionsOnly || d.Overflow.Validators != 0 {
+				t.Fatalf("Expected extensions only without custom validators, got '%+v'", d.Overflow)
+			}
+			return
+		}
+	}
+	t.Fatalf("Expected DescribedData in '%v'", descriptors)
+}
+
+func TestDescribeRejectsStructWithoutOverflow(t *testing.T) {
+	if _, err := Describe(&PersonDataWithoutOverflow{}); err == nil {
+		t.Fatal("Expected error describing struct without Overflow")
+	}
+}
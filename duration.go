@@ -0,0 +1,54 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Returns an Option that reads time.Duration fields from Go duration
+// strings such as "1h30m", or from integers counting unit, which is
+// typically time.Nanosecond or time.Millisecond, and writes them as
+// duration strings:
+//
+//	type JobData struct {
+//		Timeout  time.Duration `json:"timeout"`
+//		Overflow j2n.Overflow  `json:"-"`
+//	}
+//
+//	j2n.Configure(JobData{}, j2n.WithDurationStrings(time.Millisecond))
+//
+// Without it, encoding/json reads and writes integers of nanoseconds.
+//
+// unit must be positive. Options cannot fail when they are built, so a
+// unit that is not makes UnmarshalJSON fail for every document instead.
+func WithDurationStrings(unit time.Duration) Option {
+	if unit <= 0 {
+		return WithValidator(func([]byte) error {
+			return fmt.Errorf("WithDurationStrings requires a positive unit, got %s", unit)
+		})
+	}
+
+	decode := func(raw json.RawMessage) (time.Duration, error) {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return time.ParseDuration(s)
+		}
+
+		n, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("Expected a duration string or an integer, got %s", raw)
+		}
+		if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+			return 0, fmt.Errorf("Duration out of range: %s", raw)
+		}
+		return time.Duration(n) * unit, nil
+	}
+	encode := func(d time.Duration) ([]byte, error) {
+		return json.Marshal(d.String())
+	}
+
+	return WithTypeCodec(decode, encode)
+}
@@ -0,0 +1,71 @@
+package j2n
+
+import (
+	"testing"
+	"time"
+)
+
+type DurationData struct {
+	Timeout  time.Duration  `json:"timeout"`
+	Retry    *time.Duration `json:"retry"`
+	Overflow Overflow       `json:"-"`
+}
+
+func TestDurationStrings(t *testing.T) {
+	data := DurationData{}
+	err := UnmarshalJSON([]byte(`{"timeout":"1h30m","retry":1500}`), &data, WithDurationStrings(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Timeout != 90*time.Minute || data.Retry == nil || *data.Retry != 1500*time.Millisecond {
+		t.Fatalf("Expected 1h30m and 1.5s, got %+v", data)
+	}
+
+	output, err := MarshalJSON(&data, WithDurationStrings(time.Millisecond))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"retry":"1.5s","timeout":"1h30m0s"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+
+	err = UnmarshalJSON([]byte(`{"timeout":1.5}`), &data, WithDurationStrings(time.Nanosecond))
+	expected = "Cannot decode field 'timeout': Expected a duration string or an integer, got 1.5"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestDurationStringsRange(t *testing.T) {
+	err := UnmarshalJSON([]byte(`{"timeout":99999999999999999}`), &DurationData{}, WithDurationStrings(time.Millisecond))
+	expected := "Cannot decode field 'timeout': Duration out of range: 99999999999999999"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	data := DurationData{}
+	if err := UnmarshalJSON([]byte(`{"Timeout":"1h"}`), &data, WithDurationStrings(time.Millisecond)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Timeout != time.Hour {
+		t.Fatalf("Expected 1h, got %s", data.Timeout)
+	}
+}
+
+func TestDurationsWithoutOption(t *testing.T) {
+	err := UnmarshalJSON([]byte(`{"timeout":"1h"}`), &DurationData{})
+	if err == nil {
+		t.Fatalf("Expected encoding/json to reject a duration string")
+	}
+}
+
+func TestDurationStringsRequirePositiveUnit(t *testing.T) {
+	for _, unit := range []time.Duration{0, -time.Second} {
+		err := UnmarshalJSON([]byte(`{"timeout":5}`), &DurationData{}, WithDurationStrings(unit))
+		expected := "WithDurationStrings requires a positive unit, got " + unit.String()
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected '%s', got '%v'", expected, err)
+		}
+	}
+}
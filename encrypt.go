@@ -0,0 +1,176 @@
+package j2n
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Cipher encrypts overflow values for WithEncryptedOverflow. The key ID
+// returned by Encrypt is stored next to the ciphertext, so that keys can be
+// rotated while older values can still be decrypted. additionalData is the
+// overflow key, which must be the same when decrypting, so an encrypted
+// value cannot be moved to another key.
+type Cipher interface {
+	Encrypt(plaintext, additionalData []byte) (keyID string, ciphertext []byte, err error)
+	Decrypt(keyID string, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// EnvelopeVersion identifies the format of encrypted overflow values:
+//
+//	{"$encrypted":"v1","kid":"2024-06","data":"<base64 ciphertext>"}
+const EnvelopeVersion = "v1"
+
+type envelope struct {
+	Version string `json:"$encrypted"`
+	KeyID   string `json:"kid"`
+	Data    []byte `json:"data"`
+}
+
+// Returns a Cipher using AES-GCM with a random nonce, which it prepends to
+// the ciphertext. keys maps key IDs to 16, 24 or 32 byte AES keys, and
+// current is the ID of the key that new values are encrypted with.
+func NewAESGCM(keys map[string][]byte, current string) (Cipher, error) {
+	c := &aesGCM{aeads: make(map[string]cipher.AEAD, len(keys)), current: current}
+	for id, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid key '%s': %s", id, err)
+		}
+		if c.aeads[id], err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := c.aeads[current]; !ok {
+		return nil, fmt.Errorf("No key with ID '%s'", current)
+	}
+	return c, nil
+}
+
+type aesGCM struct {
+	aeads   map[string]cipher.AEAD
+	current string
+}
+
+func (c *aesGCM) Encrypt(plaintext, additionalData []byte) (string, []byte, error) {
+	aead := c.aeads[c.current]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return c.current, aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func (c *aesGCM) Decrypt(keyID string, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, ok := c.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("No key with ID '%s'", keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("Ciphertext is too short")
+	}
+	nonce := ciphertext[:aead.NonceSize()]
+	return aead.Open(nil, nonce, ciphertext[aead.NonceSize():], additionalData)
+}
+
+type encryptedKeys struct {
+	cipher Cipher
+	keys   map[string]bool
+}
+
+// Returns an Option that encrypts the given overflow keys, or all of them if
+// there are none, with cipher. MarshalJSON replaces their values with
+// envelopes holding the ciphertext, and UnmarshalJSON decrypts envelopes
+// back into the plain values, before any other processing of Overflow. A
+// value that is not an envelope is accepted as it is, so that documents
+// written before encryption was enabled can still be read.
+//
+// This is for storing extension data containing personal data at rest:
+//
+//	aead, err := j2n.NewAESGCM(map[string][]byte{"2024-06": key}, "2024-06")
+//	j2n.Configure(PatientData{}, j2n.WithEncryptedOverflow(aead, "ssn", "notes"))
+func WithEncryptedOverflow(cipher Cipher, keys ...string) Option {
+	e := encryptedKeys{cipher: cipher}
+	if len(keys) > 0 {
+		e.keys = make(map[string]bool, len(keys))
+		for _, k := range keys {
+			e.keys[k] = true
+		}
+	}
+
+	return func(c *config) {
+		c.ciphers = append(c.ciphers, e)
+	}
+}
+
+func (e encryptedKeys) covers(key string) bool {
+	return e.keys == nil || e.keys[key]
+}
+
+// Replaces the designated values in overflow with envelopes.
+func (c *config) encryptOverflow(overflow map[string]*json.RawMessage) error {
+	for _, e := range c.ciphers {
+		for _, k := range Overflow(overflow).sortedKeys() {
+			if !e.covers(k) {
+				continue
+			}
+
+			keyID, ciphertext, err := e.cipher.Encrypt(rawOrNull(overflow[k]), []byte(k))
+			if err != nil {
+				return &OverflowError{Key: k, Err: err}
+			}
+			sealed, err := json.Marshal(envelope{Version: EnvelopeVersion, KeyID: keyID, Data: ciphertext})
+			if err != nil {
+				return err
+			}
+			overflow[k] = (*json.RawMessage)(&sealed)
+		}
+	}
+	return nil
+}
+
+// Replaces the envelopes among the designated values in overflow with the
+// values they hold. Ciphers are applied in the reverse order of
+// encryptOverflow.
+func (c *config) decryptOverflow(overflow map[string]*json.RawMessage) error {
+	for i := len(c.ciphers) - 1; i >= 0; i-- {
+		e := c.ciphers[i]
+		for _, k := range Overflow(overflow).sortedKeys() {
+			if !e.covers(k) {
+				continue
+			}
+
+			sealed, ok := parseEnvelope(rawOrNull(overflow[k]))
+			if !ok {
+				continue
+			}
+			plaintext, err := e.cipher.Decrypt(sealed.KeyID, sealed.Data, []byte(k))
+			if err != nil {
+				return &OverflowError{Key: k, Err: fmt.Errorf("Cannot decrypt: %s", err)}
+			}
+			if !json.Valid(plaintext) {
+				return &OverflowError{Key: k, Err: errors.New("Decrypted value is not valid JSON")}
+			}
+			overflow[k] = (*json.RawMessage)(&plaintext)
+		}
+	}
+	return nil
+}
+
+func parseEnvelope(raw json.RawMessage) (envelope, bool) {
+	var e envelope
+	if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		return e, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&e); err != nil || e.Version != EnvelopeVersion {
+		return e, false
+	}
+	return e, true
+}
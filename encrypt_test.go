@@ -0,0 +1,96 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var (
+	oldKey = bytes.Repeat([]byte{1}, 32)
+	newKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestWithEncryptedOverflowRoundTrip(t *testing.T) {
+	aead, err := NewAESGCM(map[string][]byte{"k1": oldKey}, "k1")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := OverflowPersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","ssn":"123-45-6789","city":"Paris"}`), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	data, err := MarshalJSON(p, WithEncryptedOverflow(aead, "ssn"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if strings.Contains(string(data), "6789") || !strings.Contains(string(data), `"city":"Paris"`) {
+		t.Fatalf("Expected only 'ssn' to be encrypted, got '%s'", data)
+	}
+
+	var stored struct{ Ssn envelope }
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Ssn.KeyID != "k1" || stored.Ssn.Version != EnvelopeVersion {
+		t.Fatalf("Expected an envelope with key 'k1', got '%s'", data)
+	}
+
+	decoded := OverflowPersonData{}
+	if err := UnmarshalJSON(data, &decoded, WithEncryptedOverflow(aead, "ssn")); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(*decoded.Overflow["ssn"]) != `"123-45-6789"` {
+		t.Fatalf("Expected '\"123-45-6789\"', got '%s'", *decoded.Overflow["ssn"])
+	}
+}
+
+func TestWithEncryptedOverflowDecryptsWithRotatedKeys(t *testing.T) {
+	old, _ := NewAESGCM(map[string][]byte{"k1": oldKey}, "k1")
+	rotated, err := NewAESGCM(map[string][]byte{"k1": oldKey, "k2": newKey}, "k2")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := OverflowPersonData{Name: "Bert", Overflow: newTestOverflow(map[string]string{"notes": `[1,2]`})}
+	data, err := MarshalJSON(p, WithEncryptedOverflow(old))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	decoded := OverflowPersonData{}
+	if err := UnmarshalJSON(data, &decoded, WithEncryptedOverflow(rotated)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(*decoded.Overflow["notes"]) != `[1,2]` {
+		t.Fatalf("Expected '[1,2]', got '%s'", *decoded.Overflow["notes"])
+	}
+}
+
+func TestWithEncryptedOverflowRejectsMovedValues(t *testing.T) {
+	aead, _ := NewAESGCM(map[string][]byte{"k1": oldKey}, "k1")
+
+	p := OverflowPersonData{Name: "Bert", Overflow: newTestOverflow(map[string]string{"a": `"secret"`})}
+	data, err := MarshalJSON(p, WithEncryptedOverflow(aead))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	moved := strings.Replace(string(data), `"a":`, `"b":`, 1)
+	err = UnmarshalJSON([]byte(moved), &OverflowPersonData{}, WithEncryptedOverflow(aead))
+	if err == nil || !strings.HasPrefix(err.Error(), "Invalid overflow field 'b': Cannot decrypt") {
+		t.Fatalf("Expected a decryption error for 'b', got '%v'", err)
+	}
+}
+
+func TestWithEncryptedOverflowAcceptsPlainValues(t *testing.T) {
+	aead, _ := NewAESGCM(map[string][]byte{"k1": oldKey}, "k1")
+
+	p := OverflowPersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","ssn":"123"}`), &p, WithEncryptedOverflow(aead)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(*p.Overflow["ssn"]) != `"123"` {
+		t.Fatalf("Expected '\"123\"', got '%s'", *p.Overflow["ssn"])
+	}
+}
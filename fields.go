@@ -0,0 +1,218 @@
+package j2n
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A field describes a named field of a struct as encoding/json sees it.
+type field struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	tag       reflect.StructTag
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// Returns the fields that encoding/json would encode for the struct type t,
+// following its rules for embedded structs and conflicting names.
+func namedFields(t reflect.Type) []field {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]field)
+	}
+
+	fields := typeFields(t)
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// Returns the set of JSON keys of the named fields of the struct type t.
+func namedKeys(t reflect.Type) map[string]bool {
+	fields := namedFields(t)
+	keys := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keys[f.name] = true
+	}
+	return keys
+}
+
+// Returns the named field with the given JSON key, if there is one. As with
+// encoding/json, an exact match is preferred to a case-insensitive one.
+func namedField(t reflect.Type, key string) (field, bool) {
+	fields := namedFields(t)
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+func typeFields(t reflect.Type) []field {
+	type candidate struct {
+		field
+		tagged bool
+	}
+
+	var candidates []candidate
+	visited := make(map[reflect.Type]bool)
+	current := []candidate{{field: field{typ: t}}}
+
+	for len(current) > 0 {
+		var next []candidate
+
+		for _, c := range current {
+			if visited[c.typ] {
+				continue
+			}
+			visited[c.typ] = true
+
+			for i := 0; i < c.typ.NumField(); i++ {
+				sf := c.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				name, options, _ := strings.Cut(tag, ",")
+				if !isValidTagName(name) {
+					name = ""
+				}
+
+				index := make([]int, len(c.index)+1)
+				copy(index, c.index)
+				index[len(c.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, candidate{field: field{typ: ft, index: index}})
+					continue
+				}
+
+				tagged := name != ""
+				if name == "" {
+					name = sf.Name
+				}
+
+				f := field{
+					name:      name,
+					index:     index,
+					typ:       sf.Type,
+					tag:       sf.Tag,
+					omitEmpty: hasTagOption(options, "omitempty"),
+					omitZero:  hasTagOption(options, "omitzero"),
+					quoted:    hasTagOption(options, "string"),
+				}
+				candidates = append(candidates, candidate{field: f, tagged: tagged})
+			}
+		}
+
+		current = next
+	}
+
+	// Apply encoding/json's dominance rules: the shallowest field wins, then
+	// a tagged field, and any remaining tie hides the name entirely
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		return a.tagged && !b.tagged
+	})
+
+	var fields []field
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+
+		group := candidates[i:j]
+		dominant := group[0]
+		if len(group) == 1 || len(group[1].index) > len(dominant.index) || (dominant.tagged && !group[1].tagged) {
+			fields = append(fields, dominant.field)
+		}
+		i = j
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	return fields
+}
+
+func lessIndex(a, b []int) bool {
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func hasTagOption(options, option string) bool {
+	for options != "" {
+		var current string
+		current, options, _ = strings.Cut(options, ",")
+		if current == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+		case c == '\\' || c == '"' || c == '\'' || c == '`' || c == ',':
+			return false
+		case c < ' ':
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the struct value of v, unwrapping a pointer if necessary.
+func structValue(v interface{}) (reflect.Value, bool) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	return value, value.Kind() == reflect.Struct
+}
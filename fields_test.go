@@ -0,0 +1,50 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+type EmbeddedFieldsInner struct {
+	City   string `json:"city"`
+	Street string
+	Name   string `json:"name"`
+}
+
+type EmbeddedFieldsOuter struct {
+	EmbeddedFieldsInner
+	Name    string `json:"name,omitempty"`
+	Ignored string `json:"-"`
+	Count   int    `json:",string"`
+	Rank    int    `json:"rank,omitzero"`
+	private string
+}
+
+func TestNamedFieldsFollowsEncodingJSONRules(t *testing.T) {
+	fields := namedFields(reflect.TypeOf(EmbeddedFieldsOuter{}))
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+
+	expected := []string{"city", "Street", "name", "Count", "rank"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, names)
+	}
+
+	name, _ := namedField(reflect.TypeOf(EmbeddedFieldsOuter{}), "name")
+	if !name.omitEmpty || len(name.index) != 1 {
+		t.Fatalf("Expected the outer omitempty 'name' field, got '%+v'", name)
+	}
+
+	rank, _ := namedField(reflect.TypeOf(EmbeddedFieldsOuter{}), "rank")
+	if !rank.omitZero || rank.omitEmpty {
+		t.Fatalf("Expected omitzero 'rank' field, got '%+v'", rank)
+	}
+
+	count, _ := namedField(reflect.TypeOf(EmbeddedFieldsOuter{}), "count")
+	if !count.quoted {
+		t.Fatalf("Expected case-insensitive match on quoted 'Count', got '%+v'", count)
+	}
+}
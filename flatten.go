@@ -0,0 +1,157 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Returns v, which must be a struct, as a flat map whose keys are the dotted
+// paths to each leaf value.
+//
+// Named fields and the entries of v.Overflow are both included, so
+//
+//	{"name":"Bert","address":{"city":"Leeds"},"tags":["a","b"]}
+//
+// flattens to
+//
+//	name          -> "Bert"
+//	address.city  -> "Leeds"
+//	tags.0        -> "a"
+//	tags.1        -> "b"
+//
+// Leaf values are strings, bools, nil or json.Number, so that numbers are not
+// rounded on the way through. Empty objects and arrays are kept as leaves.
+//
+// Keys that themselves contain a '.' cannot be told apart from nested keys,
+// and will not survive a Flatten/Unflatten round trip.
+func Flatten(v interface{}) (map[string]interface{}, error) {
+	data, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	if err := decodeUsingNumber(data, &tree); err != nil {
+		return nil, err
+	}
+
+	flat := make(map[string]interface{})
+	flattenInto(flat, "", tree)
+	return flat, nil
+}
+
+// Parses a flat map of dotted keys, as produced by Flatten, into the struct
+// pointed to by v.
+//
+// Any keys that do not correspond to named fields end up in v.Overflow, as
+// they would with UnmarshalJSON. A level whose keys are exactly 0..n-1 is
+// rebuilt as an array.
+func Unflatten(flat map[string]interface{}, v interface{}) error {
+	tree := make(map[string]interface{})
+
+	// Sort the keys so that conflicts are reported deterministically
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := unflattenInto(tree, k, flat[k]); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(restoreArrays(tree))
+	if err != nil {
+		return err
+	}
+
+	return UnmarshalJSON(data, v)
+}
+
+func flattenInto(flat map[string]interface{}, prefix string, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 && prefix != "" {
+			flat[prefix] = value
+		}
+		for k, child := range value {
+			flattenInto(flat, joinFlatKey(prefix, k), child)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			flat[prefix] = value
+		}
+		for i, child := range value {
+			flattenInto(flat, joinFlatKey(prefix, strconv.Itoa(i)), child)
+		}
+	default:
+		flat[prefix] = value
+	}
+}
+
+func joinFlatKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func unflattenInto(tree map[string]interface{}, key string, value interface{}) error {
+	parts := strings.Split(key, ".")
+	node := tree
+
+	for i, part := range parts[:len(parts)-1] {
+		child, ok := node[part]
+		if !ok {
+			next := make(map[string]interface{})
+			node[part] = next
+			node = next
+			continue
+		}
+
+		// An explicit null is a value like any other
+		next, ok := child.(map[string]interface{})
+		if !ok {
+			path := strings.Join(parts[:i+1], ".")
+			return fmt.Errorf("Flattened key '%s' conflicts with value at '%s'", key, path)
+		}
+		node = next
+	}
+
+	last := parts[len(parts)-1]
+	if _, ok := node[last]; ok {
+		return fmt.Errorf("Flattened key '%s' conflicts with a nested key", key)
+	}
+	node[last] = value
+	return nil
+}
+
+func restoreArrays(value interface{}) interface{} {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	for k, child := range m {
+		m[k] = restoreArrays(child)
+	}
+
+	if len(m) == 0 {
+		return m
+	}
+
+	array := make([]interface{}, len(m))
+	for k, child := range m {
+		i, err := strconv.Atoi(k)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != k {
+			return m
+		}
+		array[i] = child
+	}
+	return array
+}
@@ -0,0 +1,87 @@
+package j2n
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFlattenIncludesNamedAndOverflowFields(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","address":{"city":"Leeds"},"tags":["a","b"],"age":29}`), &p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	flat, err := Flatten(&p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]interface{}{
+		"name":         "Bert",
+		"address.city": "Leeds",
+		"tags.0":       "a",
+		"tags.1":       "b",
+		"age":          json.Number("29"),
+	}
+	if !reflect.DeepEqual(flat, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, flat)
+	}
+}
+
+func TestUnflattenRoundTrip(t *testing.T) {
+	flat := map[string]interface{}{
+		"name":         "Bert",
+		"address.city": "Leeds",
+		"tags.0":       "a",
+		"tags.1":       "b",
+		"empty":        map[string]interface{}{},
+	}
+
+	p := PersonData{}
+	if err := Unflatten(flat, &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+
+	expectedAddress := `{"city":"Leeds"}`
+	if string(*p.Overflow["address"]) != expectedAddress {
+		t.Fatalf("Expected '%s', got '%s'", expectedAddress, *p.Overflow["address"])
+	}
+
+	expectedTags := `["a","b"]`
+	if string(*p.Overflow["tags"]) != expectedTags {
+		t.Fatalf("Expected '%s', got '%s'", expectedTags, *p.Overflow["tags"])
+	}
+
+	expectedEmpty := `{}`
+	if string(*p.Overflow["empty"]) != expectedEmpty {
+		t.Fatalf("Expected '%s', got '%s'", expectedEmpty, *p.Overflow["empty"])
+	}
+}
+
+func TestUnflattenReturnsErrorOnConflictingKeys(t *testing.T) {
+	flat := map[string]interface{}{
+		"address":      "Leeds",
+		"address.city": "Leeds",
+	}
+
+	p := PersonData{}
+	if err := Unflatten(flat, &p); err == nil {
+		t.Fatal("Expected error unflattening conflicting keys")
+	}
+
+	flat = map[string]interface{}{
+		"address":      nil,
+		"address.city": "Leeds",
+	}
+	err := Unflatten(flat, &p)
+	expected := "Flattened key 'address.city' conflicts with value at 'address'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
@@ -0,0 +1,156 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Parses form values into the struct pointed to by v.
+//
+// Keys matching named fields are converted according to the field's type:
+// strings, booleans and numbers from the first value, and slices and arrays
+// from all of them. Any other keys are put in v.Overflow, as a JSON string
+// if they have a single value and as an array of strings otherwise.
+func UnmarshalForm(values url.Values, v interface{}) error {
+	if _, err := getOverflowFieldValue(v); err != nil {
+		return err
+	}
+	value, _ := structValue(v)
+
+	document := make(map[string]json.RawMessage, len(values))
+	for k, vs := range values {
+		if len(vs) == 0 {
+			continue
+		}
+
+		var raw json.RawMessage
+		var err error
+		if f, ok := namedField(value.Type(), k); ok {
+			raw, err = formFieldJSON(f.typ, vs)
+		} else if len(vs) == 1 {
+			raw, err = json.Marshal(vs[0])
+		} else {
+			raw, err = json.Marshal(vs)
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid value for form key '%s': %s", k, err)
+		}
+		document[k] = raw
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	return UnmarshalJSON(data, v)
+}
+
+// Returns v, which must be a struct, as form values.
+//
+// Named fields and the entries of v.Overflow are both included. Strings are
+// used as they are, numbers and booleans as their JSON text, and arrays give
+// one value per element. Nulls are left out, and objects are encoded as JSON.
+func MarshalForm(v interface{}) (url.Values, error) {
+	data, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	document := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	values := make(url.Values, len(document))
+	for k, raw := range document {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			var elements []json.RawMessage
+			if err := json.Unmarshal(raw, &elements); err != nil {
+				return nil, err
+			}
+			for _, element := range elements {
+				if s, ok := formValue(element); ok {
+					values.Add(k, s)
+				}
+			}
+			continue
+		}
+
+		if s, ok := formValue(raw); ok {
+			values.Set(k, s)
+		}
+	}
+
+	return values, nil
+}
+
+func formValue(raw json.RawMessage) (string, bool) {
+	switch {
+	case bytes.Equal(raw, []byte("null")):
+		return "", false
+	case len(raw) > 0 && raw[0] == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s, true
+		}
+	}
+	return string(raw), true
+}
+
+func formFieldJSON(t reflect.Type, values []string) (json.RawMessage, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return json.Marshal(values[0])
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return json.Marshal(values[0])
+	case reflect.Bool:
+		b, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(values[0], 64); err != nil {
+			return nil, err
+		}
+		return json.RawMessage(values[0]), nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return json.Marshal(values[0])
+		}
+		elements := make([]json.RawMessage, len(values))
+		for i, s := range values {
+			element, err := formFieldJSON(t.Elem(), []string{s})
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return json.Marshal(elements)
+	case reflect.Interface:
+		return json.Marshal(values[0])
+	}
+
+	// Structs and maps can only come from a JSON-encoded value
+	if json.Valid([]byte(values[0])) {
+		return json.RawMessage(values[0]), nil
+	}
+	return json.Marshal(values[0])
+}
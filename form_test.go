@@ -0,0 +1,76 @@
+package j2n
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type FormData struct {
+	Name     string                      `json:"name"`
+	Age      int                         `json:"age"`
+	Admin    bool                        `json:"admin"`
+	Tags     []string                    `json:"tags"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestUnmarshalFormConvertsNamedFields(t *testing.T) {
+	values := url.Values{
+		"name":   {"Bert"},
+		"age":    {"29"},
+		"admin":  {"true"},
+		"tags":   {"a", "b"},
+		"source": {"web"},
+		"ids":    {"1", "2"},
+	}
+
+	f := FormData{}
+	if err := UnmarshalForm(values, &f); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if f.Name != "Bert" || f.Age != 29 || !f.Admin || !reflect.DeepEqual(f.Tags, []string{"a", "b"}) {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", f)
+	}
+
+	if string(*f.Overflow["source"]) != `"web"` {
+		t.Fatalf("Expected '\"web\"', got '%s'", *f.Overflow["source"])
+	}
+
+	if string(*f.Overflow["ids"]) != `["1","2"]` {
+		t.Fatalf("Expected '[\"1\",\"2\"]', got '%s'", *f.Overflow["ids"])
+	}
+}
+
+func TestUnmarshalFormReturnsErrorForInvalidNamedValue(t *testing.T) {
+	f := FormData{}
+	if err := UnmarshalForm(url.Values{"age": {"old"}}, &f); err == nil {
+		t.Fatal("Expected error for a non-numeric age")
+	}
+}
+
+func TestMarshalFormRoundTrip(t *testing.T) {
+	values := url.Values{
+		"name":   {"Bert"},
+		"age":    {"29"},
+		"admin":  {"false"},
+		"tags":   {"a", "b"},
+		"source": {"web"},
+		"ids":    {"1", "2"},
+	}
+
+	f := FormData{}
+	if err := UnmarshalForm(values, &f); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	actual, err := MarshalForm(&f)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if !reflect.DeepEqual(actual, values) {
+		t.Fatalf("Expected '%v', got '%v'", values, actual)
+	}
+}
@@ -0,0 +1,36 @@
+package j2n
+
+import "errors"
+
+// ErrFrozen is returned when a frozen Overflow is changed.
+var ErrFrozen = errors.New("Overflow is frozen")
+
+// Freezer is implemented by the OverflowStores that can be made read-only,
+// SyncOverflow and COWOverflow. Once frozen, their Set and Delete methods
+// return ErrFrozen, and so does UnmarshalJSON into their struct.
+type Freezer interface {
+	Freeze()
+	Frozen() bool
+}
+
+// Returns an Option that freezes the Overflow store once the document has
+// been decoded successfully, so that instances shared through a cache
+// cannot be modified by accident. The Overflow field must be an
+// OverflowStore that implements Freezer:
+//
+//	j2n.Configure(PlanData{}, j2n.WithFrozenOverflow())
+//
+// A COWOverflow is thawed by taking a Snapshot, which is not frozen.
+func WithFrozenOverflow() Option {
+	return func(c *config) {
+		c.freeze = true
+	}
+}
+
+// Returns store as a Freezer if it can be frozen.
+func freezerOf(store OverflowStore) (Freezer, error) {
+	if f, ok := store.(Freezer); ok {
+		return f, nil
+	}
+	return nil, errors.New("WithFrozenOverflow requires an Overflow store that implements j2n.Freezer")
+}
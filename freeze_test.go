@@ -0,0 +1,95 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestWithFrozenOverflowRejectsChanges(t *testing.T) {
+	c := SyncCatData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Tom","age":3}`), &c, WithFrozenOverflow()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if !c.Overflow.Frozen() {
+		t.Fatalf("Expected the overflow to be frozen")
+	}
+	if err := c.Overflow.Set("age", json.RawMessage(`4`)); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("Expected '%s', got '%v'", ErrFrozen, err)
+	}
+	if err := c.Overflow.Delete("age"); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("Expected '%s', got '%v'", ErrFrozen, err)
+	}
+	if err := UnmarshalJSON([]byte(`{"name":"Tom","age":4}`), &c); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("Expected '%s', got '%v'", ErrFrozen, err)
+	}
+
+	if raw, _ := c.Overflow.Get("age"); string(raw) != "3" {
+		t.Fatalf("Expected '3', got '%s'", raw)
+	}
+}
+
+func TestFrozenCOWOverflowThawsBySnapshot(t *testing.T) {
+	e := COWEventData{}
+	if err := UnmarshalJSON([]byte(`{"kind":"push","a":1}`), &e, WithFrozenOverflow()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := e.Overflow.Set("a", json.RawMessage(`2`)); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("Expected '%s', got '%v'", ErrFrozen, err)
+	}
+
+	thawed := e.Overflow.Snapshot()
+	if err := thawed.Set("a", json.RawMessage(`2`)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if raw, _ := e.Overflow.Get("a"); string(raw) != "1" {
+		t.Fatalf("Expected '1', got '%s'", raw)
+	}
+}
+
+func TestFrozenCOWOverflowThawsConcurrently(t *testing.T) {
+	e := COWEventData{}
+	if err := UnmarshalJSON([]byte(`{"kind":"push","a":1}`), &e, WithFrozenOverflow()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			snapshot := e.Overflow.Snapshot()
+			if err := snapshot.Set("a", json.RawMessage(`2`)); err != nil {
+				t.Errorf("Expected no error, got '%s'", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if raw, _ := e.Overflow.Get("a"); string(raw) != "1" {
+		t.Fatalf("Expected '1', got '%s'", raw)
+	}
+}
+
+func TestWithFrozenOverflowIsNotAppliedOnFailure(t *testing.T) {
+	c := SyncCatData{}
+	err := UnmarshalJSON([]byte(`{"name":"Tom","age":"old"}`), &c, WithFrozenOverflow(), WithOverflowType("age", 0))
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if c.Overflow.Frozen() {
+		t.Fatalf("Expected the overflow not to be frozen")
+	}
+}
+
+func TestWithFrozenOverflowRequiresAFreezer(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &p, WithFrozenOverflow())
+
+	expected := "WithFrozenOverflow requires an Overflow store that implements j2n.Freezer"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
@@ -0,0 +1,191 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// The typed getters below return ok == false, with no error, if the key is
+// absent or its value is the JSON null. If the value is present but cannot be
+// coerced to the requested type, they return ok == false and an error naming
+// the key.
+
+// Returns the value at key as a string. JSON strings are returned as they
+// are; numbers and booleans are returned as their JSON text.
+func (o Overflow) GetString(key string) (string, bool, error) {
+	raw, ok := o.lookup(key)
+	if !ok {
+		return "", false, nil
+	}
+
+	switch raw[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", false, getterError(key, "string", err)
+		}
+		return s, true, nil
+	case 't', 'f', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return string(raw), true, nil
+	}
+
+	return "", false, getterError(key, "string", nil)
+}
+
+// Returns the value at key as an int64. JSON numbers and strings containing
+// a number are accepted, provided the number is integral and in range, so
+// 29, 29.0, "29" and "2.9e1" all give 29.
+func (o Overflow) GetInt64(key string) (int64, bool, error) {
+	n, ok, err := o.getNumber(key, "int64")
+	if !ok || err != nil {
+		return 0, false, err
+	}
+
+	if i, err := n.Int64(); err == nil {
+		return i, true, nil
+	}
+
+	f, err := n.Float64()
+	if err != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false, getterError(key, "int64", nil)
+	}
+	return int64(f), true, nil
+}
+
+// Returns the value at key as a float64. JSON numbers and strings containing
+// a finite number in range are accepted.
+func (o Overflow) GetFloat(key string) (float64, bool, error) {
+	n, ok, err := o.getNumber(key, "float64")
+	if !ok || err != nil {
+		return 0, false, err
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return 0, false, getterError(key, "float64", err)
+	}
+	return f, true, nil
+}
+
+// Returns the value at key as a bool. JSON booleans are accepted, as are
+// strings understood by strconv.ParseBool, such as "true", "FALSE" and "1".
+func (o Overflow) GetBool(key string) (bool, bool, error) {
+	raw, ok := o.lookup(key)
+	if !ok {
+		return false, false, nil
+	}
+
+	switch raw[0] {
+	case 't', 'f':
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return false, false, getterError(key, "bool", err)
+		}
+		return b, true, nil
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return false, false, getterError(key, "bool", err)
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return false, false, getterError(key, "bool", err)
+		}
+		return b, true, nil
+	}
+
+	return false, false, getterError(key, "bool", nil)
+}
+
+// Returns the value at key as a time.Time. Strings in RFC 3339 format are
+// parsed as such. Numbers, and strings containing a number, are taken to be
+// seconds since the Unix epoch, and may have a fractional part.
+func (o Overflow) GetTime(key string) (time.Time, bool, error) {
+	raw, ok := o.lookup(key)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return time.Time{}, false, getterError(key, "time", err)
+		}
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, true, nil
+		}
+	}
+
+	f, ok, err := o.GetFloat(key)
+	if !ok || err != nil {
+		return time.Time{}, false, getterError(key, "time", nil)
+	}
+
+	seconds, fraction := math.Modf(f)
+	if seconds < math.MinInt64 || seconds >= math.MaxInt64 {
+		return time.Time{}, false, getterError(key, "time", nil)
+	}
+	return time.Unix(int64(seconds), int64(fraction*1e9)).UTC(), true, nil
+}
+
+// Parses the value at key into the value pointed to by v with
+// json.Unmarshal, for types that the other getters do not cover. Unlike
+// them, it does not coerce strings to numbers.
+func (o Overflow) Decode(key string, v interface{}) (bool, error) {
+	raw, ok := o.lookup(key)
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, getterError(key, reflect.TypeOf(v).Elem().String(), err)
+	}
+	return true, nil
+}
+
+func (o Overflow) lookup(key string) (json.RawMessage, bool) {
+	raw, ok := o[key]
+	if !ok || raw == nil {
+		return nil, false
+	}
+
+	trimmed := bytes.TrimSpace(*raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, false
+	}
+	return trimmed, true
+}
+
+func (o Overflow) getNumber(key, typeName string) (json.Number, bool, error) {
+	raw, ok := o.lookup(key)
+	if !ok {
+		return "", false, nil
+	}
+
+	var s string
+	if raw[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", false, getterError(key, typeName, err)
+		}
+	} else {
+		s = string(raw)
+	}
+
+	// ParseFloat also accepts "NaN" and "Inf", which JSON has no numbers for
+	if f, err := strconv.ParseFloat(s, 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", false, getterError(key, typeName, nil)
+	}
+	return json.Number(s), true, nil
+}
+
+func getterError(key, typeName string, cause error) error {
+	if cause != nil {
+		return fmt.Errorf("Overflow key '%s' is not a valid %s: %s", key, typeName, cause)
+	}
+	return fmt.Errorf("Overflow key '%s' is not a valid %s", key, typeName)
+}
@@ -0,0 +1,133 @@
+package j2n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetStringCoercesScalars(t *testing.T) {
+	o := newTestOverflow(map[string]string{"s": `"Bert"`, "n": `29`, "b": `true`, "a": `[]`})
+
+	for key, expected := range map[string]string{"s": "Bert", "n": "29", "b": "true"} {
+		actual, ok, err := o.GetString(key)
+		if err != nil || !ok {
+			t.Fatalf("Expected '%s' to be present, got ok=%t err='%v'", key, ok, err)
+		}
+		if actual != expected {
+			t.Fatalf("Expected '%s', got '%s'", expected, actual)
+		}
+	}
+
+	if _, ok, err := o.GetString("a"); ok || err == nil {
+		t.Fatal("Expected error getting an array as a string")
+	}
+}
+
+func TestGettersReportAbsentAndNullKeys(t *testing.T) {
+	o := newTestOverflow(map[string]string{"null": `null`})
+	o["nil"] = nil
+
+	for _, key := range []string{"missing", "null", "nil"} {
+		if _, ok, err := o.GetInt64(key); ok || err != nil {
+			t.Fatalf("Expected '%s' to be absent, got ok=%t err='%v'", key, ok, err)
+		}
+	}
+}
+
+func TestGetInt64AcceptsNumericStringsAndIntegralFloats(t *testing.T) {
+	o := newTestOverflow(map[string]string{"a": `29`, "b": `"29"`, "c": `2.9e1`, "d": `29.5`, "e": `"abc"`})
+
+	for _, key := range []string{"a", "b", "c"} {
+		actual, ok, err := o.GetInt64(key)
+		if err != nil || !ok || actual != 29 {
+			t.Fatalf("Expected 29 for '%s', got %d ok=%t err='%v'", key, actual, ok, err)
+		}
+	}
+
+	for _, key := range []string{"d", "e"} {
+		if _, _, err := o.GetInt64(key); err == nil {
+			t.Fatalf("Expected error for '%s'", key)
+		}
+	}
+}
+
+func TestGetFloatAndGetBool(t *testing.T) {
+	o := newTestOverflow(map[string]string{"f": `"1.5"`, "b": `"FALSE"`, "t": `true`})
+
+	f, ok, err := o.GetFloat("f")
+	if err != nil || !ok || f != 1.5 {
+		t.Fatalf("Expected 1.5, got %v ok=%t err='%v'", f, ok, err)
+	}
+
+	b, ok, err := o.GetBool("b")
+	if err != nil || !ok || b {
+		t.Fatalf("Expected false, got %t ok=%t err='%v'", b, ok, err)
+	}
+
+	b, ok, err = o.GetBool("t")
+	if err != nil || !ok || !b {
+		t.Fatalf("Expected true, got %t ok=%t err='%v'", b, ok, err)
+	}
+}
+
+func TestGetTimeAcceptsRFC3339AndEpochSeconds(t *testing.T) {
+	o := newTestOverflow(map[string]string{
+		"rfc":   `"2020-01-02T03:04:05Z"`,
+		"epoch": `1577934245`,
+		"str":   `"1577934245.5"`,
+	})
+	expected := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, key := range []string{"rfc", "epoch"} {
+		actual, ok, err := o.GetTime(key)
+		if err != nil || !ok || !actual.Equal(expected) {
+			t.Fatalf("Expected '%s' for '%s', got '%s' ok=%t err='%v'", expected, key, actual, ok, err)
+		}
+	}
+
+	actual, _, _ := o.GetTime("str")
+	if !actual.Equal(expected.Add(500 * time.Millisecond)) {
+		t.Fatalf("Expected fractional seconds, got '%s'", actual)
+	}
+}
+
+func TestGettersRejectNonFiniteAndOutOfRangeNumbers(t *testing.T) {
+	o := newTestOverflow(map[string]string{"nan": `"NaN"`, "inf": `"-Inf"`, "big": `"1e400"`, "huge": `1e300`})
+
+	for _, key := range []string{"nan", "inf", "big"} {
+		if _, _, err := o.GetFloat(key); err == nil {
+			t.Fatalf("Expected error from GetFloat for '%s'", key)
+		}
+		if _, _, err := o.GetInt64(key); err == nil {
+			t.Fatalf("Expected error from GetInt64 for '%s'", key)
+		}
+	}
+	for _, key := range []string{"nan", "inf", "big", "huge"} {
+		if _, _, err := o.GetTime(key); err == nil {
+			t.Fatalf("Expected error from GetTime for '%s'", key)
+		}
+	}
+}
+
+func TestDecodeParsesStructuredValues(t *testing.T) {
+	o := newTestOverflow(map[string]string{"address": `{"city":"Leeds"}`, "null": `null`})
+
+	var address struct {
+		City string `json:"city"`
+	}
+	if ok, err := o.Decode("address", &address); !ok || err != nil {
+		t.Fatalf("Expected 'address' to decode, got ok=%t err='%v'", ok, err)
+	}
+	if address.City != "Leeds" {
+		t.Fatalf("Expected 'Leeds', got '%s'", address.City)
+	}
+
+	if ok, err := o.Decode("null", &address); ok || err != nil {
+		t.Fatalf("Expected null to be absent, got ok=%t err='%v'", ok, err)
+	}
+
+	var n int
+	if ok, err := o.Decode("address", &n); ok || err == nil {
+		t.Fatal("Expected error decoding an object as an int")
+	}
+}
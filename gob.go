@@ -0,0 +1,64 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Overflow implements gob.GobEncoder and gob.GobDecoder, so a struct following
+// the pattern in the package documentation can be stored in gob-based caches
+// and sent over net/rpc, provided its Overflow field is declared as
+// j2n.Overflow rather than map[string]*json.RawMessage:
+//
+//	type CatData struct {
+//		Name     string       `json:"name"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+//	type Cat struct {
+//		CatData
+//	}
+//
+// As with any type, a wrapper stored in an interface value must be registered
+// with gob.Register before it is encoded.
+
+// Returns the gob encoding of o.
+func (o Overflow) GobEncode() ([]byte, error) {
+	values := make(map[string][]byte, len(o))
+	for k, v := range o {
+		if v == nil {
+			values[k] = nil
+		} else {
+			values[k] = *v
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(values); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Replaces the contents of o with the gob-encoded data.
+func (o *Overflow) GobDecode(data []byte) error {
+	var values map[string][]byte
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil {
+		return err
+	}
+
+	*o = make(Overflow, len(values))
+	for k, v := range values {
+		// A RawMessage is never empty, so an empty value was a nil entry
+		if len(v) == 0 {
+			(*o)[k] = nil
+			continue
+		}
+		raw := json.RawMessage(v)
+		(*o)[k] = &raw
+	}
+
+	return nil
+}
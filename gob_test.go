@@ -0,0 +1,59 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+)
+
+type GobPerson struct {
+	OverflowPersonData
+}
+
+func TestGobRoundTripOfWrappedStruct(t *testing.T) {
+	p := GobPerson{}
+	p.Name = "Bert"
+	p.Overflow = newTestOverflow(map[string]string{"age": `29`})
+	p.Overflow["spouse"] = nil
+
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	decoded := GobPerson{}
+	if err := gob.NewDecoder(&buffer).Decode(&decoded); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if decoded.Name != "Bert" || string(*decoded.Overflow["age"]) != "29" {
+		t.Fatalf("Expected fields to survive gob, got '%+v'", decoded)
+	}
+
+	spouse, ok := decoded.Overflow["spouse"]
+	if !ok || spouse != nil {
+		t.Fatalf("Expected nil 'spouse' entry to survive gob, got '%v'", spouse)
+	}
+}
+
+func TestGobRoundTripThroughInterface(t *testing.T) {
+	gob.Register(GobPerson{})
+
+	p := GobPerson{}
+	p.Overflow = newTestOverflow(map[string]string{"tags": `["a"]`})
+
+	var buffer bytes.Buffer
+	var in interface{} = p
+	if err := gob.NewEncoder(&buffer).Encode(&in); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var out interface{}
+	if err := gob.NewDecoder(&buffer).Decode(&out); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(*out.(GobPerson).Overflow["tags"]) != `["a"]` {
+		t.Fatalf("Expected 'tags' to survive gob, got '%v'", out)
+	}
+}
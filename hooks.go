@@ -0,0 +1,55 @@
+package j2n
+
+// Returns an Option that passes the top-level members of the document to
+// hook after any rewriting and validation, but before they are routed to
+// named fields and Overflow. hook may add, remove or replace members, and
+// the changed document is what UnmarshalJSON parses, so cross-cutting
+// transformations, such as stripping internal keys sent by clients, can be
+// applied centrally with Configure.
+//
+// Hooks are called in the order they are given, and the first error is
+// returned.
+func WithBeforeRouting(hook func(v interface{}, document Overflow) error) Option {
+	return func(c *config) {
+		c.beforeRouting = append(c.beforeRouting, hook)
+	}
+}
+
+// Returns an Option for MarshalJSON that passes the assembled top-level
+// members, named fields and Overflow entries together, to hook before they
+// are encoded. hook may add, remove or replace members, for example to stamp
+// metadata on every document of a type:
+//
+//	j2n.Configure(EventData{}, j2n.WithBeforeEncode(func(v interface{}, result j2n.Overflow) error {
+//		version := json.RawMessage(`3`)
+//		result["schemaVersion"] = &version
+//		return nil
+//	}))
+//
+// Hooks are called in the order they are given, and the first error is
+// returned.
+func WithBeforeEncode(hook func(v interface{}, result Overflow) error) Option {
+	return func(c *config) {
+		c.beforeEncode = append(c.beforeEncode, hook)
+	}
+}
+
+// Runs the WithBeforeRouting hooks on document, returning whether any were
+// run.
+func (c *config) runBeforeRouting(v interface{}, document Overflow) (bool, error) {
+	for _, hook := range c.beforeRouting {
+		if err := callHook("WithBeforeRouting", "", func() error { return hook(v, document) }); err != nil {
+			return false, err
+		}
+	}
+	return len(c.beforeRouting) > 0, nil
+}
+
+func (c *config) runBeforeEncode(v interface{}, result Overflow) error {
+	for _, hook := range c.beforeEncode {
+		if err := callHook("WithBeforeEncode", "", func() error { return hook(v, result) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
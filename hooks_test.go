@@ -0,0 +1,80 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithBeforeRoutingChangesDocument(t *testing.T) {
+	stripInternal := WithBeforeRouting(func(v interface{}, document Overflow) error {
+		document.Filter(func(key string, raw json.RawMessage) bool {
+			return !strings.HasPrefix(key, "_")
+		})
+		if raw, ok := document["fullname"]; ok {
+			document["name"] = raw
+			delete(document, "fullname")
+		}
+		return nil
+	})
+
+	p := PersonData{}
+	if err := UnmarshalJSON([]byte(`{"fullname":"Bert","_internal":1,"age":29}`), &p, stripInternal); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+	if len(p.Overflow) != 1 || p.Overflow["age"] == nil {
+		t.Fatalf("Expected only 'age' in Overflow, got '%v'", p.Overflow)
+	}
+}
+
+func TestWithBeforeRoutingError(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &p, WithBeforeRouting(func(v interface{}, document Overflow) error {
+		return errors.New("Rejected")
+	}))
+	if err == nil || err.Error() != "Rejected" {
+		t.Fatalf("Expected 'Rejected', got '%v'", err)
+	}
+}
+
+type StampedData struct {
+	Name     string   `json:"name"`
+	Internal string   `json:"internal,omitempty"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestWithBeforeEncodeChangesResult(t *testing.T) {
+	Configure(StampedData{}, WithBeforeEncode(func(v interface{}, result Overflow) error {
+		version := json.RawMessage(`3`)
+		result["schemaVersion"] = &version
+		delete(result, "internal")
+		return nil
+	}))
+	defer Configure(StampedData{})
+
+	s := StampedData{Name: "Bert", Internal: "secret"}
+	data, err := MarshalJSON(s)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"name":"Bert","schemaVersion":3}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+	if s.Overflow != nil {
+		t.Fatalf("Expected the struct to be unchanged, got '%v'", s.Overflow)
+	}
+
+	_, err = MarshalJSON(s, WithBeforeEncode(func(v interface{}, result Overflow) error {
+		return errors.New("Refused")
+	}))
+	if err == nil || err.Error() != "Refused" {
+		t.Fatalf("Expected 'Refused', got '%v'", err)
+	}
+}
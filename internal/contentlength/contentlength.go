@@ -0,0 +1,74 @@
+// Package contentlength reads and writes messages framed by a Content-Length
+// header, the base protocol shared by the Language Server Protocol and the
+// Debug Adapter Protocol.
+package contentlength
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxLength is the largest Content-Length that Read accepts, so that a
+// peer cannot make it allocate more memory than any real message needs.
+const MaxLength = 64 << 20
+
+// Returns the body of the next message from r. Headers other than
+// Content-Length, such as Content-Type, are ignored.
+func Read(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length == -1 {
+				return nil, io.EOF
+			}
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("Invalid header '%s'", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("Invalid Content-Length '%s'", strings.TrimSpace(value))
+			}
+		}
+	}
+
+	if length == -1 {
+		return nil, fmt.Errorf("Missing Content-Length header")
+	}
+	if length > MaxLength {
+		return nil, fmt.Errorf("Content-Length %d exceeds the maximum of %d", length, MaxLength)
+	}
+
+	// The buffer grows as the body arrives, rather than being sized by a
+	// length the peer may not send
+	body, err := io.ReadAll(io.LimitReader(r, int64(length)))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < length {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return body, nil
+}
+
+// Writes body to w as a message with a Content-Length header.
+func Write(w io.Writer, body []byte) error {
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
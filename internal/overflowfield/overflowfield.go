@@ -0,0 +1,52 @@
+// Package overflowfield locates the 'Overflow' field of a struct for the
+// format adapters, whose overflow maps hold raw values in their own format
+// rather than *json.RawMessage.
+package overflowfield
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Returns the 'Overflow' field of the struct pointed to by v, after checking
+// that it has type t and that it is omitted by the encoder. The first of
+// tagKeys that is present in the field's tag must have the value "-".
+func Lookup(v interface{}, t reflect.Type, tagKeys ...string) (reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() {
+		return reflect.Value{}, fmt.Errorf("Expected struct, got nil")
+	}
+
+	// Unwrap the pointer if necessary
+	if value.Type().Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	// Check that we're dealing with a struct
+	if value.Type().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("Expected struct, got %s", value.Type().Kind())
+	}
+
+	// Ensure the struct has a field called 'Overflow' of the right type
+	overflowField := value.FieldByName("Overflow")
+	if !overflowField.IsValid() {
+		return reflect.Value{}, fmt.Errorf("Overflow field is missing")
+	}
+
+	if overflowField.Type() != t {
+		return reflect.Value{}, fmt.Errorf("Overflow must be of type %s", t)
+	}
+
+	// And that it has a tag ensuring that it is omitted from the output
+	overflowFieldType, _ := value.Type().FieldByName("Overflow")
+	for _, key := range tagKeys {
+		if tag, ok := overflowFieldType.Tag.Lookup(key); ok {
+			if tag != "-" {
+				break
+			}
+			return overflowField, nil
+		}
+	}
+
+	return reflect.Value{}, fmt.Errorf("Overflow must have the tag `%s:\"-\"`", tagKeys[0])
+}
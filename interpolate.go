@@ -0,0 +1,166 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Returns an Option for UnmarshalJSON that expands references to
+// environment variables in the string values of the document, whether they
+// are decoded into named fields or kept in Overflow, for configuration
+// files that template secrets and hosts:
+//
+//	{"database": {"url": "postgres://app:${DB_PASSWORD}@${DB_HOST}/app"}}
+//
+//	j2n.Configure(ServerConfig{}, j2n.WithEnvInterpolation("DB_*"))
+//
+// A reference is ${NAME}, or ${NAME:-default} to use default when the
+// variable is not set or is empty; it is an error for a variable without a
+// default not to be set. $${ is an escaped reference, written as a literal
+// ${, and a $ not followed by { is not special. Keys are never expanded.
+//
+// Only the variables in allowed may be referenced, so that a document
+// cannot read arbitrary variables; a name ending in * allows every variable
+// with that prefix. Any other reference is an error.
+//
+// RawPayload and WithSurgicalEdit keep the document as it was written, so
+// references survive a surgical edit unless the value they are in changes.
+func WithEnvInterpolation(allowed ...string) Option {
+	allowlist := append([]string(nil), allowed...)
+	return func(c *config) {
+		c.interpolate = true
+		c.envAllowlist = allowlist
+	}
+}
+
+// Returns data with the references in its string values expanded.
+func (c *config) interpolateEnv(data []byte) ([]byte, error) {
+	if !c.interpolate || !bytes.Contains(data, []byte("${")) || !json.Valid(data) {
+		return data, nil
+	}
+
+	var expanded bytes.Buffer
+	last, changed := 0, false
+	for i := 0; i < len(data); {
+		if data[i] != '"' {
+			i++
+			continue
+		}
+		end := skipString(data, i)
+		if next := skipSpace(data, end); next < len(data) && data[next] == ':' {
+			// A key
+			i = end
+			continue
+		}
+		if !bytes.Contains(data[i:end], []byte("${")) {
+			i = end
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(data[i:end], &s); err != nil {
+			return nil, err
+		}
+		s, err := c.expandEnv(s)
+		if err != nil {
+			return nil, err
+		}
+		quoted, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		expanded.Write(data[last:i])
+		expanded.Write(quoted)
+		last, i, changed = end, end, true
+	}
+	if !changed {
+		return data, nil
+	}
+	expanded.Write(data[last:])
+	return expanded.Bytes(), nil
+}
+
+func (c *config) expandEnv(s string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "$")
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:start])
+		s = s[start:]
+
+		switch {
+		case strings.HasPrefix(s, "$${"):
+			b.WriteString("${")
+			s = s[3:]
+		case strings.HasPrefix(s, "${"):
+			end := strings.IndexByte(s, '}')
+			if end < 0 {
+				return "", fmt.Errorf("Unterminated reference in '%s'", s)
+			}
+			value, err := c.lookupEnv(s[2:end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			s = s[end+1:]
+		default:
+			b.WriteByte('$')
+			s = s[1:]
+		}
+	}
+}
+
+// Returns the value of the reference ref, which is NAME or NAME:-default.
+func (c *config) lookupEnv(ref string) (string, error) {
+	name, fallback, hasDefault := strings.Cut(ref, ":-")
+	if !isEnvName(name) {
+		return "", fmt.Errorf("Invalid environment variable name '%s'", name)
+	}
+	if !c.envAllowed(name) {
+		return "", fmt.Errorf("Environment variable '%s' is not allowed", name)
+	}
+
+	value, ok := os.LookupEnv(name)
+	switch {
+	case value != "":
+		return value, nil
+	case hasDefault:
+		return fallback, nil
+	case !ok:
+		return "", fmt.Errorf("Environment variable '%s' is not set", name)
+	}
+	return value, nil
+}
+
+func (c *config) envAllowed(name string) bool {
+	for _, allowed := range c.envAllowlist {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok && strings.HasPrefix(name, prefix) {
+			return true
+		}
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -7,6 +7,10 @@
 // This means that fields that are not explicitly named in the struct will
 // survive an Unmarshal/Marshal round trip.
 //
+// The field may also be declared with the named type j2n.Overflow, which has
+// the same representation and adds helper methods for working with the
+// unknown fields.
+//
 // To avoid recursive calls to MarshalJSON/UnmarshalJSON, use the following
 // pattern:
 //
@@ -46,26 +50,124 @@ import (
 //
 //	map[string]*json.RawMessage
 //
-func UnmarshalJSON(data []byte, v interface{}) error {
-	overflow, err := resetOverflowMap(v)
+// Any opts are applied in order; see Option.
+func UnmarshalJSON(data []byte, v interface{}, opts ...Option) (err error) {
+	config := newConfig(v, opts)
+	log := config.startLog(v)
+	var overflow, namedFieldsMap map[string]*json.RawMessage
+	defer func() {
+		log.done(err)
+		if captureErr := config.capture(v, namedFieldsMap, overflow, err); err == nil {
+			err = captureErr
+		}
+	}()
+
+	if err := config.limits.checkSize(data); err != nil {
+		return err
+	}
+
+	var payload *RawPayload
+	var original json.RawMessage
+	if config.rawPayload {
+		if payload, err = rawPayloadOf(v, "WithRawPayload"); err != nil {
+			return err
+		}
+		original = append(json.RawMessage(nil), data...)
+	}
+	if config.surgicalEdit && payload == nil {
+		if payload, err = rawPayloadOf(v, "WithSurgicalEdit"); err != nil {
+			return err
+		}
+	}
+
+	var positions *OverflowPositions
+	if config.overflowPositions {
+		if positions, err = overflowPositionsOf(v); err != nil {
+			return err
+		}
+	}
+
+	data, err = config.rewrite(data)
 	if err != nil {
 		return err
 	}
 
+	if err := config.limits.checkStructure(data, structType(v)); err != nil {
+		return err
+	}
+	if err := config.validate(data); err != nil {
+		return config.locate(err, data)
+	}
+	var layout []byte
+	if config.surgicalEdit && isObject(data) {
+		layout = append([]byte(nil), data...)
+	}
+
+	// Positions are those of the keys as written
+	written := data
+	if data, err = config.interpolateEnv(data); err != nil {
+		return err
+	}
+	log.phase("rewrite")
+
+	var store OverflowStore
+	overflow, store, err = resetOverflowMap(v)
+	if err != nil {
+		return err
+	}
+	if f, ok := store.(Freezer); ok && f.Frozen() {
+		return ErrFrozen
+	}
+	var freezer Freezer
+	if config.freeze {
+		if freezer, err = freezerOf(store); err != nil {
+			return err
+		}
+	}
+
 	if err := json.Unmarshal(data, &overflow); err != nil {
 		return err
 	}
+	var located map[string]Position
+	if positions != nil {
+		if located, err = keyPositions(written); err != nil {
+			return err
+		}
+	}
+	if routed, err := config.runBeforeRouting(v, overflow); err != nil {
+		return err
+	} else if routed {
+		if data, err = json.Marshal(overflow); err != nil {
+			return err
+		}
+	}
+	deprecated := config.deprecatedKeys(overflow)
+	present := config.presentKeys(overflow)
+	log.documentKeys(overflow)
+	log.phase("overflow")
+
+	// Fields with a codec are decoded separately, once encoding/json has
+	// decoded the others
+	fieldsData, codecs, err := config.takeCodecFields(v, data)
+	if err != nil {
+		return err
+	}
 
-	if err := json.Unmarshal(data, v); err != nil {
+	// Custom unmarshalers and marshalers of the named fields may panic
+	if err := callHook("json.Unmarshaler", "", func() error { return json.Unmarshal(fieldsData, v) }); err != nil {
 		return err
 	}
+	if err := codecs.apply(v); err != nil {
+		return err
+	}
+	log.phase("fields")
 
-	namedFieldsJSON, err := json.Marshal(v)
+	namedFieldsJSON, err := marshalNamedFields(v)
 	if err != nil {
 		return err
 	}
 
-	namedFieldsMap := make(map[string]*json.RawMessage)
+	namedFieldsMap = make(map[string]*json.RawMessage)
 	if err := json.Unmarshal(namedFieldsJSON, &namedFieldsMap); err != nil {
 		return err
 	}
@@ -73,8 +175,82 @@ func UnmarshalJSON(data []byte, v interface{}) error {
 	for k, _ := range namedFieldsMap {
 		delete(overflow, k)
 	}
+	// Fields tagged omitempty or omitzero are left out of namedFieldsJSON
+	// when they are empty, but their keys are named all the same
+	for _, f := range namedFields(structType(v)) {
+		if f.omitEmpty || f.omitZero {
+			delete(overflow, f.name)
+		}
+	}
+	if err := config.decryptOverflow(overflow); err != nil {
+		return err
+	}
+	log.phase("split")
 
-	return nil
+	if err := config.checkOverflowKeys(overflow); err != nil {
+		return config.locate(err, written)
+	}
+	if err := config.keySafety.sanitize(overflow, namedKeys(structType(v))); err != nil {
+		return err
+	}
+	if positions != nil {
+		positions.set(located, overflow)
+	}
+	if err := config.limits.checkOverflow(overflow); err != nil {
+		return err
+	}
+	if err := scrubOverflow(overflow, config.scrubbers); err != nil {
+		return err
+	}
+	if store != nil {
+		if err := store.ReplaceOverflow(overflow); err != nil {
+			return err
+		}
+	}
+	if err := config.observeOverflow(v, overflow); err != nil {
+		return err
+	}
+	log.overflowKeys(overflow)
+
+	// With WithCollectOverflowErrors, the failures are returned once v has
+	// been populated
+	invalid := config.locate(config.validateOverflow(overflow), written)
+	if invalid != nil && !config.collectOverflowErrors {
+		return invalid
+	}
+
+	if err := config.handleUnknownFields(v, overflow); err != nil {
+		return config.locate(err, written)
+	}
+	if err := config.reportDeprecations(v, deprecated); err != nil {
+		return err
+	}
+
+	if err := config.checkRules(present); err != nil {
+		return config.locate(err, written)
+	}
+	log.phase("checks")
+
+	err = config.runAfterUnmarshal(v)
+	log.phase("afterUnmarshal")
+	if err == nil && config.surgicalEdit {
+		// Take the snapshot as the struct encodes without the old layout
+		payload.layout, payload.snapshot = nil, nil
+		var snapshot []byte
+		if snapshot, err = MarshalJSON(v, opts...); err == nil {
+			payload.layout, payload.snapshot = layout, snapshot
+		}
+	}
+	if err == nil && config.rawPayload {
+		payload.raw = original
+	}
+	if err == nil && freezer != nil {
+		freezer.Freeze()
+	}
+	if err == nil {
+		err = invalid
+	}
+	return err
 }
 
 // Returns the JSON encoding of v, which must be a struct.
@@ -87,24 +263,30 @@ func UnmarshalJSON(data []byte, v interface{}) error {
 //
 // 	map[string]*json.RawMessage
 //
-func MarshalJSON(v interface{}) ([]byte, error) {
-	result := make(map[string]*json.RawMessage)
+// Any opts that apply to MarshalJSON, such as WithBeforeEncode, are applied
+// after the defaults registered for the type with Configure.
+func MarshalJSON(v interface{}, opts ...Option) ([]byte, error) {
+	config := newConfig(v, opts)
 
 	// Do a round trip of the named fields into a map[string]*json.RawMessage
-	namedFieldsJSON, err := json.Marshal(v)
+	result, err := config.encodeNamedFields(v)
 	if err != nil {
 		return nil, err
 	}
 
-	err = json.Unmarshal(namedFieldsJSON, &result)
+	overflow, err := getOverflowMap(v)
 	if err != nil {
 		return nil, err
 	}
 
-	overflow, err := getOverflowMap(v)
-	if err != nil {
+	if overflow, err = config.encodeOverflow(overflow); err != nil {
 		return nil, err
 	}
+	if config.view != "" {
+		if overflow, err = config.applyView(v, result, overflow); err != nil {
+			return nil, err
+		}
+	}
 
 	for k, v := range overflow {
 		if _, ok := result[k]; ok {
@@ -114,30 +296,98 @@ func MarshalJSON(v interface{}) ([]byte, error) {
 		result[k] = v
 	}
 
-	resultJSON, err := json.Marshal(result)
+	if err := config.runBeforeEncode(v, result); err != nil {
+		return nil, err
+	}
+
+	var resultJSON []byte
+	if config.keyOrder != nil {
+		resultJSON, err = orderedJSON(result, config.keyOrder)
+	} else {
+		resultJSON, err = json.Marshal(result)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if config.surgicalEdit {
+		if payload, ok := rawPayloadValue(v); ok && payload.layout != nil {
+			return patchDocument(payload.layout, payload.snapshot, resultJSON)
+		}
+	}
 	return resultJSON, nil
 }
 
-func resetOverflowMap(v interface{}) (map[string]*json.RawMessage, error) {
-	if value, err := getOverflowFieldValue(v); err != nil {
-		return nil, err
-	} else {
-		overflow := make(map[string]*json.RawMessage)
-		value.Set(reflect.ValueOf(overflow))
+// Returns the entries of overflow as they are output by MarshalJSON:
+// scrubbed with WithOutputScrubber, then encrypted with
+// WithEncryptedOverflow. overflow itself is unchanged.
+func (c *config) encodeOverflow(overflow map[string]*json.RawMessage) (map[string]*json.RawMessage, error) {
+	if len(c.outputScrubbers) == 0 && len(c.ciphers) == 0 {
 		return overflow, nil
 	}
+
+	encoded := make(map[string]*json.RawMessage, len(overflow))
+	for k, raw := range overflow {
+		encoded[k] = raw
+	}
+	if err := scrubOverflow(encoded, c.outputScrubbers); err != nil {
+		return nil, err
+	}
+	if err := c.encryptOverflow(encoded); err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// Returns the encoding of the named fields of v, or a *PanicError if a
+// custom marshaler panics.
+func marshalNamedFields(v interface{}) (data []byte, err error) {
+	err = callHook("json.Marshaler", "", func() (err error) {
+		data, err = json.Marshal(v)
+		return err
+	})
+	return data, err
+}
+
+var (
+	rawMapType   = reflect.TypeOf(map[string]*json.RawMessage(nil))
+	overflowType = reflect.TypeOf(Overflow(nil))
+)
+
+// Returns a new map for the overflow entries of v. If the Overflow field is
+// a map, it is set to the new map; if it is an OverflowStore, which is
+// allocated if it is nil, the store is also returned, and it is up to the
+// caller to fill it once the map is complete.
+func resetOverflowMap(v interface{}) (map[string]*json.RawMessage, OverflowStore, error) {
+	value, err := getOverflowFieldValue(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	overflow := make(map[string]*json.RawMessage)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return overflow, value.Interface().(OverflowStore), nil
+	}
+	value.Set(reflect.ValueOf(overflow).Convert(value.Type()))
+	return overflow, nil, nil
 }
 
 func getOverflowMap(v interface{}) (map[string]*json.RawMessage, error) {
-	if value, err := getOverflowFieldValue(v); err != nil {
+	value, err := getOverflowFieldValue(v)
+	if err != nil {
 		return nil, err
-	} else {
-		return value.Interface().(map[string]*json.RawMessage), nil
 	}
+
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		return value.Interface().(OverflowStore).OverflowEntries(), nil
+	}
+	return value.Convert(rawMapType).Interface().(map[string]*json.RawMessage), nil
 }
 
 func getOverflowFieldValue(v interface{}) (reflect.Value, error) {
@@ -160,15 +410,16 @@ func getOverflowFieldValue(v interface{}) (reflect.Value, error) {
 		return reflect.Value{}, errors.New("Overflow field is missing")
 	}
 
-	// And that the field has type map[string]*json.RawMessage
-	if overflowField.Type() != reflect.TypeOf(make(map[string]*json.RawMessage)) {
-		return reflect.Value{}, errors.New("Overflow must be of type map[string]*json.RawMessage")
+	// And that the field has type map[string]*json.RawMessage or Overflow, or
+	// is a pointer to an OverflowStore
+	if overflowField.Type() != rawMapType && overflowField.Type() != overflowType && !isOverflowStore(overflowField.Type()) {
+		return reflect.Value{}, errors.New("Overflow must be of type map[string]*json.RawMessage or j2n.Overflow, or a pointer implementing j2n.OverflowStore")
 	}
 
 	// And that it has a tag ensuring that it is omitted from the JSON output
 	overflowFieldType, _ := value.Type().FieldByName("Overflow")
 	if overflowFieldType.Tag != `json:"-"` {
-		return reflect.Value{}, errors.New("Overflow must be of type map[string]*json.RawMessage")
+		return reflect.Value{}, errors.New("Overflow must be of type map[string]*json.RawMessage or j2n.Overflow")
 	}
 
 	return overflowField, nil
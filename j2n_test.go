@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 )
 
 type NonStruct int
@@ -224,3 +225,30 @@ func TestErrorOnAliasedFields(t *testing.T) {
 		t.Fatal("Expected error on aliased fields, got none")
 	}
 }
+
+type OmitZeroData struct {
+	Name     string    `json:"name"`
+	Count    int       `json:"count,omitzero"`
+	Since    time.Time `json:"since,omitzero"`
+	Overflow Overflow  `json:"-"`
+}
+
+func TestOmitZeroFieldsAreNamed(t *testing.T) {
+	data := OmitZeroData{}
+	if err := UnmarshalJSON([]byte(`{"name":"bert","count":0,"since":"0001-01-01T00:00:00Z"}`), &data); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(data.Overflow) != 0 {
+		t.Fatalf("Expected no overflow, got %v", data.Overflow)
+	}
+
+	data.Count = 3
+	output, err := MarshalJSON(&data)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"count":3,"name":"bert"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
@@ -0,0 +1,297 @@
+// Package j2navro decodes Avro generic records into j2n structs, and encodes
+// them back, for pipelines where the writer's schema evolves independently of
+// the Go structs that read it.
+//
+// Record fields that are not named in the struct are put into its 'Overflow'
+// field as JSON, exactly as j2n.UnmarshalJSON would. When encoding, named
+// fields and overflow keys are matched against the fields of the writer
+// schema; keys the schema does not define are not written, and fields absent
+// from both take their schema defaults.
+//
+// Unions are unwrapped to their bare value on decode, and wrapped again on
+// encode by choosing the first branch that can hold the JSON value.
+package j2navro
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/ygt/j2n"
+)
+
+// Decodes the Avro binary encoding of a record, written with codec's schema,
+// into the struct pointed to by v.
+func UnmarshalAvro(codec *goavro.Codec, data []byte, v interface{}) error {
+	native, _, err := codec.NativeFromBinary(data)
+	if err != nil {
+		return err
+	}
+
+	return DecodeRecord(codec, native, v)
+}
+
+// Decodes a generic record, as returned by goavro with codec's schema, into
+// the struct pointed to by v.
+func DecodeRecord(codec *goavro.Codec, native interface{}, v interface{}) error {
+	s, err := parseSchema(codec.Schema())
+	if err != nil {
+		return err
+	}
+
+	value, err := toJSONValue(s, native)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return j2n.UnmarshalJSON(data, v)
+}
+
+// Returns the Avro binary encoding of v, which must be a struct, using
+// codec's schema as the writer schema.
+func MarshalAvro(codec *goavro.Codec, v interface{}) ([]byte, error) {
+	native, err := EncodeRecord(codec, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return codec.BinaryFromNative(nil, native)
+}
+
+// Returns v, which must be a struct, as a generic record for codec's schema,
+// suitable for passing to goavro.
+func EncodeRecord(codec *goavro.Codec, v interface{}) (interface{}, error) {
+	s, err := parseSchema(codec.Schema())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return fromJSONValue(s, value)
+}
+
+func toJSONValue(s *schema, native interface{}) (interface{}, error) {
+	switch s.typ {
+	case "union":
+		if native == nil {
+			return nil, nil
+		}
+		wrapped, ok := native.(map[string]interface{})
+		if !ok || len(wrapped) != 1 {
+			return nil, fmt.Errorf("Expected a union value, got '%v'", native)
+		}
+		for name, value := range wrapped {
+			for _, branch := range s.branches {
+				if branch.name == name {
+					return toJSONValue(branch, value)
+				}
+			}
+			return nil, fmt.Errorf("Unknown union branch '%s'", name)
+		}
+	case "record":
+		record, ok := native.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected a record for '%s', got '%v'", s.name, native)
+		}
+		result := make(map[string]interface{}, len(record))
+		for _, f := range s.fields {
+			value, ok := record[f.name]
+			if !ok {
+				continue
+			}
+			converted, err := toJSONValue(f.typ, value)
+			if err != nil {
+				return nil, err
+			}
+			result[f.name] = converted
+		}
+		return result, nil
+	case "array":
+		items, ok := native.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected an array, got '%v'", native)
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			converted, err := toJSONValue(s.items, item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return result, nil
+	case "map":
+		values, ok := native.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected a map, got '%v'", native)
+		}
+		result := make(map[string]interface{}, len(values))
+		for k, value := range values {
+			converted, err := toJSONValue(s.items, value)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = converted
+		}
+		return result, nil
+	}
+
+	// Primitives, including []byte and time.Time, are encoded by encoding/json
+	return native, nil
+}
+
+func fromJSONValue(s *schema, value interface{}) (interface{}, error) {
+	switch s.typ {
+	case "union":
+		for _, branch := range s.branches {
+			if !branchAccepts(branch, value) {
+				continue
+			}
+			if value == nil {
+				return nil, nil
+			}
+			converted, err := fromJSONValue(branch, value)
+			if err != nil {
+				continue
+			}
+			return goavro.Union(branch.name, converted), nil
+		}
+		return nil, fmt.Errorf("No union branch accepts '%v'", value)
+	case "record":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected an object for '%s', got '%v'", s.name, value)
+		}
+		result := make(map[string]interface{}, len(s.fields))
+		for _, f := range s.fields {
+			fieldValue, ok := object[f.name]
+			if !ok {
+				continue
+			}
+			converted, err := fromJSONValue(f.typ, fieldValue)
+			if err != nil {
+				return nil, fmt.Errorf("Field '%s': %s", f.name, err)
+			}
+			result[f.name] = converted
+		}
+		return result, nil
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected an array, got '%v'", value)
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			converted, err := fromJSONValue(s.items, item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = converted
+		}
+		return result, nil
+	case "map":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected an object, got '%v'", value)
+		}
+		result := make(map[string]interface{}, len(object))
+		for k, item := range object {
+			converted, err := fromJSONValue(s.items, item)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = converted
+		}
+		return result, nil
+	}
+
+	switch s.name {
+	case "long.timestamp-millis", "long.timestamp-micros", "int.date":
+		if text, ok := value.(string); ok {
+			return time.Parse(time.RFC3339Nano, text)
+		}
+	case "int.time-millis", "long.time-micros":
+		if n, ok := value.(json.Number); ok {
+			ns, err := n.Int64()
+			return time.Duration(ns), err
+		}
+	}
+
+	switch s.typ {
+	case "null":
+		if value != nil {
+			return nil, fmt.Errorf("Expected null, got '%v'", value)
+		}
+		return nil, nil
+	case "int", "long", "float", "double":
+		n, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("Expected a number, got '%v'", value)
+		}
+		switch s.typ {
+		case "int":
+			i, err := n.Int64()
+			return int32(i), err
+		case "long":
+			return n.Int64()
+		case "float":
+			f, err := n.Float64()
+			return float32(f), err
+		}
+		return n.Float64()
+	case "bytes", "fixed":
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("Expected a base64 string, got '%v'", value)
+		}
+		return base64.StdEncoding.DecodeString(text)
+	}
+
+	return value, nil
+}
+
+// Reports whether a union branch could hold the decoded JSON value.
+func branchAccepts(s *schema, value interface{}) bool {
+	switch value.(type) {
+	case nil:
+		return s.typ == "null"
+	case bool:
+		return s.typ == "boolean"
+	case json.Number:
+		switch s.typ {
+		case "int", "long", "float", "double":
+			return true
+		}
+	case string:
+		switch s.typ {
+		case "string", "enum", "bytes", "fixed":
+			return true
+		case "int", "long":
+			return s.name != s.typ
+		}
+	case []interface{}:
+		return s.typ == "array"
+	case map[string]interface{}:
+		return s.typ == "record" || s.typ == "map"
+	}
+	return false
+}
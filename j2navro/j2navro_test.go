@@ -0,0 +1,101 @@
+package j2navro
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+const writerSchema = `{
+	"type": "record",
+	"name": "User",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "name", "type": "string"},
+		{"name": "age", "type": ["null", "int"], "default": null},
+		{"name": "email", "type": ["null", "string"], "default": null},
+		{"name": "address", "type": ["null", {
+			"type": "record",
+			"name": "Address",
+			"fields": [{"name": "city", "type": "string"}]
+		}], "default": null},
+		{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []}
+	]
+}`
+
+type UserData struct {
+	Name     string                      `json:"name"`
+	Age      *int                        `json:"age"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestAvroRoundTripPreservesUnknownFields(t *testing.T) {
+	codec, err := goavro.NewCodec(writerSchema)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	input, err := codec.BinaryFromNative(nil, map[string]interface{}{
+		"name":    "Bert",
+		"age":     goavro.Union("int", int32(29)),
+		"email":   goavro.Union("string", "bert@example.com"),
+		"address": goavro.Union("com.example.Address", map[string]interface{}{"city": "Leeds"}),
+		"tags":    []interface{}{"a"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	u := UserData{}
+	if err := UnmarshalAvro(codec, input, &u); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if u.Name != "Bert" || u.Age == nil || *u.Age != 29 {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", u)
+	}
+
+	expected := map[string]string{
+		"email":   `"bert@example.com"`,
+		"address": `{"city":"Leeds"}`,
+		"tags":    `["a"]`,
+	}
+	for k, v := range expected {
+		if u.Overflow[k] == nil || string(*u.Overflow[k]) != v {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", v, k, u.Overflow[k])
+		}
+	}
+
+	output, err := MarshalAvro(codec, &u)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(output) != string(input) {
+		t.Fatalf("Expected '%x', got '%x'", input, output)
+	}
+}
+
+func TestMarshalAvroUsesDefaultsForMissingFields(t *testing.T) {
+	codec, err := goavro.NewCodec(writerSchema)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	u := UserData{Name: "Ernie"}
+	output, err := MarshalAvro(codec, &u)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	roundTripped := UserData{}
+	if err := UnmarshalAvro(codec, output, &roundTripped); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	email, ok := roundTripped.Overflow["email"]
+	if roundTripped.Name != "Ernie" || roundTripped.Age != nil || !ok || email != nil {
+		t.Fatalf("Expected defaults to be applied, got '%+v'", roundTripped)
+	}
+}
@@ -0,0 +1,152 @@
+package j2navro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A schema is the parsed form of an Avro schema, with named type references
+// resolved, as needed to convert between goavro's native values and JSON.
+type schema struct {
+	typ      string // primitive, "record", "enum", "array", "map", "fixed" or "union"
+	name     string // the name goavro uses for this type as a union branch
+	fields   []schemaField
+	items    *schema
+	branches []*schema
+}
+
+type schemaField struct {
+	name string
+	typ  *schema
+}
+
+// The logical types that goavro names as "type.logicalType" in unions.
+var logicalTypes = map[string]bool{
+	"long.timestamp-millis": true,
+	"long.timestamp-micros": true,
+	"int.time-millis":       true,
+	"long.time-micros":      true,
+	"int.date":              true,
+	"bytes.decimal":         true,
+	"fixed.decimal":         true,
+}
+
+func parseSchema(text string) (*schema, error) {
+	var definition interface{}
+	if err := json.Unmarshal([]byte(text), &definition); err != nil {
+		return nil, err
+	}
+
+	return newSchemaParser().parse(definition, "")
+}
+
+type schemaParser struct {
+	named map[string]*schema
+}
+
+func newSchemaParser() *schemaParser {
+	return &schemaParser{named: make(map[string]*schema)}
+}
+
+func (p *schemaParser) parse(definition interface{}, namespace string) (*schema, error) {
+	switch definition := definition.(type) {
+	case string:
+		switch definition {
+		case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+			return &schema{typ: definition, name: definition}, nil
+		}
+		if s, ok := p.named[fullName(definition, namespace)]; ok {
+			return s, nil
+		}
+		if s, ok := p.named[definition]; ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("Unknown Avro type '%s'", definition)
+	case []interface{}:
+		s := &schema{typ: "union", name: "union"}
+		for _, branch := range definition {
+			parsed, err := p.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			s.branches = append(s.branches, parsed)
+		}
+		return s, nil
+	case map[string]interface{}:
+		return p.parseComplex(definition, namespace)
+	}
+
+	return nil, fmt.Errorf("Invalid Avro schema '%v'", definition)
+}
+
+func (p *schemaParser) parseComplex(definition map[string]interface{}, namespace string) (*schema, error) {
+	typ, _ := definition["type"].(string)
+	if typ == "" {
+		// The type is itself a schema, such as {"type": {"type": "array", ...}}
+		return p.parse(definition["type"], namespace)
+	}
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		name, _ := definition["name"].(string)
+		if ns, ok := definition["namespace"].(string); ok && !strings.Contains(name, ".") {
+			namespace = ns
+		}
+		full := fullName(name, namespace)
+		if i := strings.LastIndex(full, "."); i >= 0 {
+			namespace = full[:i]
+		}
+
+		s := &schema{typ: typ, name: full}
+		if typ == "error" {
+			s.typ = "record"
+		}
+		if logical, ok := definition["logicalType"].(string); ok && logicalTypes[typ+"."+logical] {
+			s.name = typ + "." + logical
+		}
+		p.named[full] = s
+
+		if s.typ == "record" {
+			fields, _ := definition["fields"].([]interface{})
+			for _, f := range fields {
+				fieldDefinition, _ := f.(map[string]interface{})
+				fieldName, _ := fieldDefinition["name"].(string)
+				fieldType, err := p.parse(fieldDefinition["type"], namespace)
+				if err != nil {
+					return nil, err
+				}
+				s.fields = append(s.fields, schemaField{name: fieldName, typ: fieldType})
+			}
+		}
+		return s, nil
+	case "array":
+		items, err := p.parse(definition["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: "array", name: "array", items: items}, nil
+	case "map":
+		values, err := p.parse(definition["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		return &schema{typ: "map", name: "map", items: values}, nil
+	}
+
+	s, err := p.parse(typ, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if logical, ok := definition["logicalType"].(string); ok && logicalTypes[typ+"."+logical] {
+		return &schema{typ: s.typ, name: typ + "." + logical}, nil
+	}
+	return s, nil
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
@@ -0,0 +1,109 @@
+package j2nbson
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/ygt/j2n/internal/overflowfield"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+)
+
+// Returns a registry, based on the driver's default, that encodes and
+// decodes the data structs of each of types through this package, so that
+// no wrapper type is needed. Use it with the registry options of the
+// MongoDB driver:
+//
+//	registry, err := j2nbson.NewRegistry(AccountData{})
+//	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetRegistry(registry))
+//
+//	var account AccountData
+//	err = client.Database("app").Collection("accounts").FindOne(ctx, filter).Decode(&account)
+//
+// Data structs nested in the named fields of another are handled too, if
+// their types are registered.
+func NewRegistry(types ...interface{}) (*bsoncodec.Registry, error) {
+	registry := bson.NewRegistry()
+	if err := Register(registry, types...); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Registers codecs in registry for the data structs of each of types, as
+// for NewRegistry. Each type must have a valid 'Overflow' field.
+func Register(registry *bsoncodec.Registry, types ...interface{}) error {
+	for _, v := range types {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("Expected struct, got %T", v)
+		}
+
+		if _, err := overflowfield.Lookup(reflect.New(t).Interface(), overflowType, "bson"); err != nil {
+			return fmt.Errorf("%s: %s", t, err)
+		}
+
+		// The struct codec caches the codecs of fields from the first registry
+		// it is used with, so each registration needs its own
+		structCodec, err := bsoncodec.NewStructCodec(bsoncodec.DefaultStructTagParser)
+		if err != nil {
+			return err
+		}
+
+		c := &codec{structCodec: structCodec}
+		registry.RegisterTypeEncoder(t, c)
+		registry.RegisterTypeDecoder(t, c)
+	}
+	return nil
+}
+
+// A codec encodes and decodes a data struct with the driver's struct codec,
+// adding and extracting its Overflow entries.
+type codec struct {
+	structCodec *bsoncodec.StructCodec
+}
+
+func (c *codec) EncodeValue(ec bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+	var named bytes.Buffer
+	namedWriter, err := bsonrw.NewBSONValueWriter(&named)
+	if err != nil {
+		return err
+	}
+
+	// Encoding with ec keeps the caller's registry for nested values
+	if err := c.structCodec.EncodeValue(ec, namedWriter, val); err != nil {
+		return err
+	}
+
+	overflow := val.FieldByName("Overflow").Interface().(map[string]bson.RawValue)
+	data, err := appendOverflow(named.Bytes(), overflow)
+	if err != nil {
+		return err
+	}
+
+	return bsonrw.Copier{}.CopyDocumentFromBytes(vw, data)
+}
+
+func (c *codec) DecodeValue(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	data, err := bsonrw.Copier{}.CopyDocumentToBytes(vr)
+	if err != nil {
+		return err
+	}
+
+	if err := c.structCodec.DecodeValue(dc, bsonrw.NewBSONDocumentReader(data), val); err != nil {
+		return err
+	}
+
+	overflow, err := overflowElements(data, val.Addr().Interface())
+	if err != nil {
+		return err
+	}
+
+	val.FieldByName("Overflow").Set(reflect.ValueOf(overflow))
+	return nil
+}
@@ -0,0 +1,65 @@
+package j2nbson
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TeamData struct {
+	Name     string                   `bson:"name"`
+	Owner    AccountData              `bson:"owner"`
+	Overflow map[string]bson.RawValue `bson:"-"`
+}
+
+func TestRegistryKeepsUnknownFieldsOfDataStructs(t *testing.T) {
+	registry, err := NewRegistry(AccountData{}, &TeamData{})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	input, err := bson.Marshal(bson.D{
+		{Key: "name", Value: "core"},
+		{Key: "owner", Value: bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "email", Value: "bert@example.com"},
+			{Key: "plan", Value: "gold"},
+		}},
+		{Key: "size", Value: int32(4)},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	team := TeamData{}
+	if err := bson.UnmarshalWithRegistry(registry, input, &team); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if team.Overflow["size"].Int32() != 4 || team.Owner.Overflow["plan"].StringValue() != "gold" {
+		t.Fatalf("Expected unknown fields at both levels, got '%v' and '%v'", team.Overflow, team.Owner.Overflow)
+	}
+
+	team.Owner.Email = "ernie@example.com"
+	output, err := bson.MarshalWithRegistry(registry, team)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	raw := bson.Raw(output)
+	if raw.Lookup("size").Int32() != 4 || raw.Lookup("owner", "plan").StringValue() != "gold" ||
+		raw.Lookup("owner", "email").StringValue() != "ernie@example.com" {
+		t.Fatalf("Expected named and unknown fields in output, got '%s'", raw)
+	}
+}
+
+func TestRegisterRejectsTypesWithoutOverflow(t *testing.T) {
+	type Plain struct {
+		Name string `bson:"name"`
+	}
+
+	if _, err := NewRegistry(Plain{}); err == nil {
+		t.Fatal("Expected error registering struct without Overflow")
+	}
+}
@@ -0,0 +1,162 @@
+// Package j2nbson applies the j2n Overflow contract to BSON documents. Any
+// fields that are not decoded directly into the fields of the struct are put
+// into its 'Overflow' field as raw BSON values, so documents read from MongoDB
+// keep the fields that the struct does not model.
+//
+// The struct must contain a field 'Overflow' of type
+//
+//	map[string]bson.RawValue
+//
+// omitted from the encoding with `bson:"-"`. The wrapper type implements
+// bson.Marshaler and bson.Unmarshaler by delegating to this package:
+//
+//	type AccountData struct {
+//		ID       primitive.ObjectID       `bson:"_id"`
+//		Email    string                   `bson:"email"`
+//		Overflow map[string]bson.RawValue `bson:"-"`
+//	}
+//
+//	type Account struct {
+//		AccountData
+//	}
+//
+//	func (a *Account) UnmarshalBSON(data []byte) error {
+//		return j2nbson.UnmarshalBSON(data, &a.AccountData)
+//	}
+//
+//	func (a Account) MarshalBSON() ([]byte, error) {
+//		return j2nbson.MarshalBSON(a.AccountData)
+//	}
+//
+// Alternatively, NewRegistry builds a codec registry for the MongoDB driver
+// that handles the data structs directly, without wrapper types.
+package j2nbson
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/ygt/j2n/internal/overflowfield"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var overflowType = reflect.TypeOf(map[string]bson.RawValue(nil))
+
+// Parses the BSON document data into the struct pointed to by v.
+//
+// This behaves exactly like bson.Unmarshal, but any extra fields that are not
+// decoded into named fields are kept in the 'Overflow' field.
+func UnmarshalBSON(data []byte, v interface{}) error {
+	field, err := overflowfield.Lookup(v, overflowType, "bson")
+	if err != nil {
+		return err
+	}
+
+	if err := bson.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	overflow, err := overflowElements(data, v)
+	if err != nil {
+		return err
+	}
+
+	field.Set(reflect.ValueOf(overflow))
+	return nil
+}
+
+// Returns the BSON encoding of v, which must be a struct.
+//
+// Named fields are emitted in struct order, as bson.Marshal would, followed
+// by the fields in v.Overflow in key order.
+func MarshalBSON(v interface{}) ([]byte, error) {
+	field, err := overflowfield.Lookup(v, overflowType, "bson")
+	if err != nil {
+		return nil, err
+	}
+
+	named, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendOverflow(named, field.Interface().(map[string]bson.RawValue))
+}
+
+// Returns the elements of the document data that are not named fields of
+// v, which has already been decoded from it.
+func overflowElements(data []byte, v interface{}) (map[string]bson.RawValue, error) {
+	elements, err := bson.Raw(data).Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	overflow := make(map[string]bson.RawValue, len(elements))
+	for _, element := range elements {
+		overflow[element.Key()] = element.Value()
+	}
+
+	namedFields, err := namedElements(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, element := range namedFields {
+		delete(overflow, element.Key)
+	}
+
+	return overflow, nil
+}
+
+// Returns the document named, holding the named fields of a struct, with
+// the entries of overflow appended in key order.
+func appendOverflow(named []byte, overflow map[string]bson.RawValue) ([]byte, error) {
+	result, err := rawElements(named)
+	if err != nil {
+		return nil, err
+	}
+
+	isNamed := make(map[string]bool, len(result))
+	for _, element := range result {
+		isNamed[element.Key] = true
+	}
+
+	keys := make([]string, 0, len(overflow))
+	for k := range overflow {
+		if isNamed[k] {
+			return nil, fmt.Errorf("Named field present in overflow: '%s'", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		result = append(result, bson.E{Key: k, Value: overflow[k]})
+	}
+
+	return bson.Marshal(result)
+}
+
+func namedElements(v interface{}) (bson.D, error) {
+	namedFieldsBSON, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawElements(namedFieldsBSON)
+}
+
+func rawElements(data []byte) (bson.D, error) {
+	elements, err := bson.Raw(data).Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(bson.D, 0, len(elements))
+	for _, element := range elements {
+		result = append(result, bson.E{Key: element.Key(), Value: element.Value()})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package j2nbson
+
+import (
+	"bytes"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AccountData struct {
+	ID       primitive.ObjectID       `bson:"_id"`
+	Email    string                   `bson:"email"`
+	Overflow map[string]bson.RawValue `bson:"-"`
+}
+
+type Account struct {
+	AccountData
+}
+
+func (a *Account) UnmarshalBSON(data []byte) error {
+	return UnmarshalBSON(data, &a.AccountData)
+}
+
+func (a Account) MarshalBSON() ([]byte, error) {
+	return MarshalBSON(a.AccountData)
+}
+
+func TestUnknownFieldsSurviveRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	input, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "email", Value: "bert@example.com"},
+		{Key: "plan", Value: bson.D{{Key: "tier", Value: "gold"}}},
+		{Key: "visits", Value: int64(12)},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	a := Account{}
+	if err := bson.Unmarshal(input, &a); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if a.ID != id || a.Email != "bert@example.com" {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", a.AccountData)
+	}
+
+	if visits, ok := a.Overflow["visits"].Int64OK(); !ok || visits != 12 {
+		t.Fatalf("Expected 12 visits in overflow, got '%v'", a.Overflow["visits"])
+	}
+
+	output, err := bson.Marshal(a)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if !bytes.Equal(output, input) {
+		t.Fatalf("Expected '%s', got '%s'", bson.Raw(input), bson.Raw(output))
+	}
+}
+
+func TestErrorOnAliasedFields(t *testing.T) {
+	email, err := bson.Marshal(bson.D{{Key: "email", Value: "x@example.com"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	a := Account{}
+	a.Overflow = map[string]bson.RawValue{"email": bson.Raw(email).Lookup("email")}
+
+	if _, err := bson.Marshal(a); err == nil {
+		t.Fatal("Expected error on aliased fields, got none")
+	}
+}
@@ -0,0 +1,115 @@
+// Package j2ncbor applies the j2n Overflow contract to CBOR. Any map keys that
+// are not decoded directly into the fields of the struct are put into its
+// 'Overflow' field as raw CBOR, so unrecognised keys, including their CBOR
+// tags and byte strings, survive an Unmarshal/Marshal round trip.
+//
+// The struct must contain a field 'Overflow' of type
+//
+//	map[string]cbor.RawMessage
+//
+// omitted from the encoding with `cbor:"-"`:
+//
+//	type ReadingData struct {
+//		Sensor   string                     `cbor:"sensor"`
+//		Overflow map[string]cbor.RawMessage `cbor:"-"`
+//	}
+//
+//	type Reading struct {
+//		ReadingData
+//	}
+//
+//	func (r *Reading) UnmarshalCBOR(data []byte) error {
+//		return j2ncbor.UnmarshalCBOR(data, &r.ReadingData)
+//	}
+//
+//	func (r Reading) MarshalCBOR() ([]byte, error) {
+//		return j2ncbor.MarshalCBOR(r.ReadingData)
+//	}
+//
+// Only maps with text string keys are supported.
+package j2ncbor
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ygt/j2n/internal/overflowfield"
+)
+
+var (
+	overflowType = reflect.TypeOf(map[string]cbor.RawMessage(nil))
+
+	encMode, _ = cbor.EncOptions{Sort: cbor.SortBytewiseLexical}.EncMode()
+)
+
+// Parses the CBOR-encoded data into the struct pointed to by v.
+//
+// This behaves exactly like cbor.Unmarshal, but any extra map keys that are
+// not decoded into named fields are kept in the 'Overflow' field.
+func UnmarshalCBOR(data []byte, v interface{}) error {
+	field, err := overflowfield.Lookup(v, overflowType, "cbor", "json")
+	if err != nil {
+		return err
+	}
+
+	overflow := make(map[string]cbor.RawMessage)
+	if err := cbor.Unmarshal(data, &overflow); err != nil {
+		return err
+	}
+
+	if err := cbor.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	namedFields, err := namedFieldsMap(v)
+	if err != nil {
+		return err
+	}
+
+	for k := range namedFields {
+		delete(overflow, k)
+	}
+
+	field.Set(reflect.ValueOf(overflow))
+	return nil
+}
+
+// Returns the CBOR encoding of v, which must be a struct.
+//
+// This behaves like cbor.Marshal, but also emits the keys in v.Overflow. Map
+// keys are sorted so that the output is deterministic.
+func MarshalCBOR(v interface{}) ([]byte, error) {
+	field, err := overflowfield.Lookup(v, overflowType, "cbor", "json")
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := namedFieldsMap(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, raw := range field.Interface().(map[string]cbor.RawMessage) {
+		if _, ok := result[k]; ok {
+			return nil, fmt.Errorf("Named field present in overflow: '%s'", k)
+		}
+		result[k] = raw
+	}
+
+	return encMode.Marshal(result)
+}
+
+func namedFieldsMap(v interface{}) (map[string]cbor.RawMessage, error) {
+	namedFieldsCBOR, err := cbor.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	namedFields := make(map[string]cbor.RawMessage)
+	if err := cbor.Unmarshal(namedFieldsCBOR, &namedFields); err != nil {
+		return nil, err
+	}
+
+	return namedFields, nil
+}
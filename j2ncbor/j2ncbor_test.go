@@ -0,0 +1,98 @@
+package j2ncbor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+type ReadingData struct {
+	Sensor   string                     `cbor:"sensor"`
+	Value    float64                    `cbor:"value"`
+	Overflow map[string]cbor.RawMessage `cbor:"-"`
+}
+
+type Reading struct {
+	ReadingData
+}
+
+func (r *Reading) UnmarshalCBOR(data []byte) error {
+	return UnmarshalCBOR(data, &r.ReadingData)
+}
+
+func (r Reading) MarshalCBOR() ([]byte, error) {
+	return MarshalCBOR(r.ReadingData)
+}
+
+type ReadingDataWithoutTag struct {
+	Sensor   string `cbor:"sensor"`
+	Overflow map[string]cbor.RawMessage
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := cbor.Marshal(v)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return data
+}
+
+func TestUnknownKeysSurviveRoundTrip(t *testing.T) {
+	input := mustMarshal(t, map[string]interface{}{
+		"sensor":   "t1",
+		"value":    21.5,
+		"firmware": []byte{0xde, 0xad},
+		"tagged":   cbor.Tag{Number: 1, Content: uint64(1600000000)},
+	})
+
+	r := Reading{}
+	if err := cbor.Unmarshal(input, &r); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if r.Sensor != "t1" || r.Value != 21.5 {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", r.ReadingData)
+	}
+
+	if _, ok := r.Overflow["sensor"]; ok {
+		t.Fatal("Expected 'sensor' to be absent from Overflow")
+	}
+
+	expectedFirmware := mustMarshal(t, []byte{0xde, 0xad})
+	if !bytes.Equal(r.Overflow["firmware"], expectedFirmware) {
+		t.Fatalf("Expected '%x', got '%x'", expectedFirmware, r.Overflow["firmware"])
+	}
+
+	output, err := cbor.Marshal(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	roundTripped := Reading{}
+	if err := cbor.Unmarshal(output, &roundTripped); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expectedTagged := mustMarshal(t, cbor.Tag{Number: 1, Content: uint64(1600000000)})
+	if !bytes.Equal(roundTripped.Overflow["tagged"], expectedTagged) {
+		t.Fatalf("Expected '%x', got '%x'", expectedTagged, roundTripped.Overflow["tagged"])
+	}
+}
+
+func TestErrorOnAliasedFields(t *testing.T) {
+	r := Reading{}
+	r.Overflow = map[string]cbor.RawMessage{"sensor": mustMarshal(t, "t2")}
+
+	if _, err := cbor.Marshal(r); err == nil {
+		t.Fatal("Expected error on aliased fields, got none")
+	}
+}
+
+func TestReturnsErrorWhenOverflowTagMissing(t *testing.T) {
+	r := ReadingDataWithoutTag{}
+
+	if err := UnmarshalCBOR(mustMarshal(t, map[string]string{"sensor": "t1"}), &r); err == nil {
+		t.Fatal("Expected error with Overflow field missing `cbor:\"-\"` tag")
+	}
+}
@@ -0,0 +1,297 @@
+// Package j2ncheck defines an analyzer that checks the structs passed to j2n
+// at compile time, reporting what would otherwise fail at run time: a
+// missing 'Overflow' field, one of the wrong type, or one without the
+// `json:"-"` tag.
+//
+// It also reports the classic mistake of passing j2n a type with its own
+// MarshalJSON or UnmarshalJSON method, such as the wrapper type instead of
+// its data struct, which recurses until the stack overflows. And it checks
+// that wrapper methods which forward to j2n have the receivers of the
+// documented pattern: a pointer for UnmarshalJSON, so that it can modify the
+// value, and a value for MarshalJSON, so that values as well as pointers are
+// marshaled with their Overflow.
+//
+// The analyzer can be run with the j2nvet command, either directly or
+// through go vet:
+//
+//	go vet -vettool=$(which j2nvet) ./...
+package j2ncheck
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const j2nPath = "github.com/ygt/j2n"
+
+// Analyzer reports calls to j2n with structs that break its contract.
+var Analyzer = &analysis.Analyzer{
+	Name:     "j2ncheck",
+	Doc:      "check that structs passed to j2n have a valid Overflow field",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// A structArg describes the argument of a j2n function that must be a
+// struct with an Overflow field.
+type structArg struct {
+	index int
+
+	// pointer is set if the function parses into the struct
+	pointer bool
+
+	// encodes is set if the function passes the struct to encoding/json
+	encodes bool
+}
+
+var structArgs = map[string]structArg{
+	"UnmarshalJSON":              {1, true, true},
+	"MarshalJSON":                {0, false, true},
+	"Flatten":                    {0, false, true},
+	"Unflatten":                  {1, true, true},
+	"ToAnyMap":                   {0, false, true},
+	"FromAnyMap":                 {1, true, true},
+	"UnmarshalJSON5":             {1, true, true},
+	"UnmarshalJSONC":             {1, true, true},
+	"UnmarshalJSONCWithComments": {1, true, true},
+	"MarshalJSONC":               {0, false, true},
+	"UnmarshalForm":              {1, true, true},
+	"MarshalForm":                {0, false, true},
+	"Schema":                     {0, false, false},
+	"SchemaWithOverflow":         {0, false, false},
+	"Configure":                  {0, false, false},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil), (*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		if decl, ok := n.(*ast.FuncDecl); ok {
+			checkReceiver(pass, decl)
+			return
+		}
+
+		call := n.(*ast.CallExpr)
+		name, ok := j2nFunc(pass, call)
+		if !ok {
+			return
+		}
+
+		arg, ok := structArgs[name]
+		if !ok || arg.index >= len(call.Args) {
+			return
+		}
+
+		checkStructArg(pass, name, arg, call.Args[arg.index])
+	})
+
+	return nil, nil
+}
+
+// Returns the name of the j2n package function called by call, if it is one.
+func j2nFunc(pass *analysis.Pass, call *ast.CallExpr) (string, bool) {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return "", false
+	}
+
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != j2nPath {
+		return "", false
+	}
+	if sig := fn.Type().(*types.Signature); sig.Recv() != nil {
+		return "", false
+	}
+	return fn.Name(), true
+}
+
+func checkStructArg(pass *analysis.Pass, name string, arg structArg, expr ast.Expr) {
+	t := pass.TypesInfo.TypeOf(expr)
+	if t == nil {
+		return
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return
+	}
+
+	ptr, isPointer := t.Underlying().(*types.Pointer)
+	if arg.pointer && !isPointer {
+		pass.Reportf(expr.Pos(), "j2n.%s needs a pointer to a struct, got %s", name, t)
+		return
+	}
+
+	if arg.encodes {
+		methods := []string{"MarshalJSON"}
+		if arg.pointer {
+			methods = append(methods, "UnmarshalJSON")
+		}
+		for _, method := range methods {
+			if hasMethod(t, method) {
+				pass.Reportf(expr.Pos(), "j2n.%s called with %s, which has its own %s method and will recurse; pass its data struct instead", name, t, method)
+				return
+			}
+		}
+	}
+
+	if isPointer {
+		t = ptr.Elem()
+	}
+
+	if _, ok := t.Underlying().(*types.Struct); !ok {
+		pass.Reportf(expr.Pos(), "j2n.%s needs a struct, got %s", name, t)
+		return
+	}
+
+	obj, index, _ := types.LookupFieldOrMethod(t, false, pass.Pkg, "Overflow")
+	field, ok := obj.(*types.Var)
+	if !ok || !field.IsField() {
+		pass.Reportf(expr.Pos(), "%s has no Overflow field, which j2n.%s needs", t, name)
+		return
+	}
+
+	if !isOverflowType(field.Type()) {
+		pass.Reportf(expr.Pos(), "Overflow field of %s must be of type map[string]*json.RawMessage or j2n.Overflow, got %s", t, field.Type())
+		return
+	}
+
+	if tag := fieldTag(t, index); tag != `json:"-"` {
+		pass.Reportf(expr.Pos(), "Overflow field of %s must have exactly the tag `json:\"-\"`, got `%s`", t, tag)
+	}
+}
+
+// Returns whether values of type t have the named method.
+func hasMethod(t types.Type, name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// Reports UnmarshalJSON and MarshalJSON methods that forward to j2n with a
+// receiver that differs from the documented pattern.
+func checkReceiver(pass *analysis.Pass, decl *ast.FuncDecl) {
+	if decl.Recv == nil || len(decl.Recv.List) != 1 || decl.Body == nil {
+		return
+	}
+
+	var wantPointer bool
+	switch decl.Name.Name {
+	case "UnmarshalJSON":
+		wantPointer = true
+	case "MarshalJSON":
+		wantPointer = false
+	default:
+		return
+	}
+
+	if !callsJ2N(pass, decl.Body) {
+		return
+	}
+
+	_, isPointer := ast.Unparen(decl.Recv.List[0].Type).(*ast.StarExpr)
+	if wantPointer && !isPointer {
+		pass.Reportf(decl.Name.Pos(), "UnmarshalJSON forwards to j2n but has a value receiver, so its result is discarded; use a pointer receiver")
+	} else if !wantPointer && isPointer {
+		pass.Reportf(decl.Name.Pos(), "MarshalJSON forwards to j2n but has a pointer receiver, so values are marshaled without their Overflow; use a value receiver")
+	}
+}
+
+// Returns whether body calls a j2n function that takes a struct, or
+// encodes one with a MarshalCache.
+func callsJ2N(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && !found {
+			if name, ok := j2nFunc(pass, call); ok && structArgs[name].encodes {
+				found = true
+			}
+			if isCacheMarshal(pass, call) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// Returns whether call is to the Marshal method of j2n.MarshalCache.
+func isCacheMarshal(pass *analysis.Pass, call *ast.CallExpr) bool {
+	selector, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := pass.TypesInfo.Uses[selector.Sel].(*types.Func)
+	if !ok || fn.Name() != "Marshal" {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return false
+	}
+	t := recv.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && isObject(named.Obj(), j2nPath, "MarshalCache")
+}
+
+func isOverflowType(t types.Type) bool {
+	t = types.Unalias(t)
+	if named, ok := t.(*types.Named); ok {
+		return isObject(named.Obj(), j2nPath, "Overflow")
+	}
+
+	m, ok := t.(*types.Map)
+	if !ok {
+		return false
+	}
+	if key, ok := m.Key().(*types.Basic); !ok || key.Kind() != types.String {
+		return false
+	}
+
+	elem, ok := m.Elem().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	return isRawMessage(elem.Elem())
+}
+
+// Returns whether t is json.RawMessage, which is an alias of jsontext.Value
+// when encoding/json is built on encoding/json/v2.
+func isRawMessage(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Alias:
+		return isObject(t.Obj(), "encoding/json", "RawMessage") || isRawMessage(t.Rhs())
+	case *types.Named:
+		return isObject(t.Obj(), "encoding/json", "RawMessage") || isObject(t.Obj(), "encoding/json/jsontext", "Value")
+	}
+	return false
+}
+
+func isObject(obj types.Object, path, name string) bool {
+	return obj.Pkg() != nil && obj.Pkg().Path() == path && obj.Name() == name
+}
+
+// Returns the tag of the field of struct type t reached by the index path.
+func fieldTag(t types.Type, index []int) reflect.StructTag {
+	var tag string
+	for _, i := range index {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		s := t.Underlying().(*types.Struct)
+		tag = s.Tag(i)
+		t = s.Field(i).Type()
+	}
+	return reflect.StructTag(tag)
+}
@@ -0,0 +1,98 @@
+package a
+
+import (
+	"encoding/json"
+
+	"github.com/ygt/j2n"
+)
+
+type Valid struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type ValidNamed struct {
+	Overflow j2n.Overflow `json:"-"`
+}
+
+type Embedded struct {
+	Valid
+}
+
+type Missing struct {
+	Name string `json:"name"`
+}
+
+type WrongType struct {
+	Overflow map[string]interface{} `json:"-"`
+}
+
+type WrongTag struct {
+	Overflow map[string]*json.RawMessage `json:"-,omitempty"`
+}
+
+func calls(data []byte, v interface{}) {
+	j2n.UnmarshalJSON(data, &Valid{})
+	j2n.UnmarshalJSON(data, &ValidNamed{})
+	j2n.UnmarshalJSON(data, &Embedded{})
+	j2n.UnmarshalJSON(data, v)
+	j2n.MarshalJSON(Valid{})
+
+	j2n.UnmarshalJSON(data, Valid{})      // want `j2n.UnmarshalJSON needs a pointer to a struct, got a.Valid`
+	j2n.MarshalJSON("text")               // want `j2n.MarshalJSON needs a struct, got string`
+	j2n.MarshalJSON(&Missing{})           // want `a.Missing has no Overflow field, which j2n.MarshalJSON needs`
+	j2n.UnmarshalJSON(data, &WrongType{}) // want `Overflow field of a.WrongType must be of type map\[string\]\*json.RawMessage or j2n.Overflow, got map\[string\]interface\{\}`
+	j2n.MarshalJSON(WrongTag{})           // want "Overflow field of a.WrongTag must have exactly the tag `json:\"-\"`, got `json:\"-,omitempty\"`"
+}
+
+type CatData struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type Cat struct {
+	CatData
+}
+
+func (c *Cat) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &c.CatData)
+}
+
+func (c Cat) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(c.CatData)
+}
+
+type Dog struct {
+	CatData
+}
+
+func (d Dog) UnmarshalJSON(data []byte) error { // want `UnmarshalJSON forwards to j2n but has a value receiver`
+	return j2n.UnmarshalJSON(data, &d.CatData)
+}
+
+func (d *Dog) MarshalJSON() ([]byte, error) { // want `MarshalJSON forwards to j2n but has a pointer receiver`
+	return j2n.MarshalJSON(d.CatData)
+}
+
+type Fox struct {
+	CatData
+	cache *j2n.MarshalCache
+}
+
+func (f *Fox) MarshalJSON() ([]byte, error) { // want `MarshalJSON forwards to j2n but has a pointer receiver`
+	return f.cache.Marshal(f.CatData)
+}
+
+type Bird struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func (b Bird) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(b) // want `j2n.MarshalJSON called with a.Bird, which has its own MarshalJSON method and will recurse; pass its data struct instead`
+}
+
+func recursive(data []byte) {
+	j2n.UnmarshalJSON(data, &Cat{}) // want `j2n.UnmarshalJSON called with \*a.Cat, which has its own MarshalJSON method`
+	j2n.MarshalJSON(Cat{})          // want `j2n.MarshalJSON called with a.Cat, which has its own MarshalJSON method`
+}
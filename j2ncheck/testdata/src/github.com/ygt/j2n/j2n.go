@@ -0,0 +1,14 @@
+// Package j2n is a stub of the real package for the analyzer tests.
+package j2n
+
+import "encoding/json"
+
+type Overflow map[string]*json.RawMessage
+
+func UnmarshalJSON(data []byte, v interface{}) error { return nil }
+
+func MarshalJSON(v interface{}) ([]byte, error) { return nil, nil }
+
+type MarshalCache struct{}
+
+func (c *MarshalCache) Marshal(v interface{}) ([]byte, error) { return nil, nil }
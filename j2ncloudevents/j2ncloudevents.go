@@ -0,0 +1,358 @@
+// Package j2ncloudevents provides CloudEvents 1.0 envelope types built on
+// j2n. The spec's context attributes are named fields, and extension
+// attributes, which are not known in advance, are kept in Overflow so that
+// an intermediary re-emits every extension it received.
+//
+//	var e j2ncloudevents.Event
+//	if err := json.Unmarshal(body, &e); err != nil {
+//		return err
+//	}
+//	if err := e.Validate(); err != nil {
+//		return err
+//	}
+//	traceparent, _, err := e.Overflow.GetString("traceparent")
+//
+// An event is carried over HTTP in structured mode, where the whole event is
+// the JSON body, or in binary mode, where the attributes are "ce-" headers
+// and the body is the data. ReadHTTP accepts either, and WriteStructured and
+// WriteBinary produce them.
+package j2ncloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ygt/j2n"
+)
+
+// The only version of the spec that is supported.
+const SpecVersion = "1.0"
+
+// The media type of an event in structured mode.
+const StructuredContentType = "application/cloudevents+json"
+
+// The prefix of the HTTP headers that carry attributes in binary mode.
+const headerPrefix = "ce-"
+
+// EventData holds the context attributes defined by the spec, and the
+// event's data. Data holds JSON data, and DataBase64 holds binary data,
+// which is encoded as data_base64.
+type EventData struct {
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	SpecVersion     string          `json:"specversion"`
+	Type            string          `json:"type"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            *time.Time      `json:"time,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	DataBase64      []byte          `json:"data_base64,omitempty"`
+
+	// Extension attributes
+	Overflow j2n.Overflow `json:"-"`
+}
+
+// Event is a CloudEvent whose extension attributes survive a round trip.
+type Event struct {
+	EventData
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &e.EventData)
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(e.EventData)
+}
+
+// Checks that e has the required attributes, that its attributes are
+// well-formed, and that its extension attributes follow the naming rules of
+// the spec and have scalar values.
+func (e *Event) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("Unsupported specversion '%s'", e.SpecVersion)
+	}
+	if e.ID == "" {
+		return errors.New("Missing required attribute 'id'")
+	}
+	if e.Source == "" {
+		return errors.New("Missing required attribute 'source'")
+	}
+	if _, err := url.Parse(e.Source); err != nil {
+		return fmt.Errorf("Attribute 'source' is not a URI-reference: %s", err)
+	}
+	if e.Type == "" {
+		return errors.New("Missing required attribute 'type'")
+	}
+	if e.DataSchema != "" {
+		if u, err := url.Parse(e.DataSchema); err != nil || !u.IsAbs() {
+			return fmt.Errorf("Attribute 'dataschema' is not an absolute URI: '%s'", e.DataSchema)
+		}
+	}
+	if e.Data != nil && e.DataBase64 != nil {
+		return errors.New("Only one of 'data' and 'data_base64' may be present")
+	}
+
+	for name, raw := range e.Overflow.All() {
+		if err := ValidateExtensionName(name); err != nil {
+			return err
+		}
+		if _, err := extensionString(name, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks that name is a valid extension attribute name: between 1 and 20
+// lower-case ASCII letters or digits, and not the name of an attribute
+// defined by the spec.
+func ValidateExtensionName(name string) error {
+	if name == "" || len(name) > 20 {
+		return fmt.Errorf("Extension attribute name '%s' must be 1 to 20 characters long", name)
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return fmt.Errorf("Extension attribute name '%s' must contain only lower-case letters and digits", name)
+		}
+	}
+	if reserved[name] {
+		return fmt.Errorf("Extension attribute name '%s' is reserved by the spec", name)
+	}
+	return nil
+}
+
+var reserved = map[string]bool{
+	"id": true, "source": true, "specversion": true, "type": true,
+	"datacontenttype": true, "dataschema": true, "subject": true, "time": true,
+	"data": true,
+}
+
+// Sets the extension attribute name to value, which must be a string, bool
+// or integer.
+func (e *Event) SetExtension(name string, value interface{}) error {
+	if err := ValidateExtensionName(name); err != nil {
+		return err
+	}
+	switch value.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint8, uint16:
+	default:
+		return fmt.Errorf("Extension attribute '%s' cannot have type %T", name, value)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if e.Overflow == nil {
+		e.Overflow = make(j2n.Overflow)
+	}
+	message := json.RawMessage(raw)
+	e.Overflow[name] = &message
+	return nil
+}
+
+// Parses an event received over HTTP in either structured or binary mode,
+// as determined by the Content-Type header. In binary mode every extension
+// attribute is a string, as headers carry no type information, and a body
+// that is neither JSON nor text is kept in DataBase64.
+func ReadHTTP(header http.Header, body []byte) (*Event, error) {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType == StructuredContentType {
+		e := &Event{}
+		if err := json.Unmarshal(body, e); err != nil {
+			return nil, err
+		}
+		return e, e.Validate()
+	}
+	if strings.HasPrefix(mediaType, "application/cloudevents") {
+		return nil, fmt.Errorf("Unsupported CloudEvents format '%s'", mediaType)
+	}
+
+	e := &Event{}
+	for key, values := range header {
+		name := strings.ToLower(key)
+		if !strings.HasPrefix(name, headerPrefix) || len(values) == 0 {
+			continue
+		}
+		name = strings.TrimPrefix(name, headerPrefix)
+
+		value, err := url.PathUnescape(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("Header '%s' is not percent-encoded correctly: %s", key, err)
+		}
+
+		switch name {
+		case "id":
+			e.ID = value
+		case "source":
+			e.Source = value
+		case "specversion":
+			e.SpecVersion = value
+		case "type":
+			e.Type = value
+		case "dataschema":
+			e.DataSchema = value
+		case "subject":
+			e.Subject = value
+		case "time":
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("Attribute 'time' is not an RFC 3339 timestamp: %s", err)
+			}
+			e.Time = &t
+		default:
+			if err := e.SetExtension(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	e.DataContentType = header.Get("Content-Type")
+	if len(body) > 0 {
+		if isJSON(e.DataContentType) && json.Valid(body) {
+			e.Data = append(json.RawMessage(nil), body...)
+		} else if strings.HasPrefix(e.DataContentType, "text/") {
+			text, err := json.Marshal(string(body))
+			if err != nil {
+				return nil, err
+			}
+			e.Data = text
+		} else {
+			e.DataBase64 = append([]byte(nil), body...)
+		}
+	}
+	return e, e.Validate()
+}
+
+// Sets the Content-Type header for an event in structured mode and returns
+// the body, which is the JSON encoding of e including its extension
+// attributes.
+func WriteStructured(header http.Header, e *Event) ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Content-Type", StructuredContentType)
+	return body, nil
+}
+
+// Sets the "ce-" headers and Content-Type header for an event in binary
+// mode and returns the body, which is the event's data. Data that is a JSON
+// string is written as its unquoted text unless the content type is JSON.
+func WriteBinary(header http.Header, e *Event) ([]byte, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{
+		"id":          e.ID,
+		"source":      e.Source,
+		"specversion": e.SpecVersion,
+		"type":        e.Type,
+		"dataschema":  e.DataSchema,
+		"subject":     e.Subject,
+	}
+	if e.Time != nil {
+		attributes["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	for name, raw := range e.Overflow.All() {
+		value, err := extensionString(name, raw)
+		if err != nil {
+			return nil, err
+		}
+		attributes[name] = value
+	}
+
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if attributes[name] != "" {
+			header.Set(headerPrefix+name, encodeHeaderValue(attributes[name]))
+		}
+	}
+	if e.DataContentType != "" {
+		header.Set("Content-Type", e.DataContentType)
+	}
+
+	switch {
+	case e.DataBase64 != nil:
+		return e.DataBase64, nil
+	case e.Data == nil:
+		return nil, nil
+	case !isJSON(e.DataContentType) && bytes.HasPrefix(bytes.TrimSpace(e.Data), []byte(`"`)):
+		var text string
+		if err := json.Unmarshal(e.Data, &text); err != nil {
+			return nil, err
+		}
+		return []byte(text), nil
+	}
+	return e.Data, nil
+}
+
+// Returns the string form of an extension attribute, as written in a
+// header, or an error if it is not a scalar.
+func extensionString(name string, raw json.RawMessage) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", err
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		if value != float64(int64(value)) {
+			return "", fmt.Errorf("Extension attribute '%s' must be an integer, not %v", name, value)
+		}
+		return strconv.FormatInt(int64(value), 10), nil
+	}
+	return "", fmt.Errorf("Extension attribute '%s' must be a string, bool or integer", name)
+}
+
+// Returns whether data of the media type contentType is JSON. Data with no
+// content type is JSON by default.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// Percent-encodes the characters of value that the HTTP binding does not
+// allow in a header value: spaces, double quotes, percent signs, and
+// anything outside printable ASCII.
+func encodeHeaderValue(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,167 @@
+package j2ncloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+const structured = `{"data":{"total":12},"datacontenttype":"application/json","id":"A234-1234-1234","priority":3,"source":"/orders","specversion":"1.0","traceparent":"00-abc-01","type":"com.example.order.created"}`
+
+func TestStructuredRoundTripKeepsExtensions(t *testing.T) {
+	header := http.Header{"Content-Type": {StructuredContentType}}
+	e, err := ReadHTTP(header, []byte(structured))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	traceparent, _, err := e.Overflow.GetString("traceparent")
+	if err != nil || traceparent != "00-abc-01" {
+		t.Fatalf("Expected '00-abc-01', got '%s' (%v)", traceparent, err)
+	}
+
+	out := http.Header{}
+	body, err := WriteStructured(out, e)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(body) != structured {
+		t.Fatalf("Expected '%s', got '%s'", structured, body)
+	}
+	if out.Get("Content-Type") != StructuredContentType {
+		t.Fatalf("Expected '%s', got '%s'", StructuredContentType, out.Get("Content-Type"))
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	e := &Event{}
+	if err := json.Unmarshal([]byte(structured), e); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := e.SetExtension("comment", `50% "off"`); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	header := http.Header{}
+	body, err := WriteBinary(header, e)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]string{
+		"Ce-Id":          "A234-1234-1234",
+		"Ce-Priority":    "3",
+		"Ce-Traceparent": "00-abc-01",
+		"Ce-Comment":     "50%25%20%22off%22",
+		"Content-Type":   "application/json",
+	}
+	for key, value := range expected {
+		if header.Get(key) != value {
+			t.Fatalf("Expected %s '%s', got '%s'", key, value, header.Get(key))
+		}
+	}
+	if string(body) != `{"total":12}` {
+		t.Fatalf("Expected '%s', got '%s'", `{"total":12}`, body)
+	}
+
+	read, err := ReadHTTP(header, body)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	comment, _, _ := read.Overflow.GetString("comment")
+	if comment != `50% "off"` {
+		t.Fatalf("Expected '%s', got '%s'", `50% "off"`, comment)
+	}
+
+	// Headers carry no types, so the integer extension becomes a string
+	priority, _, _ := read.Overflow.GetString("priority")
+	if priority != "3" {
+		t.Fatalf("Expected '3', got '%s'", priority)
+	}
+	if string(read.Data) != `{"total":12}` {
+		t.Fatalf("Expected '%s', got '%s'", `{"total":12}`, read.Data)
+	}
+}
+
+func TestBinaryModeWithBinaryData(t *testing.T) {
+	header := http.Header{}
+	header.Set("ce-id", "1")
+	header.Set("ce-source", "urn:example")
+	header.Set("ce-specversion", "1.0")
+	header.Set("ce-type", "image.uploaded")
+	header.Set("Content-Type", "image/png")
+
+	e, err := ReadHTTP(header, []byte{0x89, 'P', 'N', 'G'})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"data_base64":"iVBORw==","datacontenttype":"image/png","id":"1","source":"urn:example","specversion":"1.0","type":"image.uploaded"}`
+	if string(body) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, body)
+	}
+}
+
+func TestValidateRejectsBadExtensions(t *testing.T) {
+	cases := map[string]string{
+		`"Trace":"x"`:                      "Extension attribute name 'Trace' must contain only lower-case letters and digits",
+		`"trace_id":"x"`:                   "Extension attribute name 'trace_id' must contain only lower-case letters and digits",
+		`"averyveryverylongextension":"x"`: "Extension attribute name 'averyveryverylongextension' must be 1 to 20 characters long",
+		`"labels":{"a":"b"}`:               "Extension attribute 'labels' must be a string, bool or integer",
+		`"ratio":0.5`:                      "Extension attribute 'ratio' must be an integer, not 0.5",
+	}
+
+	for extension, expected := range cases {
+		e := &Event{}
+		data := `{"id":"1","source":"/s","specversion":"1.0","type":"t",` + extension + `}`
+		if err := json.Unmarshal([]byte(data), e); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+
+		err := e.Validate()
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected '%s', got '%v'", expected, err)
+		}
+	}
+}
+
+func TestValidateRequiresAttributes(t *testing.T) {
+	e := &Event{EventData{SpecVersion: SpecVersion, ID: "1", Type: "t"}}
+	err := e.Validate()
+	if err == nil || err.Error() != "Missing required attribute 'source'" {
+		t.Fatalf("Expected a missing source error, got '%v'", err)
+	}
+
+	if err := e.SetExtension("data", "x"); err == nil {
+		t.Fatalf("Expected an error setting a reserved attribute name")
+	}
+}
+
+func TestBinaryModeWithTextData(t *testing.T) {
+	header := http.Header{}
+	header.Set("ce-id", "1")
+	header.Set("ce-source", "urn:example")
+	header.Set("ce-specversion", "1.0")
+	header.Set("ce-type", "note.added")
+	header.Set("Content-Type", "text/plain")
+
+	e, err := ReadHTTP(header, []byte("hello"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(e.Data) != `"hello"` {
+		t.Fatalf("Expected '\"hello\"', got '%s'", e.Data)
+	}
+
+	body, err := WriteBinary(http.Header{}, e)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(body) != "hello" {
+		t.Fatalf("Expected 'hello', got '%s'", body)
+	}
+}
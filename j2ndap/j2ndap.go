@@ -0,0 +1,135 @@
+// Package j2ndap provides Debug Adapter Protocol message types built on
+// j2n, so that middleware between a development tool and a debug adapter
+// passes on the fields of newer protocol versions instead of stripping
+// them.
+//
+//	body, err := j2ndap.ReadMessage(reader)
+//	message, err := j2ndap.Decode(body)
+//	switch m := message.(type) {
+//	case *j2ndap.Request:
+//		...
+//	case *j2ndap.Event:
+//		...
+//	}
+//
+// Arguments and bodies, whose shape depends on the command or event, are
+// kept as raw JSON.
+package j2ndap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ygt/j2n"
+	"github.com/ygt/j2n/internal/contentlength"
+)
+
+// Returns the body of the next message from r. Messages with a
+// Content-Length over 64 MiB are rejected.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	return contentlength.Read(r)
+}
+
+// Writes body to w as a message with a Content-Length header.
+func WriteMessage(w io.Writer, body []byte) error {
+	return contentlength.Write(w, body)
+}
+
+// Parses a protocol message, returning a *Request, *Response or *Event
+// according to its type.
+func Decode(data []byte) (interface{}, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var message json.Unmarshaler
+	switch header.Type {
+	case "request":
+		message = &Request{}
+	case "response":
+		message = &Response{}
+	case "event":
+		message = &Event{}
+	default:
+		return nil, fmt.Errorf("Unknown message type '%s'", header.Type)
+	}
+
+	if err := message.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// RequestData holds the fields of a request. Type is "request".
+type RequestData struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	Overflow  j2n.Overflow    `json:"-"`
+}
+
+// Request is a request from the client or the debug adapter.
+type Request struct {
+	RequestData
+}
+
+func (r *Request) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.RequestData)
+}
+
+func (r Request) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.RequestData)
+}
+
+// ResponseData holds the fields of a response. Type is "response".
+type ResponseData struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Command    string          `json:"command"`
+	Message    string          `json:"message,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Overflow   j2n.Overflow    `json:"-"`
+}
+
+// Response is the response to a request.
+type Response struct {
+	ResponseData
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.ResponseData)
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.ResponseData)
+}
+
+// EventData holds the fields of an event. Type is "event".
+type EventData struct {
+	Seq      int             `json:"seq"`
+	Type     string          `json:"type"`
+	Event    string          `json:"event"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+// Event is an event sent by the debug adapter.
+type Event struct {
+	EventData
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &e.EventData)
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(e.EventData)
+}
@@ -0,0 +1,59 @@
+package j2ndap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeKeepsNewerFields(t *testing.T) {
+	messages := []string{
+		`{"arguments":{"threadId":1},"command":"next","seq":3,"type":"request","x-origin":"ide"}`,
+		`{"body":{"allThreadsContinued":true},"command":"continue","request_seq":4,"seq":5,"success":true,"type":"response"}`,
+		`{"body":{"reason":"breakpoint"},"event":"stopped","seq":6,"timestamp":123,"type":"event"}`,
+	}
+
+	var buffer bytes.Buffer
+	for _, m := range messages {
+		if err := WriteMessage(&buffer, []byte(m)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	r := bufio.NewReader(&buffer)
+	for _, expected := range messages {
+		body, err := ReadMessage(r)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+
+		message, err := Decode(body)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+
+		out, err := json.Marshal(message)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if string(out) != expected {
+			t.Fatalf("Expected '%s', got '%s'", expected, out)
+		}
+	}
+}
+
+func TestDecodeTypes(t *testing.T) {
+	message, err := Decode([]byte(`{"seq":1,"type":"event","event":"stopped","timestamp":1}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	event, ok := message.(*Event)
+	if !ok || event.Event != "stopped" || event.Overflow["timestamp"] == nil {
+		t.Fatalf("Expected a stopped event with 'timestamp', got '%+v'", message)
+	}
+
+	if _, err := Decode([]byte(`{"seq":1,"type":"reverse"}`)); err == nil || err.Error() != "Unknown message type 'reverse'" {
+		t.Fatalf("Expected an unknown type error, got '%v'", err)
+	}
+}
@@ -0,0 +1,212 @@
+// Package j2ndrift detects schema drift: it watches the keys that end up in
+// the Overflow field of j2n structs and aggregates them per struct type, so
+// that the fields clients actually send can be promoted into the structs.
+//
+// A Detector can be fed documents directly, or observe ordinary calls to
+// j2n.UnmarshalJSON through its Option:
+//
+//	detector := j2ndrift.NewDetector()
+//	j2n.Configure(CatData{}, detector.Option())
+//	...
+//	report, err := json.Marshal(detector.Report())
+package j2ndrift
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ygt/j2n"
+)
+
+// Report is the drift seen so far, keyed by struct type name.
+type Report map[string]*TypeReport
+
+// TypeReport is the drift seen for one struct type.
+type TypeReport struct {
+	// Documents is the number of documents observed.
+	Documents int `json:"documents"`
+
+	// Keys holds the unknown keys seen in those documents.
+	Keys map[string]*KeyReport `json:"keys"`
+}
+
+// KeyReport describes one unknown key.
+type KeyReport struct {
+	// Count is the number of documents that contained the key.
+	Count int `json:"count"`
+
+	// FirstSeen and LastSeen are the times the key was first and last seen.
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+
+	// Examples holds up to MaxExamples distinct values seen for the key.
+	Examples []json.RawMessage `json:"examples"`
+}
+
+// Detector aggregates unknown keys. It is safe for concurrent use.
+type Detector struct {
+	// MaxExamples is the number of distinct example values kept per key.
+	// Set it to zero to keep no values, for example when they may hold
+	// personal data.
+	MaxExamples int
+
+	// Now returns the current time, and defaults to time.Now.
+	Now func() time.Time
+
+	mutex sync.Mutex
+	types Report
+}
+
+// Returns a Detector that keeps up to 3 example values per key.
+func NewDetector() *Detector {
+	return &Detector{MaxExamples: 3, Now: time.Now, types: make(Report)}
+}
+
+// Parses the JSON-encoded data into the struct pointed to by v, as
+// j2n.UnmarshalJSON does, and records its unknown keys.
+func (d *Detector) Feed(data []byte, v interface{}) error {
+	if err := j2n.UnmarshalJSON(data, v); err != nil {
+		return err
+	}
+
+	d.Observe(v)
+	return nil
+}
+
+// Returns an Option that records the unknown keys of each struct that
+// j2n.UnmarshalJSON parses successfully.
+func (d *Detector) Option() j2n.Option {
+	return j2n.WithAfterUnmarshal(func(v interface{}) error {
+		d.Observe(v)
+		return nil
+	})
+}
+
+// Records the unknown keys in the Overflow field of v, which must be a
+// struct or a pointer to one. Values without an Overflow field are ignored.
+func (d *Detector) Observe(v interface{}) {
+	if t, _, ok := overflowOf(v); ok {
+		d.ObserveAs(t.String(), v)
+	}
+}
+
+// Records the unknown keys in the Overflow field of v like Observe, but
+// under typeName rather than the name of v's type. This suits structs built
+// at run time with reflect.StructOf, which have no name.
+func (d *Detector) ObserveAs(typeName string, v interface{}) {
+	_, overflow, ok := overflowOf(v)
+	if !ok {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.types == nil {
+		d.types = make(Report)
+	}
+
+	report := d.types[typeName]
+	if report == nil {
+		report = &TypeReport{Keys: make(map[string]*KeyReport)}
+		d.types[typeName] = report
+	}
+	report.Documents++
+
+	now := d.now()
+	for k, raw := range overflow {
+		key := report.Keys[k]
+		if key == nil {
+			key = &KeyReport{FirstSeen: now}
+			report.Keys[k] = key
+		}
+		key.Count++
+		key.LastSeen = now
+		d.addExample(key, raw)
+	}
+}
+
+// Returns a copy of the drift seen so far.
+func (d *Detector) Report() Report {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	copied := make(Report, len(d.types))
+	for name, report := range d.types {
+		keys := make(map[string]*KeyReport, len(report.Keys))
+		for k, key := range report.Keys {
+			c := *key
+			c.Examples = append([]json.RawMessage(nil), key.Examples...)
+			keys[k] = &c
+		}
+		copied[name] = &TypeReport{Documents: report.Documents, Keys: keys}
+	}
+	return copied
+}
+
+// Returns the unknown keys of the named type, most frequent first, with ties
+// in key order. These are the best candidates for promotion to fields.
+func (r Report) Candidates(typeName string) []string {
+	report := r[typeName]
+	if report == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(report.Keys))
+	for k := range report.Keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := report.Keys[keys[i]], report.Keys[keys[j]]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func (d *Detector) now() time.Time {
+	if d.Now == nil {
+		return time.Now()
+	}
+	return d.Now()
+}
+
+func (d *Detector) addExample(key *KeyReport, raw *json.RawMessage) {
+	if len(key.Examples) >= d.MaxExamples {
+		return
+	}
+
+	value := json.RawMessage("null")
+	if raw != nil {
+		value = append(json.RawMessage(nil), *raw...)
+	}
+
+	for _, example := range key.Examples {
+		if bytes.Equal(example, value) {
+			return
+		}
+	}
+	key.Examples = append(key.Examples, value)
+}
+
+func overflowOf(v interface{}) (reflect.Type, map[string]*json.RawMessage, bool) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+
+	overflow, err := j2n.OverflowOf(value.Interface())
+	if err != nil {
+		return nil, nil, false
+	}
+	return value.Type(), overflow, true
+}
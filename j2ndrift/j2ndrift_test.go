@@ -0,0 +1,115 @@
+package j2ndrift
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ygt/j2n"
+)
+
+type PersonData struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestDetectorAggregatesUnknownKeys(t *testing.T) {
+	d := NewDetector()
+	d.MaxExamples = 2
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.Now = func() time.Time {
+		start = start.Add(time.Minute)
+		return start
+	}
+
+	for _, input := range []string{
+		`{"name":"Bert","age":29,"city":"Leeds"}`,
+		`{"name":"Ernie","age":30}`,
+		`{"name":"Elmo","age":29}`,
+		`{"name":"Grover","age":31}`,
+	} {
+		if err := d.Feed([]byte(input), &PersonData{}); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	report := d.Report()["j2ndrift.PersonData"]
+	if report == nil || report.Documents != 4 {
+		t.Fatalf("Expected 4 documents, got '%+v'", report)
+	}
+
+	age := report.Keys["age"]
+	if age.Count != 4 || !age.FirstSeen.Before(age.LastSeen) {
+		t.Fatalf("Expected 'age' seen 4 times, got '%+v'", age)
+	}
+
+	expected := []json.RawMessage{json.RawMessage("29"), json.RawMessage("30")}
+	if !reflect.DeepEqual(age.Examples, expected) {
+		t.Fatalf("Expected '%s', got '%s'", expected, age.Examples)
+	}
+
+	candidates := d.Report().Candidates("j2ndrift.PersonData")
+	if !reflect.DeepEqual(candidates, []string{"age", "city"}) {
+		t.Fatalf("Expected 'age' then 'city', got '%v'", candidates)
+	}
+}
+
+type SyncPersonData struct {
+	Name     string            `json:"name"`
+	Overflow *j2n.SyncOverflow `json:"-"`
+}
+
+func TestDetectorReadsOverflowStores(t *testing.T) {
+	d := NewDetector()
+	if err := d.Feed([]byte(`{"name":"Bert","age":29}`), &SyncPersonData{}); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	report := d.Report()["j2ndrift.SyncPersonData"]
+	if report == nil || report.Keys["age"] == nil || report.Keys["age"].Count != 1 {
+		t.Fatalf("Expected 'age' seen once, got '%+v'", report)
+	}
+}
+
+func TestDetectorObservesUnmarshalCalls(t *testing.T) {
+	d := NewDetector()
+
+	p := PersonData{}
+	if err := j2n.UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p, d.Option()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	data, err := json.Marshal(d.Report())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var exported map[string]struct {
+		Documents int `json:"documents"`
+		Keys      map[string]struct {
+			Count int `json:"count"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if exported["j2ndrift.PersonData"].Keys["age"].Count != 1 {
+		t.Fatalf("Expected 'age' to be recorded, got '%s'", data)
+	}
+}
+
+func TestDetectorObserveAsUsesGivenName(t *testing.T) {
+	d := NewDetector()
+
+	p := PersonData{}
+	if err := j2n.UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	d.ObserveAs("Person", &p)
+
+	if report := d.Report()["Person"]; report == nil || report.Keys["age"] == nil {
+		t.Fatalf("Expected 'age' to be recorded under 'Person', got '%v'", d.Report())
+	}
+}
@@ -0,0 +1,166 @@
+// Package j2nfhir provides building blocks for FHIR resources built on j2n.
+// Healthcare payloads must never lose data: a server that does not
+// recognize an element still has to return it. A resource struct embeds
+// DomainResource, which adds the elements every FHIR resource shares,
+// including extension and modifierExtension, and any element the struct
+// does not name, such as the "_" companions of primitive elements, is kept
+// in Overflow:
+//
+//	type PatientData struct {
+//		j2nfhir.DomainResource
+//		BirthDate string       `json:"birthDate,omitempty"`
+//		Overflow  j2n.Overflow `json:"-"`
+//	}
+//
+//	type Patient struct {
+//		PatientData
+//	}
+//
+//	func (p *Patient) UnmarshalJSON(data []byte) error {
+//		return j2n.UnmarshalJSON(data, &p.PatientData)
+//	}
+//
+//	func (p Patient) MarshalJSON() ([]byte, error) {
+//		return j2n.MarshalJSON(p.PatientData)
+//	}
+//
+// Extensions are looked up by URL with Extensions.ByURL and First.
+package j2nfhir
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ygt/j2n"
+)
+
+// DomainResource holds the elements shared by FHIR domain resources. It is
+// embedded in a resource struct. Contained resources are kept as raw JSON,
+// for decoding into their own types.
+type DomainResource struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id,omitempty"`
+	Meta              j2n.Overflow      `json:"meta,omitempty"`
+	ImplicitRules     string            `json:"implicitRules,omitempty"`
+	Language          string            `json:"language,omitempty"`
+	Text              j2n.Overflow      `json:"text,omitempty"`
+	Contained         []json.RawMessage `json:"contained,omitempty"`
+	Extension         Extensions        `json:"extension,omitempty"`
+	ModifierExtension Extensions        `json:"modifierExtension,omitempty"`
+}
+
+// Checks that every modifier extension of r is one of known. FHIR requires
+// that a resource with a modifier extension the application does not
+// understand is not processed, as the extension may change the meaning of
+// the other elements.
+func (r *DomainResource) CheckModifierExtensions(known ...string) error {
+	isKnown := make(map[string]bool, len(known))
+	for _, url := range known {
+		isKnown[url] = true
+	}
+
+	var unknown []string
+	for _, e := range r.ModifierExtension {
+		if !isKnown[e.URL] {
+			unknown = append(unknown, e.URL)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("Unknown modifier extensions: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Extensions is a list of extensions, as found in the extension and
+// modifierExtension elements.
+type Extensions []Extension
+
+// Returns the extensions with the given URL, in order.
+func (e Extensions) ByURL(url string) Extensions {
+	var found Extensions
+	for _, extension := range e {
+		if extension.URL == url {
+			found = append(found, extension)
+		}
+	}
+	return found
+}
+
+// Returns the first extension with the given URL.
+func (e Extensions) First(url string) (*Extension, bool) {
+	for i := range e {
+		if e[i].URL == url {
+			return &e[i], true
+		}
+	}
+	return nil, false
+}
+
+// ExtensionData holds the elements of an extension. Its value, whose
+// element name depends on its type, such as valueString or valueCoding, is
+// kept in Overflow; a complex extension has nested extensions instead.
+type ExtensionData struct {
+	ID        string       `json:"id,omitempty"`
+	URL       string       `json:"url"`
+	Extension Extensions   `json:"extension,omitempty"`
+	Overflow  j2n.Overflow `json:"-"`
+}
+
+// Extension is a FHIR extension whose value, of any type, survives a round
+// trip.
+type Extension struct {
+	ExtensionData
+}
+
+func (e *Extension) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &e.ExtensionData)
+}
+
+func (e Extension) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(e.ExtensionData)
+}
+
+// Returns the type of the value of e, such as "String" for valueString, and
+// its raw JSON. ok is false if e has no value.
+func (e *Extension) Value() (valueType string, raw json.RawMessage, ok bool) {
+	for key, raw := range e.Overflow.All() {
+		if strings.HasPrefix(key, "value") && len(key) > len("value") {
+			return strings.TrimPrefix(key, "value"), raw, true
+		}
+	}
+	return "", nil, false
+}
+
+// Parses the value of e into v, whatever its type.
+func (e *Extension) DecodeValue(v interface{}) error {
+	valueType, raw, ok := e.Value()
+	if !ok {
+		return fmt.Errorf("Extension '%s' has no value", e.URL)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("Extension '%s' value%s: %s", e.URL, valueType, err)
+	}
+	return nil
+}
+
+// Sets the value of e to v, as the element value<valueType>, replacing any
+// value it had before along with the "_" element that extends it.
+func (e *Extension) SetValue(valueType string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	e.Overflow.Filter(func(key string, raw json.RawMessage) bool {
+		return !strings.HasPrefix(strings.TrimPrefix(key, "_"), "value")
+	})
+	if e.Overflow == nil {
+		e.Overflow = make(j2n.Overflow)
+	}
+	message := json.RawMessage(raw)
+	e.Overflow["value"+valueType] = &message
+	return nil
+}
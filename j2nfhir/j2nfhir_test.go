@@ -0,0 +1,117 @@
+package j2nfhir
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type PatientData struct {
+	DomainResource
+	BirthDate string       `json:"birthDate,omitempty"`
+	Overflow  j2n.Overflow `json:"-"`
+}
+
+type Patient struct {
+	PatientData
+}
+
+func (p *Patient) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &p.PatientData)
+}
+
+func (p Patient) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(p.PatientData)
+}
+
+const race = "http://hl7.org/fhir/us/core/StructureDefinition/us-core-race"
+const birthPlace = "http://hl7.org/fhir/StructureDefinition/patient-birthPlace"
+
+const patient = `{"_birthDate":{"extension":[{"url":"http://hl7.org/fhir/StructureDefinition/patient-birthTime","valueDateTime":"1974-12-25T14:35:45-05:00"}]},"birthDate":"1974-12-25","extension":[{"extension":[{"url":"text","valueString":"Mixed"}],"url":"` + race + `"},{"url":"` + birthPlace + `","valueAddress":{"city":"Boston"}}],"gender":"male","id":"example","modifierExtension":[{"url":"http://example.org/fhir/notPatient","valueBoolean":true}],"resourceType":"Patient"}`
+
+func TestResourceRoundTrip(t *testing.T) {
+	p := Patient{}
+	if err := json.Unmarshal([]byte(patient), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.ResourceType != "Patient" || p.BirthDate != "1974-12-25" {
+		t.Fatalf("Expected a patient born 1974-12-25, got '%+v'", p)
+	}
+	if p.Overflow["_birthDate"] == nil || p.Overflow["gender"] == nil {
+		t.Fatalf("Expected '_birthDate' and 'gender' in Overflow, got '%v'", p.Overflow)
+	}
+
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != patient {
+		t.Fatalf("Expected '%s', got '%s'", patient, out)
+	}
+}
+
+func TestExtensionLookup(t *testing.T) {
+	p := Patient{}
+	if err := json.Unmarshal([]byte(patient), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	place, ok := p.Extension.First(birthPlace)
+	if !ok {
+		t.Fatalf("Expected a birth place extension")
+	}
+	valueType, _, _ := place.Value()
+	var address struct{ City string }
+	if err := place.DecodeValue(&address); err != nil || valueType != "Address" || address.City != "Boston" {
+		t.Fatalf("Expected an Address in Boston, got '%s' '%s' (%v)", valueType, address.City, err)
+	}
+
+	races := p.Extension.ByURL(race)
+	if len(races) != 1 {
+		t.Fatalf("Expected 1 race extension, got %d", len(races))
+	}
+	text, ok := races[0].Extension.First("text")
+	var s string
+	if !ok || text.DecodeValue(&s) != nil || s != "Mixed" {
+		t.Fatalf("Expected a nested text extension 'Mixed', got '%s'", s)
+	}
+	if err := races[0].DecodeValue(&s); err == nil {
+		t.Fatalf("Expected an error for a complex extension")
+	}
+}
+
+func TestSetValueReplacesValue(t *testing.T) {
+	e := Extension{}
+	if err := json.Unmarshal([]byte(`{"url":"u","valueString":"a","_valueString":{"id":"x"}}`), &e); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := e.SetValue("Integer", 3); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	out, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"url":"u","valueInteger":3}`
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
+
+func TestCheckModifierExtensions(t *testing.T) {
+	p := Patient{}
+	if err := json.Unmarshal([]byte(patient), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "Unknown modifier extensions: http://example.org/fhir/notPatient"
+	if err := p.CheckModifierExtensions(race); err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+	if err := p.CheckModifierExtensions("http://example.org/fhir/notPatient"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
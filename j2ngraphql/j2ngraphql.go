@@ -0,0 +1,136 @@
+// Package j2ngraphql provides envelope types for GraphQL over HTTP built on
+// j2n, so that a gateway passes on the vendor-specific members of requests,
+// responses and errors rather than stripping them.
+//
+//	var response j2ngraphql.Response
+//	if err := json.Unmarshal(body, &response); err != nil {
+//		return err
+//	}
+//	cost, _, err := response.Extensions.GetInt64("cost")
+//
+// Data and Variables are kept as raw JSON, for decoding into the types of
+// the operation. Extensions are kept as a j2n.Overflow, for its getters.
+package j2ngraphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ygt/j2n"
+)
+
+// RequestData holds the members of a GraphQL request.
+type RequestData struct {
+	Query         string          `json:"query,omitempty"`
+	OperationName string          `json:"operationName,omitempty"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	Extensions    j2n.Overflow    `json:"extensions,omitempty"`
+	Overflow      j2n.Overflow    `json:"-"`
+}
+
+// Request is a GraphQL request whose unknown members survive a round trip.
+type Request struct {
+	RequestData
+}
+
+func (r *Request) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.RequestData)
+}
+
+func (r Request) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.RequestData)
+}
+
+// ResponseData holds the members of a GraphQL response. Data is nil if the
+// response had no data member, and the JSON null if execution failed.
+type ResponseData struct {
+	Data       json.RawMessage `json:"data,omitempty"`
+	Errors     []Error         `json:"errors,omitempty"`
+	Extensions j2n.Overflow    `json:"extensions,omitempty"`
+	Overflow   j2n.Overflow    `json:"-"`
+}
+
+// Response is a GraphQL response whose unknown members, including those of
+// its errors, survive a round trip.
+type Response struct {
+	ResponseData
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.ResponseData)
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.ResponseData)
+}
+
+// Parses the data of r into v. It is an error if r has no data.
+func (r *Response) DecodeData(v interface{}) error {
+	if r.Data == nil {
+		return fmt.Errorf("Response has no data")
+	}
+	return json.Unmarshal(r.Data, v)
+}
+
+// Returns the errors of r as a single error, or nil if there are none.
+func (r *Response) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return Errors(r.Errors)
+}
+
+// ErrorData holds the members of an error in a GraphQL response. Path
+// elements are strings for fields and float64 for list indices.
+type ErrorData struct {
+	Message    string        `json:"message"`
+	Locations  []Location    `json:"locations,omitempty"`
+	Path       []interface{} `json:"path,omitempty"`
+	Extensions j2n.Overflow  `json:"extensions,omitempty"`
+	Overflow   j2n.Overflow  `json:"-"`
+}
+
+// Error is an error in a GraphQL response whose unknown members survive a
+// round trip, such as the top-level code fields some servers send outside
+// extensions. It implements the error interface.
+type Error struct {
+	ErrorData
+}
+
+func (e *Error) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &e.ErrorData)
+}
+
+func (e Error) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(e.ErrorData)
+}
+
+// Returns the message of e, prefixed with its path if it has one.
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return strings.Join(path, ".") + ": " + e.Message
+}
+
+// Location is a position in the GraphQL document that an error refers to.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Errors is the errors of a response as a single error.
+type Errors []Error
+
+func (errs Errors) Error() string {
+	messages := make([]string, len(errs))
+	for i := range errs {
+		messages[i] = errs[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
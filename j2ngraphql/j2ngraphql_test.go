@@ -0,0 +1,86 @@
+package j2ngraphql
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const response = `{"data":{"hero":{"name":"R2-D2","friends":[null]}},"errors":[{"code":"NOT_FOUND","extensions":{"classification":"DataFetchingException"},"locations":[{"column":5,"line":3}],"message":"Friend not found","path":["hero","friends",0]}],"extensions":{"cost":{"requested":12}},"x-served-by":"subgraph-a"}`
+
+func TestResponseRoundTrip(t *testing.T) {
+	r := Response{}
+	if err := json.Unmarshal([]byte(response), &r); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if r.Overflow["x-served-by"] == nil || r.Errors[0].Overflow["code"] == nil {
+		t.Fatalf("Expected unknown members in Overflow, got '%+v'", r)
+	}
+	classification, _, err := r.Errors[0].Extensions.GetString("classification")
+	if err != nil || classification != "DataFetchingException" {
+		t.Fatalf("Expected 'DataFetchingException', got '%s' (%v)", classification, err)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"data":{"hero":{"name":"R2-D2","friends":[null]}},"errors":[{"code":"NOT_FOUND","extensions":{"classification":"DataFetchingException"},"locations":[{"line":3,"column":5}],"message":"Friend not found","path":["hero","friends",0]}],"extensions":{"cost":{"requested":12}},"x-served-by":"subgraph-a"}`
+	if !jsonEqual(t, out, expected) {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
+
+func TestResponseErrorsAndData(t *testing.T) {
+	r := Response{}
+	if err := json.Unmarshal([]byte(response), &r); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "hero.friends.0: Friend not found"
+	if err := r.Err(); err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	var data struct {
+		Hero struct{ Name string }
+	}
+	if err := r.DecodeData(&data); err != nil || data.Hero.Name != "R2-D2" {
+		t.Fatalf("Expected 'R2-D2', got '%s' (%v)", data.Hero.Name, err)
+	}
+
+	empty := Response{}
+	if err := empty.DecodeData(&data); err == nil {
+		t.Fatalf("Expected an error for a response without data")
+	}
+}
+
+func TestRequestKeepsUnknownMembers(t *testing.T) {
+	data := `{"doc_id":"abc","extensions":{"persistedQuery":{"version":1}},"operationName":"Hero","variables":{"id":1}}`
+	r := Request{}
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != data {
+		t.Fatalf("Expected '%s', got '%s'", data, out)
+	}
+}
+
+func jsonEqual(t *testing.T, actual []byte, expected string) bool {
+	var a, e interface{}
+	if err := json.Unmarshal(actual, &a); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	ab, _ := json.Marshal(a)
+	eb, _ := json.Marshal(e)
+	return string(ab) == string(eb)
+}
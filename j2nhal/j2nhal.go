@@ -0,0 +1,150 @@
+// Package j2nhal supports HAL (JSON Hypertext Application Language)
+// resources built on j2n. A domain struct embeds Resource, which adds the
+// _links and _embedded members as named fields, so the domain attributes
+// decode into the struct, links and embedded resources round-trip intact,
+// and any other member is kept in Overflow:
+//
+//	type OrderData struct {
+//		j2nhal.Resource
+//		Total    float64      `json:"total"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+//	type Order struct {
+//		OrderData
+//	}
+//
+//	func (o *Order) UnmarshalJSON(data []byte) error {
+//		return j2n.UnmarshalJSON(data, &o.OrderData)
+//	}
+//
+//	func (o Order) MarshalJSON() ([]byte, error) {
+//		return j2n.MarshalJSON(o.OrderData)
+//	}
+//
+// Embedded resources are kept as raw JSON, and decoded on demand into their
+// own types with DecodeEmbedded.
+package j2nhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ygt/j2n"
+)
+
+// Resource holds the reserved members of a HAL resource. It is embedded in
+// a domain struct.
+type Resource struct {
+	Links    Links                      `json:"_links,omitempty"`
+	Embedded map[string]json.RawMessage `json:"_embedded,omitempty"`
+}
+
+// Returns the href of the "self" link, or "" if there is none.
+func (r *Resource) Self() string {
+	if link, ok := r.Links.Get("self"); ok {
+		return link.Href
+	}
+	return ""
+}
+
+// Parses the resources embedded under rel into v, which is a pointer to a
+// struct for a single resource or to a slice for an array of them.
+func (r *Resource) DecodeEmbedded(rel string, v interface{}) error {
+	raw, ok := r.Embedded[rel]
+	if !ok {
+		return fmt.Errorf("No resource embedded as '%s'", rel)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Embeds v under rel, replacing any resources embedded there before. v is a
+// resource, or a slice of them.
+func (r *Resource) SetEmbedded(rel string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if r.Embedded == nil {
+		r.Embedded = make(map[string]json.RawMessage)
+	}
+	r.Embedded[rel] = raw
+	return nil
+}
+
+// Links are the links of a resource, keyed by relation type.
+type Links map[string]*LinkRelation
+
+// Returns the first link of rel.
+func (l Links) Get(rel string) (*Link, bool) {
+	relation := l[rel]
+	if relation == nil || len(relation.Links) == 0 {
+		return nil, false
+	}
+	return &relation.Links[0], true
+}
+
+// Returns every link of rel.
+func (l Links) All(rel string) []Link {
+	if relation := l[rel]; relation != nil {
+		return relation.Links
+	}
+	return nil
+}
+
+// LinkRelation is the links of one relation type, which HAL encodes as a
+// single link object or as an array of them. The form it was parsed from is
+// kept, and a relation with more than one link is always an array.
+type LinkRelation struct {
+	Links []Link
+	Array bool
+}
+
+func (r *LinkRelation) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		r.Array = true
+		return json.Unmarshal(trimmed, &r.Links)
+	}
+
+	r.Array = false
+	r.Links = make([]Link, 1)
+	return json.Unmarshal(trimmed, &r.Links[0])
+}
+
+func (r LinkRelation) MarshalJSON() ([]byte, error) {
+	if len(r.Links) == 1 && !r.Array {
+		return json.Marshal(r.Links[0])
+	}
+	if r.Links == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(r.Links)
+}
+
+// LinkData holds the properties of a link object defined by HAL.
+type LinkData struct {
+	Href        string       `json:"href"`
+	Templated   bool         `json:"templated,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Deprecation string       `json:"deprecation,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Profile     string       `json:"profile,omitempty"`
+	Title       string       `json:"title,omitempty"`
+	Hreflang    string       `json:"hreflang,omitempty"`
+	Overflow    j2n.Overflow `json:"-"`
+}
+
+// Link is a link object whose unknown properties survive a round trip.
+type Link struct {
+	LinkData
+}
+
+func (l *Link) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &l.LinkData)
+}
+
+func (l Link) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(l.LinkData)
+}
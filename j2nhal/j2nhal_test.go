@@ -0,0 +1,123 @@
+package j2nhal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type OrderData struct {
+	Resource
+	Total    float64      `json:"total"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+type Order struct {
+	OrderData
+}
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &o.OrderData)
+}
+
+func (o Order) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(o.OrderData)
+}
+
+type ItemData struct {
+	Resource
+	SKU      string       `json:"sku"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+type Item struct {
+	ItemData
+}
+
+func (i *Item) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &i.ItemData)
+}
+
+func (i Item) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(i.ItemData)
+}
+
+const order = `{"_embedded":{"items":[{"_links":{"self":{"href":"/items/1"}},"sku":"A1","x-stock":4}]},"_links":{"curies":[{"href":"/docs/{rel}","name":"doc","templated":true}],"doc:customer":{"href":"/customers/7","x-cache":"hit"},"self":{"href":"/orders/123"}},"currency":"EUR","total":30}`
+
+func TestResourceRoundTrip(t *testing.T) {
+	o := Order{}
+	if err := json.Unmarshal([]byte(order), &o); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if o.Total != 30 || o.Overflow["currency"] == nil {
+		t.Fatalf("Expected the total with 'currency' in Overflow, got '%+v'", o)
+	}
+	if o.Self() != "/orders/123" {
+		t.Fatalf("Expected '/orders/123', got '%s'", o.Self())
+	}
+	customer, ok := o.Links.Get("doc:customer")
+	if !ok || customer.Overflow["x-cache"] == nil {
+		t.Fatalf("Expected a customer link with 'x-cache', got '%+v'", customer)
+	}
+
+	out, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != order {
+		t.Fatalf("Expected '%s', got '%s'", order, out)
+	}
+}
+
+func TestEmbeddedResources(t *testing.T) {
+	o := Order{}
+	if err := json.Unmarshal([]byte(order), &o); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var items []Item
+	if err := o.DecodeEmbedded("items", &items); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(items) != 1 || items[0].SKU != "A1" || items[0].Self() != "/items/1" || items[0].Overflow["x-stock"] == nil {
+		t.Fatalf("Expected item A1, got '%+v'", items)
+	}
+
+	items[0].SKU = "B2"
+	if err := o.SetEmbedded("items", items); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `[{"_links":{"self":{"href":"/items/1"}},"sku":"B2","x-stock":4}]`
+	if string(o.Embedded["items"]) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, o.Embedded["items"])
+	}
+
+	if err := o.DecodeEmbedded("payments", &items); err == nil {
+		t.Fatalf("Expected an error for a missing relation")
+	}
+}
+
+func TestLinkRelationForms(t *testing.T) {
+	links := Links{}
+	if err := json.Unmarshal([]byte(`{"a":{"href":"/a"},"b":[{"href":"/b"}]}`), &links); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if links["a"].Array || !links["b"].Array {
+		t.Fatalf("Expected the forms to be kept, got '%+v'", links)
+	}
+
+	links["a"].Links = append(links["a"].Links, Link{LinkData{Href: "/a2"}})
+	out, err := json.Marshal(links)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"a":[{"href":"/a"},{"href":"/a2"}],"b":[{"href":"/b"}]}`
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+	if len(links.All("a")) != 2 {
+		t.Fatalf("Expected 2 links, got %d", len(links.All("a")))
+	}
+}
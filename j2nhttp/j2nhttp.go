@@ -0,0 +1,233 @@
+// Package j2nhttp audits the JSON bodies of HTTP requests for keys that the
+// server's types do not name. Middleware decodes each request body into a
+// registered type, records the keys that end up in its Overflow field to a
+// Sink, and then passes the request on with its body intact.
+//
+//	audited := j2nhttp.Middleware(CreateOrder{}, j2nhttp.SinkFunc(func(r *http.Request, u j2nhttp.Unknown) {
+//		log.Printf("%s %s: %d unknown keys", r.Method, r.URL.Path, len(u.Keys))
+//	}), j2nhttp.WithContext())
+//	http.Handle("/orders", audited(ordersHandler))
+//
+// With WithContext, handlers can take the parsed value from the request
+// context with FromContext rather than decoding the body again.
+package j2nhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ygt/j2n"
+)
+
+// Unknown describes the unknown keys of one request body.
+type Unknown struct {
+	// The name of the registered type
+	Type string
+
+	// The unknown keys, in key order
+	Keys []UnknownKey
+}
+
+// UnknownKey is an unknown key and the size in bytes of its raw JSON value.
+type UnknownKey struct {
+	Name string
+	Size int
+}
+
+// Sink receives the unknown keys of request bodies. Record is only called
+// for bodies with at least one unknown key, and may be called concurrently.
+type Sink interface {
+	Record(r *http.Request, u Unknown)
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(r *http.Request, u Unknown)
+
+func (f SinkFunc) Record(r *http.Request, u Unknown) {
+	f(r, u)
+}
+
+// Option configures Middleware.
+type Option func(*middleware)
+
+type middleware struct {
+	typ           reflect.Type
+	sink          Sink
+	inject        bool
+	rejectInvalid bool
+	rejectLarge   bool
+	maxBytes      int64
+	opts          []j2n.Option
+}
+
+// Returns an Option that stores the parsed value in the request context,
+// where FromContext finds it.
+func WithContext() Option {
+	return func(m *middleware) {
+		m.inject = true
+	}
+}
+
+// Returns an Option that responds 400 Bad Request to bodies that cannot be
+// decoded into the registered type. By default they are passed on
+// unaudited, so that the handler reports the error in its own way.
+func WithRejectInvalid() Option {
+	return func(m *middleware) {
+		m.rejectInvalid = true
+	}
+}
+
+// Returns an Option that limits the bodies audited to n bytes. Larger
+// bodies are passed on unaudited, unless WithRejectTooLarge is given. The
+// default is 1MB.
+func WithMaxBytes(n int64) Option {
+	return func(m *middleware) {
+		m.maxBytes = n
+	}
+}
+
+// Returns an Option that responds 413 Request Entity Too Large to bodies
+// over the limit set by WithMaxBytes, rather than passing them on
+// unaudited.
+func WithRejectTooLarge() Option {
+	return func(m *middleware) {
+		m.rejectLarge = true
+	}
+}
+
+// Returns an Option that passes opts to j2n.UnmarshalJSON. They apply only
+// when the registered type is a data struct; a wrapper type decodes itself
+// with its own UnmarshalJSON method.
+func WithUnmarshalOptions(opts ...j2n.Option) Option {
+	return func(m *middleware) {
+		m.opts = append(m.opts, opts...)
+	}
+}
+
+// Returns middleware that decodes JSON request bodies into a new value of
+// the type of v, and records its unknown keys to sink. v is a struct with an
+// Overflow field, or a wrapper type that embeds one, or a pointer to either.
+//
+// Requests whose Content-Type is not JSON, requests with no body, and
+// requests with bodies over the limit set by WithMaxBytes are passed on
+// unaudited.
+func Middleware(v interface{}, sink Sink, opts ...Option) func(http.Handler) http.Handler {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	m := &middleware{typ: t, sink: sink, maxBytes: 1 << 20}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.serve(next, w, r)
+		})
+	}
+}
+
+func (m *middleware) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Body == nil || r.Body == http.NoBody || !isJSON(r.Header.Get("Content-Type")) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	// Read one byte more than the limit to tell whether the body is over it
+	body, err := io.ReadAll(io.LimitReader(r.Body, m.maxBytes+1))
+	if err != nil || int64(len(body)) > m.maxBytes {
+		if err == nil && m.rejectLarge {
+			r.Body.Close()
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		// The handler reads the body, or the error, as if it were unaudited
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		next.ServeHTTP(w, r)
+		return
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	v := reflect.New(m.typ)
+	if err := m.decode(body, v.Interface()); err != nil {
+		if m.rejectInvalid {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	if unknown := m.unknown(v); len(unknown.Keys) > 0 {
+		m.sink.Record(r, unknown)
+	}
+
+	if m.inject {
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, v.Interface()))
+	}
+	next.ServeHTTP(w, r)
+}
+
+func (m *middleware) decode(body []byte, v interface{}) error {
+	if u, ok := v.(json.Unmarshaler); ok {
+		return u.UnmarshalJSON(body)
+	}
+	return j2n.UnmarshalJSON(body, v, m.opts...)
+}
+
+// Returns the unknown keys in the Overflow field of the struct pointed to
+// by v, which may be promoted from an embedded data struct.
+func (m *middleware) unknown(v reflect.Value) Unknown {
+	u := Unknown{Type: m.typ.String()}
+
+	overflow, err := j2n.OverflowOf(v.Interface())
+	if err != nil {
+		return u
+	}
+	for k, raw := range overflow.All() {
+		u.Keys = append(u.Keys, UnknownKey{Name: k, Size: len(raw)})
+	}
+	return u
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type contextKey struct{}
+
+// Returns the value parsed from the request body by Middleware with
+// WithContext, as a pointer to the registered type, or nil if there is
+// none.
+func FromContext(ctx context.Context) interface{} {
+	return ctx.Value(contextKey{})
+}
+
+// Returns whether the media type contentType is JSON. Bodies with no
+// content type are assumed to be JSON.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,174 @@
+package j2nhttp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type OrderData struct {
+	Item     string       `json:"item"`
+	Quantity int          `json:"quantity"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+type Order struct {
+	OrderData
+}
+
+func (o *Order) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &o.OrderData)
+}
+
+func (o Order) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(o.OrderData)
+}
+
+type recorder struct {
+	mutex   sync.Mutex
+	unknown []Unknown
+}
+
+func (r *recorder) Record(req *http.Request, u Unknown) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.unknown = append(r.unknown, u)
+}
+
+func serve(handler http.Handler, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareRecordsUnknownKeys(t *testing.T) {
+	sink := &recorder{}
+	var seen string
+	handler := Middleware(Order{}, sink)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = string(body)
+	}))
+
+	body := `{"item":"tea","quantity":2,"giftWrap":true,"note":"hi"}`
+	serve(handler, "application/json", body)
+
+	if seen != body {
+		t.Fatalf("Expected the handler to read '%s', got '%s'", body, seen)
+	}
+	if len(sink.unknown) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(sink.unknown))
+	}
+
+	u := sink.unknown[0]
+	if u.Type != "j2nhttp.Order" {
+		t.Fatalf("Expected 'j2nhttp.Order', got '%s'", u.Type)
+	}
+	expected := []UnknownKey{{Name: "giftWrap", Size: 4}, {Name: "note", Size: 4}}
+	if len(u.Keys) != 2 || u.Keys[0] != expected[0] || u.Keys[1] != expected[1] {
+		t.Fatalf("Expected %v, got %v", expected, u.Keys)
+	}
+}
+
+type SyncOrderData struct {
+	Item     string            `json:"item"`
+	Overflow *j2n.SyncOverflow `json:"-"`
+}
+
+func TestMiddlewareRecordsOverflowStoreKeys(t *testing.T) {
+	sink := &recorder{}
+	handler := Middleware(SyncOrderData{}, sink)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve(handler, "application/json", `{"item":"tea","note":"hi"}`)
+
+	expected := UnknownKey{Name: "note", Size: 4}
+	if len(sink.unknown) != 1 || len(sink.unknown[0].Keys) != 1 || sink.unknown[0].Keys[0] != expected {
+		t.Fatalf("Expected %v, got %v", expected, sink.unknown)
+	}
+}
+
+func TestMiddlewareSkipsKnownAndNonJSONBodies(t *testing.T) {
+	sink := &recorder{}
+	handler := Middleware(&OrderData{}, sink)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve(handler, "application/json", `{"item":"tea"}`)
+	serve(handler, "text/plain", `{"item":"tea","extra":1}`)
+
+	if len(sink.unknown) != 0 {
+		t.Fatalf("Expected no records, got %v", sink.unknown)
+	}
+}
+
+func TestMiddlewareInjectsValue(t *testing.T) {
+	var order *Order
+	handler := Middleware(Order{}, &recorder{}, WithContext())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order, _ = FromContext(r.Context()).(*Order)
+	}))
+
+	serve(handler, "", `{"item":"tea","quantity":2,"giftWrap":true}`)
+
+	if order == nil || order.Item != "tea" {
+		t.Fatalf("Expected the parsed order, got %v", order)
+	}
+	data, _ := json.Marshal(order)
+	if string(data) != `{"giftWrap":true,"item":"tea","quantity":2}` {
+		t.Fatalf("Expected the order to keep its unknown keys, got '%s'", data)
+	}
+}
+
+func TestMiddlewareInvalidBodies(t *testing.T) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	serve(Middleware(Order{}, &recorder{})(next), "application/json", `{"quantity":"two"}`)
+	if !called {
+		t.Fatalf("Expected an invalid body to be passed on")
+	}
+
+	called = false
+	w := serve(Middleware(Order{}, &recorder{}, WithRejectInvalid())(next), "application/json", `{"quantity":"two"}`)
+	if called || w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+
+	called = false
+	w = serve(Middleware(Order{}, &recorder{}, WithMaxBytes(8), WithRejectTooLarge())(next), "application/json", `{"item":"a long name"}`)
+	if called || w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", w.Code)
+	}
+}
+
+func TestMiddlewarePassesLargeBodiesOnUnaudited(t *testing.T) {
+	sink := &recorder{}
+	var seen string
+	handler := Middleware(Order{}, sink, WithMaxBytes(8))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = string(body)
+	}))
+
+	body := `{"item":"a long name","extra":1}`
+	w := serve(handler, "application/json", body)
+	if w.Code != http.StatusOK || seen != body {
+		t.Fatalf("Expected the whole body to be passed on, got %d and '%s'", w.Code, seen)
+	}
+	if len(sink.unknown) != 0 {
+		t.Fatalf("Expected nothing to be recorded, got %v", sink.unknown)
+	}
+
+	// A body of exactly the limit is audited
+	w = serve(handler, "application/json", `{"x":12}`)
+	if w.Code != http.StatusOK || len(sink.unknown) != 1 {
+		t.Fatalf("Expected the body to be audited, got %v", sink.unknown)
+	}
+}
@@ -0,0 +1,356 @@
+// Package j2njsonapi provides types for JSON:API documents built on j2n, so
+// that a client keeps the members a server sends that it does not model,
+// such as meta objects and vendor extension members, instead of stripping
+// them on the way through.
+//
+//	var doc j2njsonapi.Document
+//	if err := json.Unmarshal(body, &doc); err != nil {
+//		return err
+//	}
+//	if err := doc.Validate(); err != nil {
+//		return err
+//	}
+//	article, err := doc.Resource()
+//	err = article.DecodeAttributes(&attributes)
+//
+// Primary data, attributes and relationship data are kept as raw JSON, as
+// their shape depends on the document; the methods of Document and
+// Relationship decode them. Meta and links objects are kept as a
+// j2n.Overflow, for its getters.
+package j2njsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ygt/j2n"
+)
+
+// DocumentData holds the top-level members of a document. Data is nil if
+// the document has no primary data, and the JSON null for an empty to-one
+// result.
+type DocumentData struct {
+	Data     json.RawMessage `json:"data,omitempty"`
+	Errors   []Error         `json:"errors,omitempty"`
+	Meta     j2n.Overflow    `json:"meta,omitempty"`
+	JSONAPI  j2n.Overflow    `json:"jsonapi,omitempty"`
+	Links    j2n.Overflow    `json:"links,omitempty"`
+	Included []Resource      `json:"included,omitempty"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+// Document is a JSON:API top-level document whose unknown members survive a
+// round trip.
+type Document struct {
+	DocumentData
+}
+
+func (d *Document) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &d.DocumentData)
+}
+
+func (d Document) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(d.DocumentData)
+}
+
+// Checks the rules of the specification for the top-level members, and
+// validates the resources of the document.
+func (d *Document) Validate() error {
+	if d.Data == nil && d.Errors == nil && d.Meta == nil && len(d.Overflow) == 0 {
+		return errors.New("A document must contain at least one of 'data', 'errors' and 'meta'")
+	}
+	if d.Data != nil && d.Errors != nil {
+		return errors.New("A document must not contain both 'data' and 'errors'")
+	}
+	if d.Included != nil && d.Data == nil {
+		return errors.New("A document must not contain 'included' without 'data'")
+	}
+
+	var resources []Resource
+	switch {
+	case isArray(d.Data):
+		if err := json.Unmarshal(d.Data, &resources); err != nil {
+			return err
+		}
+	case d.Data != nil && !isNull(d.Data):
+		r, err := d.Resource()
+		if err != nil {
+			return err
+		}
+		resources = []Resource{*r}
+	}
+
+	for i := range resources {
+		if err := resources[i].Validate(); err != nil {
+			return err
+		}
+	}
+	for i := range d.Included {
+		if err := d.Included[i].Validate(); err != nil {
+			return fmt.Errorf("Included: %s", err)
+		}
+	}
+	return nil
+}
+
+// Returns the primary data of d as a single resource, or nil if it is null.
+func (d *Document) Resource() (*Resource, error) {
+	if d.Data == nil {
+		return nil, errors.New("Document has no primary data")
+	}
+	if isArray(d.Data) {
+		return nil, errors.New("Primary data is a collection")
+	}
+	if isNull(d.Data) {
+		return nil, nil
+	}
+
+	r := &Resource{}
+	if err := json.Unmarshal(d.Data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Returns the primary data of d as a collection of resources.
+func (d *Document) Resources() ([]Resource, error) {
+	if !isArray(d.Data) {
+		return nil, errors.New("Primary data is not a collection")
+	}
+
+	var resources []Resource
+	if err := json.Unmarshal(d.Data, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// Sets the primary data of d to v, which is a Resource, a slice of them, or
+// nil for null.
+func (d *Document) SetData(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	d.Data = data
+	return nil
+}
+
+// ResourceData holds the members of a resource object. Lid is the local
+// identifier of a resource created by the client, which has no ID yet.
+type ResourceData struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id,omitempty"`
+	Lid           string                  `json:"lid,omitempty"`
+	Attributes    json.RawMessage         `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         j2n.Overflow            `json:"links,omitempty"`
+	Meta          j2n.Overflow            `json:"meta,omitempty"`
+	Overflow      j2n.Overflow            `json:"-"`
+}
+
+// Resource is a resource object whose unknown members survive a round
+// trip.
+type Resource struct {
+	ResourceData
+}
+
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.ResourceData)
+}
+
+func (r Resource) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.ResourceData)
+}
+
+// Checks that r has a type and an id or lid, and that its attributes and
+// relationships share a namespace that does not include 'id' or 'type'.
+func (r *Resource) Validate() error {
+	if r.Type == "" {
+		return errors.New("Resource is missing required member 'type'")
+	}
+	if r.ID == "" && r.Lid == "" {
+		return fmt.Errorf("Resource of type '%s' is missing required member 'id'", r.Type)
+	}
+
+	var attributes map[string]json.RawMessage
+	if r.Attributes != nil {
+		if err := json.Unmarshal(r.Attributes, &attributes); err != nil {
+			return fmt.Errorf("Resource '%s/%s' attributes: %s", r.Type, r.ID, err)
+		}
+	}
+
+	for _, name := range []string{"id", "type"} {
+		_, attribute := attributes[name]
+		_, relationship := r.Relationships[name]
+		if attribute || relationship {
+			return fmt.Errorf("Resource '%s/%s' must not have a field named '%s'", r.Type, r.ID, name)
+		}
+	}
+	for name, relationship := range r.Relationships {
+		if _, ok := attributes[name]; ok {
+			return fmt.Errorf("Resource '%s/%s' has both an attribute and a relationship named '%s'", r.Type, r.ID, name)
+		}
+		if err := relationship.Validate(); err != nil {
+			return fmt.Errorf("Resource '%s/%s' relationship '%s': %s", r.Type, r.ID, name, err)
+		}
+	}
+	return nil
+}
+
+// Parses the attributes of r into v with json.Unmarshal. v may be a wrapper
+// type following the j2n pattern, which keeps the attributes it does not
+// name.
+func (r *Resource) DecodeAttributes(v interface{}) error {
+	if r.Attributes == nil {
+		return json.Unmarshal([]byte("{}"), v)
+	}
+	return json.Unmarshal(r.Attributes, v)
+}
+
+// Sets the attributes of r to the JSON encoding of v.
+func (r *Resource) EncodeAttributes(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.Attributes = data
+	return nil
+}
+
+// RelationshipData holds the members of a relationship object. Data is the
+// raw resource linkage: nil if absent, null or a resource identifier for a
+// to-one relationship, and an array of them for a to-many relationship.
+type RelationshipData struct {
+	Data     json.RawMessage `json:"data,omitempty"`
+	Links    j2n.Overflow    `json:"links,omitempty"`
+	Meta     j2n.Overflow    `json:"meta,omitempty"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+// Relationship is a relationship object whose unknown members survive a
+// round trip.
+type Relationship struct {
+	RelationshipData
+}
+
+func (r *Relationship) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.RelationshipData)
+}
+
+func (r Relationship) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.RelationshipData)
+}
+
+// Checks that r has at least one of links, data and meta.
+func (r *Relationship) Validate() error {
+	if r.Data == nil && r.Links == nil && r.Meta == nil {
+		return errors.New("A relationship must contain at least one of 'links', 'data' and 'meta'")
+	}
+	return nil
+}
+
+// Returns the resource linkage of a to-one relationship, or nil if it is
+// null.
+func (r Relationship) Identifier() (*Identifier, error) {
+	if r.Data == nil || isArray(r.Data) {
+		return nil, errors.New("Relationship is not to-one")
+	}
+	if isNull(r.Data) {
+		return nil, nil
+	}
+
+	id := &Identifier{}
+	if err := json.Unmarshal(r.Data, id); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// Returns the resource linkage of a to-many relationship.
+func (r Relationship) Identifiers() ([]Identifier, error) {
+	if !isArray(r.Data) {
+		return nil, errors.New("Relationship is not to-many")
+	}
+
+	var ids []Identifier
+	if err := json.Unmarshal(r.Data, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// IdentifierData holds the members of a resource identifier object.
+type IdentifierData struct {
+	Type     string       `json:"type"`
+	ID       string       `json:"id,omitempty"`
+	Lid      string       `json:"lid,omitempty"`
+	Meta     j2n.Overflow `json:"meta,omitempty"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+// Identifier is a resource identifier object whose unknown members survive
+// a round trip.
+type Identifier struct {
+	IdentifierData
+}
+
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &i.IdentifierData)
+}
+
+func (i Identifier) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(i.IdentifierData)
+}
+
+// ErrorData holds the members of an error object. Status is the HTTP status
+// code as a string, as the specification requires.
+type ErrorData struct {
+	ID       string       `json:"id,omitempty"`
+	Links    j2n.Overflow `json:"links,omitempty"`
+	Status   string       `json:"status,omitempty"`
+	Code     string       `json:"code,omitempty"`
+	Title    string       `json:"title,omitempty"`
+	Detail   string       `json:"detail,omitempty"`
+	Source   j2n.Overflow `json:"source,omitempty"`
+	Meta     j2n.Overflow `json:"meta,omitempty"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+// Error is an error object whose unknown members survive a round trip. It
+// implements the error interface.
+type Error struct {
+	ErrorData
+}
+
+func (e *Error) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &e.ErrorData)
+}
+
+func (e Error) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(e.ErrorData)
+}
+
+// Returns the detail of e, or its title if there is no detail, prefixed with
+// its status.
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+	if e.Status != "" {
+		return e.Status + ": " + message
+	}
+	return message
+}
+
+func isArray(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '['
+}
+
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
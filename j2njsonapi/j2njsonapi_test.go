@@ -0,0 +1,123 @@
+package j2njsonapi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+const article = `{"data":{"attributes":{"title":"Rails is Omakase","wordCount":120},"id":"1","meta":{"etag":"abc"},"relationships":{"author":{"data":{"id":"9","type":"people"},"links":{"related":"/articles/1/author"}},"tags":{"data":[{"id":"2","type":"tags"}]}},"type":"articles","x-vendor":true},"included":[{"attributes":{"name":"Dan"},"id":"9","type":"people"}],"meta":{"requestId":"r1"},"x-trace":"t1"}`
+
+type ArticleAttributesData struct {
+	Title    string       `json:"title"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+type ArticleAttributes struct {
+	ArticleAttributesData
+}
+
+func (a *ArticleAttributes) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &a.ArticleAttributesData)
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	doc := Document{}
+	if err := json.Unmarshal([]byte(article), &doc); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != article {
+		t.Fatalf("Expected '%s', got '%s'", article, out)
+	}
+}
+
+func TestResourceAccessors(t *testing.T) {
+	doc := Document{}
+	if err := json.Unmarshal([]byte(article), &doc); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	r, err := doc.Resource()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if r.Overflow["x-vendor"] == nil {
+		t.Fatalf("Expected 'x-vendor' in Overflow, got '%+v'", r.Overflow)
+	}
+
+	attributes := ArticleAttributes{}
+	if err := r.DecodeAttributes(&attributes); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if attributes.Title != "Rails is Omakase" || attributes.Overflow["wordCount"] == nil {
+		t.Fatalf("Expected the title with 'wordCount' in Overflow, got '%+v'", attributes)
+	}
+
+	author, err := r.Relationships["author"].Identifier()
+	if err != nil || author.ID != "9" || author.Type != "people" {
+		t.Fatalf("Expected people/9, got '%+v' (%v)", author, err)
+	}
+	tags, err := r.Relationships["tags"].Identifiers()
+	if err != nil || len(tags) != 1 {
+		t.Fatalf("Expected 1 tag, got %d (%v)", len(tags), err)
+	}
+
+	if _, err := doc.Resources(); err == nil {
+		t.Fatalf("Expected an error for a single resource")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]string{
+		`{}`:                        "A document must contain at least one of 'data', 'errors' and 'meta'",
+		`{"data":null,"errors":[]}`: "A document must not contain both 'data' and 'errors'",
+		`{"meta":{},"included":[]}`: "A document must not contain 'included' without 'data'",
+		`{"data":{"id":"1"}}`:       "Resource is missing required member 'type'",
+		`{"data":[{"type":"a"}]}`:   "Resource of type 'a' is missing required member 'id'",
+		`{"data":{"type":"a","id":"1","attributes":{"type":"b"}}}`:                              "Resource 'a/1' must not have a field named 'type'",
+		`{"data":{"type":"a","id":"1","relationships":{"b":{}}}}`:                               "Resource 'a/1' relationship 'b': A relationship must contain at least one of 'links', 'data' and 'meta'",
+		`{"data":{"type":"a","id":"1","attributes":{"b":1},"relationships":{"b":{"meta":{}}}}}`: "Resource 'a/1' has both an attribute and a relationship named 'b'",
+	}
+
+	for data, expected := range cases {
+		doc := Document{}
+		if err := json.Unmarshal([]byte(data), &doc); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if err := doc.Validate(); err == nil || err.Error() != expected {
+			t.Fatalf("%s: Expected '%s', got '%v'", data, expected, err)
+		}
+	}
+
+	doc := Document{}
+	if err := json.Unmarshal([]byte(`{"data":{"type":"a","lid":"tmp-1"}}`), &doc); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Expected a resource with a lid to be valid, got '%s'", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	doc := Document{}
+	if err := json.Unmarshal([]byte(`{"errors":[{"status":"422","title":"Invalid","source":{"pointer":"/data/attributes/title"},"x-rule":"len"}]}`), &doc); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	e := doc.Errors[0]
+	if e.Error() != "422: Invalid" || e.Overflow["x-rule"] == nil {
+		t.Fatalf("Expected '422: Invalid' with 'x-rule' in Overflow, got '%s'", e.Error())
+	}
+}
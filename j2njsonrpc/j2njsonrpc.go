@@ -0,0 +1,198 @@
+// Package j2njsonrpc provides JSON-RPC 2.0 envelope types built on j2n, so
+// that nonstandard members of requests, responses and errors, such as
+// tracing fields or batching extensions, pass through a proxy intact.
+//
+//	requests, batch, err := j2njsonrpc.DecodeRequests(body)
+//	for _, r := range requests {
+//		if err := r.Validate(); err != nil { ... }
+//		traceID, _, _ := r.Overflow.GetString("traceId")
+//	}
+//
+// Params, Result and Data are kept as raw JSON, to be decoded by whoever
+// handles the method.
+package j2njsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ygt/j2n"
+)
+
+// The value of the jsonrpc member.
+const Version = "2.0"
+
+// The error codes defined by the specification.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// RequestData holds the members of a request or notification. ID is the raw
+// JSON of the id member, and is nil for a notification.
+type RequestData struct {
+	JSONRPC  string          `json:"jsonrpc"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	ID       json.RawMessage `json:"id,omitempty"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+// Request is a JSON-RPC request whose nonstandard members survive a round
+// trip.
+type Request struct {
+	RequestData
+}
+
+func (r *Request) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.RequestData)
+}
+
+func (r Request) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.RequestData)
+}
+
+// Returns whether r is a notification, which has no id and gets no
+// response.
+func (r *Request) IsNotification() bool {
+	return r.ID == nil
+}
+
+// Checks that r has the members the specification requires: the version,
+// a method, params that are structured if present, and an id that is a
+// string, number or null if present.
+func (r *Request) Validate() error {
+	if r.JSONRPC != Version {
+		return fmt.Errorf("Unsupported jsonrpc version '%s'", r.JSONRPC)
+	}
+	if r.Method == "" {
+		return errors.New("Missing required member 'method'")
+	}
+	if r.Params != nil && !isStructured(r.Params) {
+		return errors.New("Member 'params' must be an array or an object")
+	}
+	if r.ID != nil {
+		return validateID(r.ID)
+	}
+	return nil
+}
+
+// ResponseData holds the members of a response. Exactly one of Result and
+// Error is set. ID is the raw JSON of the id member, which is null if the
+// request's id could not be determined.
+type ResponseData struct {
+	JSONRPC  string          `json:"jsonrpc"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    *Error          `json:"error,omitempty"`
+	ID       json.RawMessage `json:"id"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+// Response is a JSON-RPC response whose nonstandard members survive a
+// round trip.
+type Response struct {
+	ResponseData
+}
+
+func (r *Response) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.ResponseData)
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.ResponseData)
+}
+
+// Checks that r has the version, exactly one of result and error, and an id
+// that is a string, number or null.
+func (r *Response) Validate() error {
+	if r.JSONRPC != Version {
+		return fmt.Errorf("Unsupported jsonrpc version '%s'", r.JSONRPC)
+	}
+	if (r.Result == nil) == (r.Error == nil) {
+		return errors.New("Exactly one of 'result' and 'error' must be present")
+	}
+	if r.ID == nil {
+		return errors.New("Missing required member 'id'")
+	}
+	return validateID(r.ID)
+}
+
+// ErrorData holds the members of the error object of a response.
+type ErrorData struct {
+	Code     int             `json:"code"`
+	Message  string          `json:"message"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+// Error is the error object of a response, whose nonstandard members
+// survive a round trip. It implements the error interface.
+type Error struct {
+	ErrorData
+}
+
+func (e *Error) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &e.ErrorData)
+}
+
+func (e Error) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(e.ErrorData)
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// Parses a single request or a batch of them. batch reports whether data
+// was an array, so that the responses can be sent back in the same form.
+func DecodeRequests(data []byte) (requests []Request, batch bool, err error) {
+	batch, err = decodeMessages(data, &requests)
+	return requests, batch, err
+}
+
+// Parses a single response or a batch of them. batch reports whether data
+// was an array.
+func DecodeResponses(data []byte) (responses []Response, batch bool, err error) {
+	batch, err = decodeMessages(data, &responses)
+	return responses, batch, err
+}
+
+func decodeMessages(data []byte, messages interface{}) (bool, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raw); err != nil {
+			return true, err
+		}
+		if len(raw) == 0 {
+			return true, errors.New("Empty batch")
+		}
+		return true, json.Unmarshal(trimmed, messages)
+	}
+
+	// Decode the single message as a batch of one
+	batch := append(append([]byte("["), trimmed...), ']')
+	return false, json.Unmarshal(batch, messages)
+}
+
+func isStructured(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && (raw[0] == '[' || raw[0] == '{')
+}
+
+func validateID(raw json.RawMessage) error {
+	var id interface{}
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return err
+	}
+	switch id.(type) {
+	case string, float64, nil:
+		return nil
+	}
+	return errors.New("Member 'id' must be a string, number or null")
+}
@@ -0,0 +1,112 @@
+package j2njsonrpc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestKeepsNonstandardMembers(t *testing.T) {
+	data := `{"id":1,"jsonrpc":"2.0","method":"sum","params":[1,2],"traceId":"abc"}`
+	requests, batch, err := DecodeRequests([]byte(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if batch || len(requests) != 1 {
+		t.Fatalf("Expected a single request, got %d (batch %v)", len(requests), batch)
+	}
+
+	r := requests[0]
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if r.Method != "sum" || r.IsNotification() {
+		t.Fatalf("Expected a 'sum' request, got '%+v'", r)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != data {
+		t.Fatalf("Expected '%s', got '%s'", data, out)
+	}
+}
+
+func TestBatchOfRequests(t *testing.T) {
+	data := `[{"jsonrpc":"2.0","method":"notify","x-priority":1},{"jsonrpc":"2.0","method":"get","id":"a"}]`
+	requests, batch, err := DecodeRequests([]byte(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !batch || len(requests) != 2 {
+		t.Fatalf("Expected a batch of 2, got %d (batch %v)", len(requests), batch)
+	}
+	if !requests[0].IsNotification() || requests[0].Overflow["x-priority"] == nil {
+		t.Fatalf("Expected a notification with 'x-priority', got '%+v'", requests[0])
+	}
+	if string(requests[1].ID) != `"a"` {
+		t.Fatalf("Expected id '\"a\"', got '%s'", requests[1].ID)
+	}
+
+	if _, _, err := DecodeRequests([]byte(` [ ] `)); err == nil {
+		t.Fatalf("Expected an error for an empty batch")
+	}
+}
+
+func TestResponseWithError(t *testing.T) {
+	data := `{"error":{"code":-32601,"message":"Method not found","retryAfter":5},"id":null,"jsonrpc":"2.0","node":"b"}`
+	responses, _, err := DecodeResponses([]byte(data))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	r := responses[0]
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if r.Error.Code != MethodNotFound || r.Error.Error() != "JSON-RPC error -32601: Method not found" {
+		t.Fatalf("Expected a method not found error, got '%v'", r.Error)
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != data {
+		t.Fatalf("Expected '%s', got '%s'", data, out)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]string{
+		`{"jsonrpc":"1.0","method":"a"}`:              "Unsupported jsonrpc version '1.0'",
+		`{"jsonrpc":"2.0"}`:                           "Missing required member 'method'",
+		`{"jsonrpc":"2.0","method":"a","params":3}`:   "Member 'params' must be an array or an object",
+		`{"jsonrpc":"2.0","method":"a","id":{"a":1}}`: "Member 'id' must be a string, number or null",
+	}
+	for data, expected := range cases {
+		r := Request{}
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if err := r.Validate(); err == nil || err.Error() != expected {
+			t.Fatalf("Expected '%s', got '%v'", expected, err)
+		}
+	}
+
+	r := Response{}
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","result":null,"error":{"code":1,"message":"x"},"id":1}`), &r); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := r.Validate(); err == nil {
+		t.Fatalf("Expected an error for both result and error")
+	}
+
+	r = Response{}
+	if err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","result":null,"id":1}`), &r); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Expected a null result to be valid, got '%s'", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package j2njwt decodes and encodes JWT payloads with j2n, so that private
+// and vendor-specific claims survive being read into a struct and written
+// back out when a token is re-minted.
+//
+// Claims holds the registered claims of RFC 7519 as named fields and every
+// other claim in its 'Overflow' field. Structs that model claims of their own
+// embed RegisteredClaims and are used like any other j2n struct:
+//
+//	type SessionClaimsData struct {
+//		j2njwt.RegisteredClaims
+//		Scope    string       `json:"scope"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+// Signatures are not handled here: decode a payload only after its token
+// has been verified, and sign the result of EncodePayload with the library
+// that issues the tokens.
+package j2njwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ygt/j2n"
+)
+
+// RegisteredClaims holds the claims registered by RFC 7519.
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// Claims is a JWT claims set with the registered claims as named fields and
+// any other claims in Overflow.
+type Claims struct {
+	RegisteredClaims
+	Overflow j2n.Overflow `json:"-"`
+}
+
+// claims has the fields of Claims without its methods, so that j2n can be
+// called on it without recursing.
+type claims Claims
+
+// Parses the JSON-encoded claims set into c, keeping unregistered claims in
+// c.Overflow.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, (*claims)(c))
+}
+
+// Returns the JSON encoding of c, including the claims in c.Overflow.
+func (c Claims) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(claims(c))
+}
+
+// Audience is the 'aud' claim, which may be a single string or an array of
+// strings. A single audience is encoded as a string.
+type Audience []string
+
+// Parses either form of the 'aud' claim.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("Audience must be a string or an array of strings: %s", err)
+	}
+	*a = many
+	return nil
+}
+
+// Returns the 'aud' claim as a string if a has one entry, or an array.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// NumericDate is a time encoded as the number of seconds since the epoch.
+type NumericDate struct {
+	time.Time
+}
+
+// Returns a NumericDate for t, truncated to whole seconds.
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t.Truncate(time.Second)}
+}
+
+// Parses a number of seconds since the epoch, which may be fractional.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	seconds, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("NumericDate must be a number, got '%s'", data)
+	}
+
+	whole, fraction := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(math.Round(fraction*1e9))).UTC()
+	return nil
+}
+
+// Returns the time as whole seconds since the epoch.
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(d.Unix(), 10)), nil
+}
+
+// Parses the payload of the compact-serialized JWT token into the struct
+// pointed to by v, which must satisfy the requirements of j2n.UnmarshalJSON.
+//
+// The signature is not checked, so token must already have been verified.
+func DecodePayload(token string, v interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("Expected token with 3 parts, got %d", len(parts))
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fmt.Errorf("Invalid token payload: %s", err)
+	}
+
+	if u, ok := v.(json.Unmarshaler); ok {
+		return u.UnmarshalJSON(data)
+	}
+	return j2n.UnmarshalJSON(data, v)
+}
+
+// Returns the base64url-encoded JSON payload for v, including the claims in
+// its Overflow field, ready to be signed.
+func EncodePayload(v interface{}) (string, error) {
+	var data []byte
+	var err error
+	if m, ok := v.(json.Marshaler); ok {
+		data, err = m.MarshalJSON()
+	} else {
+		data, err = j2n.MarshalJSON(v)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
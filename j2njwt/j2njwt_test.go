@@ -0,0 +1,98 @@
+package j2njwt
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ygt/j2n"
+)
+
+func testToken(payload string) string {
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".c2ln"
+}
+
+func TestDecodePayloadKeepsCustomClaims(t *testing.T) {
+	token := testToken(`{"iss":"auth","aud":"api","exp":1700000000,"https://example.com/roles":["admin"]}`)
+
+	c := Claims{}
+	if err := DecodePayload(token, &c); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if c.Issuer != "auth" || len(c.Audience) != 1 || c.Audience[0] != "api" {
+		t.Fatalf("Expected registered claims to be parsed, got '%+v'", c)
+	}
+
+	if !c.ExpiresAt.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("Expected '1700000000', got '%d'", c.ExpiresAt.Unix())
+	}
+
+	roles := c.Overflow["https://example.com/roles"]
+	if roles == nil || string(*roles) != `["admin"]` {
+		t.Fatalf("Expected roles in overflow, got '%v'", c.Overflow)
+	}
+}
+
+func TestEncodePayloadRoundTrip(t *testing.T) {
+	token := testToken(`{"sub":"42","aud":["a","b"],"tenant":"acme"}`)
+
+	c := Claims{}
+	if err := DecodePayload(token, &c); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	c.IssuedAt = NewNumericDate(time.Unix(1600000000, 0))
+	payload, err := EncodePayload(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	data, _ := base64.RawURLEncoding.DecodeString(payload)
+	expected := `{"aud":["a","b"],"iat":1600000000,"sub":"42","tenant":"acme"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+type SessionClaimsData struct {
+	RegisteredClaims
+	Scope    string       `json:"scope"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+func TestDecodePayloadIntoCustomStruct(t *testing.T) {
+	token := testToken(`{"iss":"auth","scope":"read","org":"acme"}`)
+
+	s := SessionClaimsData{}
+	if err := DecodePayload(token, &s); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if s.Issuer != "auth" || s.Scope != "read" {
+		t.Fatalf("Expected named claims to be parsed, got '%+v'", s)
+	}
+
+	if _, ok := s.Overflow["org"]; !ok || len(s.Overflow) != 1 {
+		t.Fatalf("Expected only 'org' in overflow, got '%v'", s.Overflow)
+	}
+}
+
+func TestDecodePayloadReturnsErrorOnMalformedToken(t *testing.T) {
+	c := Claims{}
+	if err := DecodePayload("not-a-token", &c); err == nil {
+		t.Fatal("Expected error decoding malformed token")
+	}
+}
+
+func TestAudienceEncodesSingleValueAsString(t *testing.T) {
+	data, err := json.Marshal(Audience{"api"})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(data) != `"api"` {
+		t.Fatalf("Expected '\"api\"', got '%s'", data)
+	}
+}
@@ -0,0 +1,88 @@
+// Package j2nk8s converts between Kubernetes' untyped object representations
+// and j2n structs. Fields that a typed struct does not model are kept in its
+// 'Overflow' field and written back out, so controllers can work with typed
+// objects without losing data that other clients or newer API versions set.
+//
+// To use a j2n struct in a CustomResourceDefinition's Go types, declare its
+// Overflow field as j2n.Overflow, which has the DeepCopyInto method that
+// controller-gen expects, and mark the type so that the API server keeps the
+// fields it does not model:
+//
+//	// +kubebuilder:pruning:PreserveUnknownFields
+//	type WidgetSpecData struct {
+//		Size     int          `json:"size"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+// CreateStrategicMergePatch, CreateThreeWayMergePatch and
+// ApplyStrategicMergePatch work with strategic merge patches of j2n structs,
+// honouring the patchStrategy and patchMergeKey tags of their named fields.
+package j2nk8s
+
+import (
+	"encoding/json"
+
+	"github.com/ygt/j2n"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// Parses the RawExtension ext into the struct pointed to by v.
+//
+// If ext holds a decoded Object rather than raw JSON, the Object is encoded
+// first.
+func FromRawExtension(ext runtime.RawExtension, v interface{}) error {
+	data := ext.Raw
+	if data == nil && ext.Object != nil {
+		var err error
+		if data, err = json.Marshal(ext.Object); err != nil {
+			return err
+		}
+	}
+
+	if data == nil {
+		data = []byte(`{}`)
+	}
+
+	return j2n.UnmarshalJSON(data, v)
+}
+
+// Returns v, which must be a struct, as a RawExtension holding its JSON
+// encoding, including the entries of v.Overflow.
+func ToRawExtension(v interface{}) (runtime.RawExtension, error) {
+	data, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return runtime.RawExtension{}, err
+	}
+
+	return runtime.RawExtension{Raw: data}, nil
+}
+
+// Parses the Unstructured object u into the struct pointed to by v.
+func FromUnstructured(u *unstructured.Unstructured, v interface{}) error {
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return err
+	}
+
+	return j2n.UnmarshalJSON(data, v)
+}
+
+// Returns v, which must be a struct, as an Unstructured object, including the
+// entries of v.Overflow.
+//
+// Numbers are converted to int64 or float64, as Unstructured requires.
+func ToUnstructured(v interface{}) (*unstructured.Unstructured, error) {
+	data, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	object := make(map[string]interface{})
+	if err := utiljson.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	return &unstructured.Unstructured{Object: object}, nil
+}
@@ -0,0 +1,83 @@
+package j2nk8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type WidgetSpec struct {
+	Size int `json:"size"`
+}
+
+type WidgetData struct {
+	metav1.TypeMeta `json:",inline"`
+	Metadata        metav1.ObjectMeta           `json:"metadata"`
+	Spec            WidgetSpec                  `json:"spec"`
+	Overflow        map[string]*json.RawMessage `json:"-"`
+}
+
+func TestUnstructuredRoundTrip(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "w1"},
+		"spec":       map[string]interface{}{"size": int64(3)},
+		"status":     map[string]interface{}{"ready": true},
+	}}
+
+	w := WidgetData{}
+	if err := FromUnstructured(u, &w); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if w.Kind != "Widget" || w.Metadata.Name != "w1" || w.Spec.Size != 3 {
+		t.Fatalf("Expected typed fields to be parsed, got '%+v'", w)
+	}
+
+	if string(*w.Overflow["status"]) != `{"ready":true}` {
+		t.Fatalf("Expected 'status' in overflow, got '%v'", w.Overflow)
+	}
+
+	w.Spec.Size = 5
+	out, err := ToUnstructured(&w)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	size, _, _ := unstructured.NestedInt64(out.Object, "spec", "size")
+	ready, _, _ := unstructured.NestedBool(out.Object, "status", "ready")
+	if size != 5 || !ready || out.GetName() != "w1" {
+		t.Fatalf("Expected typed and unknown fields in output, got '%v'", out.Object)
+	}
+}
+
+func TestRawExtensionRoundTrip(t *testing.T) {
+	ext := runtime.RawExtension{Raw: []byte(`{"spec":{"size":2},"extra":[1,2]}`)}
+
+	w := WidgetData{}
+	if err := FromRawExtension(ext, &w); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if w.Spec.Size != 2 || string(*w.Overflow["extra"]) != `[1,2]` {
+		t.Fatalf("Expected typed and unknown fields, got '%+v'", w)
+	}
+
+	out, err := ToRawExtension(&w)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	roundTripped := WidgetData{}
+	if err := FromRawExtension(out, &roundTripped); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(*roundTripped.Overflow["extra"]) != `[1,2]` {
+		t.Fatalf("Expected 'extra' to survive a round trip, got '%s'", out.Raw)
+	}
+}
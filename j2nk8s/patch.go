@@ -0,0 +1,161 @@
+package j2nk8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ygt/j2n"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// Returns a strategic merge patch that turns original into modified, which
+// are j2n structs of the same type, or pointers to them.
+//
+// The patchStrategy and patchMergeKey tags of the named fields are honoured,
+// so a list of containers tagged with patchMergeKey:"name" is patched by
+// name, as the API server would. Overflow entries, which have no tags, are
+// patched with JSON merge patch semantics: objects are merged and every
+// other value, including lists, is replaced. The same applies to unknown
+// keys nested in the objects of named fields.
+func CreateStrategicMergePatch(original, modified interface{}) ([]byte, error) {
+	schema, err := patchSchema(original)
+	if err != nil {
+		return nil, err
+	}
+
+	originalData, err := encode(original)
+	if err != nil {
+		return nil, err
+	}
+	modifiedData, err := encode(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	return strategicpatch.CreateTwoWayMergePatchUsingLookupPatchMeta(originalData, modifiedData, schema)
+}
+
+// Returns a three-way strategic merge patch, as kubectl apply computes it:
+// original is the configuration last applied, modified the new
+// configuration, and current the live object. Fields of current that are
+// in neither configuration, including unknown ones, are left alone; if
+// overwrite is false, the patch fails rather than overwrite changes made to
+// current since original was applied.
+func CreateThreeWayMergePatch(original, modified, current interface{}, overwrite bool) ([]byte, error) {
+	schema, err := patchSchema(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents [3][]byte
+	for i, v := range []interface{}{original, modified, current} {
+		if documents[i], err = encode(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return strategicpatch.CreateThreeWayMergePatch(documents[0], documents[1], documents[2], schema, overwrite)
+}
+
+// Applies the strategic merge patch to the j2n struct pointed to by v,
+// replacing its contents with the result. Keys of the patch that v does not
+// name end up in its Overflow.
+func ApplyStrategicMergePatch(v interface{}, patch []byte) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("Expected pointer to struct, got %T", v)
+	}
+
+	schema, err := patchSchema(v)
+	if err != nil {
+		return err
+	}
+
+	original, err := encode(v)
+	if err != nil {
+		return err
+	}
+
+	patched, err := strategicpatch.StrategicMergePatchUsingLookupPatchMeta(original, patch, schema)
+	if err != nil {
+		return err
+	}
+
+	result := reflect.New(value.Elem().Type())
+	if err := decode(patched, result.Interface()); err != nil {
+		return err
+	}
+	value.Elem().Set(result.Elem())
+	return nil
+}
+
+func patchSchema(v interface{}) (strategicpatch.LookupPatchMeta, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Expected struct, got %T", v)
+	}
+
+	schema, err := strategicpatch.NewPatchMetaFromStruct(reflect.Zero(t).Interface())
+	if err != nil {
+		return nil, err
+	}
+	return overflowPatchMeta{schema}, nil
+}
+
+// overflowPatchMeta looks up the patch metadata of named fields in the
+// struct tags, and treats any key that is not a named field as untyped.
+type overflowPatchMeta struct {
+	strategicpatch.LookupPatchMeta
+}
+
+func (m overflowPatchMeta) LookupPatchMetadataForStruct(key string) (strategicpatch.LookupPatchMeta, strategicpatch.PatchMeta, error) {
+	schema, meta, err := m.LookupPatchMeta.LookupPatchMetadataForStruct(key)
+	if err != nil {
+		return untypedPatchMeta{}, strategicpatch.PatchMeta{}, nil
+	}
+	return overflowPatchMeta{schema}, meta, nil
+}
+
+func (m overflowPatchMeta) LookupPatchMetadataForSlice(key string) (strategicpatch.LookupPatchMeta, strategicpatch.PatchMeta, error) {
+	schema, meta, err := m.LookupPatchMeta.LookupPatchMetadataForSlice(key)
+	if err != nil {
+		return untypedPatchMeta{}, strategicpatch.PatchMeta{}, nil
+	}
+	return overflowPatchMeta{schema}, meta, nil
+}
+
+// untypedPatchMeta has no patch metadata, so values below it are patched
+// with JSON merge patch semantics.
+type untypedPatchMeta struct{}
+
+func (untypedPatchMeta) LookupPatchMetadataForStruct(key string) (strategicpatch.LookupPatchMeta, strategicpatch.PatchMeta, error) {
+	return untypedPatchMeta{}, strategicpatch.PatchMeta{}, nil
+}
+
+func (untypedPatchMeta) LookupPatchMetadataForSlice(key string) (strategicpatch.LookupPatchMeta, strategicpatch.PatchMeta, error) {
+	return untypedPatchMeta{}, strategicpatch.PatchMeta{}, nil
+}
+
+func (untypedPatchMeta) Name() string {
+	return ""
+}
+
+// Returns the JSON encoding of v, with the MarshalJSON method of a wrapper
+// type or with j2n for a data struct.
+func encode(v interface{}) ([]byte, error) {
+	if _, ok := v.(json.Marshaler); ok {
+		return json.Marshal(v)
+	}
+	return j2n.MarshalJSON(v)
+}
+
+func decode(data []byte, v interface{}) error {
+	if _, ok := v.(json.Unmarshaler); ok {
+		return json.Unmarshal(data, v)
+	}
+	return j2n.UnmarshalJSON(data, v)
+}
@@ -0,0 +1,69 @@
+package j2nk8s
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type PodContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type PodSpecData struct {
+	Containers []PodContainer              `json:"containers" patchStrategy:"merge" patchMergeKey:"name"`
+	Overflow   map[string]*json.RawMessage `json:"-"`
+}
+
+func parsePodSpec(t *testing.T, data string) *PodSpecData {
+	spec := &PodSpecData{}
+	if err := FromRawExtension(runtime.RawExtension{Raw: []byte(data)}, spec); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return spec
+}
+
+func TestStrategicMergePatchRoundTrip(t *testing.T) {
+	original := parsePodSpec(t, `{"containers":[{"name":"app","image":"app:1"}],"tolerations":[{"key":"a"}],"labels":{"tier":"web"}}`)
+	modified := parsePodSpec(t, `{"containers":[{"name":"app","image":"app:2"},{"name":"proxy","image":"envoy"}],"tolerations":[{"key":"b"}],"labels":{"tier":"web","team":"core"}}`)
+
+	patch, err := CreateStrategicMergePatch(original, modified)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"$setElementOrder/containers":[{"name":"app"},{"name":"proxy"}],"containers":[{"image":"app:2","name":"app"},{"image":"envoy","name":"proxy"}],"labels":{"team":"core"},"tolerations":[{"key":"b"}]}`
+	if string(patch) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, patch)
+	}
+
+	if err := ApplyStrategicMergePatch(original, patch); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(original.Containers) != 2 || original.Containers[0].Image != "app:2" ||
+		string(*original.Overflow["labels"]) != `{"team":"core","tier":"web"}` {
+		t.Fatalf("Expected patched spec, got '%+v' with overflow '%v'", original, original.Overflow)
+	}
+}
+
+func TestThreeWayMergePatchLeavesLiveFieldsAlone(t *testing.T) {
+	original := parsePodSpec(t, `{"containers":[{"name":"app","image":"app:1"}]}`)
+	modified := parsePodSpec(t, `{"containers":[{"name":"app","image":"app:2"}]}`)
+	current := parsePodSpec(t, `{"containers":[{"name":"app","image":"app:1"},{"name":"sidecar","image":"mesh"}],"nodeName":"n1"}`)
+
+	patch, err := CreateThreeWayMergePatch(original, modified, current, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if err := ApplyStrategicMergePatch(current, patch); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(current.Containers) != 2 || current.Containers[0].Image != "app:2" || string(*current.Overflow["nodeName"]) != `"n1"` {
+		t.Fatalf("Expected sidecar and nodeName to survive, got '%+v' with overflow '%v'", current, current.Overflow)
+	}
+}
@@ -0,0 +1,195 @@
+// Package j2nlsp provides Language Server Protocol message types built on
+// j2n, so that middleware between an editor and a language server, such as
+// a proxy or a logger, passes on the fields of newer protocol versions
+// instead of stripping them.
+//
+// Messages are framed with a Content-Length header, which ReadMessage and
+// WriteMessage handle, and their bodies are JSON-RPC 2.0 envelopes, for
+// which the j2njsonrpc types are used:
+//
+//	body, err := j2nlsp.ReadMessage(reader)
+//	requests, _, err := j2njsonrpc.DecodeRequests(body)
+//	if requests[0].Method == "textDocument/hover" {
+//		var params j2nlsp.TextDocumentPositionParams
+//		err = json.Unmarshal(requests[0].Params, &params)
+//	}
+//
+// The params and results with fields that the protocol commonly extends
+// keep unknown fields in Overflow. Client and server capabilities, which
+// grow with every version, are kept whole as a j2n.Overflow.
+package j2nlsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/ygt/j2n"
+	"github.com/ygt/j2n/internal/contentlength"
+)
+
+// Returns the body of the next message from r. Messages with a
+// Content-Length over 64 MiB are rejected.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	return contentlength.Read(r)
+}
+
+// Writes body to w as a message with a Content-Length header.
+func WriteMessage(w io.Writer, body []byte) error {
+	return contentlength.Write(w, body)
+}
+
+// Position is a zero-based line and character offset in a text document.
+type Position struct {
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+}
+
+// Range is a range in a text document, with an exclusive end.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in the document with the given URI.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// TextDocumentIdentifier identifies a text document by its URI.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// ClientInfo describes the client, or the server in ServerInfo.
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// ServerInfo describes the server.
+type ServerInfo = ClientInfo
+
+// InitializeParamsData holds the params of the initialize request.
+// ProcessID and RootURI are null when unset, as the protocol requires.
+type InitializeParamsData struct {
+	ProcessID             *int            `json:"processId"`
+	ClientInfo            *ClientInfo     `json:"clientInfo,omitempty"`
+	Locale                string          `json:"locale,omitempty"`
+	RootURI               *string         `json:"rootUri"`
+	InitializationOptions json.RawMessage `json:"initializationOptions,omitempty"`
+	Capabilities          j2n.Overflow    `json:"capabilities"`
+	Trace                 string          `json:"trace,omitempty"`
+	WorkspaceFolders      json.RawMessage `json:"workspaceFolders,omitempty"`
+	Overflow              j2n.Overflow    `json:"-"`
+}
+
+// InitializeParams are the params of the initialize request.
+type InitializeParams struct {
+	InitializeParamsData
+}
+
+func (p *InitializeParams) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &p.InitializeParamsData)
+}
+
+func (p InitializeParams) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(p.InitializeParamsData)
+}
+
+// InitializeResultData holds the result of the initialize request.
+type InitializeResultData struct {
+	Capabilities j2n.Overflow `json:"capabilities"`
+	ServerInfo   *ServerInfo  `json:"serverInfo,omitempty"`
+	Overflow     j2n.Overflow `json:"-"`
+}
+
+// InitializeResult is the result of the initialize request.
+type InitializeResult struct {
+	InitializeResultData
+}
+
+func (r *InitializeResult) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &r.InitializeResultData)
+}
+
+func (r InitializeResult) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(r.InitializeResultData)
+}
+
+// TextDocumentPositionParamsData holds the params of requests about a
+// position in a document, such as textDocument/hover and
+// textDocument/definition. Request-specific fields, such as workDoneToken,
+// are kept in Overflow.
+type TextDocumentPositionParamsData struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	Overflow     j2n.Overflow           `json:"-"`
+}
+
+// TextDocumentPositionParams are the params of requests about a position in
+// a document.
+type TextDocumentPositionParams struct {
+	TextDocumentPositionParamsData
+}
+
+func (p *TextDocumentPositionParams) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &p.TextDocumentPositionParamsData)
+}
+
+func (p TextDocumentPositionParams) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(p.TextDocumentPositionParamsData)
+}
+
+// DiagnosticData holds the fields of a diagnostic. Code is an integer or a
+// string, and is kept as raw JSON.
+type DiagnosticData struct {
+	Range              Range           `json:"range"`
+	Severity           int             `json:"severity,omitempty"`
+	Code               json.RawMessage `json:"code,omitempty"`
+	CodeDescription    j2n.Overflow    `json:"codeDescription,omitempty"`
+	Source             string          `json:"source,omitempty"`
+	Message            string          `json:"message"`
+	Tags               []int           `json:"tags,omitempty"`
+	RelatedInformation json.RawMessage `json:"relatedInformation,omitempty"`
+	Data               json.RawMessage `json:"data,omitempty"`
+	Overflow           j2n.Overflow    `json:"-"`
+}
+
+// Diagnostic is a compiler error, warning or other message about a range of
+// a document.
+type Diagnostic struct {
+	DiagnosticData
+}
+
+func (d *Diagnostic) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &d.DiagnosticData)
+}
+
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(d.DiagnosticData)
+}
+
+// PublishDiagnosticsParamsData holds the params of the
+// textDocument/publishDiagnostics notification.
+type PublishDiagnosticsParamsData struct {
+	URI         string       `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Overflow    j2n.Overflow `json:"-"`
+}
+
+// PublishDiagnosticsParams are the params of the
+// textDocument/publishDiagnostics notification.
+type PublishDiagnosticsParams struct {
+	PublishDiagnosticsParamsData
+}
+
+func (p *PublishDiagnosticsParams) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &p.PublishDiagnosticsParamsData)
+}
+
+func (p PublishDiagnosticsParams) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(p.PublishDiagnosticsParamsData)
+}
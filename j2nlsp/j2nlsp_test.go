@@ -0,0 +1,118 @@
+package j2nlsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ygt/j2n/j2njsonrpc"
+)
+
+func TestReadAndWriteMessages(t *testing.T) {
+	var buffer bytes.Buffer
+	for _, body := range []string{`{"a":1}`, `{"b":"é"}`} {
+		if err := WriteMessage(&buffer, []byte(body)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	expected := "Content-Length: 7\r\n\r\n{\"a\":1}Content-Length: 10\r\n\r\n{\"b\":\"é\"}"
+	if buffer.String() != expected {
+		t.Fatalf("Expected '%q', got '%q'", expected, buffer.String())
+	}
+
+	r := bufio.NewReader(&buffer)
+	for _, body := range []string{`{"a":1}`, `{"b":"é"}`} {
+		message, err := ReadMessage(r)
+		if err != nil || string(message) != body {
+			t.Fatalf("Expected '%s', got '%s' (%v)", body, message, err)
+		}
+	}
+	if _, err := ReadMessage(r); err != io.EOF {
+		t.Fatalf("Expected EOF, got '%v'", err)
+	}
+
+	_, err := ReadMessage(bufio.NewReader(strings.NewReader("Content-Type: x\r\n\r\n{}")))
+	if err == nil || err.Error() != "Missing Content-Length header" {
+		t.Fatalf("Expected a missing header error, got '%v'", err)
+	}
+
+	_, err = ReadMessage(bufio.NewReader(strings.NewReader("Content-Length: 1000000000000\r\n\r\n{}")))
+	expected = "Content-Length 1000000000000 exceeds the maximum of 67108864"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	_, err = ReadMessage(bufio.NewReader(strings.NewReader("Content-Length: 1000\r\n\r\n{}")))
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("Expected '%s', got '%v'", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestParamsKeepNewerFields(t *testing.T) {
+	message := `{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"position":{"character":4,"line":2},"textDocument":{"uri":"file:///a.go"},"workDoneToken":"w1"}}`
+	requests, _, err := j2njsonrpc.DecodeRequests([]byte(message))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	params := TextDocumentPositionParams{}
+	if err := json.Unmarshal(requests[0].Params, &params); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if params.Position.Line != 2 || params.Overflow["workDoneToken"] == nil {
+		t.Fatalf("Expected line 2 with 'workDoneToken' in Overflow, got '%+v'", params)
+	}
+
+	out, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"position":{"line":2,"character":4},"textDocument":{"uri":"file:///a.go"},"workDoneToken":"w1"}`
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
+
+func TestInitializeParams(t *testing.T) {
+	data := `{"capabilities":{"textDocument":{"inlineCompletion":{"dynamicRegistration":true}}},"processId":null,"rootUri":null,"x-editor":"vim"}`
+	params := InitializeParams{}
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if params.Capabilities["textDocument"] == nil || params.ProcessID != nil {
+		t.Fatalf("Expected the capabilities to be kept, got '%+v'", params)
+	}
+
+	out, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"capabilities":{"textDocument":{"inlineCompletion":{"dynamicRegistration":true}}},"processId":null,"rootUri":null,"x-editor":"vim"}`
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	data := `{"diagnostics":[{"code":"E1","message":"unused","range":{"end":{"character":3,"line":1},"start":{"character":0,"line":1}},"severity":2,"x-fix":{"title":"remove"}}],"uri":"file:///a.go"}`
+	params := PublishDiagnosticsParams{}
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if params.Diagnostics[0].Overflow["x-fix"] == nil {
+		t.Fatalf("Expected 'x-fix' in Overflow, got '%+v'", params.Diagnostics[0])
+	}
+
+	out, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"diagnostics":[{"code":"E1","message":"unused","range":{"start":{"line":1,"character":0},"end":{"line":1,"character":3}},"severity":2,"x-fix":{"title":"remove"}}],"uri":"file:///a.go"}`
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
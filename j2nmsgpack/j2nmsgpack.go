@@ -0,0 +1,119 @@
+// Package j2nmsgpack applies the j2n Overflow contract to MessagePack. Any map
+// keys that are not decoded directly into the fields of the struct are put
+// into its 'Overflow' field as raw MessagePack, so unrecognised keys,
+// including extension types, survive an Unmarshal/Marshal round trip.
+//
+// The struct must contain a field 'Overflow' of type
+//
+//	map[string]msgpack.RawMessage
+//
+// omitted from the encoding with `msgpack:"-"`:
+//
+//	type SessionData struct {
+//		UserID   string                        `msgpack:"user_id"`
+//		Overflow map[string]msgpack.RawMessage `msgpack:"-"`
+//	}
+//
+//	type Session struct {
+//		SessionData
+//	}
+//
+//	func (s *Session) UnmarshalMsgpack(data []byte) error {
+//		return j2nmsgpack.UnmarshalMsgpack(data, &s.SessionData)
+//	}
+//
+//	func (s Session) MarshalMsgpack() ([]byte, error) {
+//		return j2nmsgpack.MarshalMsgpack(s.SessionData)
+//	}
+//
+// Only maps with string keys are supported.
+package j2nmsgpack
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/ygt/j2n/internal/overflowfield"
+)
+
+var overflowType = reflect.TypeOf(map[string]msgpack.RawMessage(nil))
+
+// Parses the MessagePack-encoded data into the struct pointed to by v.
+//
+// This behaves exactly like msgpack.Unmarshal, but any extra map keys that
+// are not decoded into named fields are kept in the 'Overflow' field.
+func UnmarshalMsgpack(data []byte, v interface{}) error {
+	field, err := overflowfield.Lookup(v, overflowType, "msgpack")
+	if err != nil {
+		return err
+	}
+
+	overflow := make(map[string]msgpack.RawMessage)
+	if err := msgpack.Unmarshal(data, &overflow); err != nil {
+		return err
+	}
+
+	if err := msgpack.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	namedFields, err := namedFieldsMap(v)
+	if err != nil {
+		return err
+	}
+
+	for k := range namedFields {
+		delete(overflow, k)
+	}
+
+	field.Set(reflect.ValueOf(overflow))
+	return nil
+}
+
+// Returns the MessagePack encoding of v, which must be a struct.
+//
+// This behaves like msgpack.Marshal, but also emits the keys in v.Overflow.
+// Map keys are sorted so that the output is deterministic.
+func MarshalMsgpack(v interface{}) ([]byte, error) {
+	field, err := overflowfield.Lookup(v, overflowType, "msgpack")
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := namedFieldsMap(v)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, raw := range field.Interface().(map[string]msgpack.RawMessage) {
+		if _, ok := result[k]; ok {
+			return nil, fmt.Errorf("Named field present in overflow: '%s'", k)
+		}
+		result[k] = raw
+	}
+
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetSortMapKeys(true)
+	if err := encoder.Encode(result); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func namedFieldsMap(v interface{}) (map[string]msgpack.RawMessage, error) {
+	namedFieldsMsgpack, err := msgpack.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	namedFields := make(map[string]msgpack.RawMessage)
+	if err := msgpack.Unmarshal(namedFieldsMsgpack, &namedFields); err != nil {
+		return nil, err
+	}
+
+	return namedFields, nil
+}
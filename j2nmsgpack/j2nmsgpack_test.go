@@ -0,0 +1,82 @@
+package j2nmsgpack
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type SessionData struct {
+	UserID   string                        `msgpack:"user_id"`
+	Overflow map[string]msgpack.RawMessage `msgpack:"-"`
+}
+
+type Session struct {
+	SessionData
+}
+
+func (s *Session) UnmarshalMsgpack(data []byte) error {
+	return UnmarshalMsgpack(data, &s.SessionData)
+}
+
+func (s Session) MarshalMsgpack() ([]byte, error) {
+	return MarshalMsgpack(s.SessionData)
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := msgpack.Marshal(v)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	return data
+}
+
+func TestUnknownKeysSurviveRoundTrip(t *testing.T) {
+	input := mustMarshal(t, map[string]interface{}{
+		"user_id": "u1",
+		"roles":   []string{"admin"},
+		"ttl":     int64(3600),
+	})
+
+	s := Session{}
+	if err := msgpack.Unmarshal(input, &s); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if s.UserID != "u1" {
+		t.Fatalf("Expected 'u1', got '%s'", s.UserID)
+	}
+
+	if _, ok := s.Overflow["user_id"]; ok {
+		t.Fatal("Expected 'user_id' to be absent from Overflow")
+	}
+
+	output, err := msgpack.Marshal(s)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	roundTripped := Session{}
+	if err := msgpack.Unmarshal(output, &roundTripped); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expectedRoles := mustMarshal(t, []string{"admin"})
+	if !bytes.Equal(roundTripped.Overflow["roles"], expectedRoles) {
+		t.Fatalf("Expected '%x', got '%x'", expectedRoles, roundTripped.Overflow["roles"])
+	}
+
+	if roundTripped.UserID != "u1" || len(roundTripped.Overflow) != 2 {
+		t.Fatalf("Expected all keys to survive, got '%+v'", roundTripped.SessionData)
+	}
+}
+
+func TestErrorOnAliasedFields(t *testing.T) {
+	s := Session{}
+	s.Overflow = map[string]msgpack.RawMessage{"user_id": mustMarshal(t, "u2")}
+
+	if _, err := msgpack.Marshal(s); err == nil {
+		t.Fatal("Expected error on aliased fields, got none")
+	}
+}
@@ -0,0 +1,101 @@
+// Package j2notel records the unknown keys seen by j2n as OpenTelemetry
+// metrics, so that schema drift in production can be graphed and alerted
+// on.
+//
+//	hook, err := j2notel.NewHook(otel.Meter("myapp"))
+//	j2n.Configure(CatData{}, j2n.WithMetricsHook(hook))
+//
+// Two instruments are recorded, with the attributes j2n.type and j2n.key:
+// the counter j2n.overflow.keys counts the documents in which each key
+// overflowed, and the histogram j2n.overflow.size the sizes in bytes of
+// their raw values.
+package j2notel
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ygt/j2n"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// The j2n.key attribute used for keys beyond the limit set by WithMaxKeys.
+const OtherKey = "_other"
+
+// Hook is a j2n.MetricsHook that records to OpenTelemetry instruments.
+type Hook struct {
+	keys metric.Int64Counter
+	size metric.Int64Histogram
+
+	maxKeys int
+	mutex   sync.Mutex
+	seen    map[string]map[string]bool
+}
+
+var _ j2n.MetricsHook = &Hook{}
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// Returns an Option that limits the number of distinct j2n.key attributes
+// recorded for each type to n, so that clients sending arbitrary keys cannot
+// create unbounded metric streams. Further keys are recorded as OtherKey.
+// The default is 100.
+func WithMaxKeys(n int) Option {
+	return func(h *Hook) {
+		h.maxKeys = n
+	}
+}
+
+// Returns a Hook whose instruments are created with meter.
+func NewHook(meter metric.Meter, opts ...Option) (*Hook, error) {
+	keys, err := meter.Int64Counter("j2n.overflow.keys",
+		metric.WithDescription("Number of documents in which a key was not a named field of the struct."),
+		metric.WithUnit("{key}"))
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := meter.Int64Histogram("j2n.overflow.size",
+		metric.WithDescription("Size of the raw JSON values of keys that were not named fields of the struct."),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hook{keys: keys, size: size, maxKeys: 100, seen: make(map[string]map[string]bool)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
+}
+
+func (h *Hook) ObserveOverflow(typeName, key string, size int) {
+	attributes := metric.WithAttributes(
+		attribute.String("j2n.type", typeName),
+		attribute.String("j2n.key", h.label(typeName, key)),
+	)
+	h.keys.Add(context.Background(), 1, attributes)
+	h.size.Record(context.Background(), int64(size), attributes)
+}
+
+// Returns the attribute value for key, which is OtherKey once maxKeys other
+// keys have been seen for the type.
+func (h *Hook) label(typeName, key string) string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	seen := h.seen[typeName]
+	if seen == nil {
+		seen = make(map[string]bool)
+		h.seen[typeName] = seen
+	}
+	if !seen[key] {
+		if len(seen) >= h.maxKeys {
+			return OtherKey
+		}
+		seen[key] = true
+	}
+	return key
+}
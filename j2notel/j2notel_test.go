@@ -0,0 +1,71 @@
+package j2notel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ygt/j2n"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type CatData struct {
+	Name     string       `json:"name"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestHookRecordsOverflowKeys(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	hook, err := NewHook(provider.Meter("test"), WithMaxKeys(1))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	for _, document := range []string{`{"name":"Tom","age":3}`, `{"name":"Tom","age":12,"colour":"grey"}`} {
+		c := CatData{}
+		if err := j2n.UnmarshalJSON([]byte(document), &c, j2n.WithMetricsHook(hook)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	metrics := collect(t, reader)
+
+	counts := make(map[string]int64)
+	for _, dp := range metrics["j2n.overflow.keys"].(metricdata.Sum[int64]).DataPoints {
+		typeName, _ := dp.Attributes.Value(attribute.Key("j2n.type"))
+		if typeName.AsString() != "j2notel.CatData" {
+			t.Fatalf("Expected 'j2notel.CatData', got '%s'", typeName.AsString())
+		}
+		key, _ := dp.Attributes.Value(attribute.Key("j2n.key"))
+		counts[key.AsString()] = dp.Value
+	}
+	if len(counts) != 2 || counts["age"] != 2 || counts[OtherKey] != 1 {
+		t.Fatalf("Expected age 2 and %s 1, got %v", OtherKey, counts)
+	}
+
+	var total int64
+	for _, dp := range metrics["j2n.overflow.size"].(metricdata.Histogram[int64]).DataPoints {
+		total += dp.Sum
+	}
+	if total != 9 {
+		t.Fatalf("Expected 9 bytes, got %d", total)
+	}
+}
@@ -0,0 +1,112 @@
+// Package j2nprometheus exports the unknown keys seen by j2n as Prometheus
+// metrics, so that schema drift in production can be graphed and alerted
+// on.
+//
+//	hook := j2nprometheus.NewHook("myapp")
+//	prometheus.MustRegister(hook)
+//	j2n.Configure(CatData{}, j2n.WithMetricsHook(hook))
+//
+// Two counters are exported, labelled with the struct type and the key:
+// <namespace>_j2n_overflow_keys_total counts the documents in which each key
+// overflowed, and <namespace>_j2n_overflow_bytes_total the bytes of their
+// raw values.
+package j2nprometheus
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ygt/j2n"
+)
+
+// The key label used for keys beyond the limit set by WithMaxKeys.
+const OtherKey = "_other"
+
+// Hook is a j2n.MetricsHook and a prometheus.Collector.
+type Hook struct {
+	keys  *prometheus.CounterVec
+	bytes *prometheus.CounterVec
+
+	maxKeys int
+	mutex   sync.Mutex
+	seen    map[string]map[string]bool
+}
+
+var (
+	_ j2n.MetricsHook      = &Hook{}
+	_ prometheus.Collector = &Hook{}
+)
+
+// Option configures a Hook.
+type Option func(*Hook)
+
+// Returns an Option that limits the number of distinct keys labelled for
+// each type to n, so that clients sending arbitrary keys cannot create
+// unbounded metric series. Further keys are counted under OtherKey. The
+// default is 100.
+func WithMaxKeys(n int) Option {
+	return func(h *Hook) {
+		h.maxKeys = n
+	}
+}
+
+// Returns a Hook whose metric names are prefixed with namespace, which may
+// be "".
+func NewHook(namespace string, opts ...Option) *Hook {
+	h := &Hook{
+		keys: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "j2n",
+			Name:      "overflow_keys_total",
+			Help:      "Number of documents in which a key was not a named field of the struct.",
+		}, []string{"type", "key"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "j2n",
+			Name:      "overflow_bytes_total",
+			Help:      "Size of the raw JSON values of keys that were not named fields of the struct.",
+		}, []string{"type", "key"}),
+		maxKeys: 100,
+		seen:    make(map[string]map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Hook) ObserveOverflow(typeName, key string, size int) {
+	key = h.label(typeName, key)
+	h.keys.WithLabelValues(typeName, key).Inc()
+	h.bytes.WithLabelValues(typeName, key).Add(float64(size))
+}
+
+func (h *Hook) Describe(ch chan<- *prometheus.Desc) {
+	h.keys.Describe(ch)
+	h.bytes.Describe(ch)
+}
+
+func (h *Hook) Collect(ch chan<- prometheus.Metric) {
+	h.keys.Collect(ch)
+	h.bytes.Collect(ch)
+}
+
+// Returns the label for key, which is OtherKey once maxKeys other keys have
+// been seen for the type.
+func (h *Hook) label(typeName, key string) string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	seen := h.seen[typeName]
+	if seen == nil {
+		seen = make(map[string]bool)
+		h.seen[typeName] = seen
+	}
+	if !seen[key] {
+		if len(seen) >= h.maxKeys {
+			return OtherKey
+		}
+		seen[key] = true
+	}
+	return key
+}
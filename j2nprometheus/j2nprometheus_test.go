@@ -0,0 +1,59 @@
+package j2nprometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/ygt/j2n"
+)
+
+type CatData struct {
+	Name     string       `json:"name"`
+	Overflow j2n.Overflow `json:"-"`
+}
+
+func TestHookCountsOverflowKeys(t *testing.T) {
+	hook := NewHook("test")
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(hook)
+
+	for _, document := range []string{`{"name":"Tom","age":3}`, `{"name":"Tom","age":12,"colour":"grey"}`} {
+		c := CatData{}
+		if err := j2n.UnmarshalJSON([]byte(document), &c, j2n.WithMetricsHook(hook)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	expected := `
+# HELP test_j2n_overflow_keys_total Number of documents in which a key was not a named field of the struct.
+# TYPE test_j2n_overflow_keys_total counter
+test_j2n_overflow_keys_total{key="age",type="j2nprometheus.CatData"} 2
+test_j2n_overflow_keys_total{key="colour",type="j2nprometheus.CatData"} 1
+# HELP test_j2n_overflow_bytes_total Size of the raw JSON values of keys that were not named fields of the struct.
+# TYPE test_j2n_overflow_bytes_total counter
+test_j2n_overflow_bytes_total{key="age",type="j2nprometheus.CatData"} 3
+test_j2n_overflow_bytes_total{key="colour",type="j2nprometheus.CatData"} 6
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestHookLimitsKeys(t *testing.T) {
+	hook := NewHook("", WithMaxKeys(1))
+	for _, key := range []string{"a", "b", "c", "a"} {
+		hook.ObserveOverflow("Cat", key, 1)
+	}
+
+	if n := testutil.ToFloat64(hook.keys.WithLabelValues("Cat", "a")); n != 2 {
+		t.Fatalf("Expected 2, got %v", n)
+	}
+	if n := testutil.ToFloat64(hook.keys.WithLabelValues("Cat", OtherKey)); n != 2 {
+		t.Fatalf("Expected 2, got %v", n)
+	}
+	if n := testutil.CollectAndCount(hook.keys); n != 2 {
+		t.Fatalf("Expected 2 series, got %d", n)
+	}
+}
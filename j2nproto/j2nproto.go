@@ -0,0 +1,44 @@
+// Package j2nproto converts between j2n structs and protocol buffers, so that
+// the unknown fields kept in a struct's 'Overflow' field are carried across
+// the proto/JSON boundary instead of being dropped.
+//
+// Codec does the same for REST gateways that parse JSON requests straight
+// into messages, returning the fields that a message does not define as an
+// Overflow to be echoed back in the response.
+package j2nproto
+
+import (
+	"github.com/ygt/j2n"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Returns v, which must be a struct, as a protobuf Struct.
+//
+// Named fields and the entries of v.Overflow are merged, as they are by
+// j2n.MarshalJSON. Protobuf Structs store all numbers as doubles, so integers
+// beyond 2^53 lose precision.
+func ToStructPB(v interface{}) (*structpb.Struct, error) {
+	data, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Parses the protobuf Struct s into the struct pointed to by v, putting any
+// fields that are not named in v into v.Overflow.
+func FromStructPB(s *structpb.Struct, v interface{}) error {
+	data, err := protojson.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return j2n.UnmarshalJSON(data, v)
+}
@@ -0,0 +1,51 @@
+package j2nproto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type PayloadData struct {
+	Kind     string                      `json:"kind"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestStructPBRoundTrip(t *testing.T) {
+	s, err := structpb.NewStruct(map[string]interface{}{
+		"kind":  "order",
+		"count": 3,
+		"tags":  []interface{}{"a"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := PayloadData{}
+	if err := FromStructPB(s, &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Kind != "order" {
+		t.Fatalf("Expected 'order', got '%s'", p.Kind)
+	}
+
+	if string(*p.Overflow["count"]) != "3" || string(*p.Overflow["tags"]) != `["a"]` {
+		t.Fatalf("Expected 'count' and 'tags' in overflow, got '%v'", p.Overflow)
+	}
+
+	out, err := ToStructPB(&p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	fields := out.GetFields()
+	if fields["kind"].GetStringValue() != "order" || fields["count"].GetNumberValue() != 3 {
+		t.Fatalf("Expected named and overflow fields in Struct, got '%v'", out)
+	}
+
+	if fields["tags"].GetListValue().GetValues()[0].GetStringValue() != "a" {
+		t.Fatalf("Expected 'tags' list in Struct, got '%v'", out)
+	}
+}
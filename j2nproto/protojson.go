@@ -0,0 +1,149 @@
+package j2nproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ygt/j2n"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Codec converts between JSON documents and protobuf messages with
+// protojson, as a REST gateway in front of a gRPC service does, keeping the
+// top-level fields that a message does not define rather than rejecting or
+// dropping them. This lets a REST handler accept extra client fields, pass
+// the typed message to the backend, and echo the extra fields back in its
+// response:
+//
+//	req := &pb.CreateOrderRequest{}
+//	overflow, err := j2nproto.UnmarshalMessage(body, req)
+//	...
+//	resp, err := client.CreateOrder(ctx, req)
+//	...
+//	out, err := j2nproto.MarshalMessage(resp, overflow)
+//
+// The zero Codec uses the default protojson options.
+type Codec struct {
+	MarshalOptions   protojson.MarshalOptions
+	UnmarshalOptions protojson.UnmarshalOptions
+}
+
+// Parses data into m with the default Codec.
+func UnmarshalMessage(data []byte, m proto.Message) (j2n.Overflow, error) {
+	return Codec{}.UnmarshalMessage(data, m)
+}
+
+// Returns the encoding of m and overflow with the default Codec.
+func MarshalMessage(m proto.Message, overflow j2n.Overflow) ([]byte, error) {
+	return Codec{}.MarshalMessage(m, overflow)
+}
+
+// Parses the JSON object data into m with protojson, and returns the
+// top-level fields that m does not define, by either their JSON or proto
+// name, as an Overflow. Fields of nested messages are parsed as protojson
+// would, so unknown fields there are still errors unless the options
+// discard them.
+//
+// Well-known types such as google.protobuf.Struct, which have their own
+// JSON mapping, are parsed as they are and have no overflow.
+func (c Codec) UnmarshalMessage(data []byte, m proto.Message) (j2n.Overflow, error) {
+	descriptor := m.ProtoReflect().Descriptor()
+	if isWellKnown(descriptor) {
+		return nil, c.UnmarshalOptions.Unmarshal(data, m)
+	}
+
+	var document map[string]*json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]*json.RawMessage, len(document))
+	overflow := make(j2n.Overflow)
+	for k, raw := range document {
+		if definesField(descriptor, k) {
+			known[k] = raw
+		} else {
+			overflow[k] = raw
+		}
+	}
+
+	knownData, err := json.Marshal(known)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.UnmarshalOptions.Unmarshal(knownData, m); err != nil {
+		return nil, err
+	}
+	return overflow, nil
+}
+
+// Returns the protojson encoding of m with the entries of overflow added,
+// as j2n.MarshalJSON adds the Overflow of a struct. It fails if a key of
+// overflow names a field of m.
+func (c Codec) MarshalMessage(m proto.Message, overflow j2n.Overflow) ([]byte, error) {
+	data, err := c.MarshalOptions.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor := m.ProtoReflect().Descriptor()
+	if len(overflow) == 0 || isWellKnown(descriptor) {
+		return data, nil
+	}
+
+	var document map[string]*json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	for k, raw := range overflow {
+		if _, ok := document[k]; ok || definesField(descriptor, k) {
+			return nil, fmt.Errorf("Named field present in overflow: '%s'", k)
+		}
+		document[k] = raw
+	}
+
+	return json.Marshal(document)
+}
+
+// Reports whether key names a field of the message, as protojson accepts
+// it: by JSON name, by proto name, or as an extension in brackets.
+func definesField(descriptor protoreflect.MessageDescriptor, key string) bool {
+	if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
+		return true
+	}
+
+	fields := descriptor.Fields()
+	if fields.ByJSONName(key) != nil || fields.ByTextName(key) != nil {
+		return true
+	}
+	return fields.ByName(protoreflect.Name(key)) != nil
+}
+
+// The well-known types with a special JSON mapping.
+var wellKnownTypes = map[protoreflect.FullName]bool{
+	"google.protobuf.Any":         true,
+	"google.protobuf.Timestamp":   true,
+	"google.protobuf.Duration":    true,
+	"google.protobuf.Struct":      true,
+	"google.protobuf.Value":       true,
+	"google.protobuf.ListValue":   true,
+	"google.protobuf.FieldMask":   true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.BytesValue":  true,
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.UInt64Value": true,
+}
+
+func isWellKnown(descriptor protoreflect.MessageDescriptor) bool {
+	return wellKnownTypes[descriptor.FullName()]
+}
@@ -0,0 +1,74 @@
+package j2nproto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ygt/j2n"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestMessageKeepsExtraClientFields(t *testing.T) {
+	m := &descriptorpb.FileDescriptorProto{}
+	overflow, err := UnmarshalMessage([]byte(`{"name":"a.proto","package":"pkg","requestId":"r-1","x-trace":[1,2]}`), m)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if m.GetName() != "a.proto" || m.GetPackage() != "pkg" {
+		t.Fatalf("Expected message fields to be parsed, got '%v'", m)
+	}
+
+	if len(overflow) != 2 || string(*overflow["requestId"]) != `"r-1"` || string(*overflow["x-trace"]) != `[1,2]` {
+		t.Fatalf("Expected extra fields in overflow, got '%v'", overflow)
+	}
+
+	m.Syntax = new(string)
+	*m.Syntax = "proto3"
+	out, err := MarshalMessage(m, overflow)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"name":"a.proto","package":"pkg","requestId":"r-1","syntax":"proto3","x-trace":[1,2]}`
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
+
+func TestMessageAcceptsProtoNames(t *testing.T) {
+	codec := Codec{MarshalOptions: protojson.MarshalOptions{UseProtoNames: true}}
+
+	m := &descriptorpb.FileDescriptorProto{}
+	overflow, err := codec.UnmarshalMessage([]byte(`{"public_dependency":[1],"publicDependency":[2],"extra":true}`), m)
+	if err == nil {
+		t.Fatalf("Expected protojson to reject a field given twice, got '%v'", overflow)
+	}
+
+	overflow, err = codec.UnmarshalMessage([]byte(`{"public_dependency":[1],"extra":true}`), m)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(m.PublicDependency) != 1 || len(overflow) != 1 {
+		t.Fatalf("Expected proto name parsed and 'extra' in overflow, got '%v' and '%v'", m, overflow)
+	}
+
+	raw := json.RawMessage(`1`)
+	if _, err := codec.MarshalMessage(m, j2n.Overflow{"public_dependency": &raw}); err == nil {
+		t.Fatal("Expected error with a message field in overflow")
+	}
+}
+
+func TestMessageWellKnownTypesHaveNoOverflow(t *testing.T) {
+	s := &structpb.Struct{}
+	overflow, err := UnmarshalMessage([]byte(`{"anything":1}`), s)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(overflow) != 0 || s.Fields["anything"].GetNumberValue() != 1 {
+		t.Fatalf("Expected Struct to keep every field, got '%v' and '%v'", s, overflow)
+	}
+}
@@ -0,0 +1,95 @@
+package j2nproto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ygt/j2n"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The overflow key under which FromMessage stores the unknown fields of a
+// message, as a base64 string of their wire encoding, and from which
+// ToMessage restores them.
+const UnknownFieldsKey = "@protoUnknownFields"
+
+// Parses the protobuf message m into the struct pointed to by v.
+//
+// The message is rendered with protojson, so fields that are not named in v
+// end up in v.Overflow. Unknown fields of m, which protojson would drop, are
+// also stored in v.Overflow under UnknownFieldsKey.
+//
+// Only the unknown fields of m itself are kept. Those of the messages nested
+// in it are dropped by protojson, so a message whose nested messages may
+// have unknown fields does not survive FromMessage and ToMessage intact.
+func FromMessage(m proto.Message, v interface{}) error {
+	data, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	unknown := m.ProtoReflect().GetUnknown()
+	if len(unknown) > 0 {
+		document := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(data, &document); err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(unknown))
+		if err != nil {
+			return err
+		}
+		document[UnknownFieldsKey] = encoded
+
+		if data, err = json.Marshal(document); err != nil {
+			return err
+		}
+	}
+
+	return j2n.UnmarshalJSON(data, v)
+}
+
+// Sets the fields of the protobuf message m from v, which must be a struct.
+//
+// The output of j2n.MarshalJSON is parsed with protojson, so both named fields
+// and overflow fields must correspond to fields of m. The entry under
+// UnknownFieldsKey, if any, is restored as the unknown fields of m.
+func ToMessage(v interface{}, m proto.Message) error {
+	data, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return err
+	}
+
+	document := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	var unknown []byte
+	if encoded, ok := document[UnknownFieldsKey]; ok {
+		var s string
+		if err := json.Unmarshal(encoded, &s); err != nil {
+			return fmt.Errorf("Invalid value for '%s': %s", UnknownFieldsKey, err)
+		}
+		if unknown, err = base64.StdEncoding.DecodeString(s); err != nil {
+			return fmt.Errorf("Invalid value for '%s': %s", UnknownFieldsKey, err)
+		}
+
+		delete(document, UnknownFieldsKey)
+		if data, err = json.Marshal(document); err != nil {
+			return err
+		}
+	}
+
+	if err := protojson.Unmarshal(data, m); err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		m.ProtoReflect().SetUnknown(unknown)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package j2nproto
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type FileData struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestUnknownFieldsSurviveTranscoding(t *testing.T) {
+	unknown := protowire.AppendTag(nil, 999, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 150)
+
+	m := &descriptorpb.FileDescriptorProto{Name: proto.String("a.proto"), Package: proto.String("pkg")}
+	m.ProtoReflect().SetUnknown(unknown)
+
+	f := FileData{}
+	if err := FromMessage(m, &f); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if f.Name != "a.proto" || string(*f.Overflow["package"]) != `"pkg"` {
+		t.Fatalf("Expected named and overflow fields, got '%+v'", f)
+	}
+
+	if f.Overflow[UnknownFieldsKey] == nil {
+		t.Fatalf("Expected unknown fields under '%s', got '%v'", UnknownFieldsKey, f.Overflow)
+	}
+
+	out := &descriptorpb.FileDescriptorProto{}
+	if err := ToMessage(&f, out); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if out.GetName() != "a.proto" || out.GetPackage() != "pkg" {
+		t.Fatalf("Expected fields to be restored, got '%v'", out)
+	}
+
+	if !bytes.Equal(out.ProtoReflect().GetUnknown(), unknown) {
+		t.Fatalf("Expected unknown fields '%x', got '%x'", unknown, out.ProtoReflect().GetUnknown())
+	}
+}
+
+func TestFromMessageWithoutUnknownFields(t *testing.T) {
+	m := &descriptorpb.FileDescriptorProto{Name: proto.String("a.proto")}
+
+	f := FileData{}
+	if err := FromMessage(m, &f); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if _, ok := f.Overflow[UnknownFieldsKey]; ok {
+		t.Fatalf("Expected no '%s' key, got '%v'", UnknownFieldsKey, f.Overflow)
+	}
+}
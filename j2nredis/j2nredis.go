@@ -0,0 +1,186 @@
+// Package j2nredis stores j2n structs in Redis with the RedisJSON module,
+// using a github.com/redis/go-redis/v9 client.
+//
+// Set and Get store and fetch whole documents, keeping the keys the struct
+// does not name. Update writes only the named fields of a struct into the
+// stored document, so that keys written by other services, which the struct
+// knows nothing about, are left untouched even if they changed since the
+// document was read:
+//
+//	var cart Cart
+//	if err := j2nredis.Get(ctx, rdb, "cart:42", &cart); err != nil {
+//		return err
+//	}
+//	cart.Total = 1299
+//	err := j2nredis.Update(ctx, rdb, "cart:42", &cart, "total")
+//
+// Values are either wrapper types following the j2n pattern, which are
+// encoded with encoding/json, or data structs with an Overflow field, which
+// are passed to j2n directly. Values that are neither, such as the string
+// at a path, are encoded with encoding/json.
+package j2nredis
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ygt/j2n"
+)
+
+// Doer runs a Redis command. It is implemented by *redis.Client,
+// *redis.ClusterClient, *redis.Ring and redis.Pipeliner; with a pipeline,
+// errors are reported by Exec rather than by the functions of this package.
+type Doer interface {
+	Do(ctx context.Context, args ...interface{}) *redis.Cmd
+}
+
+// Stores v as the whole document at key, replacing any document there.
+func Set(ctx context.Context, c Doer, key string, v interface{}) error {
+	return SetPath(ctx, c, key, "$", v)
+}
+
+// Stores v at the JSONPath path of the document at key. The path must be
+// "$" if the document does not exist yet.
+func SetPath(ctx context.Context, c Doer, key, path string, v interface{}) error {
+	data, err := encode(v)
+	if err != nil {
+		return err
+	}
+	return c.Do(ctx, "JSON.SET", key, path, string(data)).Err()
+}
+
+// Parses the whole document at key into v. It returns redis.Nil if there is
+// no document at key.
+func Get(ctx context.Context, c Doer, key string, v interface{}) error {
+	return GetPath(ctx, c, key, "$", v)
+}
+
+// Parses the value at the JSONPath path of the document at key into v. If
+// the path matches several values, the first is used. It returns redis.Nil
+// if there is no document at key or nothing matches the path.
+func GetPath(ctx context.Context, c Doer, key, path string, v interface{}) error {
+	reply, err := c.Do(ctx, "JSON.GET", key, path).Text()
+	if err != nil {
+		return err
+	}
+
+	// JSONPath queries return an array of the matching values
+	var matches []json.RawMessage
+	if err := json.Unmarshal([]byte(reply), &matches); err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return redis.Nil
+	}
+
+	return decode(matches[0], v)
+}
+
+// Writes the named fields of v with the given JSON keys into the document
+// at key, leaving every other key of the stored document as it is. With no
+// keys, every named field of v is written, but not its Overflow entries.
+// A named field that v leaves out of its encoding, because it is tagged
+// omitempty or omitzero, is deleted from the document with JSON.DEL.
+//
+// Each field is written with its own JSON.SET command; use a transaction
+// pipeline as c if the fields must change together.
+func Update(ctx context.Context, c Doer, key string, v interface{}, keys ...string) error {
+	data, err := encode(v)
+	if err != nil {
+		return err
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	named := namedKeys(v)
+	if len(keys) == 0 {
+		overflow := overflowKeys(v)
+		for k := range document {
+			if !overflow[k] && !named[k] {
+				keys = append(keys, k)
+			}
+		}
+		for k := range named {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+	}
+
+	for _, k := range keys {
+		value, ok := document[k]
+		switch {
+		case ok:
+			err = c.Do(ctx, "JSON.SET", key, memberPath(k), string(value)).Err()
+		case named[k]:
+			err = c.Do(ctx, "JSON.DEL", key, memberPath(k)).Err()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the JSON keys of the named fields of v, or none if v is not a
+// struct with an Overflow field.
+func namedKeys(v interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	d, err := j2n.Describe(v)
+	if err != nil {
+		return keys
+	}
+	for _, f := range d.Fields {
+		keys[f.Key] = true
+	}
+	return keys
+}
+
+// Returns the JSONPath of the top-level member k, in bracket notation so
+// that any key can be expressed.
+func memberPath(k string) string {
+	quoted, _ := json.Marshal(k)
+	return "$[" + string(quoted) + "]"
+}
+
+func encode(v interface{}) ([]byte, error) {
+	if _, ok := v.(json.Marshaler); !ok && hasOverflow(v) {
+		return j2n.MarshalJSON(v)
+	}
+	return json.Marshal(v)
+}
+
+func decode(data []byte, v interface{}) error {
+	if _, ok := v.(json.Unmarshaler); !ok && hasOverflow(v) {
+		return j2n.UnmarshalJSON(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func hasOverflow(v interface{}) bool {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return false
+	}
+	f, ok := value.Type().FieldByName("Overflow")
+	return ok && len(f.Index) == 1
+}
+
+// Returns the keys in the Overflow field of v, which may be promoted from
+// an embedded data struct.
+func overflowKeys(v interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	overflow, _ := j2n.OverflowOf(v)
+	for k := range overflow {
+		keys[k] = true
+	}
+	return keys
+}
@@ -0,0 +1,202 @@
+package j2nredis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/ygt/j2n"
+)
+
+type CartData struct {
+	Owner    string                      `json:"owner"`
+	Total    int                         `json:"total"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type Cart struct {
+	CartData
+}
+
+func (c *Cart) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &c.CartData)
+}
+
+func (c Cart) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(c.CartData)
+}
+
+// fakeRedis stores documents for JSON.SET and JSON.GET at the root, and
+// JSON.SET and JSON.DEL of top-level members, which is all this package
+// uses.
+type fakeRedis struct {
+	documents map[string]map[string]json.RawMessage
+	commands  []string
+}
+
+func (f *fakeRedis) Do(ctx context.Context, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	f.commands = append(f.commands, fmt.Sprint(args...))
+
+	name, key, path := args[0].(string), args[1].(string), args[2].(string)
+	document := f.documents[key]
+
+	switch {
+	case name == "JSON.SET" && path == "$":
+		document = make(map[string]json.RawMessage)
+		if err := json.Unmarshal([]byte(args[3].(string)), &document); err != nil {
+			cmd.SetErr(err)
+			return cmd
+		}
+		f.documents[key] = document
+		cmd.SetVal("OK")
+	case name == "JSON.SET" && strings.HasPrefix(path, "$["):
+		if document == nil {
+			cmd.SetErr(fmt.Errorf("ERR new objects must be created at the root"))
+			return cmd
+		}
+		var member string
+		json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(path, "$["), "]")), &member)
+		document[member] = json.RawMessage(args[3].(string))
+		cmd.SetVal("OK")
+	case name == "JSON.DEL" && strings.HasPrefix(path, "$["):
+		var member string
+		json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(path, "$["), "]")), &member)
+		if _, ok := document[member]; ok {
+			delete(document, member)
+			cmd.SetVal(int64(1))
+		} else {
+			cmd.SetVal(int64(0))
+		}
+	case name == "JSON.GET" && path == "$":
+		if document == nil {
+			cmd.SetErr(redis.Nil)
+			return cmd
+		}
+		data, _ := json.Marshal([]interface{}{document})
+		cmd.SetVal(string(data))
+	default:
+		cmd.SetErr(fmt.Errorf("Unsupported command %v", args))
+	}
+	return cmd
+}
+
+func TestSetAndGetKeepUnknownKeys(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRedis{documents: make(map[string]map[string]json.RawMessage)}
+
+	cart := Cart{}
+	if err := json.Unmarshal([]byte(`{"owner":"bert","total":10,"coupon":"SAVE5"}`), &cart); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := Set(ctx, f, "cart:1", &cart); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	fetched := CartData{}
+	if err := Get(ctx, f, "cart:1", &fetched); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if fetched.Owner != "bert" || string(*fetched.Overflow["coupon"]) != `"SAVE5"` {
+		t.Fatalf("Expected named and unknown fields, got '%+v'", fetched)
+	}
+
+	if err := Get(ctx, f, "cart:2", &fetched); err != redis.Nil {
+		t.Fatalf("Expected redis.Nil for a missing key, got '%v'", err)
+	}
+}
+
+func TestUpdateWritesOnlyNamedFields(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRedis{documents: map[string]map[string]json.RawMessage{
+		"cart:1": {"owner": json.RawMessage(`"bert"`), "total": json.RawMessage(`10`), "coupon": json.RawMessage(`"SAVE10"`)},
+	}}
+
+	cart := Cart{}
+	cart.Owner = "ernie"
+	cart.Total = 20
+	coupon := json.RawMessage(`"STALE"`)
+	cart.Overflow = map[string]*json.RawMessage{"coupon": &coupon}
+
+	if err := Update(ctx, f, "cart:1", &cart); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	document := f.documents["cart:1"]
+	if string(document["owner"]) != `"ernie"` || string(document["total"]) != `20` || string(document["coupon"]) != `"SAVE10"` {
+		t.Fatalf("Expected named fields updated and 'coupon' untouched, got '%s'", document)
+	}
+
+	f.commands = nil
+	cart.Total = 30
+	if err := Update(ctx, f, "cart:1", &cart, "total"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(f.commands) != 1 || string(document["total"]) != `30` {
+		t.Fatalf("Expected a single JSON.SET of 'total', got '%v'", f.commands)
+	}
+}
+
+type SyncCartData struct {
+	Owner    string            `json:"owner"`
+	Overflow *j2n.SyncOverflow `json:"-"`
+}
+
+func TestUpdateSkipsOverflowStoreEntries(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRedis{documents: map[string]map[string]json.RawMessage{
+		"cart:1": {"owner": json.RawMessage(`"bert"`), "coupon": json.RawMessage(`"SAVE10"`)},
+	}}
+
+	coupon := json.RawMessage(`"STALE"`)
+	cart := SyncCartData{Owner: "ernie", Overflow: &j2n.SyncOverflow{}}
+	cart.Overflow.ReplaceOverflow(map[string]*json.RawMessage{"coupon": &coupon})
+
+	if err := Update(ctx, f, "cart:1", &cart); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := []string{`JSON.SETcart:1$["owner"]"ernie"`}
+	if fmt.Sprint(f.commands) != fmt.Sprint(expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, f.commands)
+	}
+	if string(f.documents["cart:1"]["coupon"]) != `"SAVE10"` {
+		t.Fatalf("Expected 'coupon' to be untouched, got '%s'", f.documents["cart:1"])
+	}
+}
+
+type CouponCartData struct {
+	Owner    string                      `json:"owner"`
+	Coupon   string                      `json:"coupon,omitempty"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestUpdateDeletesOmittedFields(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRedis{documents: map[string]map[string]json.RawMessage{
+		"cart:1": {"owner": json.RawMessage(`"bert"`), "coupon": json.RawMessage(`"SAVE10"`), "note": json.RawMessage(`"x"`)},
+	}}
+
+	cart := CouponCartData{Owner: "bert"}
+	if err := Update(ctx, f, "cart:1", &cart, "coupon"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if _, ok := f.documents["cart:1"]["coupon"]; ok {
+		t.Fatalf("Expected the cleared coupon to be deleted, got '%s'", f.documents["cart:1"])
+	}
+
+	f.documents["cart:1"]["coupon"] = json.RawMessage(`"SAVE10"`)
+	f.commands = nil
+	if err := Update(ctx, f, "cart:1", &cart); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := []string{`JSON.DELcart:1$["coupon"]`, `JSON.SETcart:1$["owner"]"bert"`}
+	if fmt.Sprint(f.commands) != fmt.Sprint(expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, f.commands)
+	}
+	if _, ok := f.documents["cart:1"]["note"]; !ok {
+		t.Fatalf("Expected 'note' to be untouched")
+	}
+}
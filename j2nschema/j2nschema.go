@@ -0,0 +1,158 @@
+// Package j2nschema validates documents against a JSON Schema before j2n
+// routes their fields, so that unknown fields which break the schema are
+// rejected instead of being kept in Overflow.
+//
+//	schema, err := j2nschema.Compile(schemaJSON)
+//	...
+//	err = j2n.UnmarshalJSON(data, &c.CatData, j2nschema.WithSchema(schema))
+//
+// Schemas are compiled with github.com/santhosh-tekuri/jsonschema, and may
+// use any draft it supports. Remote references are not loaded.
+package j2nschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/ygt/j2n"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// Violation is a single failure of a document to match a schema.
+type Violation struct {
+	// Pointer is the JSON Pointer to the value that failed, or "" for the
+	// document itself.
+	Pointer string
+
+	// Message describes the failure.
+	Message string
+
+	// Where the value is in the document, with j2n.WithErrorPositions.
+	// Line is 0 if it is not known.
+	Position j2n.Position
+}
+
+// ValidationError is returned when a document does not match its schema.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Returns the violations, one per line.
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		if v.Position.Line > 0 {
+			lines[i] = fmt.Sprintf("'%s' at %s: %s", v.Pointer, v.Position, v.Message)
+		} else {
+			lines[i] = fmt.Sprintf("'%s': %s", v.Pointer, v.Message)
+		}
+	}
+	return "Document does not match schema:\n" + strings.Join(lines, "\n")
+}
+
+// Sets the Position of each violation, for j2n.WithErrorPositions.
+func (e *ValidationError) Locate(position func(pointer string) (j2n.Position, bool)) {
+	for i, v := range e.Violations {
+		if p, ok := position(v.Pointer); ok {
+			e.Violations[i].Position = p
+		}
+	}
+}
+
+const schemaURL = "j2nschema:///schema.json"
+
+var printer = message.NewPrinter(language.English)
+
+// Parses and compiles the JSON-encoded schema.
+func Compile(schema []byte) (*Schema, error) {
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid schema: %s", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, document); err != nil {
+		return nil, err
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{schema: compiled}, nil
+}
+
+// Checks the JSON-encoded document against s. If it does not match, the
+// error is a *ValidationError listing every violation.
+func (s *Schema) Validate(data []byte) error {
+	return s.validate(data, nil)
+}
+
+// Checks data against s, reporting violations relative to the location
+// given by prefix.
+func (s *Schema) validate(data []byte, prefix []string) error {
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	err = s.schema.Validate(document)
+	if validationErr, ok := err.(*jsonschema.ValidationError); ok {
+		return &ValidationError{Violations: violations(validationErr, prefix, nil)}
+	}
+	return err
+}
+
+// Returns an Option that validates the raw document against s before
+// j2n.UnmarshalJSON parses it.
+func WithSchema(s *Schema) j2n.Option {
+	return j2n.WithValidator(s.Validate)
+}
+
+// Returns an Option that validates each entry that ends up in Overflow
+// against s, which describes a single value in the same way as an
+// additionalProperties schema. Named fields are not checked.
+//
+// A failure is returned as a *j2n.OverflowError wrapping a
+// *ValidationError, whose pointers are relative to the whole document.
+func WithOverflowSchema(s *Schema) j2n.Option {
+	return j2n.WithOverflowValidator(func(key string, raw json.RawMessage) error {
+		return s.validate(raw, []string{key})
+	})
+}
+
+// Returns the leaves of the error tree, which are the individual failures.
+func violations(err *jsonschema.ValidationError, prefix []string, result []Violation) []Violation {
+	if len(err.Causes) == 0 {
+		location := append(append([]string(nil), prefix...), err.InstanceLocation...)
+		return append(result, Violation{
+			Pointer: pointer(location),
+			Message: err.ErrorKind.LocalizedString(printer),
+		})
+	}
+
+	for _, cause := range err.Causes {
+		result = violations(cause, prefix, result)
+	}
+	return result
+}
+
+func pointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		b.WriteString("/" + token)
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package j2nschema
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type PersonData struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+const testSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0}
+	},
+	"additionalProperties": {"type": "string"}
+}`
+
+func TestWithSchemaAcceptsValidDocument(t *testing.T) {
+	schema, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := PersonData{}
+	err = j2n.UnmarshalJSON([]byte(`{"name":"Bert","age":29,"city":"Leeds"}`), &p, WithSchema(schema))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" || len(p.Overflow) != 2 {
+		t.Fatalf("Expected document to be parsed, got '%+v'", p)
+	}
+}
+
+func TestWithSchemaReportsViolationsWithPointers(t *testing.T) {
+	schema, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := PersonData{}
+	err = j2n.UnmarshalJSON([]byte(`{"name":"Bert","age":-1,"a/b":3}`), &p, WithSchema(schema))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("Expected *ValidationError, got '%v'", err)
+	}
+
+	pointers := make(map[string]bool)
+	for _, v := range validationErr.Violations {
+		pointers[v.Pointer] = true
+	}
+	if len(pointers) != 2 || !pointers["/age"] || !pointers["/a~1b"] {
+		t.Fatalf("Expected violations at '/age' and '/a~1b', got '%v'", validationErr.Violations)
+	}
+
+	if p.Name != "" || p.Overflow != nil {
+		t.Fatalf("Expected struct to be left unchanged, got '%+v'", p)
+	}
+}
+
+func TestWithSchemaViolationPositions(t *testing.T) {
+	schema, err := Compile([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := PersonData{}
+	err = j2n.UnmarshalJSON([]byte("{\n  \"name\": \"Bert\",\n  \"age\": -1\n}"), &p, WithSchema(schema), j2n.WithErrorPositions())
+	validationErr, ok := err.(*ValidationError)
+	if !ok || len(validationErr.Violations) != 1 {
+		t.Fatalf("Expected a single violation, got '%v'", err)
+	}
+	expected := j2n.Position{Offset: 22, Line: 3, Column: 3}
+	if validationErr.Violations[0].Position != expected {
+		t.Fatalf("Expected '%v', got '%v'", expected, validationErr.Violations[0].Position)
+	}
+	if message := err.Error(); !strings.Contains(message, "'/age' at line 3, column 3: ") {
+		t.Fatalf("Expected the position in '%s'", message)
+	}
+}
+
+func TestCompileReturnsErrorOnInvalidSchema(t *testing.T) {
+	if _, err := Compile([]byte(`{"type": 3}`)); err == nil {
+		t.Fatal("Expected error compiling invalid schema")
+	}
+}
+
+func TestWithOverflowSchemaChecksOnlyOverflow(t *testing.T) {
+	schema, err := Compile([]byte(`{"type": "string", "maxLength": 5}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p := PersonData{}
+	err = j2n.UnmarshalJSON([]byte(`{"name":"Bertrand Russell","city":"Leeds"}`), &p, WithOverflowSchema(schema))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	err = j2n.UnmarshalJSON([]byte(`{"name":"Bert","city":"Huddersfield"}`), &p, WithOverflowSchema(schema))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got '%v'", err)
+	}
+
+	if len(validationErr.Violations) != 1 || validationErr.Violations[0].Pointer != "/city" {
+		t.Fatalf("Expected violation at '/city', got '%v'", validationErr.Violations)
+	}
+}
+
+type TaggedData struct {
+	Tags     []string                    `json:"tags"`
+	Owner    *PersonData                 `json:"owner"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestGeneratedSchemaAcceptsNilValues(t *testing.T) {
+	generated, err := j2n.Schema(&TaggedData{})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	schema, err := Compile(generated)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	data, err := j2n.MarshalJSON(&TaggedData{})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := schema.Validate(data); err != nil {
+		t.Fatalf("Expected '%s' to match the generated schema, got '%s'", data, err)
+	}
+}
@@ -0,0 +1,74 @@
+// Package j2nsql stores j2n structs in JSON and JSONB database columns with
+// database/sql, so that keys the struct does not name survive a
+// read-modify-write cycle instead of being silently dropped on update.
+//
+//	var prefs j2nsql.Column[Preferences]
+//	err := db.QueryRow("SELECT prefs FROM users WHERE id = $1", id).Scan(&prefs)
+//	prefs.V.Theme = "dark"
+//	_, err = db.Exec("UPDATE users SET prefs = $1 WHERE id = $2", prefs, id)
+//
+// T is either a wrapper type following the j2n pattern, with its own
+// UnmarshalJSON and MarshalJSON methods, or a data struct with an Overflow
+// field, which is passed to j2n directly.
+package j2nsql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ygt/j2n"
+)
+
+// Column holds a value of type T encoded as JSON in a database column. It
+// implements sql.Scanner and driver.Valuer.
+type Column[T any] struct {
+	V T
+}
+
+// Parses the JSON column value src into c.V, replacing its contents. src
+// may be a []byte or a string, as returned by the driver; a NULL column
+// sets c.V to its zero value.
+func (c *Column[T]) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		var zero T
+		c.V = zero
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("Cannot scan %T into a JSON column", src)
+	}
+
+	var v T
+	if u, ok := interface{}(&v).(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(data); err != nil {
+			return err
+		}
+	} else if err := j2n.UnmarshalJSON(data, &v); err != nil {
+		return err
+	}
+
+	c.V = v
+	return nil
+}
+
+// Returns the JSON encoding of c.V, including its Overflow entries, as a
+// string, which drivers accept for both JSON and JSONB columns.
+func (c Column[T]) Value() (driver.Value, error) {
+	var data []byte
+	var err error
+	if m, ok := interface{}(c.V).(json.Marshaler); ok {
+		data, err = m.MarshalJSON()
+	} else {
+		data, err = j2n.MarshalJSON(c.V)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
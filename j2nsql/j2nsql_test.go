@@ -0,0 +1,83 @@
+package j2nsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type PreferencesData struct {
+	Theme    string                      `json:"theme"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type Preferences struct {
+	PreferencesData
+}
+
+func (p *Preferences) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &p.PreferencesData)
+}
+
+func (p Preferences) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(p.PreferencesData)
+}
+
+var (
+	_ sql.Scanner   = &Column[Preferences]{}
+	_ driver.Valuer = Column[Preferences]{}
+)
+
+func TestColumnKeepsUnknownKeys(t *testing.T) {
+	c := Column[Preferences]{}
+	if err := c.Scan([]byte(`{"theme":"light","fontSize":14}`)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	c.V.Theme = "dark"
+	value, err := c.Value()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"fontSize":14,"theme":"dark"}`
+	if value != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, value)
+	}
+}
+
+func TestColumnOfDataStruct(t *testing.T) {
+	c := Column[PreferencesData]{}
+	if err := c.Scan(`{"theme":"light","beta":true}`); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	value, err := c.Value()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"beta":true,"theme":"light"}`
+	if value != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, value)
+	}
+}
+
+func TestColumnScansNullAsZeroValue(t *testing.T) {
+	c := Column[Preferences]{}
+	c.V.Theme = "dark"
+
+	if err := c.Scan(nil); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if c.V.Theme != "" || c.V.Overflow != nil {
+		t.Fatalf("Expected zero value, got '%+v'", c.V)
+	}
+
+	if err := c.Scan(42); err == nil {
+		t.Fatal("Expected error scanning an integer")
+	}
+}
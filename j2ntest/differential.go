@@ -0,0 +1,120 @@
+package j2ntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Checks j2n against encoding/json for every input in corpus, failing t with
+// a description of each disagreement. Returns whether they agreed on all of
+// them.
+//
+// v is a pointer to a value of the type under test, which is used only for
+// its type: either a data struct with an Overflow field, or a wrapper type
+// embedding one, as produced by j2ngen. For each input, the data struct
+// parsed by j2n must have the same named field values as one parsed by
+// json.Unmarshal, and the two must fail on the same inputs. The document
+// that j2n marshals must then contain everything that json.Marshal produces
+// for the plain struct, and may add only the unknown fields.
+//
+// This is a safety net for adapters and refactors of the parsing code: any
+// change in how named fields are decoded shows up as a difference.
+func Differential(t testing.TB, v interface{}, corpus [][]byte) bool {
+	t.Helper()
+
+	typ := reflect.TypeOf(v)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		t.Errorf("Expected a pointer to a struct, got %T", v)
+		return false
+	}
+
+	index, err := dataFieldIndex(typ.Elem())
+	if err != nil {
+		t.Errorf("%s", err)
+		return false
+	}
+
+	agreed := true
+	for i, input := range corpus {
+		if differences := compareWithEncodingJSON(typ.Elem(), index, input); len(differences) > 0 {
+			t.Errorf("j2n and encoding/json disagree on input %d through %T:\n%s\ninput: %s",
+				i, v, strings.Join(differences, "\n"), input)
+			agreed = false
+		}
+	}
+	return agreed
+}
+
+// Returns the index of the data struct within t: nil if t has its own
+// Overflow field, or the index of the embedded struct that does.
+func dataFieldIndex(t reflect.Type) ([]int, error) {
+	if f, ok := t.FieldByName("Overflow"); ok && len(f.Index) == 1 {
+		return nil, nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if _, ok := f.Type.FieldByName("Overflow"); ok {
+				return []int{i}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s has no Overflow field and embeds no struct with one", t)
+}
+
+func compareWithEncodingJSON(t reflect.Type, index []int, input []byte) []string {
+	parsed := reflect.New(t)
+	output, j2nErr := RoundTrip(parsed.Interface(), input)
+
+	dataType := t
+	data := parsed.Elem()
+	if index != nil {
+		dataType = t.FieldByIndex(index).Type
+		data = data.FieldByIndex(index)
+	}
+
+	plain := reflect.New(dataType)
+	plainErr := json.Unmarshal(input, plain.Interface())
+
+	switch {
+	case j2nErr != nil && plainErr != nil:
+		return nil
+	case j2nErr != nil:
+		return []string{fmt.Sprintf("j2n failed where encoding/json did not: %s", j2nErr)}
+	case plainErr != nil:
+		return []string{fmt.Sprintf("encoding/json failed where j2n did not: %s", plainErr)}
+	}
+
+	var differences []string
+	for i := 0; i < dataType.NumField(); i++ {
+		f := dataType.Field(i)
+		if f.Name == "Overflow" || !f.IsExported() {
+			continue
+		}
+
+		got, want := data.Field(i).Interface(), plain.Elem().Field(i).Interface()
+		if !reflect.DeepEqual(got, want) {
+			differences = append(differences, fmt.Sprintf("field %s: j2n %#v, encoding/json %#v", f.Name, got, want))
+		}
+	}
+
+	plainOutput, err := json.Marshal(plain.Interface())
+	if err != nil {
+		return append(differences, fmt.Sprintf("encoding/json failed to marshal: %s", err))
+	}
+
+	changes, err := Diff(plainOutput, output)
+	if err != nil {
+		return append(differences, fmt.Sprintf("j2n produced invalid JSON: %s", err))
+	}
+	for _, change := range changes {
+		if !strings.HasPrefix(change, "added ") {
+			differences = append(differences, "output "+change)
+		}
+	}
+	return differences
+}
@@ -0,0 +1,56 @@
+package j2ntest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+// Shouter parses its name in upper case, unlike encoding/json.
+type Shouter struct {
+	PersonData
+}
+
+func (s *Shouter) UnmarshalJSON(data []byte) error {
+	if err := j2n.UnmarshalJSON(data, &s.PersonData); err != nil {
+		return err
+	}
+	s.Name = strings.ToUpper(s.Name)
+	return nil
+}
+
+func (s Shouter) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(s.PersonData)
+}
+
+var differentialCorpus = [][]byte{
+	[]byte(`{"name":"Bert","age":29}`),
+	[]byte(`{"name":"Ernie","address":{"city":"Leeds"}}`),
+	[]byte(`{"NAME":"Bert","tags":[1,2]}`),
+	[]byte(`{"age":"old"}`),
+	[]byte(`[]`),
+}
+
+func TestDifferentialAgreesWithEncodingJSON(t *testing.T) {
+	Differential(t, &Person{}, differentialCorpus)
+	Differential(t, &PersonData{}, differentialCorpus)
+}
+
+func TestDifferentialReportsDisagreements(t *testing.T) {
+	r := &recorder{TB: t}
+	if Differential(r, &Shouter{}, differentialCorpus) {
+		t.Fatal("Expected Shouter to disagree with encoding/json")
+	}
+
+	if len(r.errors) != 3 || !strings.Contains(r.errors[0], `field Name: j2n "BERT", encoding/json "Bert"`) {
+		t.Fatalf("Expected 3 errors naming the field, got '%v'", r.errors)
+	}
+}
+
+func TestDifferentialRejectsTypesWithoutOverflow(t *testing.T) {
+	r := &recorder{TB: t}
+	if Differential(r, &struct{ Name string }{}, differentialCorpus) {
+		t.Fatal("Expected struct without Overflow to be rejected")
+	}
+}
@@ -0,0 +1,444 @@
+package j2ntest
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// The depth beyond which generated documents stop nesting.
+const maxDepth = 3
+
+var (
+	rawMessageType       = reflect.TypeOf(json.RawMessage(nil))
+	timeType             = reflect.TypeOf(time.Time{})
+	unmarshalerType      = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	generatedStringRunes = []rune("abcxyzABC019 _-\"\\/<>&\né☃😀")
+)
+
+// Returns a random JSON object for the type of v, which is a pointer to a
+// data struct or to a wrapper type embedding one, as for Differential.
+//
+// The object holds a value of the right type for each named field, and a few
+// keys that the type does not name, with arbitrary JSON values. Fields
+// tagged omitempty or omitzero are either left out or given a value that is
+// not omitted, and nested wrapper types get unknown keys of their own, so
+// that a faithful type round trips every generated document exactly. Keys
+// appear in a random order.
+//
+// Generate takes a *rand.Rand so that it can be driven by any source of
+// randomness; with pgregory.net/rapid, draw a seed:
+//
+//	seed := rapid.Int64().Draw(rt, "seed")
+//	doc, err := j2ntest.Generate(&Cat{}, rand.New(rand.NewSource(seed)))
+func Generate(v interface{}, r *rand.Rand) ([]byte, error) {
+	typ := reflect.TypeOf(v)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Expected a pointer to a struct, got %T", v)
+	}
+
+	if _, err := dataFieldIndex(typ.Elem()); err != nil {
+		return nil, err
+	}
+
+	g := generator{r: r}
+	return g.object(typ.Elem(), 0, true), nil
+}
+
+// Returns a function for the Values field of a quick.Config, which fills
+// each []byte or json.RawMessage argument with a document from Generate. It
+// panics if Generate would fail for v.
+func Values(v interface{}) func([]reflect.Value, *rand.Rand) {
+	if _, err := Generate(v, rand.New(rand.NewSource(0))); err != nil {
+		panic(err)
+	}
+
+	return func(args []reflect.Value, r *rand.Rand) {
+		for i := range args {
+			doc, _ := Generate(v, r)
+			args[i] = reflect.ValueOf(doc)
+		}
+	}
+}
+
+// Checks with testing/quick that documents from Generate round trip through
+// the type of v without any difference, failing t with the first document
+// that does not. config may be nil; its Values function is replaced.
+// Returns whether every document round tripped.
+func CheckRoundTrips(t testing.TB, v interface{}, config *quick.Config) bool {
+	t.Helper()
+
+	typ := reflect.TypeOf(v)
+	if _, err := Generate(v, rand.New(rand.NewSource(0))); err != nil {
+		t.Errorf("%s", err)
+		return false
+	}
+
+	c := quick.Config{}
+	if config != nil {
+		c = *config
+	}
+	c.Values = Values(v)
+
+	var failure string
+	property := func(doc []byte) bool {
+		output, err := RoundTrip(reflect.New(typ.Elem()).Interface(), doc)
+		if err != nil {
+			failure = fmt.Sprintf("Round trip failed: %s\ninput: %s", err, doc)
+			return false
+		}
+
+		differences, err := Diff(doc, output)
+		if err != nil {
+			failure = fmt.Sprintf("Round trip produced invalid JSON: %s\ninput: %s", err, doc)
+			return false
+		}
+		if len(differences) > 0 {
+			failure = fmt.Sprintf("Round trip through %T was not faithful:\n%s\ninput:  %s\noutput: %s",
+				v, strings.Join(differences, "\n"), doc, output)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &c); err != nil {
+		if failure == "" {
+			failure = err.Error()
+		}
+		t.Errorf("%s", failure)
+		return false
+	}
+	return true
+}
+
+// A jsonField is a named field of a struct as encoding/json sees it.
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitEmpty bool
+	omitZero  bool
+	quoted    bool
+}
+
+// Returns the named fields of the struct type t, flattening embedded
+// structs without a name in their tag.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(ft)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			typ:       f.Type,
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+			omitZero:  strings.Contains(","+options+",", ",omitzero,"),
+			quoted:    strings.Contains(","+options+",", ",string,"),
+		})
+	}
+	return fields
+}
+
+type generator struct {
+	r *rand.Rand
+}
+
+// Returns an object for the struct type t, which is a j2n type with unknown
+// keys if unknown is set.
+func (g *generator) object(t reflect.Type, depth int, unknown bool) []byte {
+	if index, err := dataFieldIndex(t); unknown && err == nil && index != nil {
+		t = t.FieldByIndex(index).Type
+	}
+
+	var members [][]byte
+	var names []string
+	for _, f := range jsonFields(t) {
+		nonEmpty := false
+		if f.omitEmpty || f.omitZero {
+			if depth >= maxDepth || g.r.Intn(2) == 0 {
+				continue
+			}
+			nonEmpty = true
+		}
+
+		value := g.value(f.typ, depth+1, nonEmpty)
+		if value == nil || (f.omitZero && decodesToZero(f.typ, value)) {
+			continue
+		}
+		if f.quoted && isQuotable(f.typ) && string(value) != "null" {
+			value, _ = json.Marshal(string(value))
+		}
+
+		names = append(names, f.name)
+		members = append(members, member(f.name, value))
+	}
+
+	if unknown {
+		for n := g.r.Intn(4); n > 0; n-- {
+			key := g.unknownKey(names)
+			names = append(names, key)
+			members = append(members, member(key, g.anyValue(depth+1)))
+		}
+	}
+
+	g.r.Shuffle(len(members), func(i, j int) {
+		members[i], members[j] = members[j], members[i]
+	})
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	b.Write(bytes.Join(members, []byte(",")))
+	b.WriteByte('}')
+	return b.Bytes()
+}
+
+// Returns a key that matches none of names, even ignoring case as
+// encoding/json does.
+func (g *generator) unknownKey(names []string) string {
+	for {
+		key := "x-" + g.string(true)
+		taken := false
+		for _, name := range names {
+			if strings.EqualFold(key, name) {
+				taken = true
+			}
+		}
+		if !taken {
+			return key
+		}
+	}
+}
+
+// Returns a value of type t, which is not empty in the sense of omitempty if
+// nonEmpty is set, or nil if no such value can be generated.
+func (g *generator) value(t reflect.Type, depth int, nonEmpty bool) []byte {
+	switch {
+	case t == rawMessageType:
+		return g.anyValue(depth)
+	case t == timeType:
+		data, _ := json.Marshal(time.Unix(g.r.Int63n(1<<32), g.r.Int63n(1e9)).UTC())
+		return data
+	case t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface &&
+		(reflect.PointerTo(t).Implements(unmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)):
+		if t.Kind() == reflect.Struct {
+			if index, err := dataFieldIndex(t); err == nil && index != nil {
+				return g.object(t, depth, true)
+			}
+		}
+		if nonEmpty {
+			return nil
+		}
+		data, err := json.Marshal(reflect.Zero(t).Interface())
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+
+	var value interface{}
+	switch t.Kind() {
+	case reflect.Bool:
+		value = nonEmpty || g.r.Intn(2) == 0
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = g.nonZero(nonEmpty, func() int64 { return g.r.Int63n(255) - 127 })
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		value = g.nonZero(nonEmpty, func() int64 { return g.r.Int63n(256) })
+	case reflect.Float32, reflect.Float64:
+		// Quarters are exact in binary, so they survive any float type
+		value = float64(g.nonZero(nonEmpty, func() int64 { return g.r.Int63n(4001) - 2000 })) / 4
+	case reflect.String:
+		value = g.string(nonEmpty)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, g.length(depth, nonEmpty))
+			g.r.Read(data)
+			value = data
+			break
+		}
+		return g.array(t.Elem(), g.length(depth, nonEmpty), depth)
+	case reflect.Array:
+		return g.array(t.Elem(), t.Len(), depth)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			if nonEmpty {
+				return nil
+			}
+			return []byte("{}")
+		}
+
+		var members [][]byte
+		var names []string
+		for n := g.length(depth, nonEmpty); n > 0; n-- {
+			key := g.unknownKey(names)
+			names = append(names, key)
+			element := g.value(t.Elem(), depth+1, false)
+			if element == nil {
+				return nil
+			}
+			members = append(members, member(key, element))
+		}
+		return append(append([]byte("{"), bytes.Join(members, []byte(","))...), '}')
+	case reflect.Ptr:
+		if !nonEmpty && (depth >= maxDepth || g.r.Intn(4) == 0) {
+			return []byte("null")
+		}
+		return g.value(t.Elem(), depth, false)
+	case reflect.Struct:
+		return g.object(t, depth, false)
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return nil
+		}
+		if nonEmpty {
+			return g.value(reflect.TypeOf(""), depth, true)
+		}
+		return g.anyValue(depth)
+	default:
+		return nil
+	}
+
+	data, _ := json.Marshal(value)
+	return data
+}
+
+func (g *generator) array(elem reflect.Type, length, depth int) []byte {
+	elements := make([][]byte, length)
+	for i := range elements {
+		if elements[i] = g.value(elem, depth+1, false); elements[i] == nil {
+			return nil
+		}
+	}
+	return append(append([]byte("["), bytes.Join(elements, []byte(","))...), ']')
+}
+
+// Returns an arbitrary JSON value, as might appear under an unknown key.
+func (g *generator) anyValue(depth int) []byte {
+	kinds := 6
+	if depth >= maxDepth {
+		kinds = 4
+	}
+
+	switch g.r.Intn(kinds) {
+	case 0:
+		return []byte("null")
+	case 1:
+		return []byte(strconv.FormatBool(g.r.Intn(2) == 0))
+	case 2:
+		if g.r.Intn(2) == 0 {
+			return []byte(strconv.Itoa(g.r.Intn(2001) - 1000))
+		}
+		return []byte(strconv.FormatFloat(float64(g.r.Intn(2001)-1000)/8, 'f', -1, 64))
+	case 3:
+		data, _ := json.Marshal(g.string(false))
+		return data
+	case 4:
+		elements := make([][]byte, g.r.Intn(4))
+		for i := range elements {
+			elements[i] = g.anyValue(depth + 1)
+		}
+		return append(append([]byte("["), bytes.Join(elements, []byte(","))...), ']')
+	default:
+		var members [][]byte
+		var names []string
+		for n := g.r.Intn(4); n > 0; n-- {
+			key := g.unknownKey(names)
+			names = append(names, key)
+			members = append(members, member(key, g.anyValue(depth+1)))
+		}
+		return append(append([]byte("{"), bytes.Join(members, []byte(","))...), '}')
+	}
+}
+
+func (g *generator) string(nonEmpty bool) string {
+	n := g.r.Intn(8)
+	if nonEmpty {
+		n++
+	}
+
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = generatedStringRunes[g.r.Intn(len(generatedStringRunes))]
+	}
+	return string(runes)
+}
+
+func (g *generator) length(depth int, nonEmpty bool) int {
+	n := 0
+	if depth < maxDepth {
+		n = g.r.Intn(4)
+	}
+	if nonEmpty && n == 0 {
+		n = 1
+	}
+	return n
+}
+
+func (g *generator) nonZero(nonEmpty bool, next func() int64) int64 {
+	for {
+		if n := next(); n != 0 || !nonEmpty {
+			return n
+		}
+	}
+}
+
+func member(key string, value []byte) []byte {
+	name, _ := json.Marshal(key)
+	return append(append(name, ':'), value...)
+}
+
+func isQuotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// Reports whether value decodes to a value of type t that omitzero leaves
+// out: the zero value, or one whose IsZero method returns true.
+func decodesToZero(t reflect.Type, value []byte) bool {
+	decoded := reflect.New(t)
+	if err := json.Unmarshal(value, decoded.Interface()); err != nil {
+		return false
+	}
+	if z, ok := decoded.Elem().Interface().(interface{ IsZero() bool }); ok {
+		return z.IsZero()
+	}
+	if z, ok := decoded.Interface().(interface{ IsZero() bool }); ok {
+		return z.IsZero()
+	}
+	return decoded.Elem().IsZero()
+}
@@ -0,0 +1,112 @@
+package j2ntest
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/ygt/j2n"
+)
+
+type OwnerData struct {
+	Name     string                      `json:"name"`
+	Pets     []Person                    `json:"pets"`
+	Friends  map[string]*Person          `json:"friends,omitempty"`
+	Count    int64                       `json:"count,string"`
+	Scores   [2]float32                  `json:"scores"`
+	Since    time.Time                   `json:"since"`
+	Avatar   []byte                      `json:"avatar,omitempty"`
+	Notes    interface{}                 `json:"notes"`
+	Extra    json.RawMessage             `json:"extra,omitempty"`
+	Verified *bool                       `json:"verified"`
+	Address  AddressData                 `json:"address,omitzero"`
+	Updated  time.Time                   `json:"updated,omitzero"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type AddressData struct {
+	City string `json:"city,omitempty"`
+}
+
+type Owner struct {
+	OwnerData
+}
+
+func (o *Owner) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &o.OwnerData)
+}
+
+func (o Owner) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(o.OwnerData)
+}
+
+// Forgetful drops its unknown fields when marshaled.
+type Forgetful struct {
+	PersonData
+}
+
+func (f *Forgetful) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &f.PersonData)
+}
+
+func (f Forgetful) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.PersonData)
+}
+
+func TestGenerateProducesKnownAndUnknownKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	unknown := false
+	for i := 0; i < 20; i++ {
+		doc, err := Generate(&Person{}, r)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+
+		p := Person{}
+		if err := json.Unmarshal(doc, &p); err != nil {
+			t.Fatalf("Expected generated document to parse, got '%s' for '%s'", err, doc)
+		}
+		if !strings.Contains(string(doc), `"name":`) || !strings.Contains(string(doc), `"age":`) {
+			t.Fatalf("Expected named fields in '%s'", doc)
+		}
+		unknown = unknown || len(p.Overflow) > 0
+	}
+
+	if !unknown {
+		t.Fatal("Expected some generated documents to have unknown keys")
+	}
+}
+
+func TestCheckRoundTripsPassesFaithfulTypes(t *testing.T) {
+	config := &quick.Config{MaxCount: 200, Rand: rand.New(rand.NewSource(1))}
+
+	CheckRoundTrips(t, &Person{}, config)
+	CheckRoundTrips(t, &PersonData{}, config)
+	CheckRoundTrips(t, &Owner{}, config)
+}
+
+func TestCheckRoundTripsReportsLossyTypes(t *testing.T) {
+	r := &recorder{TB: t}
+	if CheckRoundTrips(r, &Forgetful{}, nil) {
+		t.Fatal("Expected Forgetful to lose unknown keys")
+	}
+
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "lost /x-") {
+		t.Fatalf("Expected lost unknown key, got '%v'", r.errors)
+	}
+}
+
+func TestValuesFillsQuickArguments(t *testing.T) {
+	config := &quick.Config{Values: Values(&Person{})}
+	property := func(doc []byte) bool {
+		return json.Valid(doc)
+	}
+
+	if err := quick.Check(property, config); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
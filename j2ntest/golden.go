@@ -0,0 +1,126 @@
+package j2ntest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+var update = flag.Bool("update", false, "rewrite j2ntest golden files with the current output")
+
+// Compares the canonical JSON encoding of v with the golden file
+// testdata/<name>.golden, failing t with a readable diff if they differ.
+// Returns whether they matched.
+//
+// Running the tests with -update writes the current encoding to the golden
+// file instead, creating it if necessary:
+//
+//	go test ./... -update
+//
+// v is encoded as by Canonical, so golden files are stable however Overflow
+// happens to be ordered or spaced.
+func AssertGolden(t testing.TB, name string, v interface{}) bool {
+	t.Helper()
+
+	got, err := Canonical(v)
+	if err != nil {
+		t.Errorf("Failed to encode %T: %s", v, err)
+		return false
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("Failed to update golden file: %s", err)
+			return false
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Errorf("Failed to update golden file: %s", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("Failed to read golden file, run the tests with -update to create it: %s", err)
+		return false
+	}
+
+	if bytes.Equal(got, want) {
+		return true
+	}
+
+	differences, err := Diff(want, got)
+	if err != nil {
+		t.Errorf("Golden file %s is not valid JSON: %s", path, err)
+		return false
+	}
+	if len(differences) == 0 {
+		differences = []string{"formatting differs"}
+	}
+
+	t.Errorf("Output does not match %s, run the tests with -update if this is expected:\n%s",
+		path, strings.Join(differences, "\n"))
+	return false
+}
+
+// Returns the JSON encoding of v in a canonical form for comparison: keys
+// are sorted at every level, numbers are kept exactly as written, HTML
+// characters are not escaped, and the result is indented by two spaces and
+// ends with a newline.
+//
+// If v is a data struct with an Overflow field, it is marshaled with
+// j2n.MarshalJSON, so the unknown fields appear alongside the named ones;
+// otherwise json.Marshal is used, which calls the MarshalJSON method of a
+// wrapper type.
+func Canonical(v interface{}) ([]byte, error) {
+	var data []byte
+	var err error
+	if hasOverflowField(v) {
+		data, err = j2n.MarshalJSON(v)
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func hasOverflowField(v interface{}) bool {
+	if _, ok := v.(json.Marshaler); ok {
+		return false
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return false
+	}
+
+	f, ok := value.Type().FieldByName("Overflow")
+	return ok && len(f.Index) == 1
+}
@@ -0,0 +1,57 @@
+package j2ntest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func goldenPerson() *PersonData {
+	address := json.RawMessage(`{ "city" : "Leeds" }`)
+	score := json.RawMessage(`1.50`)
+	return &PersonData{
+		Name:     "Bert <b>",
+		Age:      29,
+		Overflow: map[string]*json.RawMessage{"score": &score, "address": &address},
+	}
+}
+
+func TestAssertGoldenMatchesCanonicalEncoding(t *testing.T) {
+	AssertGolden(t, "person", goldenPerson())
+	AssertGolden(t, "person", &Person{PersonData: *goldenPerson()})
+}
+
+func TestAssertGoldenReportsDifferences(t *testing.T) {
+	person := goldenPerson()
+	person.Age = 30
+
+	r := &recorder{TB: t}
+	if AssertGolden(r, "person", person) {
+		t.Fatal("Expected changed age to fail")
+	}
+
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "changed /age: 29 -> 30") {
+		t.Fatalf("Expected diff of age, got '%v'", r.errors)
+	}
+}
+
+func TestAssertGoldenUpdatesFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	*update = true
+	defer func() { *update = false }()
+
+	if !AssertGolden(t, "nested/person", goldenPerson()) {
+		t.Fatal("Expected update to succeed")
+	}
+
+	written, err := os.ReadFile(filepath.Join("testdata", "nested", "person.golden"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if !strings.HasPrefix(string(written), "{\n  \"address\": {\n    \"city\": \"Leeds\"\n  },") {
+		t.Fatalf("Expected canonical encoding, got '%s'", written)
+	}
+}
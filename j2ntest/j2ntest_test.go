@@ -0,0 +1,78 @@
+package j2ntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type PersonData struct {
+	Name     string                      `json:"name"`
+	Age      int                         `json:"age"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type Person struct {
+	PersonData
+}
+
+func (p *Person) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &p.PersonData)
+}
+
+func (p Person) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(p.PersonData)
+}
+
+// recorder captures failures instead of failing the enclosing test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertRoundTripPassesFaithfulTypes(t *testing.T) {
+	input := []byte(`{"name":"Bert","age":29,"address":{"city":"Leeds"},"tags":["a"]}`)
+
+	AssertRoundTrip(t, &Person{}, input)
+	AssertRoundTrip(t, &PersonData{}, input)
+}
+
+func TestAssertRoundTripReportsDifferences(t *testing.T) {
+	r := &recorder{TB: t}
+	if AssertRoundTrip(r, &PersonData{}, []byte(`{"name":"Bert"}`)) {
+		t.Fatal("Expected round trip adding 'age' to fail")
+	}
+
+	if len(r.errors) != 1 {
+		t.Fatalf("Expected 1 error, got '%v'", r.errors)
+	}
+}
+
+func TestDiffListsChanges(t *testing.T) {
+	differences, err := Diff(
+		[]byte(`{"name":"Bert","address":{"city":"Leeds"},"tags":["a","b"],"n":1}`),
+		[]byte(`{"name":"Ernie","address":{},"tags":["a"],"n":1.0,"x/y":true}`),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := []string{
+		`lost /address/city: "Leeds"`,
+		`changed /name: "Bert" -> "Ernie"`,
+		`lost /tags/1: "b"`,
+		`added /x~1y: true`,
+	}
+	if !reflect.DeepEqual(differences, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, differences)
+	}
+}
@@ -0,0 +1,117 @@
+// Package j2ntoml applies the j2n Overflow contract to TOML documents. Any
+// keys that are not marshaled directly into the fields of the struct are put
+// into its 'Overflow' field, exactly as j2n.UnmarshalJSON does for JSON, so
+// unrecognised keys survive an Unmarshal/Marshal round trip.
+//
+// Structs are described with their json tags, and overflow values are stored
+// as JSON, so the same struct can be used with j2n, j2nyaml and j2ntoml.
+//
+// TOML date-times have no JSON equivalent. They are stored as strings in
+// their TOML form, and are written back out as strings.
+package j2ntoml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/ygt/j2n"
+)
+
+// Parses the TOML-encoded data into the struct pointed to by v.
+//
+// The document is converted to JSON and passed to j2n.UnmarshalJSON, so v
+// must satisfy the same requirements.
+func UnmarshalTOML(data []byte, v interface{}) error {
+	jsonData, err := ToJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return j2n.UnmarshalJSON(jsonData, v)
+}
+
+// Returns the TOML encoding of v, which must be a struct.
+//
+// Named fields and overflow keys are emitted together. Keys whose value is
+// null are left out, as TOML cannot represent them.
+func MarshalTOML(v interface{}) ([]byte, error) {
+	jsonData, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromJSON(jsonData)
+}
+
+// Converts a TOML document to the equivalent JSON object.
+func ToJSON(data []byte) ([]byte, error) {
+	document := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(document)
+}
+
+// Converts a JSON object to a TOML document.
+func FromJSON(data []byte) ([]byte, error) {
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	converted, err := fromJSONValue(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return toml.Marshal(converted)
+}
+
+// Converts the numbers in a decoded JSON value to int64 or float64, and drops
+// nulls, so that the TOML encoder writes them natively.
+func fromJSONValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, child := range value {
+			if child == nil {
+				continue
+			}
+			converted, err := fromJSONValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = converted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, child := range value {
+			if child == nil {
+				return nil, fmt.Errorf("TOML arrays cannot contain null")
+			}
+			converted, err := fromJSONValue(child)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, converted)
+		}
+		return result, nil
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("Number '%s' cannot be represented in TOML", value)
+		}
+		return f, nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,69 @@
+package j2ntoml
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type ServerData struct {
+	Host     string                      `json:"host"`
+	Port     int                         `json:"port"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestUnmarshalTOMLPutsUnknownKeysInOverflow(t *testing.T) {
+	data := []byte(`
+host = "localhost"
+port = 8080
+timeout = 1.5
+
+[tls]
+enabled = true
+`)
+
+	s := ServerData{}
+	if err := UnmarshalTOML(data, &s); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if s.Host != "localhost" || s.Port != 8080 {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", s)
+	}
+
+	expected := map[string]string{
+		"timeout": `1.5`,
+		"tls":     `{"enabled":true}`,
+	}
+	for k, v := range expected {
+		if s.Overflow[k] == nil || string(*s.Overflow[k]) != v {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", v, k, s.Overflow[k])
+		}
+	}
+}
+
+func TestMarshalTOMLRoundTrip(t *testing.T) {
+	data := []byte("host = 'localhost'\nport = 8080\nretries = 3\n\n[tls]\nenabled = true\n")
+
+	s := ServerData{}
+	if err := UnmarshalTOML(data, &s); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	out, err := MarshalTOML(&s)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	roundTripped := ServerData{}
+	if err := UnmarshalTOML(out, &roundTripped); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if roundTripped.Port != 8080 || string(*roundTripped.Overflow["retries"]) != "3" {
+		t.Fatalf("Expected values to survive a round trip, got '%s'", out)
+	}
+
+	if string(*roundTripped.Overflow["tls"]) != `{"enabled":true}` {
+		t.Fatalf("Expected 'tls' table to survive a round trip, got '%s'", out)
+	}
+}
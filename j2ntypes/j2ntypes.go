@@ -0,0 +1,150 @@
+// Package j2ntypes decodes named fields of common value types from the
+// string forms that documents carry them in, so that projects do not need
+// wrapper types with their own UnmarshalJSON methods:
+//
+//	type DeviceData struct {
+//		ID       uuid.UUID       `json:"id"`
+//		Address  netip.Addr      `json:"address"`
+//		Callback url.URL         `json:"callback"`
+//		Balance  decimal.Decimal `json:"balance"`
+//		Overflow j2n.Overflow    `json:"-"`
+//	}
+//
+//	j2n.Configure(DeviceData{}, j2ntypes.Options()...)
+//
+// Each type is handled by an option made with j2n.WithTypeCodec, which
+// applies to fields of the type and to pointers to it. An empty string
+// decodes to the zero value, and the zero values of the UUID, address and
+// URL types encode as empty strings.
+package j2ntypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/ygt/j2n"
+)
+
+// Returns the options for all the types in this package.
+func Options() []j2n.Option {
+	return []j2n.Option{UUID(), IP(), Addr(), URL(), Decimal()}
+}
+
+// Returns an Option for uuid.UUID fields, which are read in any form that
+// uuid.Parse accepts, such as with or without hyphens, or as a URN, and
+// written in the canonical hyphenated form.
+func UUID() j2n.Option {
+	return j2n.WithTypeCodec(
+		func(raw json.RawMessage) (uuid.UUID, error) {
+			s, err := decodeString(raw, "UUID")
+			if err != nil || s == "" {
+				return uuid.Nil, err
+			}
+			return uuid.Parse(s)
+		},
+		func(id uuid.UUID) ([]byte, error) {
+			if id == uuid.Nil {
+				return json.Marshal("")
+			}
+			return json.Marshal(id.String())
+		},
+	)
+}
+
+// Returns an Option for net.IP fields, which are read and written as IPv4
+// or IPv6 addresses.
+func IP() j2n.Option {
+	return j2n.WithTypeCodec(
+		func(raw json.RawMessage) (net.IP, error) {
+			s, err := decodeString(raw, "IP address")
+			if err != nil || s == "" {
+				return nil, err
+			}
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid IP address '%s'", s)
+			}
+			return ip, nil
+		},
+		func(ip net.IP) ([]byte, error) {
+			if ip == nil {
+				return json.Marshal("")
+			}
+			return json.Marshal(ip.String())
+		},
+	)
+}
+
+// Returns an Option for netip.Addr fields, which are read and written as
+// IPv4 or IPv6 addresses, with a zone if they have one.
+func Addr() j2n.Option {
+	return j2n.WithTypeCodec(
+		func(raw json.RawMessage) (netip.Addr, error) {
+			s, err := decodeString(raw, "IP address")
+			if err != nil || s == "" {
+				return netip.Addr{}, err
+			}
+			return netip.ParseAddr(s)
+		},
+		func(addr netip.Addr) ([]byte, error) {
+			if !addr.IsValid() {
+				return json.Marshal("")
+			}
+			return json.Marshal(addr.String())
+		},
+	)
+}
+
+// Returns an Option for url.URL fields, which encoding/json would otherwise
+// read and write as objects, to read and write them as URL strings.
+func URL() j2n.Option {
+	return j2n.WithTypeCodec(
+		func(raw json.RawMessage) (url.URL, error) {
+			s, err := decodeString(raw, "URL")
+			if err != nil || s == "" {
+				return url.URL{}, err
+			}
+			u, err := url.Parse(s)
+			if err != nil {
+				return url.URL{}, err
+			}
+			return *u, nil
+		},
+		func(u url.URL) ([]byte, error) {
+			return json.Marshal(u.String())
+		},
+	)
+}
+
+// Returns an Option for decimal.Decimal fields, which are read from strings
+// or numbers, without loss of precision, and written as strings.
+func Decimal() j2n.Option {
+	return j2n.WithTypeCodec(
+		func(raw json.RawMessage) (decimal.Decimal, error) {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				s = string(raw)
+			}
+			if s == "" {
+				return decimal.Zero, nil
+			}
+			return decimal.NewFromString(s)
+		},
+		func(d decimal.Decimal) ([]byte, error) {
+			return json.Marshal(d.String())
+		},
+	)
+}
+
+func decodeString(raw json.RawMessage, what string) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", fmt.Errorf("Expected a string for the %s, got %s", what, raw)
+	}
+	return s, nil
+}
@@ -0,0 +1,74 @@
+package j2ntypes
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/ygt/j2n"
+)
+
+type DeviceData struct {
+	ID       uuid.UUID       `json:"id"`
+	Parent   *uuid.UUID      `json:"parent"`
+	IP       net.IP          `json:"ip"`
+	Address  netip.Addr      `json:"address"`
+	Callback url.URL         `json:"callback"`
+	Balance  decimal.Decimal `json:"balance"`
+	Fee      decimal.Decimal `json:"fee"`
+	Overflow j2n.Overflow    `json:"-"`
+}
+
+func TestOptions(t *testing.T) {
+	input := `{"id":"6BA7B8109DAD11D180B400C04FD430C8","parent":null,"ip":"10.0.0.1","address":"fe80::1%eth0",` +
+		`"callback":"https://example.com/hook?a=1","balance":"12.345678901234567890","fee":0.1,"x":true}`
+
+	data := DeviceData{}
+	if err := j2n.UnmarshalJSON([]byte(input), &data, Options()...); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.ID.String() != "6ba7b810-9dad-11d1-80b4-00c04fd430c8" || data.Parent != nil {
+		t.Fatalf("Expected the UUID to be parsed, got %s and %v", data.ID, data.Parent)
+	}
+	if data.Callback.Host != "example.com" || data.Address.Zone() != "eth0" {
+		t.Fatalf("Expected the URL and address to be parsed, got %+v", data)
+	}
+
+	output, err := j2n.MarshalJSON(&data, Options()...)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"address":"fe80::1%eth0","balance":"12.34567890123456789","callback":"https://example.com/hook?a=1",` +
+		`"fee":"0.1","id":"6ba7b810-9dad-11d1-80b4-00c04fd430c8","ip":"10.0.0.1","parent":null,"x":true}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestEmptyStrings(t *testing.T) {
+	data := DeviceData{}
+	err := j2n.UnmarshalJSON([]byte(`{"id":"","ip":"","address":"","callback":"","balance":""}`), &data, Options()...)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	output, err := j2n.MarshalJSON(&data, Options()...)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"address":"","balance":"0","callback":"","fee":"0","id":"","ip":"","parent":null}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestInvalidValues(t *testing.T) {
+	err := j2n.UnmarshalJSON([]byte(`{"ip":"10.0.0"}`), &DeviceData{}, Options()...)
+	expected := "Cannot decode field 'ip': Invalid IP address '10.0.0'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
@@ -0,0 +1,29 @@
+// Package j2nvalidator runs github.com/go-playground/validator struct
+// validation as part of j2n.UnmarshalJSON, once the named fields and
+// Overflow have been populated:
+//
+//	err := j2n.UnmarshalJSON(data, &c.CatData, j2nvalidator.WithStructValidation(nil))
+//
+// A failure is returned as validator.ValidationErrors, so callers can
+// inspect it in the usual way.
+package j2nvalidator
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/ygt/j2n"
+)
+
+var defaultValidate = validator.New(validator.WithRequiredStructEnabled())
+
+// Returns an Option that validates the struct with validate after it has
+// been parsed. If validate is nil, a shared validator with required struct
+// checks enabled is used.
+func WithStructValidation(validate *validator.Validate) j2n.Option {
+	if validate == nil {
+		validate = defaultValidate
+	}
+
+	return j2n.WithAfterUnmarshal(func(v interface{}) error {
+		return validate.Struct(v)
+	})
+}
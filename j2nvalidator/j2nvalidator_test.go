@@ -0,0 +1,42 @@
+package j2nvalidator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/ygt/j2n"
+)
+
+type PersonData struct {
+	Name     string                      `json:"name" validate:"required"`
+	Email    string                      `json:"email" validate:"omitempty,email"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestWithStructValidationAcceptsValidStruct(t *testing.T) {
+	p := PersonData{}
+	err := j2n.UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p, WithStructValidation(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" || p.Overflow["age"] == nil {
+		t.Fatalf("Expected document to be parsed, got '%+v'", p)
+	}
+}
+
+func TestWithStructValidationReturnsValidationErrors(t *testing.T) {
+	p := PersonData{}
+	err := j2n.UnmarshalJSON([]byte(`{"email":"not-an-email"}`), &p, WithStructValidation(validator.New()))
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected validator.ValidationErrors, got '%v'", err)
+	}
+
+	if len(validationErrs) != 2 {
+		t.Fatalf("Expected 2 validation errors, got '%v'", validationErrs)
+	}
+}
@@ -0,0 +1,297 @@
+// Package j2nxml applies the j2n Overflow contract to XML. Any attributes and
+// child elements of the root element that are not mapped to fields of the
+// struct are kept in its 'Overflow' field, and are emitted again when the
+// struct is marshaled.
+//
+// The struct must contain a field 'Overflow' of type j2nxml.Overflow, omitted
+// from the encoding with `xml:"-"`:
+//
+//	type OrderData struct {
+//		XMLName  xml.Name        `xml:"order"`
+//		ID       string          `xml:"id,attr"`
+//		Total    string          `xml:"total"`
+//		Overflow j2nxml.Overflow `xml:"-"`
+//	}
+//
+//	err := j2nxml.UnmarshalXML(data, &order)
+//
+// Fields are matched on local names only. Unknown elements are kept as the
+// raw bytes of the input, so any namespace prefixes they use must also be
+// declared where the marshaled output is consumed.
+package j2nxml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ygt/j2n/internal/overflowfield"
+)
+
+// Overflow holds the attributes and child elements of an element that are
+// not mapped to fields of the struct, in document order.
+type Overflow struct {
+	Attrs    []xml.Attr
+	Elements [][]byte
+}
+
+var overflowType = reflect.TypeOf(Overflow{})
+
+// Parses the XML-encoded data into the struct pointed to by v.
+//
+// This behaves exactly like xml.Unmarshal, but any extra attributes and child
+// elements of the root element are kept in v.Overflow.
+func UnmarshalXML(data []byte, v interface{}) error {
+	field, err := overflowfield.Lookup(v, overflowType, "xml")
+	if err != nil {
+		return err
+	}
+
+	if err := xml.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	overflow, err := scanOverflow(data, knownNames(reflect.Indirect(reflect.ValueOf(v)).Type()))
+	if err != nil {
+		return err
+	}
+
+	field.Set(reflect.ValueOf(overflow))
+	return nil
+}
+
+// Returns the XML encoding of v, which must be a struct.
+//
+// This behaves like xml.Marshal, but also emits the attributes and elements
+// in v.Overflow, after those of the named fields.
+func MarshalXML(v interface{}) ([]byte, error) {
+	field, err := overflowfield.Lookup(v, overflowType, "xml")
+	if err != nil {
+		return nil, err
+	}
+	overflow := field.Interface().(Overflow)
+
+	output, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overflow.Attrs) == 0 && len(overflow.Elements) == 0 {
+		return output, nil
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(output))
+	token, err := decoder.RawToken()
+	if err != nil {
+		return nil, err
+	}
+
+	start, ok := token.(xml.StartElement)
+	if !ok {
+		return nil, errors.New("Expected a start element")
+	}
+
+	for _, attr := range overflow.Attrs {
+		for _, named := range start.Attr {
+			if named.Name.Local == attr.Name.Local && named.Name.Space == attr.Name.Space {
+				return nil, fmt.Errorf("Named attribute present in overflow: '%s'", attr.Name.Local)
+			}
+		}
+	}
+
+	// encoding/xml always emits an explicit end tag, so the start tag ends
+	// just before the current offset, and the end tag is the last in the output
+	startEnd := int(decoder.InputOffset()) - 1
+	endStart := bytes.LastIndex(output, []byte("</"))
+	if startEnd < 0 || endStart < startEnd {
+		return nil, errors.New("Unexpected XML output")
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(output[:startEnd])
+	for _, attr := range overflow.Attrs {
+		buffer.WriteByte(' ')
+		buffer.WriteString(qualifiedName(attr.Name))
+		buffer.WriteString(`="`)
+		if err := xml.EscapeText(&buffer, []byte(attr.Value)); err != nil {
+			return nil, err
+		}
+		buffer.WriteByte('"')
+	}
+	buffer.Write(output[startEnd:endStart])
+	for _, element := range overflow.Elements {
+		buffer.Write(element)
+	}
+	buffer.Write(output[endStart:])
+
+	return buffer.Bytes(), nil
+}
+
+// The local names of the attributes and elements mapped by a struct type. A
+// nil map means that the struct captures all of them with ",any".
+type names struct {
+	attrs    map[string]bool
+	elements map[string]bool
+}
+
+func knownNames(t reflect.Type) names {
+	known := names{attrs: make(map[string]bool), elements: make(map[string]bool)}
+	addKnownNames(&known, t)
+	return known
+}
+
+func addKnownNames(known *names, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("xml")
+		if tag == "-" || field.Name == "XMLName" || field.Name == "Overflow" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addKnownNames(known, embedded)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name, flags := tag, ""
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, flags = tag[:i], tag[i:]
+		}
+		if i := strings.LastIndex(name, " "); i >= 0 {
+			name = name[i+1:]
+		}
+		if i := strings.Index(name, ">"); i >= 0 {
+			name = name[:i]
+		}
+
+		switch {
+		case strings.Contains(flags, ",any") && strings.Contains(flags, ",attr"):
+			known.attrs = nil
+		case strings.Contains(flags, ",any"):
+			known.elements = nil
+		case strings.Contains(flags, ",innerxml"):
+			known.elements = nil
+		case strings.Contains(flags, ",chardata"), strings.Contains(flags, ",cdata"), strings.Contains(flags, ",comment"):
+		case strings.Contains(flags, ",attr"):
+			if name == "" {
+				name = field.Name
+			}
+			if known.attrs != nil {
+				known.attrs[name] = true
+			}
+		default:
+			if name == "" {
+				name = elementName(field)
+			}
+			if known.elements != nil {
+				known.elements[name] = true
+			}
+		}
+	}
+}
+
+// Returns the element name of an untagged field, which encoding/xml takes
+// from the XMLName of the field's type if it has one.
+func elementName(field reflect.StructField) string {
+	t := field.Type
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		if xmlName, ok := t.FieldByName("XMLName"); ok {
+			tag := strings.Split(xmlName.Tag.Get("xml"), ",")[0]
+			if i := strings.LastIndex(tag, " "); i >= 0 {
+				tag = tag[i+1:]
+			}
+			if tag != "" {
+				return tag
+			}
+		}
+	}
+	return field.Name
+}
+
+func scanOverflow(data []byte, known names) (Overflow, error) {
+	overflow := Overflow{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth := 0
+
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return overflow, nil
+		}
+		if err != nil {
+			return Overflow{}, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				for _, attr := range token.Attr {
+					if isNamespaceDeclaration(attr) || (known.attrs == nil || known.attrs[attr.Name.Local]) {
+						continue
+					}
+					overflow.Attrs = append(overflow.Attrs, attr)
+				}
+			} else if depth == 2 && known.elements != nil && !known.elements[token.Name.Local] {
+				if err := skipElement(decoder); err != nil {
+					return Overflow{}, err
+				}
+				depth--
+				fragment := append([]byte(nil), data[offset:decoder.InputOffset()]...)
+				overflow.Elements = append(overflow.Elements, fragment)
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return overflow, nil
+			}
+		}
+	}
+}
+
+// Consumes tokens up to the end of the current element. decoder.Skip cannot
+// be used, as it checks element nesting that RawToken does not record.
+func skipElement(decoder *xml.Decoder) error {
+	for depth := 1; depth > 0; {
+		token, err := decoder.RawToken()
+		if err != nil {
+			return err
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return nil
+}
+
+func isNamespaceDeclaration(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns")
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,84 @@
+package j2nxml
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+type OrderData struct {
+	XMLName  xml.Name `xml:"order"`
+	ID       string   `xml:"id,attr"`
+	Total    string   `xml:"total"`
+	Items    []string `xml:"items>item"`
+	Overflow Overflow `xml:"-"`
+}
+
+type OrderDataWithoutTag struct {
+	XMLName  xml.Name `xml:"order"`
+	Overflow Overflow
+}
+
+func TestUnmarshalXMLKeepsUnknownAttributesAndElements(t *testing.T) {
+	data := []byte(`<order id="7" vendor:priority="high"><total>9.99</total>` +
+		`<vendor:note lang="en">Leave at <b>door</b></vendor:note><items><item>a</item></items></order>`)
+
+	o := OrderData{}
+	if err := UnmarshalXML(data, &o); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if o.ID != "7" || o.Total != "9.99" || len(o.Items) != 1 {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", o)
+	}
+
+	if len(o.Overflow.Attrs) != 1 || o.Overflow.Attrs[0].Name.Local != "priority" {
+		t.Fatalf("Expected 'priority' attribute in overflow, got '%v'", o.Overflow.Attrs)
+	}
+
+	expectedElement := `<vendor:note lang="en">Leave at <b>door</b></vendor:note>`
+	if len(o.Overflow.Elements) != 1 || string(o.Overflow.Elements[0]) != expectedElement {
+		t.Fatalf("Expected '%s', got '%q'", expectedElement, o.Overflow.Elements)
+	}
+}
+
+func TestMarshalXMLEmitsOverflow(t *testing.T) {
+	o := OrderData{ID: "7", Total: "9.99"}
+	o.Overflow.Attrs = []xml.Attr{{Name: xml.Name{Local: "channel"}, Value: `web & "app"`}}
+	o.Overflow.Elements = [][]byte{[]byte(`<gift>true</gift>`)}
+
+	data, err := MarshalXML(&o)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `<order id="7" channel="web &amp; &#34;app&#34;"><total>9.99</total><items></items><gift>true</gift></order>`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+
+	roundTripped := OrderData{}
+	if err := UnmarshalXML(data, &roundTripped); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if roundTripped.Overflow.Attrs[0].Value != `web & "app"` || string(roundTripped.Overflow.Elements[0]) != `<gift>true</gift>` {
+		t.Fatalf("Expected overflow to survive a round trip, got '%+v'", roundTripped.Overflow)
+	}
+}
+
+func TestErrorOnAliasedAttributes(t *testing.T) {
+	o := OrderData{ID: "7"}
+	o.Overflow.Attrs = []xml.Attr{{Name: xml.Name{Local: "id"}, Value: "8"}}
+
+	if _, err := MarshalXML(&o); err == nil {
+		t.Fatal("Expected error on aliased attributes, got none")
+	}
+}
+
+func TestReturnsErrorWhenOverflowTagMissing(t *testing.T) {
+	o := OrderDataWithoutTag{}
+
+	if err := UnmarshalXML([]byte(`<order/>`), &o); err == nil {
+		t.Fatal("Expected error with Overflow field missing `xml:\"-\"` tag")
+	}
+}
@@ -0,0 +1,325 @@
+package j2nyaml
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ygt/j2n"
+	"gopkg.in/yaml.v3"
+)
+
+// Document retains the layout of a parsed YAML document: its comments, the
+// order of its keys, its blank lines and the style of its values. Marshaling
+// a struct through the Document edits only the values that changed, so
+// rewriting a hand-maintained file gives a minimal diff.
+type Document struct {
+	source []byte
+	root   yaml.Node
+}
+
+// Parses the YAML-encoded data into the struct pointed to by v, as
+// UnmarshalYAML does, and returns the Document for later marshaling.
+func ParseDocument(data []byte, v interface{}) (*Document, error) {
+	d := &Document{source: append([]byte(nil), data...)}
+	if err := yaml.Unmarshal(data, &d.root); err != nil {
+		return nil, err
+	}
+
+	if err := UnmarshalYAML(data, v); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Returns the YAML encoding of v, which must be a struct, laid out like the
+// original document.
+//
+// Values that are unchanged keep their original form, including quoting and
+// number formatting. Changed values are replaced in place, keys that are no
+// longer present are removed along with their comments, and new keys are
+// added at the end of their mapping. If nothing changed, the original bytes
+// are returned.
+func (d *Document) Marshal(v interface{}) ([]byte, error) {
+	jsonData, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated yaml.Node
+	if err := yaml.Unmarshal(jsonData, &updated); err != nil {
+		return nil, err
+	}
+	clearStyle(&updated)
+
+	if len(d.root.Content) == 0 {
+		return FromJSON(jsonData)
+	}
+
+	root := copyNode(&d.root)
+	if !mergeNode(root.Content[0], updated.Content[0]) {
+		return append([]byte(nil), d.source...), nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(detectIndent(&d.root))
+	if err := encoder.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return restoreBlankLines(d.source, &d.root, buffer.Bytes())
+}
+
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = copyNode(child)
+	}
+	return &copied
+}
+
+// Merges the values of updated into original, keeping the layout of
+// original wherever the values are equal. Returns whether anything changed.
+func mergeNode(original, updated *yaml.Node) bool {
+	if original.Kind == yaml.AliasNode {
+		if equalJSON(original, updated) {
+			return false
+		}
+		replaceNode(original, updated)
+		return true
+	}
+
+	if original.Kind != updated.Kind {
+		replaceNode(original, updated)
+		return true
+	}
+
+	switch original.Kind {
+	case yaml.MappingNode:
+		return mergeMapping(original, updated)
+	case yaml.SequenceNode:
+		changed := len(original.Content) != len(updated.Content)
+		for i := 0; i < len(original.Content) && i < len(updated.Content); i++ {
+			if mergeNode(original.Content[i], updated.Content[i]) {
+				changed = true
+			}
+		}
+		if len(original.Content) > len(updated.Content) {
+			original.Content = original.Content[:len(updated.Content)]
+		}
+		for i := len(original.Content); i < len(updated.Content); i++ {
+			original.Content = append(original.Content, updated.Content[i])
+		}
+		return changed
+	case yaml.ScalarNode:
+		if equalJSON(original, updated) {
+			return false
+		}
+		original.Value = updated.Value
+		original.Tag = updated.Tag
+		original.Style = updated.Style
+		return true
+	}
+
+	return false
+}
+
+func mergeMapping(original, updated *yaml.Node) bool {
+	updatedValues := make(map[string]*yaml.Node, len(updated.Content)/2)
+	var updatedKeys []*yaml.Node
+	for i := 0; i+1 < len(updated.Content); i += 2 {
+		updatedValues[updated.Content[i].Value] = updated.Content[i+1]
+		updatedKeys = append(updatedKeys, updated.Content[i])
+	}
+
+	// Merge keys are expanded into the updated document, so leave them alone
+	// and compare the merged keys against the values they provide
+	changed := false
+	seen := make(map[string]bool, len(updatedValues))
+	content := make([]*yaml.Node, 0, len(original.Content))
+
+	for i := 0; i+1 < len(original.Content); i += 2 {
+		key, value := original.Content[i], original.Content[i+1]
+		if key.Tag == "!!merge" {
+			content = append(content, key, value)
+			continue
+		}
+
+		updatedValue, ok := updatedValues[key.Value]
+		if !ok {
+			changed = true
+			continue
+		}
+		seen[key.Value] = true
+
+		if mergeNode(value, updatedValue) {
+			changed = true
+		}
+		content = append(content, key, value)
+	}
+
+	inherited, _ := mappingPairs(original)
+	inheritedValues := make(map[string]*yaml.Node, len(inherited))
+	for _, p := range inherited {
+		inheritedValues[p.key] = p.value
+	}
+
+	for _, key := range updatedKeys {
+		if seen[key.Value] {
+			continue
+		}
+		if value, ok := inheritedValues[key.Value]; ok && equalJSON(value, updatedValues[key.Value]) {
+			continue
+		}
+		content = append(content, key, updatedValues[key.Value])
+		changed = true
+	}
+
+	original.Content = content
+	return changed
+}
+
+// Replaces the value of original with updated, keeping original's comments.
+func replaceNode(original, updated *yaml.Node) {
+	head, line, foot := original.HeadComment, original.LineComment, original.FootComment
+	*original = *updated
+	original.HeadComment, original.LineComment, original.FootComment = head, line, foot
+}
+
+func equalJSON(a, b *yaml.Node) bool {
+	var aJSON, bJSON bytes.Buffer
+	if writeJSON(&aJSON, a) != nil || writeJSON(&bJSON, b) != nil {
+		return false
+	}
+	return bytes.Equal(normalizeJSON(aJSON.Bytes()), normalizeJSON(bJSON.Bytes()))
+}
+
+// Returns data with numbers in a canonical form, so that 1.0 and 1 compare
+// equal, as they decode to the same value.
+func normalizeJSON(data []byte) []byte {
+	var document yaml.Node
+	if yaml.Unmarshal(data, &document) != nil {
+		return data
+	}
+	var normalized bytes.Buffer
+	normalizeNumbers(&document)
+	if writeJSON(&normalized, &document) != nil {
+		return data
+	}
+	return normalized.Bytes()
+}
+
+func normalizeNumbers(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && (node.ShortTag() == "!!int" || node.ShortTag() == "!!float") {
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			node.Value = strconv.FormatFloat(f, 'g', -1, 64)
+			node.Tag = "!!float"
+		}
+	}
+	for _, child := range node.Content {
+		normalizeNumbers(child)
+	}
+}
+
+// Returns the indentation used by nested mappings in the document, or 2.
+func detectIndent(node *yaml.Node) int {
+	indent := 0
+	var walk func(*yaml.Node)
+	walk = func(n *yaml.Node) {
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				if value.Kind == yaml.MappingNode && len(value.Content) > 0 && value.Style&yaml.FlowStyle == 0 {
+					if d := value.Content[0].Column - key.Column; d > 0 && (indent == 0 || d < indent) {
+						indent = d
+					}
+				}
+			}
+		}
+		for _, child := range n.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	if indent == 0 {
+		return 2
+	}
+	return indent
+}
+
+// Inserts blank lines into output before the keys and sequence items that
+// were preceded by blank lines in the source.
+func restoreBlankLines(source []byte, original *yaml.Node, output []byte) ([]byte, error) {
+	sourceLines := strings.Split(string(source), "\n")
+	blank := make(map[string]bool)
+	collectPaths(original, "", func(path string, node *yaml.Node) {
+		line := node.Line - 1 - strings.Count(node.HeadComment, "\n") - boolToInt(node.HeadComment != "")
+		if line >= 1 && line <= len(sourceLines) && strings.TrimSpace(sourceLines[line-1]) == "" {
+			blank[path] = true
+		}
+	})
+
+	if len(blank) == 0 {
+		return output, nil
+	}
+
+	var rendered yaml.Node
+	if err := yaml.Unmarshal(output, &rendered); err != nil {
+		return nil, err
+	}
+
+	outputLines := strings.Split(string(output), "\n")
+	var insertAt []int
+	collectPaths(&rendered, "", func(path string, node *yaml.Node) {
+		if blank[path] {
+			line := node.Line - strings.Count(node.HeadComment, "\n") - boolToInt(node.HeadComment != "")
+			if line > 1 && strings.TrimSpace(outputLines[line-2]) != "" {
+				insertAt = append(insertAt, line-1)
+			}
+		}
+	})
+
+	sort.Sort(sort.Reverse(sort.IntSlice(insertAt)))
+	for _, i := range insertAt {
+		outputLines = append(outputLines[:i], append([]string{""}, outputLines[i:]...)...)
+	}
+
+	return []byte(strings.Join(outputLines, "\n")), nil
+}
+
+// Calls fn with a path for each mapping key and sequence item below node.
+func collectPaths(node *yaml.Node, path string, fn func(string, *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectPaths(child, path, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			childPath := path + "/" + strconv.Quote(node.Content[i].Value)
+			fn(childPath, node.Content[i])
+			collectPaths(node.Content[i+1], childPath, fn)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			childPath := path + "/" + strconv.Itoa(i)
+			fn(childPath, child)
+			collectPaths(child, childPath, fn)
+		}
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
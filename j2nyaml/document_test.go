@@ -0,0 +1,89 @@
+package j2nyaml
+
+import (
+	"testing"
+)
+
+const testDocument = `# Deployment settings
+name: web # the service name
+
+replicas: 3
+image: "nginx:1.25"
+# Settings we do not model
+labels:
+    tier: frontend
+    team: core
+
+ports:
+    - 80
+    - 443
+`
+
+func TestDocumentReturnsSourceWhenUnchanged(t *testing.T) {
+	c := Config{}
+	d, err := ParseDocument([]byte(testDocument), &c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	data, err := d.Marshal(&c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(data) != testDocument {
+		t.Fatalf("Expected '%s', got '%s'", testDocument, data)
+	}
+}
+
+func TestDocumentEditsOnlyChangedValues(t *testing.T) {
+	c := Config{}
+	d, err := ParseDocument([]byte(testDocument), &c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	c.Replicas = 5
+	data, err := d.Marshal(&c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `# Deployment settings
+name: web # the service name
+
+replicas: 5
+image: "nginx:1.25"
+# Settings we do not model
+labels:
+    tier: frontend
+    team: core
+
+ports:
+    - 80
+    - 443
+`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+func TestDocumentRemovesAndAddsKeys(t *testing.T) {
+	c := Config{}
+	d, err := ParseDocument([]byte("name: web\nreplicas: 3\nregion: eu # where\n"), &c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	delete(c.Overflow, "region")
+	c.Name = ""
+	data, err := d.Marshal(&c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "name: \"\"\nreplicas: 3\n"
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
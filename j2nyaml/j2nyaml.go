@@ -0,0 +1,302 @@
+// Package j2nyaml applies the j2n Overflow contract to YAML documents. Any
+// keys that are not marshaled directly into the fields of the struct are put
+// into its 'Overflow' field, exactly as j2n.UnmarshalJSON does for JSON, so
+// unrecognised keys survive an Unmarshal/Marshal round trip.
+//
+// Structs are described with their json tags, in the same way as
+// sigs.k8s.io/yaml, and overflow values are stored as JSON:
+//
+//	type ConfigData struct {
+//		Name     string                      `json:"name"`
+//		Overflow map[string]*json.RawMessage `json:"-"`
+//	}
+//
+//	type Config struct {
+//		ConfigData
+//	}
+//
+//	func (c *Config) UnmarshalJSON(data []byte) error {
+//		return j2n.UnmarshalJSON(data, &c.ConfigData)
+//	}
+//
+//	func (c Config) MarshalJSON() ([]byte, error) {
+//		return j2n.MarshalJSON(c.ConfigData)
+//	}
+//
+//	err := j2nyaml.UnmarshalYAML(data, &c.ConfigData)
+package j2nyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ygt/j2n"
+	"gopkg.in/yaml.v3"
+)
+
+// Parses the YAML-encoded data into the struct pointed to by v.
+//
+// The document is converted to JSON and passed to j2n.UnmarshalJSON, so v
+// must satisfy the same requirements. Mapping keys that are not strings are
+// converted to their string form, and aliases and merge keys are expanded,
+// up to a million nodes in all.
+func UnmarshalYAML(data []byte, v interface{}) error {
+	jsonData, err := ToJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return j2n.UnmarshalJSON(jsonData, v)
+}
+
+// Returns the YAML encoding of v, which must be a struct.
+//
+// This is the output of j2n.MarshalJSON rendered in block style, so named
+// fields and overflow keys are emitted together in key order.
+func MarshalYAML(v interface{}) ([]byte, error) {
+	jsonData, err := j2n.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromJSON(jsonData)
+}
+
+// Converts a YAML document to the equivalent JSON. It fails if aliases and
+// merge keys would expand into more than a million nodes, as a document
+// built to exhaust memory does.
+func ToJSON(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if len(document.Content) == 0 {
+		buffer.WriteString("null")
+	} else if err := writeJSON(&buffer, document.Content[0]); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Converts a JSON document to block-style YAML, preserving the order of keys.
+func FromJSON(data []byte) ([]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	clearStyle(&document)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// The number of nodes that aliases and merge keys may expand into across a
+// document, so that a few kilobytes of nested aliases cannot grow into
+// gigabytes of JSON
+const maxAliasNodes = 1 << 20
+
+func writeJSON(buffer *bytes.Buffer, node *yaml.Node) error {
+	c := converter{buffer: buffer}
+	return c.write(node, false)
+}
+
+// converter writes YAML nodes as JSON, counting the nodes written again
+// through aliases.
+type converter struct {
+	buffer     *bytes.Buffer
+	aliasNodes int
+}
+
+func (c *converter) write(node *yaml.Node, aliased bool) error {
+	if aliased {
+		c.aliasNodes++
+		if c.aliasNodes > maxAliasNodes {
+			return fmt.Errorf("YAML aliases expand to more than %d nodes", maxAliasNodes)
+		}
+	}
+
+	buffer := c.buffer
+	switch node.Kind {
+	case yaml.DocumentNode:
+		return c.write(node.Content[0], aliased)
+	case yaml.AliasNode:
+		return c.write(node.Alias, true)
+	case yaml.SequenceNode:
+		buffer.WriteByte('[')
+		for i, child := range node.Content {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := c.write(child, aliased); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+		return nil
+	case yaml.MappingNode:
+		pairs, err := mappingPairs(node)
+		if err != nil {
+			return err
+		}
+		buffer.WriteByte('{')
+		for i, pair := range pairs {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			key, _ := json.Marshal(pair.key)
+			buffer.Write(key)
+			buffer.WriteByte(':')
+			if err := c.write(pair.value, aliased || pair.merged); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+		return nil
+	case yaml.ScalarNode:
+		return writeScalar(buffer, node)
+	}
+
+	return fmt.Errorf("Unsupported YAML node at line %d", node.Line)
+}
+
+type pair struct {
+	key   string
+	value *yaml.Node
+
+	// Whether the pair comes from a merge key
+	merged bool
+}
+
+// Returns the key/value pairs of a mapping in document order, with merge keys
+// expanded and later keys overriding earlier ones.
+func mappingPairs(node *yaml.Node) ([]pair, error) {
+	var pairs []pair
+	index := make(map[string]int)
+
+	add := func(key string, value *yaml.Node, merged bool) {
+		if i, ok := index[key]; ok {
+			if !merged {
+				pairs[i] = pair{key: key, value: value}
+			}
+			return
+		}
+		index[key] = len(pairs)
+		pairs = append(pairs, pair{key: key, value: value, merged: merged})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		if keyNode.Tag == "!!merge" {
+			merged, err := mergedPairs(valueNode)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range merged {
+				add(p.key, p.value, true)
+			}
+			continue
+		}
+
+		if keyNode.Kind == yaml.AliasNode {
+			keyNode = keyNode.Alias
+		}
+		if keyNode.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("Unsupported non-scalar YAML key at line %d", keyNode.Line)
+		}
+		add(keyNode.Value, valueNode, false)
+	}
+
+	return pairs, nil
+}
+
+func mergedPairs(node *yaml.Node) ([]pair, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		return mappingPairs(node)
+	case yaml.SequenceNode:
+		var pairs []pair
+		for _, child := range node.Content {
+			merged, err := mergedPairs(child)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, merged...)
+		}
+		return pairs, nil
+	}
+
+	return nil, fmt.Errorf("Invalid YAML merge at line %d", node.Line)
+}
+
+func writeScalar(buffer *bytes.Buffer, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		buffer.WriteString("null")
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return err
+		}
+		buffer.WriteString(strconv.FormatBool(b))
+	case "!!int":
+		// Preserve the digits of plain decimal integers, however large
+		if isJSONNumber(node.Value) {
+			buffer.WriteString(node.Value)
+			return nil
+		}
+		var i int64
+		if err := node.Decode(&i); err != nil {
+			return err
+		}
+		buffer.WriteString(strconv.FormatInt(i, 10))
+	case "!!float":
+		if isJSONNumber(node.Value) {
+			buffer.WriteString(node.Value)
+			return nil
+		}
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return err
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Errorf("YAML value '%s' at line %d has no JSON equivalent", node.Value, node.Line)
+		}
+		buffer.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	default:
+		s, _ := json.Marshal(node.Value)
+		buffer.Write(s)
+	}
+
+	return nil
+}
+
+func isJSONNumber(s string) bool {
+	return json.Valid([]byte(s)) && s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9'))
+}
@@ -0,0 +1,135 @@
+package j2nyaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ygt/j2n"
+)
+
+type ConfigData struct {
+	Name     string                      `json:"name"`
+	Replicas int                         `json:"replicas"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+type Config struct {
+	ConfigData
+}
+
+func (c *Config) UnmarshalJSON(data []byte) error {
+	return j2n.UnmarshalJSON(data, &c.ConfigData)
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	return j2n.MarshalJSON(&c.ConfigData)
+}
+
+func TestUnmarshalYAMLPutsUnknownKeysInOverflow(t *testing.T) {
+	data := []byte(`
+name: web
+replicas: 3
+labels:
+  tier: frontend
+big: 99999999999999999999
+hex: 0x1F
+`)
+
+	c := Config{}
+	if err := UnmarshalYAML(data, &c.ConfigData); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if c.Name != "web" || c.Replicas != 3 {
+		t.Fatalf("Expected named fields to be parsed, got '%+v'", c.ConfigData)
+	}
+
+	expected := map[string]string{
+		"labels": `{"tier":"frontend"}`,
+		"big":    `99999999999999999999`,
+		"hex":    `31`,
+	}
+	for k, v := range expected {
+		if c.Overflow[k] == nil || string(*c.Overflow[k]) != v {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", v, k, c.Overflow[k])
+		}
+	}
+}
+
+func TestUnmarshalYAMLExpandsMergeKeys(t *testing.T) {
+	data := []byte(`
+defaults: &defaults
+  replicas: 2
+  region: eu
+<<: *defaults
+name: web
+`)
+
+	c := Config{}
+	if err := UnmarshalYAML(data, &c.ConfigData); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if c.Replicas != 2 {
+		t.Fatalf("Expected 2 replicas, got %d", c.Replicas)
+	}
+
+	if c.Overflow["region"] == nil || string(*c.Overflow["region"]) != `"eu"` {
+		t.Fatalf("Expected merged 'region' in overflow, got '%v'", c.Overflow["region"])
+	}
+}
+
+func TestToJSONLimitsAliasExpansion(t *testing.T) {
+	// Each level refers to the one before it ten times, so the last expands
+	// to ten billion strings
+	var data strings.Builder
+	data.WriteString("a0: &a0 [lol, lol, lol, lol, lol, lol, lol, lol, lol, lol]\n")
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&data, "a%d: &a%d [", i, i)
+		for j := 0; j < 10; j++ {
+			if j > 0 {
+				data.WriteString(", ")
+			}
+			fmt.Fprintf(&data, "*a%d", i-1)
+		}
+		data.WriteString("]\n")
+	}
+
+	_, err := ToJSON([]byte(data.String()))
+	expected := "YAML aliases expand to more than 1048576 nodes"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	// Modest use of aliases is expanded as before
+	data.Reset()
+	data.WriteString("a: &a [1, 2]\nb: [*a, *a]\n")
+	output, err := ToJSON([]byte(data.String()))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(output) != `{"a":[1,2],"b":[[1,2],[1,2]]}` {
+		t.Fatalf("Expected the aliases to be expanded, got '%s'", output)
+	}
+}
+
+func TestMarshalYAMLRoundTrip(t *testing.T) {
+	data := []byte("name: web\nreplicas: 3\nlabels:\n  tier: frontend\nenabled: \"true\"\n")
+
+	c := Config{}
+	if err := UnmarshalYAML(data, &c.ConfigData); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	out, err := MarshalYAML(&c.ConfigData)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "enabled: \"true\"\nlabels:\n  tier: frontend\nname: web\nreplicas: 3\n"
+	if string(out) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, out)
+	}
+}
@@ -0,0 +1,409 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parses the JSON5-encoded data into the struct pointed to by v.
+//
+// JSON5 extends JSON with the conveniences of hand-written files: comments,
+// unquoted keys, single-quoted strings, trailing commas, hexadecimal numbers
+// and numbers with leading or trailing decimal points or a leading '+'.
+//
+// The document is first rewritten as strict JSON and then parsed with
+// UnmarshalJSON, so the values stored in v.Overflow are always strict JSON.
+// Infinity and NaN have no JSON equivalent and are rejected.
+func UnmarshalJSON5(data []byte, v interface{}) error {
+	jsonData, err := json5ToJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return UnmarshalJSON(jsonData, v)
+}
+
+func json5ToJSON(data []byte) ([]byte, error) {
+	p := &json5Parser{data: data}
+
+	p.skipSpace()
+	if err := p.value(); err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected data after top-level value")
+	}
+
+	return p.out.Bytes(), nil
+}
+
+type json5Parser struct {
+	data []byte
+	pos  int
+	out  bytes.Buffer
+	err  error
+}
+
+func (p *json5Parser) errorf(format string, args ...interface{}) error {
+	line := 1 + bytes.Count(p.data[:p.pos], []byte("\n"))
+	return fmt.Errorf("Invalid JSON5 at line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *json5Parser) peek() rune {
+	if p.pos >= len(p.data) {
+		return -1
+	}
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	return r
+}
+
+func (p *json5Parser) next() rune {
+	r, size := utf8.DecodeRune(p.data[p.pos:])
+	p.pos += size
+	return r
+}
+
+func (p *json5Parser) skipSpace() {
+	for p.pos < len(p.data) {
+		r := p.peek()
+		switch {
+		case r == '\uFEFF' || unicode.IsSpace(r) || unicode.Is(unicode.Zs, r):
+			p.next()
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexAny(p.data[p.pos:], "\n\r")
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end
+			}
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				p.err = p.errorf("unterminated comment")
+				p.pos = len(p.data)
+				return
+			}
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (p *json5Parser) value() error {
+	if p.err != nil {
+		return p.err
+	}
+
+	switch r := p.peek(); {
+	case r == '{':
+		return p.object()
+	case r == '[':
+		return p.array()
+	case r == '"' || r == '\'':
+		s, err := p.string()
+		if err != nil {
+			return err
+		}
+		return p.writeString(s)
+	case r == '-' || r == '+' || r == '.' || (r >= '0' && r <= '9'):
+		return p.number()
+	case r == 'I' || r == 'N':
+		return p.errorf("Infinity and NaN cannot be represented in JSON")
+	case r == -1:
+		return p.errorf("unexpected end of input")
+	}
+
+	word := p.identifier()
+	switch word {
+	case "true", "false", "null":
+		p.out.WriteString(word)
+		return nil
+	}
+	return p.errorf("unexpected '%s'", word)
+}
+
+func (p *json5Parser) object() error {
+	p.next()
+	p.out.WriteByte('{')
+
+	for first := true; ; first = false {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.next()
+			p.out.WriteByte('}')
+			return p.err
+		}
+
+		if !first {
+			p.out.WriteByte(',')
+		}
+
+		var key string
+		var err error
+		if r := p.peek(); r == '"' || r == '\'' {
+			key, err = p.string()
+		} else if key = p.identifier(); key == "" {
+			err = p.errorf("expected object key")
+		}
+		if err != nil {
+			return err
+		}
+		if err := p.writeString(key); err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		if p.next() != ':' {
+			return p.errorf("expected ':' after key '%s'", key)
+		}
+		p.out.WriteByte(':')
+
+		p.skipSpace()
+		if err := p.value(); err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+		default:
+			return p.errorf("expected ',' or '}' in object")
+		}
+	}
+}
+
+func (p *json5Parser) array() error {
+	p.next()
+	p.out.WriteByte('[')
+
+	for first := true; ; first = false {
+		p.skipSpace()
+		if p.peek() == ']' {
+			p.next()
+			p.out.WriteByte(']')
+			return p.err
+		}
+
+		if !first {
+			p.out.WriteByte(',')
+		}
+
+		if err := p.value(); err != nil {
+			return err
+		}
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+		default:
+			return p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// Reads an ECMAScript identifier name, as allowed for unquoted keys.
+func (p *json5Parser) identifier() string {
+	var name strings.Builder
+	for p.pos < len(p.data) {
+		r := p.peek()
+		if r == '\\' && bytes.HasPrefix(p.data[p.pos:], []byte(`\u`)) && p.pos+6 <= len(p.data) {
+			var decoded string
+			if err := json.Unmarshal([]byte(`"`+string(p.data[p.pos:p.pos+6])+`"`), &decoded); err != nil {
+				break
+			}
+			name.WriteString(decoded)
+			p.pos += 6
+			continue
+		}
+		if r == '$' || r == '_' || unicode.IsLetter(r) || (name.Len() > 0 && (unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Pc, r))) {
+			name.WriteRune(p.next())
+			continue
+		}
+		break
+	}
+	return name.String()
+}
+
+func (p *json5Parser) string() (string, error) {
+	quote := p.next()
+	var s strings.Builder
+
+	for {
+		if p.pos >= len(p.data) {
+			return "", p.errorf("unterminated string")
+		}
+
+		r := p.next()
+		switch {
+		case r == quote:
+			return s.String(), nil
+		case r == '\n' || r == '\r':
+			return "", p.errorf("unescaped line break in string")
+		case r != '\\':
+			s.WriteRune(r)
+			continue
+		}
+
+		if p.pos >= len(p.data) {
+			return "", p.errorf("unterminated string")
+		}
+
+		switch escaped := p.next(); escaped {
+		case 'b':
+			s.WriteByte('\b')
+		case 'f':
+			s.WriteByte('\f')
+		case 'n':
+			s.WriteByte('\n')
+		case 'r':
+			s.WriteByte('\r')
+		case 't':
+			s.WriteByte('\t')
+		case 'v':
+			s.WriteByte('\v')
+		case '0':
+			s.WriteByte(0)
+		case 'x', 'u':
+			digits := 2
+			if escaped == 'u' {
+				digits = 4
+			}
+			if p.pos+digits > len(p.data) {
+				return "", p.errorf("invalid escape sequence")
+			}
+			var code rune
+			if _, err := fmt.Sscanf(string(p.data[p.pos:p.pos+digits]), "%x", &code); err != nil {
+				return "", p.errorf("invalid escape sequence")
+			}
+			p.pos += digits
+			if utf16IsHighSurrogate(code) && bytes.HasPrefix(p.data[p.pos:], []byte(`\u`)) && p.pos+6 <= len(p.data) {
+				var low rune
+				if _, err := fmt.Sscanf(string(p.data[p.pos+2:p.pos+6]), "%x", &low); err == nil {
+					code = (code-0xD800)<<10 + (low - 0xDC00) + 0x10000
+					p.pos += 6
+				}
+			}
+			s.WriteRune(code)
+		case '\r':
+			// Line continuation, including a CRLF pair
+			if p.peek() == '\n' {
+				p.next()
+			}
+		case '\n', '\u2028', '\u2029':
+			// Line continuation
+		default:
+			s.WriteRune(escaped)
+		}
+	}
+}
+
+func utf16IsHighSurrogate(r rune) bool {
+	return r >= 0xD800 && r < 0xDC00
+}
+
+func (p *json5Parser) writeString(s string) error {
+	encoder := json.NewEncoder(&p.out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return err
+	}
+	// Encode appends a newline
+	p.out.Truncate(p.out.Len() - 1)
+	return nil
+}
+
+func (p *json5Parser) number() error {
+	start := p.pos
+	sign := ""
+	switch p.peek() {
+	case '-':
+		sign = "-"
+		p.next()
+	case '+':
+		p.next()
+	}
+
+	if p.peek() == 'I' || p.peek() == 'N' {
+		return p.errorf("Infinity and NaN cannot be represented in JSON")
+	}
+
+	if bytes.HasPrefix(p.data[p.pos:], []byte("0x")) || bytes.HasPrefix(p.data[p.pos:], []byte("0X")) {
+		p.pos += 2
+		digitsStart := p.pos
+		for p.pos < len(p.data) && isHexDigit(p.data[p.pos]) {
+			p.pos++
+		}
+		n, ok := new(big.Int).SetString(string(p.data[digitsStart:p.pos]), 16)
+		if !ok {
+			return p.errorf("invalid hexadecimal number")
+		}
+		if sign == "-" {
+			n.Neg(n)
+		}
+		p.out.WriteString(n.String())
+		return nil
+	}
+
+	var integer, fraction, exponent string
+	integer = p.digits()
+	if p.peek() == '.' {
+		p.next()
+		fraction = p.digits()
+	}
+	if r := p.peek(); r == 'e' || r == 'E' {
+		p.next()
+		exponent = "e"
+		if r := p.peek(); r == '+' || r == '-' {
+			exponent += string(p.next())
+		}
+		digits := p.digits()
+		if digits == "" {
+			return p.errorf("invalid number '%s'", p.data[start:p.pos])
+		}
+		exponent += digits
+	}
+
+	if integer == "" && fraction == "" {
+		return p.errorf("invalid number '%s'", p.data[start:p.pos])
+	}
+	if integer == "" {
+		integer = "0"
+	}
+	if len(integer) > 1 && integer[0] == '0' {
+		return p.errorf("invalid number '%s'", p.data[start:p.pos])
+	}
+
+	p.out.WriteString(sign + integer)
+	if fraction != "" {
+		p.out.WriteString("." + fraction)
+	}
+	p.out.WriteString(exponent)
+	return nil
+}
+
+func (p *json5Parser) digits() string {
+	start := p.pos
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
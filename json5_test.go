@@ -0,0 +1,58 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestJSON5ToJSON(t *testing.T) {
+	cases := map[string]string{
+		`{name: 'Bert', 'x-y': "z",}`:              `{"name":"Bert","x-y":"z"}`,
+		`[1, 2, 3,]`:                               `[1,2,3]`,
+		`{a: 0x1F, b: -0xff, c: .5, d: 5., e: +1}`: `{"a":31,"b":-255,"c":0.5,"d":5,"e":1}`,
+		"// header\n{/* inline */ a: 1}":           `{"a":1}`,
+		`'it\'s "quoted"'`:                         `"it's \"quoted\""`,
+		"'line \\\ncontinued'":                     `"line continued"`,
+		`'\x41é <tag>'`:                            `"Aé <tag>"`,
+		`{$id: null, _ok: true}`:                   `{"$id":null,"_ok":true}`,
+	}
+
+	for input, expected := range cases {
+		actual, err := json5ToJSON([]byte(input))
+		if err != nil {
+			t.Fatalf("Expected no error for '%s', got '%s'", input, err)
+		}
+		if string(actual) != expected {
+			t.Fatalf("Expected '%s' for '%s', got '%s'", expected, input, actual)
+		}
+	}
+}
+
+func TestJSON5ToJSONRejectsInvalidInput(t *testing.T) {
+	for _, input := range []string{`{a: Infinity}`, `[NaN]`, `{a: 1} x`, `{a 1}`, `'unterminated`, `[01]`, `/* open`} {
+		if _, err := json5ToJSON([]byte(input)); err == nil {
+			t.Fatalf("Expected error for '%s'", input)
+		}
+	}
+}
+
+func TestUnmarshalJSON5NormalizesOverflow(t *testing.T) {
+	p := PersonData{}
+
+	err := UnmarshalJSON5([]byte(`{
+		// The person's name
+		name: 'Bert',
+		flags: {debug: true, level: 0x10,},
+	}`), &p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+
+	expectedFlags := `{"debug":true,"level":16}`
+	if string(*p.Overflow["flags"]) != expectedFlags {
+		t.Fatalf("Expected '%s', got '%s'", expectedFlags, *p.Overflow["flags"])
+	}
+}
@@ -0,0 +1,263 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// Comments holds the comments attached to the top-level keys of a JSONC
+// document, as captured by UnmarshalJSONCWithComments. Comment text includes
+// its '//' or '/* */' markers, so it can be written back out unchanged.
+type Comments struct {
+	// Comments on the lines before each key
+	Before map[string][]string
+
+	// The comment following each key's value on the same line
+	Trailing map[string]string
+
+	// The top-level keys in the order they appeared in the document
+	Order []string
+}
+
+// Parses the JSONC-encoded data into the struct pointed to by v.
+//
+// JSONC is JSON with '//' and '/* */' comments and trailing commas, as used by
+// VS Code configuration files. Comments are discarded; use
+// UnmarshalJSONCWithComments to keep them.
+func UnmarshalJSONC(data []byte, v interface{}) error {
+	jsonData, _, err := stripJSONC(data, false)
+	if err != nil {
+		return err
+	}
+
+	return UnmarshalJSON(jsonData, v)
+}
+
+// Parses the JSONC-encoded data into the struct pointed to by v, like
+// UnmarshalJSONC, and returns the comments attached to its top-level keys.
+//
+// A comment is attached to the key that follows it, unless it starts on the
+// same line as the end of the previous value, in which case it is that key's
+// trailing comment. Comments outside the top-level object, or after its last
+// key, are not kept.
+func UnmarshalJSONCWithComments(data []byte, v interface{}) (*Comments, error) {
+	jsonData, comments, err := stripJSONC(data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := UnmarshalJSON(jsonData, v); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Returns the JSONC encoding of v, which must be a struct, with the given
+// comments written alongside their keys.
+//
+// Keys listed in comments.Order are written first, in that order, followed
+// by any other keys in sorted order. The output is indented with four spaces.
+func MarshalJSONC(v interface{}, comments *Comments) ([]byte, error) {
+	data, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+
+	if comments == nil {
+		comments = &Comments{}
+	}
+
+	keys := make([]string, 0, len(members))
+	seen := make(map[string]bool, len(members))
+	for _, k := range comments.Order {
+		if _, ok := members[k]; ok && !seen[k] {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := make([]string, 0, len(members))
+	for k := range members {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	const indent = "    "
+	var buffer bytes.Buffer
+	buffer.WriteString("{\n")
+
+	for i, k := range keys {
+		for _, comment := range comments.Before[k] {
+			buffer.WriteString(indent + comment + "\n")
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(indent)
+		buffer.Write(key)
+		buffer.WriteString(": ")
+		if err := json.Indent(&buffer, members[k], indent, indent); err != nil {
+			return nil, err
+		}
+
+		if i < len(keys)-1 {
+			buffer.WriteByte(',')
+		}
+		if trailing, ok := comments.Trailing[k]; ok {
+			buffer.WriteString(" " + trailing)
+		}
+		buffer.WriteByte('\n')
+	}
+
+	buffer.WriteString("}\n")
+	return buffer.Bytes(), nil
+}
+
+// Returns data with comments and trailing commas replaced by spaces, so that
+// offsets in any later parse errors still refer to the original input.
+func stripJSONC(data []byte, capture bool) ([]byte, *Comments, error) {
+	out := append([]byte(nil), data...)
+
+	var comments *Comments
+	if capture {
+		comments = &Comments{Before: make(map[string][]string), Trailing: make(map[string]string)}
+	}
+
+	depth, line := 0, 0
+	expectKey := false
+	currentKey := ""
+	lastTokenLine := -1
+	var pending []string
+
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '\n':
+			line++
+		case c == '"':
+			end, err := jsonStringEnd(data, i)
+			if err != nil {
+				return nil, nil, err
+			}
+			if capture && depth == 1 && expectKey {
+				var key string
+				if err := json.Unmarshal(data[i:end], &key); err != nil {
+					return nil, nil, err
+				}
+				if len(pending) > 0 {
+					comments.Before[key] = pending
+					pending = nil
+				}
+				comments.Order = append(comments.Order, key)
+				currentKey = key
+				expectKey = false
+			}
+			i = end - 1
+			lastTokenLine = line
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end, err := jsoncCommentEnd(data, i)
+			if err != nil {
+				return nil, nil, err
+			}
+			if capture && depth == 1 {
+				comment := string(data[i:end])
+				if currentKey != "" && lastTokenLine == line {
+					comments.Trailing[currentKey] = comment
+				} else {
+					pending = append(pending, comment)
+				}
+			}
+			for j := i; j < end; j++ {
+				if data[j] == '\n' {
+					line++
+				} else {
+					out[j] = ' '
+				}
+			}
+			i = end - 1
+		case c == ',':
+			next := skipJSONCSpace(data, i+1)
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				out[i] = ' '
+			} else if depth == 1 {
+				expectKey = true
+			}
+			lastTokenLine = line
+		case c == '{' || c == '[':
+			depth++
+			if depth == 1 && c == '{' {
+				expectKey = true
+			}
+			lastTokenLine = line
+		case c == '}' || c == ']':
+			depth--
+			lastTokenLine = line
+		case c != ' ' && c != '\t' && c != '\r':
+			lastTokenLine = line
+		}
+	}
+
+	return out, comments, nil
+}
+
+// Returns the offset just after the JSON string starting at start.
+func jsonStringEnd(data []byte, start int) (int, error) {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("Invalid JSONC: unterminated string")
+}
+
+// Returns the offset just after the comment starting at start.
+func jsoncCommentEnd(data []byte, start int) (int, error) {
+	if data[start+1] == '/' {
+		end := bytes.IndexByte(data[start:], '\n')
+		if end < 0 {
+			return len(data), nil
+		}
+		if end > 0 && data[start+end-1] == '\r' {
+			end--
+		}
+		return start + end, nil
+	}
+
+	end := bytes.Index(data[start+2:], []byte("*/"))
+	if end < 0 {
+		return 0, errors.New("Invalid JSONC: unterminated comment")
+	}
+	return start + 2 + end + 2, nil
+}
+
+func skipJSONCSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch c := data[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end, err := jsoncCommentEnd(data, i)
+			if err != nil {
+				return len(data)
+			}
+			i = end
+		default:
+			return i
+		}
+	}
+	return i
+}
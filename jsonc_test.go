@@ -0,0 +1,91 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testJSONC = `// Settings for Bert
+{
+    // The display name
+    /* shown in the title bar */
+    "name": "Bert", // required
+    "editor": {
+        "fontSize": 14, // points
+    },
+    "url": "http://example.com/*not a comment*/",
+}
+`
+
+func TestUnmarshalJSONCIgnoresCommentsAndTrailingCommas(t *testing.T) {
+	p := PersonData{}
+
+	if err := UnmarshalJSONC([]byte(testJSONC), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+
+	expectedURL := `"http://example.com/*not a comment*/"`
+	if string(*p.Overflow["url"]) != expectedURL {
+		t.Fatalf("Expected '%s', got '%s'", expectedURL, *p.Overflow["url"])
+	}
+}
+
+func TestUnmarshalJSONCWithCommentsCapturesTopLevelComments(t *testing.T) {
+	p := PersonData{}
+
+	comments, err := UnmarshalJSONCWithComments([]byte(testJSONC), &p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expectedBefore := []string{"// The display name", "/* shown in the title bar */"}
+	if !reflect.DeepEqual(comments.Before["name"], expectedBefore) {
+		t.Fatalf("Expected '%v', got '%v'", expectedBefore, comments.Before["name"])
+	}
+
+	if comments.Trailing["name"] != "// required" {
+		t.Fatalf("Expected '// required', got '%s'", comments.Trailing["name"])
+	}
+
+	if _, ok := comments.Trailing["editor"]; ok {
+		t.Fatalf("Expected nested comment not to be captured, got '%s'", comments.Trailing["editor"])
+	}
+
+	expectedOrder := []string{"name", "editor", "url"}
+	if !reflect.DeepEqual(comments.Order, expectedOrder) {
+		t.Fatalf("Expected '%v', got '%v'", expectedOrder, comments.Order)
+	}
+}
+
+func TestMarshalJSONCReemitsComments(t *testing.T) {
+	p := PersonData{}
+
+	comments, err := UnmarshalJSONCWithComments([]byte(testJSONC), &p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	p.Name = "Ernie"
+	data, err := MarshalJSONC(&p, comments)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{
+    // The display name
+    /* shown in the title bar */
+    "name": "Ernie", // required
+    "editor": {
+        "fontSize": 14
+    },
+    "url": "http://example.com/*not a comment*/"
+}
+`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
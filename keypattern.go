@@ -0,0 +1,57 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+type keyPattern struct {
+	pattern *regexp.Regexp
+	drop    bool
+}
+
+// Returns an Option that rejects any key that would end up in Overflow but
+// does not match pattern, with an *OverflowError. Named fields are not
+// checked. For example
+//
+//	j2n.WithOverflowKeyPattern(regexp.MustCompile(`^[a-z0-9_]{1,64}$`))
+//
+// keeps keys with control characters or other surprises out of systems that
+// consume Overflow.
+func WithOverflowKeyPattern(pattern *regexp.Regexp) Option {
+	return func(c *config) {
+		c.keyPatterns = append(c.keyPatterns, keyPattern{pattern: pattern})
+	}
+}
+
+// Returns an Option that silently drops any key that would end up in
+// Overflow but does not match pattern.
+func WithOverflowKeyFilter(pattern *regexp.Regexp) Option {
+	return func(c *config) {
+		c.keyPatterns = append(c.keyPatterns, keyPattern{pattern: pattern, drop: true})
+	}
+}
+
+// Applies the key patterns to overflow, dropping keys or failing on the
+// first that does not match, in key order. This happens before any overflow
+// validators are called.
+func (c *config) checkOverflowKeys(overflow map[string]*json.RawMessage) error {
+	if len(c.keyPatterns) == 0 {
+		return nil
+	}
+
+	for _, k := range Overflow(overflow).sortedKeys() {
+		for _, p := range c.keyPatterns {
+			if p.pattern.MatchString(k) {
+				continue
+			}
+			if !p.drop {
+				return &OverflowError{Key: k, Err: fmt.Errorf("Key does not match '%s'", p.pattern)}
+			}
+			delete(overflow, k)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package j2n
+
+import (
+	"regexp"
+	"testing"
+)
+
+var testKeyPattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+func TestWithOverflowKeyPatternRejectsNonConformingKeys(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29,"bad\u0007key":1}`), &p, WithOverflowKeyPattern(testKeyPattern))
+
+	overflowErr, ok := err.(*OverflowError)
+	if !ok || overflowErr.Key != "bad\akey" {
+		t.Fatalf("Expected overflow error for 'bad\\akey', got '%v'", err)
+	}
+
+	expected := `Invalid overflow field 'bad\akey': Key does not match '^[a-z0-9_]{1,64}$'`
+	if err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err)
+	}
+}
+
+func TestWithOverflowKeyFilterDropsNonConformingKeys(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29,"Bad Key":1}`), &p, WithOverflowKeyFilter(testKeyPattern))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(p.Overflow) != 1 || p.Overflow["age"] == nil {
+		t.Fatalf("Expected only 'age' in overflow, got '%v'", p.Overflow)
+	}
+}
@@ -0,0 +1,128 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The presets that can be given in place of a layout in the j2n tag of a
+// time.Time field. The unix presets read and write numbers of seconds,
+// milliseconds, microseconds or nanoseconds since the Unix epoch, and read
+// them from strings too.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+var unixUnits = map[string]time.Duration{
+	"unix":      time.Second,
+	"unixmilli": time.Millisecond,
+	"unixmicro": time.Microsecond,
+	"unixnano":  time.Nanosecond,
+}
+
+// timeCodec reads and writes time.Time fields with the layout in their j2n
+// tag, a time.Parse layout or one of the presets:
+//
+//	Born    time.Time  `json:"born" j2n:"layout=2006-01-02"`
+//	Created time.Time  `json:"created" j2n:"layout=unixmilli"`
+//	Expires *time.Time `json:"expires" j2n:"layout=RFC1123"`
+//
+// Without a layout, encoding/json reads and writes RFC 3339.
+type timeCodec struct {
+	layout string
+	unit   time.Duration
+}
+
+func newTimeCodec(t reflect.Type, layout string) (fieldCodec, error) {
+	if t != timeType {
+		return nil, fmt.Errorf("layout requires a time.Time field, not %s", t)
+	}
+	if layout == "" {
+		return nil, errors.New("layout requires a value")
+	}
+	if unit, ok := unixUnits[layout]; ok {
+		return timeCodec{unit: unit}, nil
+	}
+	if preset, ok := timeLayouts[layout]; ok {
+		layout = preset
+	}
+	return timeCodec{layout: layout}, nil
+}
+
+func (c timeCodec) decode(raw json.RawMessage, value reflect.Value) error {
+	if c.unit != 0 {
+		t, err := c.decodeUnix(raw)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return fmt.Errorf("Expected a time string in the layout '%s'", c.layout)
+	}
+	t, err := time.Parse(c.layout, s)
+	if err != nil {
+		return err
+	}
+	value.Set(reflect.ValueOf(t))
+	return nil
+}
+
+func (c timeCodec) decodeUnix(raw json.RawMessage) (time.Time, error) {
+	text := string(raw)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		seconds, rest := n/int64(time.Second/c.unit), n%int64(time.Second/c.unit)
+		return time.Unix(seconds, rest*int64(c.unit)).UTC(), nil
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Expected a Unix time, got %s", raw)
+	}
+	// Fractional times are only as precise as a float64 of nanoseconds, so
+	// they must fit in one
+	nanoseconds := f * float64(c.unit)
+	if math.IsNaN(nanoseconds) || nanoseconds < math.MinInt64 || nanoseconds >= math.MaxInt64 {
+		return time.Time{}, fmt.Errorf("Unix time out of range: %s", raw)
+	}
+	return time.Unix(0, int64(nanoseconds)).UTC(), nil
+}
+
+func (c timeCodec) encode(value reflect.Value) ([]byte, error) {
+	t := value.Interface().(time.Time)
+	switch c.unit {
+	case time.Second:
+		return []byte(strconv.FormatInt(t.Unix(), 10)), nil
+	case time.Millisecond:
+		return []byte(strconv.FormatInt(t.UnixMilli(), 10)), nil
+	case time.Microsecond:
+		return []byte(strconv.FormatInt(t.UnixMicro(), 10)), nil
+	case time.Nanosecond:
+		return []byte(strconv.FormatInt(t.UnixNano(), 10)), nil
+	}
+	return json.Marshal(t.Format(c.layout))
+}
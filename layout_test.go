@@ -0,0 +1,77 @@
+package j2n
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type LayoutData struct {
+	Born     time.Time  `json:"born" j2n:"layout=2006-01-02"`
+	Created  time.Time  `json:"created" j2n:"layout=unixmilli"`
+	Seen     time.Time  `json:"seen" j2n:"layout=unix"`
+	Expires  *time.Time `json:"expires" j2n:"layout=RFC1123"`
+	Updated  time.Time  `json:"updated"`
+	Overflow Overflow   `json:"-"`
+}
+
+func TestTimeLayouts(t *testing.T) {
+	input := `{"born":"1969-11-10","created":1700000000123,"seen":"1700000000","expires":"Mon, 02 Jan 2006 15:04:05 UTC","updated":"2024-01-02T03:04:05Z"}`
+
+	data := LayoutData{}
+	if err := UnmarshalJSON([]byte(input), &data); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !data.Born.Equal(time.Date(1969, 11, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected 1969-11-10, got %s", data.Born)
+	}
+	if !data.Created.Equal(time.UnixMilli(1700000000123)) || !data.Seen.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("Expected Unix times, got %s and %s", data.Created, data.Seen)
+	}
+	if data.Expires == nil || data.Expires.Year() != 2006 {
+		t.Fatalf("Expected an RFC 1123 time, got %v", data.Expires)
+	}
+	if len(data.Overflow) != 0 {
+		t.Fatalf("Expected no overflow, got %v", data.Overflow)
+	}
+
+	output, err := MarshalJSON(&data)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"born":"1969-11-10","created":1700000000123,"expires":"Mon, 02 Jan 2006 15:04:05 UTC","seen":1700000000,"updated":"2024-01-02T03:04:05Z"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestTimeLayoutErrors(t *testing.T) {
+	err := UnmarshalJSON([]byte(`{"born":"10/11/1969"}`), &LayoutData{})
+	expected := `Cannot decode field 'born': parsing time "10/11/1969" as "2006-01-02": cannot parse "10/11/1969" as "2006"`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	err = UnmarshalJSON([]byte(`{"created":true}`), &LayoutData{})
+	expected = "Cannot decode field 'created': Expected a Unix time, got true"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	for _, input := range []string{`{"seen":1e30}`, `{"seen":-1e30}`, `{"seen":"NaN"}`, `{"seen":"Inf"}`} {
+		err = UnmarshalJSON([]byte(input), &LayoutData{})
+		if err == nil || !strings.HasPrefix(err.Error(), "Cannot decode field 'seen': Unix time out of range") {
+			t.Fatalf("Expected an out of range error for %s, got '%v'", input, err)
+		}
+	}
+}
+
+func TestTimeLayoutsMatchKeysCaseInsensitively(t *testing.T) {
+	data := LayoutData{}
+	if err := UnmarshalJSON([]byte(`{"Born":"2020-01-02","SEEN":1.5}`), &data); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !data.Born.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) || !data.Seen.Equal(time.Unix(1, 5e8)) {
+		t.Fatalf("Expected the layouts to apply, got %s and %s", data.Born, data.Seen)
+	}
+}
@@ -0,0 +1,104 @@
+package j2n
+
+import (
+	"encoding/json"
+)
+
+// Lazy holds a document that is only decoded when it is needed, for
+// services that forward every message but inspect only a few of them:
+//
+//	type Envelope struct {
+//		Route string             `json:"route"`
+//		Body  j2n.Lazy[CatData]  `json:"body"`
+//	}
+//
+// Unmarshalling a Lazy keeps a copy of the document, which Get decodes
+// into a T on first access with UnmarshalJSON, so a message that is never
+// inspected is never decoded. T is a struct with an Overflow field. The
+// document is decoded as UnmarshalJSON would decode it, with the options
+// given to Configure for T and those given to NewLazy, so codecs, tags and
+// overflow options all apply, and Overflow returns the same entries as the
+// value's Overflow field.
+//
+// A Lazy is encoded as the document it holds, byte for byte, unless a new
+// value has been given with Set. Changes made to the value returned by Get
+// are therefore not encoded until it is passed to Set.
+type Lazy[T any] struct {
+	raw  json.RawMessage
+	opts []Option
+
+	value *T
+	err   error
+	set   bool
+}
+
+// Returns a Lazy holding a copy of raw, which is decoded with opts.
+func NewLazy[T any](raw json.RawMessage, opts ...Option) *Lazy[T] {
+	return &Lazy[T]{raw: append(json.RawMessage(nil), raw...), opts: opts}
+}
+
+func (l *Lazy[T]) UnmarshalJSON(data []byte) error {
+	*l = Lazy[T]{raw: append(json.RawMessage(nil), data...), opts: l.opts}
+	return nil
+}
+
+// Returns the document held by l, or the encoding of the value given to
+// Set with MarshalJSON.
+func (l Lazy[T]) MarshalJSON() ([]byte, error) {
+	if l.set && l.value != nil {
+		return MarshalJSON(l.value)
+	}
+	if l.set || l.raw == nil {
+		return []byte("null"), nil
+	}
+	return l.raw, nil
+}
+
+// Returns the document held by l, which must not be modified.
+func (l Lazy[T]) Raw() json.RawMessage {
+	return l.raw
+}
+
+// Reports whether the document has been decoded by Get, or replaced by Set.
+func (l Lazy[T]) Decoded() bool {
+	return l.value != nil
+}
+
+// Returns the value, decoding the document with UnmarshalJSON on the first
+// call. Every call returns the same pointer, or the same error.
+func (l *Lazy[T]) Get() (*T, error) {
+	if l.value != nil || l.err != nil {
+		return l.value, l.err
+	}
+
+	v := new(T)
+	if err := UnmarshalJSON(rawOrNull(&l.raw), v, l.opts...); err != nil {
+		l.err = err
+		return nil, err
+	}
+	l.value = v
+	return v, nil
+}
+
+// Returns the entries of the value's Overflow field, decoding the document
+// with Get if it has not been decoded yet.
+func (l *Lazy[T]) Overflow() (Overflow, error) {
+	if l.set && l.value == nil {
+		return nil, nil
+	}
+	v, err := l.Get()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := getOverflowMap(v)
+	if err != nil {
+		return nil, err
+	}
+	return Overflow(entries), nil
+}
+
+// Replaces the value, which is then encoded with MarshalJSON in place of
+// the document, or as null if v is nil.
+func (l *Lazy[T]) Set(v *T) {
+	l.value, l.err, l.set = v, nil, true
+}
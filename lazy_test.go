@@ -0,0 +1,104 @@
+package j2n
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type LazyEnvelope struct {
+	Route string                   `json:"route"`
+	Body  Lazy[OverflowPersonData] `json:"body"`
+}
+
+func TestLazyForwardsUnchanged(t *testing.T) {
+	input := `{"body":{ "name": "bert",  "age":3 },"route":"a"}`
+
+	var envelope LazyEnvelope
+	if err := json.Unmarshal([]byte(input), &envelope); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if envelope.Body.Decoded() {
+		t.Fatalf("Expected the body not to be decoded")
+	}
+	if _, err := envelope.Body.Get(); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	body, err := envelope.Body.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{ "name": "bert",  "age":3 }`
+	if string(body) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, body)
+	}
+
+	// encoding/json compacts the body, but keeps its members in order
+	output, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"route":"a","body":{"name":"bert","age":3}}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestLazyGetAndOverflow(t *testing.T) {
+	lazy := NewLazy[OverflowPersonData](json.RawMessage(`{"name":"bert","age":3}`))
+
+	person, err := lazy.Get()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if person.Name != "bert" || string(*person.Overflow["age"]) != "3" {
+		t.Fatalf("Expected the document to be decoded, got %+v", person)
+	}
+
+	overflow, err := lazy.Overflow()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(overflow) != 1 || string(*overflow["age"]) != "3" {
+		t.Fatalf("Expected overflow 'age', got %v", overflow)
+	}
+}
+
+func TestLazyDecodesWithOptions(t *testing.T) {
+	lazy := NewLazy[DurationData](json.RawMessage(`{"timeout":"1h","retry":"2s","extra":1}`), WithDurationStrings(time.Millisecond))
+
+	overflow, err := lazy.Overflow()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(overflow) != 1 || string(*overflow["extra"]) != "1" {
+		t.Fatalf("Expected overflow 'extra', got %v", overflow)
+	}
+
+	data, err := lazy.Get()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if data.Timeout != time.Hour || data.Retry == nil || *data.Retry != 2*time.Second {
+		t.Fatalf("Expected 1h and 2s, got %+v", data)
+	}
+}
+
+func TestLazySet(t *testing.T) {
+	lazy := NewLazy[OverflowPersonData](json.RawMessage(`{"name":"bert","age":3}`))
+
+	person, _ := lazy.Get()
+	lazy.Overflow()
+	person.Name = "Bert"
+	lazy.Set(person)
+
+	output, err := json.Marshal(lazy)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"age":3,"name":"Bert"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
@@ -0,0 +1,308 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// limits are the bounds on documents set by the options in this file. A zero
+// maximum means no limit.
+type limits struct {
+	maxSize        int
+	maxDepth       int
+	maxKeys        int
+	duplicateKeys  bool
+	strictUTF8     bool
+	maxOverflowLen int
+}
+
+// Returns an Option bundling the limits that an internet-facing service
+// should set on documents from untrusted clients:
+//
+//	WithMaxSize(1 << 20)
+//	WithMaxDepth(32)
+//	WithMaxKeys(1000)
+//	WithRejectDuplicateKeys()
+//	WithStrictUTF8()
+//	WithMaxOverflowValueSize(64 << 10)
+//
+// Options given after Untrusted take precedence, so a limit can still be
+// raised for a type that needs it:
+//
+//	j2n.Configure(UploadData{}, j2n.Untrusted(), j2n.WithMaxSize(16<<20))
+func Untrusted() Option {
+	opts := []Option{
+		WithMaxSize(1 << 20),
+		WithMaxDepth(32),
+		WithMaxKeys(1000),
+		WithRejectDuplicateKeys(),
+		WithStrictUTF8(),
+		WithMaxOverflowValueSize(64 << 10),
+	}
+
+	return func(c *config) {
+		for _, opt := range opts {
+			opt(c)
+		}
+	}
+}
+
+// Returns an Option that rejects documents larger than size bytes, before
+// they are rewritten or parsed.
+func WithMaxSize(size int) Option {
+	return func(c *config) {
+		c.limits.maxSize = size
+	}
+}
+
+// Returns an Option that rejects documents with objects and arrays nested
+// more than depth deep. The document's own object is at depth 1.
+func WithMaxDepth(depth int) Option {
+	return func(c *config) {
+		c.limits.maxDepth = depth
+	}
+}
+
+// Returns an Option that rejects documents with an object, at any depth,
+// that has more than keys members.
+func WithMaxKeys(keys int) Option {
+	return func(c *config) {
+		c.limits.maxKeys = keys
+	}
+}
+
+// Returns an Option that rejects documents with an object, at any depth,
+// that has the same key more than once. encoding/json keeps the last value
+// of a repeated key, which other parsers may not agree with.
+//
+// encoding/json also matches keys to named fields case-insensitively, so in
+// objects decoded into structs, keys such as "name" and "Name" that match
+// the same field are repeats too. Objects decoded by a type's own
+// UnmarshalJSON method, and those that end up in Overflow, are only checked
+// for exact repeats.
+func WithRejectDuplicateKeys() Option {
+	return func(c *config) {
+		c.limits.duplicateKeys = true
+	}
+}
+
+// Returns an Option that rejects documents that are not valid UTF-8, or
+// that have escaped UTF-16 surrogates that are not paired. encoding/json
+// would otherwise replace them with U+FFFD.
+func WithStrictUTF8() Option {
+	return func(c *config) {
+		c.limits.strictUTF8 = true
+	}
+}
+
+// Returns an Option that rejects any entry that ends up in Overflow with a
+// raw value larger than size bytes, with an *OverflowError.
+func WithMaxOverflowValueSize(size int) Option {
+	return func(c *config) {
+		c.limits.maxOverflowLen = size
+	}
+}
+
+// Checks the limits that apply to the document as it was received.
+func (l *limits) checkSize(data []byte) error {
+	if l.maxSize > 0 && len(data) > l.maxSize {
+		return fmt.Errorf("Document exceeds the maximum size of %d bytes", l.maxSize)
+	}
+	return nil
+}
+
+// Checks the limits on the structure of the document, which is decoded into
+// the struct type t. Invalid JSON is left for the parser to report.
+func (l *limits) checkStructure(data []byte, t reflect.Type) error {
+	if l.maxDepth <= 0 && l.maxKeys <= 0 && !l.duplicateKeys && !l.strictUTF8 {
+		return nil
+	}
+	if l.strictUTF8 && !utf8.Valid(data) {
+		return errors.New("Document is not valid UTF-8")
+	}
+	if !json.Valid(data) {
+		return nil
+	}
+
+	// A frame is an object or array that has been opened but not closed.
+	// When duplicate keys are rejected, typ is the struct type an object is
+	// decoded into, or the element type of an array, and next is the type
+	// of the value of the object's current key.
+	type frame struct {
+		object    bool
+		expectKey bool
+		keys      int
+		seen      map[string]bool
+		typ       reflect.Type
+		next      reflect.Type
+		fields    map[string]string
+	}
+	var stack []*frame
+
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '{', '[':
+			f := &frame{object: data[i] == '{', expectKey: data[i] == '{'}
+			if f.object && l.duplicateKeys {
+				f.seen = make(map[string]bool)
+				f.fields = make(map[string]string)
+			}
+			if l.duplicateKeys {
+				valueType := t
+				if len(stack) > 0 {
+					if top := stack[len(stack)-1]; top.object {
+						valueType = top.next
+					} else {
+						valueType = top.typ
+					}
+				}
+				f.typ = frameType(valueType, f.object)
+			}
+			stack = append(stack, f)
+			if l.maxDepth > 0 && len(stack) > l.maxDepth {
+				return fmt.Errorf("Document exceeds the maximum depth of %d", l.maxDepth)
+			}
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+		case ',':
+			if top := stack[len(stack)-1]; top.object {
+				top.expectKey = true
+			}
+		case '"':
+			end := skipString(data, i)
+			if err := l.checkString(data[i:end], stack != nil && stack[len(stack)-1].expectKey); err != nil {
+				return err
+			}
+			if len(stack) > 0 {
+				if top := stack[len(stack)-1]; top.expectKey {
+					top.expectKey = false
+					top.keys++
+					if l.maxKeys > 0 && top.keys > l.maxKeys {
+						return fmt.Errorf("Object has more than %d keys", l.maxKeys)
+					}
+					if top.seen != nil {
+						var key string
+						if err := json.Unmarshal(data[i:end], &key); err != nil {
+							return err
+						}
+						if top.seen[key] {
+							return fmt.Errorf("Duplicate key '%s'", printableKey(key))
+						}
+						top.seen[key] = true
+
+						top.next = nil
+						if top.typ != nil {
+							if f, ok := namedField(top.typ, key); ok {
+								if first, ok := top.fields[f.name]; ok {
+									return fmt.Errorf("Duplicate key '%s', which matches the same field as '%s'", printableKey(key), printableKey(first))
+								}
+								top.fields[f.name] = key
+								top.next = f.typ
+							}
+						}
+					}
+				}
+			}
+			i = end - 1
+		}
+	}
+	return nil
+}
+
+// Returns the struct type that an object of type t is decoded into field by
+// field, or the element type of an array of type t, or nil if there is none.
+func frameType(t reflect.Type, object bool) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	switch {
+	case object && t.Kind() == reflect.Struct:
+		return t
+	case !object && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		return t.Elem()
+	}
+	return nil
+}
+
+// Checks the escapes of a JSON string, including its quotes, for unpaired
+// surrogates, if strict UTF-8 is required.
+func (l *limits) checkString(s []byte, isKey bool) error {
+	if !l.strictUTF8 {
+		return nil
+	}
+	return checkSurrogates(s, isKey)
+}
+
+// Checks the escapes of a JSON string, including its quotes, for unpaired
+// surrogates, which encoding/json decodes to U+FFFD whatever their value.
+func checkSurrogates(s []byte, isKey bool) error {
+	pending := false
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] != '\\' {
+			if pending {
+				return unpairedSurrogateError(isKey)
+			}
+			continue
+		}
+		i++
+		if s[i] != 'u' {
+			if pending {
+				return unpairedSurrogateError(isKey)
+			}
+			continue
+		}
+
+		r, _ := strconv.ParseUint(string(s[i+1:i+5]), 16, 16)
+		i += 4
+		switch {
+		case utf16.IsSurrogate(rune(r)) && r < 0xdc00:
+			if pending {
+				return unpairedSurrogateError(isKey)
+			}
+			pending = true
+		case utf16.IsSurrogate(rune(r)):
+			if !pending {
+				return unpairedSurrogateError(isKey)
+			}
+			pending = false
+		case pending:
+			return unpairedSurrogateError(isKey)
+		}
+	}
+	if pending {
+		return unpairedSurrogateError(isKey)
+	}
+	return nil
+}
+
+func unpairedSurrogateError(isKey bool) error {
+	if isKey {
+		return errors.New("Key has an unpaired UTF-16 surrogate")
+	}
+	return errors.New("String has an unpaired UTF-16 surrogate")
+}
+
+// Checks the size of the values in overflow, in key order.
+func (l *limits) checkOverflow(overflow map[string]*json.RawMessage) error {
+	if l.maxOverflowLen <= 0 {
+		return nil
+	}
+
+	for _, k := range Overflow(overflow).sortedKeys() {
+		if size := len(rawOrNull(overflow[k])); size > l.maxOverflowLen {
+			return &OverflowError{Key: k, Err: fmt.Errorf("Value of %d bytes exceeds the maximum of %d", size, l.maxOverflowLen)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package j2n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimitsRejectDocuments(t *testing.T) {
+	cases := []struct {
+		data     string
+		opt      Option
+		expected string
+	}{
+		{`{"name":"Bert","x":"abcdef"}`, WithMaxSize(20), "Document exceeds the maximum size of 20 bytes"},
+		{`{"name":"Bert","x":{"y":[1]}}`, WithMaxDepth(2), "Document exceeds the maximum depth of 2"},
+		{`{"name":"Bert","x":{"a":1,"b":2,"c":3}}`, WithMaxKeys(2), "Object has more than 2 keys"},
+		{`{"name":"Bert","x":{"a":1,"a":2}}`, WithRejectDuplicateKeys(), "Duplicate key 'a'"},
+		{`{"name":"Bert","Name":"Ernie"}`, WithRejectDuplicateKeys(), "Duplicate key 'Name', which matches the same field as 'name'"},
+		{"{\"name\":\"B\xffrt\"}", WithStrictUTF8(), "Document is not valid UTF-8"},
+		{`{"name":"\ud800"}`, WithStrictUTF8(), "String has an unpaired UTF-16 surrogate"},
+		{`{"\udc00":1}`, WithStrictUTF8(), "Key has an unpaired UTF-16 surrogate"},
+		{`{"name":"Bert","x":"abcdef"}`, WithMaxOverflowValueSize(4), "Invalid overflow field 'x': Value of 8 bytes exceeds the maximum of 4"},
+	}
+
+	for _, c := range cases {
+		p := OverflowPersonData{}
+		err := UnmarshalJSON([]byte(c.data), &p, c.opt)
+		if err == nil || err.Error() != c.expected {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", c.expected, c.data, err)
+		}
+	}
+}
+
+func TestLimitsAcceptDocumentsWithinThem(t *testing.T) {
+	data := `{"name":"Bert 😀","x":{"a":[1,{"b":"{[,"}],"c":"a\"b"},"y":"é\u00e9\ud83d\ude00"}`
+
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(data), &p, Untrusted(), WithMaxDepth(4), WithMaxKeys(3))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if p.Name != "Bert 😀" {
+		t.Fatalf("Expected 'Bert 😀', got '%s'", p.Name)
+	}
+}
+
+func TestRejectDuplicateKeysFoldsNestedFieldKeys(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type Person struct {
+		Addresses []*Address `json:"addresses"`
+		Overflow  Overflow   `json:"-"`
+	}
+
+	data := `{"addresses":[{"city":"Leeds"},{"city":"York","CITY":"Hull"}]}`
+	err := UnmarshalJSON([]byte(data), &Person{}, WithRejectDuplicateKeys())
+	expected := "Duplicate key 'CITY', which matches the same field as 'city'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	// Keys that end up in Overflow are only compared exactly
+	data = `{"addresses":[],"extra":{"a":1,"A":2},"x":1,"X":2}`
+	if err := UnmarshalJSON([]byte(data), &Person{}, WithRejectDuplicateKeys()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestUntrustedCanBeRelaxed(t *testing.T) {
+	data := `{"name":"` + strings.Repeat("a", 2<<20) + `"}`
+
+	p := OverflowPersonData{}
+	if err := UnmarshalJSON([]byte(data), &p, Untrusted()); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if err := UnmarshalJSON([]byte(data), &p, Untrusted(), WithMaxSize(4<<20)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestLimitsLeaveInvalidJSONToTheParser(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert",}`), &p, Untrusted())
+	if err == nil || !strings.Contains(err.Error(), "invalid character") {
+		t.Fatalf("Expected a syntax error, got '%v'", err)
+	}
+}
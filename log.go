@@ -0,0 +1,178 @@
+package j2n
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Logger receives the warnings that j2n logs, such as unknown keys with
+// WarnUnknown and deprecated keys. *slog.Logger implements it, and
+// SlogLogger and NopLogger adapt the usual choices.
+type Logger interface {
+	Warn(msg string, args ...interface{})
+}
+
+// Returns a Logger that writes to logger, or to slog.Default at the time of
+// each warning if logger is nil.
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Warn(msg string, args ...interface{}) {
+	if l.logger == nil {
+		slog.Default().Warn(msg, args...)
+		return
+	}
+	l.logger.Warn(msg, args...)
+}
+
+// Returns a Logger that discards every warning.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Warn(msg string, args ...interface{}) {}
+
+var defaultLogger atomic.Pointer[Logger]
+
+// Sets the Logger for warnings of every call that is not given one with
+// WithWarningLogger or WithLogger. A nil logger restores the default, which
+// is SlogLogger(nil).
+func SetLogger(logger Logger) {
+	if logger == nil {
+		defaultLogger.Store(nil)
+		return
+	}
+	defaultLogger.Store(&logger)
+}
+
+// Returns an Option that logs warnings to logger, rather than to the logger
+// given with WithLogger or SetLogger.
+func WithWarningLogger(logger Logger) Option {
+	return func(c *config) {
+		c.warnLogger = logger
+	}
+}
+
+// Returns an Option that logs diagnostics for each call to UnmarshalJSON to
+// logger at debug level: the keys that went to Overflow, the named fields
+// that the document did not contain, the time spent in each phase, and the
+// error, if any. This answers "where did my field go" without a debugger:
+//
+//	j2n.Configure(CatData{}, j2n.WithLogger(slog.Default()))
+//
+// Warnings are also logged to logger, unless WithWarningLogger is given.
+// Nothing is measured unless logger is enabled at debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// Returns the Logger for warnings: the one given with WithWarningLogger or
+// WithLogger, or else the one set with SetLogger.
+func (c *config) warningLogger() Logger {
+	switch {
+	case c.warnLogger != nil:
+		return c.warnLogger
+	case c.logger != nil:
+		return c.logger
+	}
+	if logger := defaultLogger.Load(); logger != nil {
+		return *logger
+	}
+	return SlogLogger(nil)
+}
+
+// A callLog gathers the diagnostics of one call to UnmarshalJSON. Its
+// methods do nothing on a nil callLog, which is what startLog returns when
+// debug logging is off.
+type callLog struct {
+	logger   *slog.Logger
+	v        interface{}
+	start    time.Time
+	last     time.Time
+	phases   []slog.Attr
+	document []string
+	overflow []string
+}
+
+func (c *config) startLog(v interface{}) *callLog {
+	if c.logger == nil || !c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return nil
+	}
+	now := time.Now()
+	return &callLog{logger: c.logger, v: v, start: now, last: now}
+}
+
+// Records the time since the previous phase ended as the duration of the
+// phase called name.
+func (l *callLog) phase(name string) {
+	if l == nil {
+		return
+	}
+	now := time.Now()
+	l.phases = append(l.phases, slog.Duration(name, now.Sub(l.last)))
+	l.last = now
+}
+
+// Records the keys of the document, before the named fields are removed.
+func (l *callLog) documentKeys(document map[string]*json.RawMessage) {
+	if l == nil {
+		return
+	}
+	l.document = Overflow(document).sortedKeys()
+}
+
+func (l *callLog) overflowKeys(overflow map[string]*json.RawMessage) {
+	if l == nil {
+		return
+	}
+	l.overflow = Overflow(overflow).sortedKeys()
+}
+
+// Logs the diagnostics gathered, with err if the call failed.
+func (l *callLog) done(err error) {
+	if l == nil {
+		return
+	}
+
+	present := make(map[string]bool, len(l.document))
+	for _, k := range l.document {
+		present[k] = true
+	}
+	missing := []string{}
+	for _, f := range namedFields(structType(l.v)) {
+		if !present[f.name] {
+			missing = append(missing, f.name)
+		}
+	}
+	sort.Strings(missing)
+
+	overflow := l.overflow
+	if overflow == nil {
+		overflow = []string{}
+	}
+
+	attrs := []slog.Attr{
+		slog.String("type", structType(l.v).String()),
+		slog.Any("overflow", overflow),
+		slog.Any("missing", missing),
+		slog.Duration("elapsed", time.Since(l.start)),
+		slog.Any("phases", slog.GroupValue(l.phases...)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, "j2n.UnmarshalJSON", attrs...)
+}
@@ -0,0 +1,144 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+type LoggedCatData struct {
+	Name     string                      `json:"name"`
+	Colour   string                      `json:"colour"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestWithLoggerLogsDiagnostics(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := LoggedCatData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Tom","color":"grey"}`), &c, WithLogger(logger)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var record struct {
+		Msg      string
+		Type     string
+		Overflow []string
+		Missing  []string
+		Phases   map[string]int64
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if record.Msg != "j2n.UnmarshalJSON" || record.Type != "j2n.LoggedCatData" {
+		t.Fatalf("Expected a record for j2n.LoggedCatData, got '%s'", buffer.String())
+	}
+	if !reflect.DeepEqual(record.Overflow, []string{"color"}) {
+		t.Fatalf("Expected '[color]', got '%v'", record.Overflow)
+	}
+	if !reflect.DeepEqual(record.Missing, []string{"colour"}) {
+		t.Fatalf("Expected '[colour]', got '%v'", record.Missing)
+	}
+	for _, phase := range []string{"rewrite", "overflow", "fields", "split", "checks", "afterUnmarshal"} {
+		if _, ok := record.Phases[phase]; !ok {
+			t.Fatalf("Expected a timing for phase '%s', got '%s'", phase, buffer.String())
+		}
+	}
+}
+
+func TestWithLoggerLogsErrors(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c := LoggedCatData{}
+	if err := UnmarshalJSON([]byte(`{"name":3}`), &c, WithLogger(logger)); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	var record struct{ Error string }
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if record.Error == "" {
+		t.Fatalf("Expected the error to be logged, got '%s'", buffer.String())
+	}
+}
+
+func TestWithLoggerIsQuietAboveDebug(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, nil))
+
+	c := LoggedCatData{}
+	err := UnmarshalJSON([]byte(`{"name":"Tom","color":"grey"}`), &c, WithLogger(logger), WithUnknownFields(WarnUnknown))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var record struct{ Msg, Key string }
+	if err := json.Unmarshal(buffer.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one warning, got '%s'", buffer.String())
+	}
+	if record.Msg != "Unknown JSON field" || record.Key != "color" {
+		t.Fatalf("Expected a warning for 'color', got '%s'", buffer.String())
+	}
+}
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Warn(msg string, args ...interface{}) {
+	l.messages = append(l.messages, msg)
+}
+
+func TestWithWarningLoggerTakesPrecedence(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := &recordingLogger{}
+
+	c := LoggedCatData{}
+	err := UnmarshalJSON([]byte(`{"name":"Tom","color":"grey"}`), &c,
+		WithLogger(slog.New(slog.NewJSONHandler(&buffer, nil))),
+		WithWarningLogger(logger),
+		WithUnknownFields(WarnUnknown),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if !reflect.DeepEqual(logger.messages, []string{"Unknown JSON field"}) {
+		t.Fatalf("Expected one warning, got '%v'", logger.messages)
+	}
+	if buffer.Len() != 0 {
+		t.Fatalf("Expected nothing in the slog logger, got '%s'", buffer.String())
+	}
+}
+
+func TestSetLoggerSetsTheDefault(t *testing.T) {
+	logger := &recordingLogger{}
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	c := LoggedCatData{}
+	if err := UnmarshalJSON([]byte(`{"fullname":"Tom"}`), &c, WithDeprecatedKey("fullname", "name")); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !reflect.DeepEqual(logger.messages, []string{"Deprecated JSON field"}) {
+		t.Fatalf("Expected one warning, got '%v'", logger.messages)
+	}
+
+	// A per-call logger still takes precedence
+	err := UnmarshalJSON([]byte(`{"fullname":"Tom"}`), &c,
+		WithDeprecatedKey("fullname", "name"),
+		WithWarningLogger(NopLogger()),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if len(logger.messages) != 1 {
+		t.Fatalf("Expected one warning, got '%v'", logger.messages)
+	}
+}
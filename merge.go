@@ -0,0 +1,217 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ArrayStrategy selects how a merge combines an array in the base document
+// with an array at the same location in the overlay.
+type ArrayStrategy int
+
+const (
+	// ReplaceArrays uses the overlay's array. This is the default.
+	ReplaceArrays ArrayStrategy = iota
+
+	// AppendArrays appends the overlay's elements to the base's.
+	AppendArrays
+
+	// MergeArraysByIndex merges elements at the same index, keeping any
+	// extra elements from the longer array.
+	MergeArraysByIndex
+
+	// MergeArraysByKey merges objects whose key field, set with
+	// WithArrayMergeKey, is equal, and appends the rest of the overlay's
+	// elements. Arrays with elements that lack the key are replaced.
+	MergeArraysByKey
+)
+
+// MergeOption configures MergeJSON and DeepMerge.
+type MergeOption func(*mergeConfig)
+
+type mergeConfig struct {
+	arrays   ArrayStrategy
+	arrayKey string
+}
+
+// Returns a MergeOption that sets how arrays are combined.
+func WithArrayStrategy(strategy ArrayStrategy) MergeOption {
+	return func(c *mergeConfig) {
+		c.arrays = strategy
+	}
+}
+
+// Returns a MergeOption that merges arrays of objects by the value of their
+// key field, as with MergeArraysByKey.
+func WithArrayMergeKey(key string) MergeOption {
+	return func(c *mergeConfig) {
+		c.arrays = MergeArraysByKey
+		c.arrayKey = key
+	}
+}
+
+// Returns the deep merge of the JSON documents base and overlay.
+//
+// Objects are merged key by key, recursively, so keys from either document
+// survive whether or not any struct names them. A null in the overlay
+// removes the key from the result, as in a JSON merge patch. Other values
+// in the overlay replace those in the base, and arrays are combined as set
+// by WithArrayStrategy. Numbers are copied verbatim.
+func MergeJSON(base, overlay []byte, opts ...MergeOption) ([]byte, error) {
+	config := &mergeConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	var baseValue, overlayValue interface{}
+	if err := decodeUsingNumber(base, &baseValue); err != nil {
+		return nil, err
+	}
+	if err := decodeUsingNumber(overlay, &overlayValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(config.merge(baseValue, overlayValue))
+}
+
+// Deep merges src into dst, which must be a pointer to a struct. Both are
+// marshaled with MarshalJSON, merged as with MergeJSON, and the result is
+// parsed back into dst with UnmarshalJSON, so the Overflow entries of both
+// take part in the merge.
+//
+// Every named field that src marshals replaces the one in dst, including
+// zero values; fields tagged omitempty or omitzero are left alone when
+// they are omitted.
+func DeepMerge(dst, src interface{}, opts ...MergeOption) error {
+	if value := reflect.ValueOf(dst); value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("Expected pointer to struct, got %T", dst)
+	}
+
+	base, err := MarshalJSON(dst)
+	if err != nil {
+		return err
+	}
+
+	overlay, err := MarshalJSON(src)
+	if err != nil {
+		return err
+	}
+
+	merged, err := MergeJSON(base, overlay, opts...)
+	if err != nil {
+		return err
+	}
+
+	return UnmarshalJSON(merged, dst)
+}
+
+func (c *mergeConfig) merge(base, overlay interface{}) interface{} {
+	switch overlay := overlay.(type) {
+	case map[string]interface{}:
+		baseObject, ok := base.(map[string]interface{})
+		if !ok {
+			return withoutNulls(overlay)
+		}
+
+		merged := make(map[string]interface{}, len(baseObject)+len(overlay))
+		for k, v := range baseObject {
+			merged[k] = v
+		}
+		for k, v := range overlay {
+			if v == nil {
+				delete(merged, k)
+			} else if existing, ok := merged[k]; ok {
+				merged[k] = c.merge(existing, v)
+			} else {
+				merged[k] = withoutNulls(v)
+			}
+		}
+		return merged
+	case []interface{}:
+		if baseArray, ok := base.([]interface{}); ok {
+			return c.mergeArrays(baseArray, overlay)
+		}
+	}
+
+	return overlay
+}
+
+func (c *mergeConfig) mergeArrays(base, overlay []interface{}) []interface{} {
+	switch c.arrays {
+	case AppendArrays:
+		return append(append([]interface{}(nil), base...), overlay...)
+	case MergeArraysByIndex:
+		merged := append([]interface{}(nil), base...)
+		for i, v := range overlay {
+			if i < len(merged) {
+				merged[i] = c.merge(merged[i], v)
+			} else {
+				merged = append(merged, v)
+			}
+		}
+		return merged
+	case MergeArraysByKey:
+		if merged, ok := c.mergeArraysByKey(base, overlay); ok {
+			return merged
+		}
+	}
+
+	return overlay
+}
+
+func (c *mergeConfig) mergeArraysByKey(base, overlay []interface{}) ([]interface{}, bool) {
+	keyOf := func(v interface{}) (string, bool) {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		key, ok := object[c.arrayKey]
+		if !ok {
+			return "", false
+		}
+		encoded, err := json.Marshal(key)
+		return string(encoded), err == nil
+	}
+
+	merged := append([]interface{}(nil), base...)
+	index := make(map[string]int, len(base))
+	for i, v := range base {
+		key, ok := keyOf(v)
+		if !ok {
+			return nil, false
+		}
+		index[key] = i
+	}
+
+	for _, v := range overlay {
+		key, ok := keyOf(v)
+		if !ok {
+			return nil, false
+		}
+		if i, ok := index[key]; ok {
+			merged[i] = c.merge(merged[i], v)
+		} else {
+			index[key] = len(merged)
+			merged = append(merged, v)
+		}
+	}
+	return merged, true
+}
+
+// Returns value with the null members of its objects removed, as they would
+// be if merged into an empty object.
+func withoutNulls(value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+
+	result := make(map[string]interface{}, len(object))
+	for k, v := range object {
+		if v != nil {
+			result[k] = withoutNulls(v)
+		}
+	}
+	return result
+}
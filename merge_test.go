@@ -0,0 +1,68 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestMergeJSONMergesObjectsRecursively(t *testing.T) {
+	merged, err := MergeJSON(
+		[]byte(`{"name":"Bert","address":{"city":"Leeds","zip":"LS1"},"age":29,"big":12345678901234567890}`),
+		[]byte(`{"address":{"city":"York","line":{"a":null,"b":1}},"age":null,"tags":["a"]}`),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"address":{"city":"York","line":{"b":1},"zip":"LS1"},"big":12345678901234567890,"name":"Bert","tags":["a"]}`
+	if string(merged) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, merged)
+	}
+}
+
+func TestMergeJSONArrayStrategies(t *testing.T) {
+	base := []byte(`{"a":[{"id":1,"x":1},{"id":2,"x":2}]}`)
+	overlay := []byte(`{"a":[{"id":2,"y":3},{"id":3}]}`)
+
+	for _, test := range []struct {
+		opts     []MergeOption
+		expected string
+	}{
+		{nil, `{"a":[{"id":2,"y":3},{"id":3}]}`},
+		{[]MergeOption{WithArrayStrategy(AppendArrays)}, `{"a":[{"id":1,"x":1},{"id":2,"x":2},{"id":2,"y":3},{"id":3}]}`},
+		{[]MergeOption{WithArrayStrategy(MergeArraysByIndex)}, `{"a":[{"id":2,"x":1,"y":3},{"id":3,"x":2}]}`},
+		{[]MergeOption{WithArrayMergeKey("id")}, `{"a":[{"id":1,"x":1},{"id":2,"x":2,"y":3},{"id":3}]}`},
+	} {
+		merged, err := MergeJSON(base, overlay, test.opts...)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if string(merged) != test.expected {
+			t.Fatalf("Expected '%s', got '%s'", test.expected, merged)
+		}
+	}
+}
+
+func TestDeepMergeKeepsOverflowFromBoth(t *testing.T) {
+	dst := PersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","settings":{"theme":"dark"}}`), &dst); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	src := PersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Ernie","settings":{"font":"mono"}}`), &src); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if err := DeepMerge(&dst, &src); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if dst.Name != "Ernie" {
+		t.Fatalf("Expected 'Ernie', got '%s'", dst.Name)
+	}
+
+	expected := `{"font":"mono","theme":"dark"}`
+	if string(*dst.Overflow["settings"]) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, *dst.Overflow["settings"])
+	}
+}
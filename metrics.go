@@ -0,0 +1,60 @@
+package j2n
+
+import "encoding/json"
+
+// MetricsHook is told about every key that ends up in the Overflow field, so
+// that unknown fields can be counted and alerted on as a sign of schema
+// drift. The j2nprometheus and j2notel packages adapt it to Prometheus and
+// OpenTelemetry.
+//
+// ObserveOverflow may be called concurrently by concurrent calls to
+// UnmarshalJSON.
+type MetricsHook interface {
+	// Called with the name of the struct type being parsed, the unknown
+	// key, and the size in bytes of its raw JSON value.
+	ObserveOverflow(typeName, key string, size int)
+}
+
+// MetricsHookFunc adapts a function to a MetricsHook.
+type MetricsHookFunc func(typeName, key string, size int)
+
+func (f MetricsHookFunc) ObserveOverflow(typeName, key string, size int) {
+	f(typeName, key, size)
+}
+
+// Returns an Option that reports each key in Overflow to hook, in key
+// order, once keys dropped by WithOverflowKeyFilter have been removed. Keys
+// are reported even if the document is then rejected, for example by
+// RejectUnknown, so rejected drift is visible too.
+//
+// A hook is normally registered for every type that needs watching:
+//
+//	hook := j2nprometheus.NewHook("myapp")
+//	prometheus.MustRegister(hook)
+//	j2n.Configure(CatData{}, j2n.WithMetricsHook(hook))
+func WithMetricsHook(hook MetricsHook) Option {
+	return func(c *config) {
+		c.metricsHooks = append(c.metricsHooks, hook)
+	}
+}
+
+func (c *config) observeOverflow(v interface{}, overflow map[string]*json.RawMessage) error {
+	if len(c.metricsHooks) == 0 || len(overflow) == 0 {
+		return nil
+	}
+
+	typeName := structType(v).String()
+	for _, k := range Overflow(overflow).sortedKeys() {
+		size := len(rawOrNull(overflow[k]))
+		for _, hook := range c.metricsHooks {
+			err := callHook("MetricsHook", k, func() error {
+				hook.ObserveOverflow(typeName, k, size)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
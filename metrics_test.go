@@ -0,0 +1,44 @@
+package j2n
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestWithMetricsHookReportsOverflowKeys(t *testing.T) {
+	var observed []string
+	hook := MetricsHookFunc(func(typeName, key string, size int) {
+		observed = append(observed, fmt.Sprintf("%s %s %d", typeName, key, size))
+	})
+
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29,"_tmp":1,"tags":["a","b"]}`), &p,
+		WithMetricsHook(hook),
+		WithOverflowKeyFilter(regexp.MustCompile(`^[a-z]`)),
+		WithUnknownFields(RejectUnknown),
+	)
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	expected := []string{"j2n.PersonData age 2", "j2n.PersonData tags 9"}
+	if !reflect.DeepEqual(observed, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, observed)
+	}
+}
+
+func TestWithMetricsHookIgnoresNamedFields(t *testing.T) {
+	called := false
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &p, WithMetricsHook(MetricsHookFunc(func(typeName, key string, size int) {
+		called = true
+	})))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if called {
+		t.Fatalf("Expected the hook not to be called")
+	}
+}
@@ -0,0 +1,188 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// MigrationFunc rewrites the top-level keys of a document in place.
+type MigrationFunc func(document map[string]*json.RawMessage) error
+
+// Migrations is an ordered set of document migrations, chosen by the value
+// of a version key in each document. They are applied to the raw document
+// before it is parsed, so keys that no migration touches still end up in
+// Overflow.
+//
+//	migrations := j2n.NewMigrations("version").
+//		Register("1", "2", j2n.Rename("fullname", "name")).
+//		Register("2", "3", j2n.Move("addr", "address", "street"))
+//
+//	err := j2n.UnmarshalJSON(data, &c.CatData, j2n.WithMigrations(migrations))
+type Migrations struct {
+	versionKey string
+	steps      map[string]migrationStep
+}
+
+type migrationStep struct {
+	to string
+	fn MigrationFunc
+}
+
+// Returns an empty set of migrations keyed off versionKey.
+func NewMigrations(versionKey string) *Migrations {
+	return &Migrations{versionKey: versionKey, steps: make(map[string]migrationStep)}
+}
+
+// Registers fn to migrate documents at version from to version to, and
+// returns m. Documents without a version key are at version "". Numeric
+// versions are compared by their text, so 1 and "1" are the same version.
+func (m *Migrations) Register(from, to string, fn MigrationFunc) *Migrations {
+	m.steps[from] = migrationStep{to: to, fn: fn}
+	return m
+}
+
+// Applies each migration in turn to the JSON-encoded document, starting at
+// its current version, and returns the result with the version key updated.
+// A document with no migration for its version, or null, is returned
+// unchanged.
+func (m *Migrations) Apply(data []byte) ([]byte, error) {
+	var document map[string]*json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return data, nil
+	}
+
+	version, numeric, err := m.version(document)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := m.steps[version]
+	if !ok {
+		return data, nil
+	}
+
+	seen := make(map[string]bool)
+	for ok {
+		if seen[version] {
+			return nil, fmt.Errorf("Migrations loop at version '%s'", version)
+		}
+		seen[version] = true
+
+		if err := step.fn(document); err != nil {
+			return nil, fmt.Errorf("Migrating from version '%s' to '%s': %s", version, step.to, err)
+		}
+		version = step.to
+		step, ok = m.steps[version]
+	}
+
+	raw := json.RawMessage(strconv.Quote(version))
+	if _, err := strconv.ParseFloat(version, 64); err == nil && numeric {
+		raw = json.RawMessage(version)
+	}
+	document[m.versionKey] = &raw
+
+	return json.Marshal(document)
+}
+
+// Returns the version of document and whether it was given as a number.
+func (m *Migrations) version(document map[string]*json.RawMessage) (string, bool, error) {
+	raw := document[m.versionKey]
+	if raw == nil {
+		return "", false, nil
+	}
+
+	trimmed := bytes.TrimSpace(*raw)
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var version string
+		if err := json.Unmarshal(trimmed, &version); err != nil {
+			return "", false, err
+		}
+		return version, false, nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(trimmed, &number); err != nil {
+		return "", false, fmt.Errorf("Version key '%s' must be a string or number", m.versionKey)
+	}
+	return number.String(), true, nil
+}
+
+// Returns an Option that applies m to the raw document before it is
+// validated and parsed.
+func WithMigrations(m *Migrations) Option {
+	return func(c *config) {
+		c.rewriters = append(c.rewriters, m.Apply)
+		c.migrations = append(c.migrations, m)
+	}
+}
+
+// Returns a MigrationFunc that renames the key from to to. It does nothing
+// if from is absent, and fails if to is already present.
+func Rename(from, to string) MigrationFunc {
+	return func(document map[string]*json.RawMessage) error {
+		raw, ok := document[from]
+		if !ok {
+			return nil
+		}
+		if _, ok := document[to]; ok {
+			return fmt.Errorf("Cannot rename '%s' to '%s', which is already present", from, to)
+		}
+
+		delete(document, from)
+		document[to] = raw
+		return nil
+	}
+}
+
+// Returns a MigrationFunc that moves the top-level key to the nested
+// location given by path, creating objects along the way. It does nothing
+// if key is absent.
+func Move(key string, path ...string) MigrationFunc {
+	return func(document map[string]*json.RawMessage) error {
+		raw, ok := document[key]
+		if !ok {
+			return nil
+		}
+		if len(path) == 0 {
+			return fmt.Errorf("Cannot move '%s' to an empty path", key)
+		}
+
+		delete(document, key)
+		return setPath(document, path, raw)
+	}
+}
+
+func setPath(object map[string]*json.RawMessage, path []string, value *json.RawMessage) error {
+	if len(path) == 1 {
+		if _, ok := object[path[0]]; ok {
+			return fmt.Errorf("Cannot move to '%s', which is already present", path[0])
+		}
+		object[path[0]] = value
+		return nil
+	}
+
+	child := make(map[string]*json.RawMessage)
+	if raw := object[path[0]]; raw != nil {
+		if err := json.Unmarshal(*raw, &child); err != nil {
+			return fmt.Errorf("Cannot move under '%s', which is not an object", path[0])
+		}
+	}
+
+	if err := setPath(child, path[1:], value); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(child)
+	if err != nil {
+		return err
+	}
+
+	raw := json.RawMessage(encoded)
+	object[path[0]] = &raw
+	return nil
+}
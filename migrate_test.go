@@ -0,0 +1,80 @@
+package j2n
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func testMigrations() *Migrations {
+	return NewMigrations("version").
+		Register("1", "2", Rename("fullname", "name")).
+		Register("2", "3", Move("addr", "address", "street"))
+}
+
+func TestWithMigrationsAppliesStepsInOrder(t *testing.T) {
+	p := PersonData{}
+	input := `{"version":1,"fullname":"Bert","addr":"1 Sesame St","age":29}`
+	if err := UnmarshalJSON([]byte(input), &p, WithMigrations(testMigrations())); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Name != "Bert" {
+		t.Fatalf("Expected 'Bert', got '%s'", p.Name)
+	}
+
+	expected := map[string]string{
+		"version": `3`,
+		"address": `{"street":"1 Sesame St"}`,
+		"age":     `29`,
+	}
+	if len(p.Overflow) != len(expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, p.Overflow)
+	}
+	for k, v := range expected {
+		if p.Overflow[k] == nil || string(*p.Overflow[k]) != v {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", v, k, p.Overflow[k])
+		}
+	}
+}
+
+func TestMigrationsStartAtDocumentVersion(t *testing.T) {
+	data, err := testMigrations().Apply([]byte(`{"version":"2","fullname":"Bert","addr":"x"}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"address":{"street":"x"},"fullname":"Bert","version":"3"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+func TestMigrationsLeaveCurrentDocumentsUnchanged(t *testing.T) {
+	input := `{"version":3, "name":"Bert"}`
+	data, err := testMigrations().Apply([]byte(input))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(data) != input {
+		t.Fatalf("Expected '%s', got '%s'", input, data)
+	}
+}
+
+func TestMigrationsLeaveNullUnchanged(t *testing.T) {
+	noop := func(document map[string]*json.RawMessage) error { return nil }
+	data, err := NewMigrations("version").Register("", "1", noop).Apply([]byte("null"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(data) != "null" {
+		t.Fatalf("Expected 'null', got '%s'", data)
+	}
+}
+
+func TestMigrationsReturnErrorOnConflict(t *testing.T) {
+	_, err := testMigrations().Apply([]byte(`{"version":1,"fullname":"Bert","name":"Ernie"}`))
+	if err == nil {
+		t.Fatal("Expected error renaming onto an existing key")
+	}
+}
@@ -0,0 +1,252 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Option configures a call to UnmarshalJSON, or to MarshalJSON or Sign for
+// the options that say so. Defaults for a struct type can be set with Configure.
+type Option func(*config)
+
+type config struct {
+	rewriters          []func(data []byte) ([]byte, error)
+	validators         []func(data []byte) error
+	keyPatterns        []keyPattern
+	keySafety          keySafety
+	overflowValidators []func(key string, raw json.RawMessage) error
+	afterUnmarshal     []func(v interface{}) error
+
+	collectOverflowErrors bool
+
+	limits limits
+
+	unknownFields UnknownFieldPolicy
+	warnUnknown   func(key string, raw json.RawMessage)
+	unknownReport *UnknownFieldReport
+
+	deprecations   map[string]string
+	warnDeprecated func(d Deprecation)
+
+	rules []Rule
+
+	metricsHooks []MetricsHook
+	debugSinks   []DebugSink
+	logger       *slog.Logger
+	warnLogger   Logger
+	rawPayload   bool
+	surgicalEdit bool
+	interpolate  bool
+	envAllowlist []string
+
+	scrubbers       []*Scrubber
+	outputScrubbers []*Scrubber
+	ciphers         []encryptedKeys
+
+	signatureKey  string
+	keyOrder      []string
+	view          string
+	viewOverflow  map[string]ViewOverflow
+	viewNamespace map[string]string
+	typeCodecs    map[reflect.Type]fieldCodec
+
+	overflowPositions bool
+	errorPositions    bool
+	provenance        *Provenance
+	freeze            bool
+
+	beforeRouting []func(v interface{}, document Overflow) error
+	beforeEncode  []func(v interface{}, result Overflow) error
+
+	// Recorded only for Describe
+	migrations          []*Migrations
+	overflowTypes       map[string]reflect.Type
+	extensionsOnly      bool
+	describedValidators int
+}
+
+// Returns the configuration for a call on v: the defaults registered for
+// its type, followed by opts.
+func newConfig(v interface{}, opts []Option) *config {
+	c := &config{}
+	for _, opt := range typeOptions(v) {
+		opt(c)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *config) rewrite(data []byte) ([]byte, error) {
+	for _, rewrite := range c.rewriters {
+		err := callHook("WithMigrations", "", func() (err error) {
+			data, err = rewrite(data)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (c *config) validate(data []byte) error {
+	for _, validate := range c.validators {
+		if err := callHook("WithValidator", "", func() error { return validate(data) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *config) validateOverflow(overflow map[string]*json.RawMessage) error {
+	if len(c.overflowValidators) == 0 {
+		return nil
+	}
+
+	// Check the keys in order so that the error reported is deterministic
+	var errs OverflowErrors
+	for _, k := range Overflow(overflow).sortedKeys() {
+		for _, validate := range c.overflowValidators {
+			err := callHook("WithOverflowValidator", k, func() error { return validate(k, rawOrNull(overflow[k])) })
+			if _, panicked := err.(*PanicError); panicked {
+				return err
+			}
+			if err != nil {
+				if !c.collectOverflowErrors {
+					return &OverflowError{Key: k, Err: err}
+				}
+				errs.Add(k, err)
+				break
+			}
+		}
+	}
+	return errs.Err()
+}
+
+func (c *config) runAfterUnmarshal(v interface{}) error {
+	for _, hook := range c.afterUnmarshal {
+		if err := callHook("WithAfterUnmarshal", "", func() error { return hook(v) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns an Option that passes the raw document to validate before any
+// fields are parsed. If validate returns an error, UnmarshalJSON returns it
+// and leaves v unchanged, so invalid unknown fields are rejected rather than
+// kept in Overflow.
+//
+// Validators are called in the order they are given.
+func WithValidator(validate func(data []byte) error) Option {
+	return func(c *config) {
+		c.validators = append(c.validators, validate)
+	}
+}
+
+// Returns an Option that passes each entry that ends up in Overflow to
+// validate, in key order, after the named fields have been parsed. The
+// first failure is returned as an *OverflowError.
+//
+// This enforces a policy on unknown fields, such as a maximum length,
+// without modelling every key.
+func WithOverflowValidator(validate func(key string, raw json.RawMessage) error) Option {
+	return func(c *config) {
+		c.overflowValidators = append(c.overflowValidators, validate)
+	}
+}
+
+// Returns an Option that calls hook with v once its named fields and
+// Overflow have been populated and validated, so that struct-level checks
+// can be made in the same call. An error from hook is returned by
+// UnmarshalJSON.
+//
+// Hooks are called in the order they are given, and not at all if parsing
+// fails.
+func WithAfterUnmarshal(hook func(v interface{}) error) Option {
+	return func(c *config) {
+		c.afterUnmarshal = append(c.afterUnmarshal, hook)
+	}
+}
+
+// Returns an Option that only allows specification extensions, whose keys
+// start with "x-", into Overflow, as in OpenAPI and AsyncAPI documents. Any
+// other key that is not a named field is reported as an *OverflowError.
+func WithExtensionsOnly() Option {
+	validate := WithOverflowValidator(func(key string, raw json.RawMessage) error {
+		if !strings.HasPrefix(key, "x-") {
+			return errors.New("Unknown field, only 'x-' extensions are allowed")
+		}
+		return nil
+	})
+
+	return func(c *config) {
+		validate(c)
+		c.extensionsOnly = true
+		c.describedValidators++
+	}
+}
+
+// OverflowError is returned when an overflow entry fails validation.
+type OverflowError struct {
+	Key string
+	Err error
+
+	// Where the key is in the document, with WithErrorPositions or from
+	// LoadConfig. Line is 0 if it is not known.
+	Position Position
+
+	// The name of the Source the key came from, from LoadConfig
+	Source string
+
+	// The named field the key was most likely meant to be, for unknown keys
+	// rejected by RejectUnknown
+	Suggestion string
+}
+
+func (e *OverflowError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Invalid overflow field '%s'", printableKey(e.Key))
+	if e.Source != "" {
+		fmt.Fprintf(&b, " in %s", e.Source)
+	}
+	if e.Position.Line > 0 {
+		fmt.Fprintf(&b, " at %s", e.Position)
+	}
+	fmt.Fprintf(&b, ": %s", e.Err)
+	if e.Suggestion != "" {
+		fmt.Fprintf(&b, " (did you mean '%s'?)", printableKey(e.Suggestion))
+	}
+	return b.String()
+}
+
+// Sets the Position of e to that of its key, for WithErrorPositions.
+func (e *OverflowError) Locate(position func(pointer string) (Position, bool)) {
+	if p, ok := position(keyPointer(e.Key)); ok {
+		e.Position = p
+	}
+}
+
+// Returns k with any unprintable characters escaped, so that untrusted keys
+// cannot corrupt error messages or logs.
+func printableKey(k string) string {
+	for _, r := range k {
+		if !strconv.IsPrint(r) {
+			quoted := strconv.Quote(k)
+			return quoted[1 : len(quoted)-1]
+		}
+	}
+	return k
+}
+
+// Returns the validation failure.
+func (e *OverflowError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,128 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestWithValidatorRejectsDocument(t *testing.T) {
+	p := PersonData{Name: "Unchanged"}
+	rejected := errors.New("Rejected")
+
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p, WithValidator(func(data []byte) error {
+		return rejected
+	}))
+	if err != rejected {
+		t.Fatalf("Expected '%s', got '%v'", rejected, err)
+	}
+
+	if p.Name != "Unchanged" || p.Overflow != nil {
+		t.Fatalf("Expected struct to be left unchanged, got '%+v'", p)
+	}
+}
+
+func TestWithValidatorReceivesRawDocument(t *testing.T) {
+	p := PersonData{}
+	input := `{"name":"Bert","age":29}`
+
+	var seen []string
+	validator := func(label string) Option {
+		return WithValidator(func(data []byte) error {
+			seen = append(seen, label+":"+string(data))
+			return nil
+		})
+	}
+
+	if err := UnmarshalJSON([]byte(input), &p, validator("a"), validator("b")); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(seen) != 2 || seen[0] != "a:"+input || seen[1] != "b:"+input {
+		t.Fatalf("Expected validators to be called in order, got '%v'", seen)
+	}
+
+	if p.Name != "Bert" || p.Overflow["age"] == nil {
+		t.Fatalf("Expected document to be parsed, got '%+v'", p)
+	}
+}
+
+func TestWithOverflowValidatorChecksOnlyOverflow(t *testing.T) {
+	p := PersonData{}
+
+	var keys []string
+	err := UnmarshalJSON([]byte(`{"name":"Bert","city":"Leeds","age":29}`), &p, WithOverflowValidator(func(key string, raw json.RawMessage) error {
+		keys = append(keys, key)
+		if raw[0] != '"' {
+			return errors.New("Must be a string")
+		}
+		return nil
+	}))
+
+	overflowErr, ok := err.(*OverflowError)
+	if !ok || overflowErr.Key != "age" {
+		t.Fatalf("Expected overflow error for 'age', got '%v'", err)
+	}
+
+	if len(keys) != 1 || keys[0] != "age" {
+		t.Fatalf("Expected only 'age' to be checked before failing, got '%v'", keys)
+	}
+}
+
+func TestWithOverflowValidatorAcceptsValidEntries(t *testing.T) {
+	p := PersonData{}
+
+	err := UnmarshalJSON([]byte(`{"name":"Bert","city":"Leeds"}`), &p, WithOverflowValidator(func(key string, raw json.RawMessage) error {
+		if len(raw) > 256 {
+			return errors.New("Too long")
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Overflow["city"] == nil {
+		t.Fatalf("Expected 'city' in overflow, got '%v'", p.Overflow)
+	}
+}
+
+func TestWithExtensionsOnlyAllowsExtensionKeys(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","x-internal":true}`), &p, WithExtensionsOnly())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if p.Overflow["x-internal"] == nil {
+		t.Fatalf("Expected 'x-internal' in overflow, got '%v'", p.Overflow)
+	}
+}
+
+func TestWithExtensionsOnlyRejectsOtherKeys(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","x-internal":true,"nmae":"typo"}`), &p, WithExtensionsOnly())
+
+	overflowErr, ok := err.(*OverflowError)
+	if !ok || overflowErr.Key != "nmae" {
+		t.Fatalf("Expected overflow error for 'nmae', got '%v'", err)
+	}
+}
+
+func TestWithAfterUnmarshalSeesPopulatedStruct(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"","age":29}`), &p, WithAfterUnmarshal(func(v interface{}) error {
+		person := v.(*PersonData)
+		if person.Overflow["age"] == nil {
+			t.Fatalf("Expected overflow to be populated, got '%v'", person.Overflow)
+		}
+		if person.Name == "" {
+			return errors.New("Name is required")
+		}
+		return nil
+	}))
+
+	if err == nil || err.Error() != "Name is required" {
+		t.Fatalf("Expected 'Name is required', got '%v'", err)
+	}
+}
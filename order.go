@@ -0,0 +1,88 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// RestKeys stands for the keys not listed in WithKeyOrder, to place them
+// somewhere other than at the end.
+const RestKeys = "\x00rest"
+
+// Returns an Option for MarshalJSON that outputs the top-level keys in the
+// given order, for downstream parsers and reviewers that depend on it:
+//
+//	j2n.Configure(EventData{}, j2n.WithKeyOrder("id", "type", j2n.RestKeys, "signature"))
+//
+// The keys that are not listed, whether named fields or overflow, are
+// output in sorted order where RestKeys appears, or after the listed keys
+// if it does not. Listed keys that are not in the document are skipped.
+// Nested objects keep the usual sorted order.
+func WithKeyOrder(keys ...string) Option {
+	order := append([]string(nil), keys...)
+	return func(c *config) {
+		c.keyOrder = order
+	}
+}
+
+// Returns the encoding of result with its keys in the given order.
+func orderedJSON(result map[string]*json.RawMessage, order []string) ([]byte, error) {
+	listed := make(map[string]bool, len(order))
+	hasRest := false
+	for _, k := range order {
+		if k == RestKeys {
+			hasRest = true
+		}
+		listed[k] = true
+	}
+	if !hasRest {
+		order = append(order[:len(order):len(order)], RestKeys)
+	}
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	written := make(map[string]bool, len(result))
+	write := func(k string) error {
+		raw, ok := result[k]
+		if !ok || written[k] {
+			return nil
+		}
+		written[k] = true
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+		return nil
+	}
+
+	for _, k := range order {
+		if k != RestKeys {
+			if err := write(k); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		for _, rest := range Overflow(result).sortedKeys() {
+			if listed[rest] {
+				continue
+			}
+			if err := write(rest); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
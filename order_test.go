@@ -0,0 +1,45 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestKeyOrder(t *testing.T) {
+	data := OverflowPersonData{Name: "bert", Overflow: newTestOverflow(map[string]string{
+		"age": "3", "id": `"p1"`, "signature": `"x"`, "colour": `"yellow"`,
+	})}
+
+	output, err := MarshalJSON(&data, WithKeyOrder("id", "missing", RestKeys, "signature"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"id":"p1","age":3,"colour":"yellow","name":"bert","signature":"x"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+
+	output, err = MarshalJSON(&data, WithKeyOrder("signature", "name"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"signature":"x","name":"bert","age":3,"colour":"yellow","id":"p1"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestKeyOrderMatchesDefaultEncoding(t *testing.T) {
+	data := OverflowPersonData{Name: "<b>", Overflow: newTestOverflow(map[string]string{"x": `{ "b": 1, "a": [ 1, 2 ] }`})}
+
+	ordered, err := MarshalJSON(&data, WithKeyOrder(RestKeys))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	unordered, err := MarshalJSON(&data)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(ordered) != string(unordered) {
+		t.Fatalf("Expected '%s', got '%s'", unordered, ordered)
+	}
+}
@@ -0,0 +1,125 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"sort"
+)
+
+// Overflow holds the JSON fields of a struct that are not explicitly named in
+// it. It can be used in place of map[string]*json.RawMessage as the type of
+// the 'Overflow' field:
+//
+//	type CatData struct {
+//		Name     string       `json:"name"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+// A nil entry is treated as the JSON value null.
+type Overflow map[string]*json.RawMessage
+
+// Returns the entries of the Overflow field of v, a struct or a pointer to
+// one, whether the field is a map or an OverflowStore. This is for code that
+// inspects the unknown keys of any j2n type. The entries may be shared with
+// v, and must not be modified.
+func OverflowOf(v interface{}) (Overflow, error) {
+	value := reflect.ValueOf(v)
+	if !value.IsValid() || value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, errors.New("Expected struct, got nil")
+	}
+
+	entries, err := getOverflowMap(v)
+	if err != nil {
+		return nil, err
+	}
+	return Overflow(entries), nil
+}
+
+// Returns an iterator over the entries of o in ascending key order, so that
+// code built on iteration produces the same output on every run.
+//
+// The keys are captured when iteration starts; entries deleted during
+// iteration are skipped.
+func (o Overflow) All() iter.Seq2[string, json.RawMessage] {
+	return func(yield func(string, json.RawMessage) bool) {
+		for _, k := range o.sortedKeys() {
+			v, ok := o[k]
+			if !ok {
+				continue
+			}
+			if !yield(k, rawOrNull(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Removes every entry for which keep returns false.
+func (o Overflow) Filter(keep func(key string, raw json.RawMessage) bool) {
+	for k, v := range o {
+		if !keep(k, rawOrNull(v)) {
+			delete(o, k)
+		}
+	}
+}
+
+// Replaces every entry with the key and value returned by fn. Returning an
+// empty key removes the entry.
+//
+// If fn returns an error, or two entries are given the same key, Transform
+// returns an error and leaves o unchanged.
+func (o Overflow) Transform(fn func(key string, raw json.RawMessage) (string, json.RawMessage, error)) error {
+	result := make(map[string]*json.RawMessage, len(o))
+	sources := make(map[string]string, len(o))
+
+	for k, v := range o {
+		newKey, newRaw, err := fn(k, rawOrNull(v))
+		if err != nil {
+			return err
+		}
+
+		if newKey == "" {
+			continue
+		}
+
+		if previous, ok := sources[newKey]; ok {
+			return fmt.Errorf("Overflow keys '%s' and '%s' both transformed to '%s'", previous, k, newKey)
+		}
+
+		if !json.Valid(newRaw) {
+			return fmt.Errorf("Invalid JSON for overflow key '%s'", newKey)
+		}
+
+		raw := append(json.RawMessage(nil), newRaw...)
+		result[newKey] = &raw
+		sources[newKey] = k
+	}
+
+	for k := range o {
+		delete(o, k)
+	}
+	for k, v := range result {
+		o[k] = v
+	}
+
+	return nil
+}
+
+func (o Overflow) sortedKeys() []string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func rawOrNull(raw *json.RawMessage) json.RawMessage {
+	if raw == nil {
+		return json.RawMessage("null")
+	}
+	return *raw
+}
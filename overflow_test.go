@@ -0,0 +1,148 @@
+package j2n
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type OverflowPersonData struct {
+	Name     string   `json:"name"`
+	Overflow Overflow `json:"-"`
+}
+
+func newTestOverflow(entries map[string]string) Overflow {
+	o := make(Overflow)
+	for k, v := range entries {
+		raw := json.RawMessage(v)
+		o[k] = &raw
+	}
+	return o
+}
+
+func TestParsesIntoOverflowType(t *testing.T) {
+	p := OverflowPersonData{}
+
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(*p.Overflow["age"]) != "29" {
+		t.Fatalf("Expected '29', got '%s'", *p.Overflow["age"])
+	}
+
+	data, err := MarshalJSON(&p)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expectedData := `{"age":29,"name":"Bert"}`
+	if string(data) != expectedData {
+		t.Fatalf("Expected '%s', got '%s'", expectedData, data)
+	}
+}
+
+func TestFilterRemovesRejectedEntries(t *testing.T) {
+	o := newTestOverflow(map[string]string{"x-trace": `"abc"`, "internal": `1`})
+
+	o.Filter(func(key string, raw json.RawMessage) bool {
+		return strings.HasPrefix(key, "x-")
+	})
+
+	if len(o) != 1 || o["x-trace"] == nil {
+		t.Fatalf("Expected only 'x-trace' to remain, got '%v'", o)
+	}
+}
+
+func TestTransformRewritesKeysAndValues(t *testing.T) {
+	o := newTestOverflow(map[string]string{"age": `29`, "drop": `true`})
+
+	err := o.Transform(func(key string, raw json.RawMessage) (string, json.RawMessage, error) {
+		if key == "drop" {
+			return "", nil, nil
+		}
+		return "years", json.RawMessage(`[` + string(raw) + `]`), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if len(o) != 1 || string(*o["years"]) != "[29]" {
+		t.Fatalf("Expected only 'years' with '[29]', got '%v'", o)
+	}
+}
+
+func TestTransformLeavesOverflowUnchangedOnCollision(t *testing.T) {
+	o := newTestOverflow(map[string]string{"a": `1`, "b": `2`})
+
+	err := o.Transform(func(key string, raw json.RawMessage) (string, json.RawMessage, error) {
+		return "same", raw, nil
+	})
+	if err == nil {
+		t.Fatal("Expected error when two keys collide")
+	}
+
+	if len(o) != 2 || o["a"] == nil || o["b"] == nil {
+		t.Fatalf("Expected overflow to be unchanged, got '%v'", o)
+	}
+}
+
+func TestAllYieldsEntriesInKeyOrder(t *testing.T) {
+	o := newTestOverflow(map[string]string{"c": `3`, "a": `1`, "b": `2`})
+	o["d"] = nil
+
+	var keys, values []string
+	for k, v := range o.All() {
+		keys = append(keys, k)
+		values = append(values, string(v))
+	}
+
+	expectedKeys := "a,b,c,d"
+	if strings.Join(keys, ",") != expectedKeys {
+		t.Fatalf("Expected '%s', got '%s'", expectedKeys, strings.Join(keys, ","))
+	}
+
+	expectedValues := "1,2,3,null"
+	if strings.Join(values, ",") != expectedValues {
+		t.Fatalf("Expected '%s', got '%s'", expectedValues, strings.Join(values, ","))
+	}
+}
+
+func TestAllStopsWhenYieldReturnsFalse(t *testing.T) {
+	o := newTestOverflow(map[string]string{"a": `1`, "b": `2`})
+
+	count := 0
+	for range o.All() {
+		count++
+		break
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected 1 iteration, got %d", count)
+	}
+}
+
+func TestOverflowOfReadsEveryOverflowType(t *testing.T) {
+	raw := json.RawMessage(`1`)
+	store := &SyncOverflow{}
+	store.ReplaceOverflow(map[string]*json.RawMessage{"a": &raw})
+
+	for _, v := range []interface{}{
+		&PersonData{Overflow: map[string]*json.RawMessage{"a": &raw}},
+		OverflowPersonData{Overflow: Overflow{"a": &raw}},
+		&SyncCatData{Overflow: store},
+	} {
+		overflow, err := OverflowOf(v)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if len(overflow) != 1 || string(*overflow["a"]) != "1" {
+			t.Fatalf("Expected overflow 'a', got %v", overflow)
+		}
+	}
+
+	if _, err := OverflowOf((*PersonData)(nil)); err == nil {
+		t.Fatalf("Expected an error for a nil pointer")
+	}
+}
@@ -0,0 +1,47 @@
+package j2n
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned when a function supplied by the caller panics
+// during UnmarshalJSON or MarshalJSON, so that one misbehaving hook or
+// custom marshaler cannot crash the program that is parsing documents.
+type PanicError struct {
+	// The kind of function that panicked, such as "WithAfterUnmarshal" or
+	// "MetricsHook"
+	Hook string
+
+	// The key that the function was called for, or "" if it was called for
+	// the whole document
+	Key string
+
+	// The value passed to panic, and the stack trace of the goroutine when
+	// it was recovered
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("Panic in %s for key '%s': %v", e.Hook, printableKey(e.Key), e.Value)
+	}
+	return fmt.Sprintf("Panic in %s: %v", e.Hook, e.Value)
+}
+
+// Returns the value passed to panic if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Calls f, returning a *PanicError if it panics.
+func callHook(hook, key string, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Hook: hook, Key: key, Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,93 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPanicInHookIsReturnedAsError(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &p, WithAfterUnmarshal(func(v interface{}) error {
+		panic("boom")
+	}))
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("Expected a PanicError, got '%v'", err)
+	}
+	expected := "Panic in WithAfterUnmarshal: boom"
+	if err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, err)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Fatalf("Expected a stack trace")
+	}
+}
+
+func TestPanicInKeyHookIdentifiesKey(t *testing.T) {
+	cause := errors.New("boom")
+	hook := MetricsHookFunc(func(typeName, key string, size int) {
+		if key == "colour" {
+			panic(cause)
+		}
+	})
+
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":3,"colour":"red"}`), &p, WithMetricsHook(hook))
+
+	expected := "Panic in MetricsHook for key 'colour': boom"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatalf("Expected the error to wrap the panic value")
+	}
+}
+
+func TestPanicInOverflowValidatorIsNotCollected(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":3}`), &p,
+		WithCollectOverflowErrors(),
+		WithOverflowValidator(func(key string, raw json.RawMessage) error {
+			var m map[string]int
+			m[key] = 1
+			return nil
+		}),
+	)
+
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) || panicErr.Hook != "WithOverflowValidator" || panicErr.Key != "age" {
+		t.Fatalf("Expected a PanicError for 'age', got '%v'", err)
+	}
+}
+
+type PanickingField struct{}
+
+func (PanickingField) MarshalJSON() ([]byte, error) {
+	panic("cannot encode")
+}
+
+func (*PanickingField) UnmarshalJSON(data []byte) error {
+	panic("cannot decode")
+}
+
+type PanickingData struct {
+	Field    PanickingField `json:"field"`
+	Overflow Overflow       `json:"-"`
+}
+
+func TestPanicInCustomMarshalerIsReturnedAsError(t *testing.T) {
+	d := PanickingData{}
+	err := UnmarshalJSON([]byte(`{"field":1}`), &d)
+	expected := "Panic in json.Unmarshaler: cannot decode"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	_, err = MarshalJSON(d)
+	expected = "Panic in json.Marshaler: cannot encode"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
@@ -0,0 +1,226 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// Passthrough supports the "receive, tweak, forward" pattern of webhook
+// proxies. It decodes a document into a struct, lets the caller change its
+// fields, and then re-emits the original document with only the changes
+// applied, so that every member the caller did not touch, including unknown
+// ones, keeps its exact bytes: its formatting, key order, escaping and
+// number representation.
+//
+//	var event Event
+//	p, err := j2n.NewPassthrough(body, &event)
+//	if !verify(p.Raw(), signature) { ... }
+//	event.Status = "forwarded"
+//	forwarded, err := p.Encode()
+type Passthrough struct {
+	raw      []byte
+	v        interface{}
+	snapshot []byte
+}
+
+// Parses data into v and returns a Passthrough for re-emitting it. v is a
+// pointer to a struct with an Overflow field, decoded with UnmarshalJSON and
+// opts, or a pointer to a wrapper type with its own UnmarshalJSON and
+// MarshalJSON methods, in which case opts are ignored. data must be a JSON
+// object.
+func NewPassthrough(data []byte, v interface{}, opts ...Option) (*Passthrough, error) {
+	if !json.Valid(data) {
+		return nil, errors.New("Invalid JSON document")
+	}
+	if data[skipSpace(data, 0)] != '{' {
+		return nil, errors.New("Expected a JSON object")
+	}
+
+	raw := append([]byte(nil), data...)
+	if u, ok := v.(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+	} else if err := UnmarshalJSON(raw, v, opts...); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := passthroughEncode(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Passthrough{raw: raw, v: v, snapshot: snapshot}, nil
+}
+
+// Returns the document exactly as it was received, for verifying its
+// signature. It must not be modified.
+func (p *Passthrough) Raw() []byte {
+	return p.raw
+}
+
+// Returns the original document with the changes made to the struct since
+// it was decoded. Members whose encoding has not changed keep their
+// original bytes, and changed objects are patched member by member in the
+// same way. Removed members are deleted, and new members are appended in
+// key order. Members of the original that the struct never modelled, such
+// as keys dropped by WithOverflowKeyFilter, are passed through unchanged.
+//
+// If nothing has changed, the result is identical to Raw.
+func (p *Passthrough) Encode() ([]byte, error) {
+	current, err := passthroughEncode(p.v)
+	if err != nil {
+		return nil, err
+	}
+	return patchDocument(p.raw, p.snapshot, current)
+}
+
+// Returns raw, a JSON object that encoded as before when it was decoded,
+// patched to encode as after.
+func patchDocument(raw, before, after []byte) ([]byte, error) {
+	var edits []passthroughEdit
+	if err := patchObject(raw, skipSpace(raw, 0), before, after, &edits); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].end < edits[j].end
+	})
+
+	var result bytes.Buffer
+	offset := 0
+	for _, e := range edits {
+		result.Write(raw[offset:e.start])
+		result.Write(e.text)
+		offset = e.end
+	}
+	result.Write(raw[offset:])
+	return result.Bytes(), nil
+}
+
+// A passthroughEdit replaces the bytes of the raw document between start and
+// end with text.
+type passthroughEdit struct {
+	start, end int
+	text       []byte
+}
+
+func passthroughEncode(v interface{}) ([]byte, error) {
+	if m, ok := v.(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+	return MarshalJSON(v)
+}
+
+// Plans the edits that turn the object at data[offset], which encoded as
+// before when it was decoded, into one that encodes as after.
+func patchObject(data []byte, offset int, before, after []byte, edits *[]passthroughEdit) error {
+	var beforeMembers, afterMembers map[string]json.RawMessage
+	if err := json.Unmarshal(before, &beforeMembers); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(after, &afterMembers); err != nil {
+		return err
+	}
+
+	members, closing, err := objectMembers(data, offset)
+	if err != nil {
+		return err
+	}
+
+	// Replace or remove the members that have changed
+	removed := make([]bool, len(members))
+	present := make(map[string]bool, len(members))
+	for i, m := range members {
+		present[m.key] = true
+
+		old, wasEncoded := beforeMembers[m.key]
+		value, isEncoded := afterMembers[m.key]
+		switch {
+		case !wasEncoded && !isEncoded:
+			// Not modelled by the struct, or omitted both times
+		case !isEncoded:
+			removed[i] = true
+		case wasEncoded && jsonEqual(old, value):
+		case wasEncoded && isObject(old) && isObject(value) && data[m.valueStart] == '{':
+			if err := patchObject(data, m.valueStart, old, value, edits); err != nil {
+				return err
+			}
+		default:
+			*edits = append(*edits, passthroughEdit{start: m.valueStart, end: m.end, text: value})
+		}
+	}
+
+	last := -1
+	for i := range members {
+		if !removed[i] {
+			last = i
+		}
+	}
+	for i, m := range members {
+		if removed[i] && i < last {
+			*edits = append(*edits, passthroughEdit{start: m.start, end: members[i+1].start})
+		}
+	}
+	if last < len(members)-1 {
+		start := members[0].start
+		if last >= 0 {
+			start = members[last].end
+		}
+		*edits = append(*edits, passthroughEdit{start: start, end: members[len(members)-1].end})
+	}
+
+	// Append the new members. A member that the struct encodes but the
+	// document never had, such as a field without omitempty, is only new if
+	// it has changed since the document was decoded.
+	var added []string
+	for k, value := range afterMembers {
+		if old, ok := beforeMembers[k]; !present[k] && (!ok || !jsonEqual(old, value)) {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+	if len(added) == 0 {
+		return nil
+	}
+
+	var text bytes.Buffer
+	for i, k := range added {
+		if i > 0 || last >= 0 {
+			text.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		text.Write(key)
+		text.WriteByte(':')
+		text.Write(afterMembers[k])
+	}
+
+	at := closing
+	if last >= 0 {
+		at = members[last].end
+	} else if len(members) > 0 {
+		at = members[0].start
+	}
+	*edits = append(*edits, passthroughEdit{start: at, end: at, text: text.Bytes()})
+	return nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return false
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
+
+func isObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
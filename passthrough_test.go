@@ -0,0 +1,143 @@
+package j2n
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type WebhookCustomer struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+type WebhookData struct {
+	Status   string           `json:"status"`
+	Customer *WebhookCustomer `json:"customer,omitempty"`
+	Retries  int              `json:"retries,omitempty"`
+	Overflow Overflow         `json:"-"`
+}
+
+const webhookBody = `{
+  "status": "paid",
+  "amount": 1.50,
+  "customer": {"name": "Bert", "email": "b@example.com", "vip": true},
+  "note": "café"
+}`
+
+func TestPassthroughIsByteFaithfulWhenUnchanged(t *testing.T) {
+	w := WebhookData{}
+	p, err := NewPassthrough([]byte(webhookBody), &w)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	result, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(result) != webhookBody || string(p.Raw()) != webhookBody {
+		t.Fatalf("Expected '%s', got '%s'", webhookBody, result)
+	}
+}
+
+func TestPassthroughPatchesOnlyChangedMembers(t *testing.T) {
+	w := WebhookData{}
+	p, err := NewPassthrough([]byte(webhookBody), &w)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	w.Status = "forwarded"
+	w.Customer.Email = ""
+	w.Retries = 2
+	delete(w.Overflow, "note")
+
+	result, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{
+  "status": "forwarded",
+  "amount": 1.50,
+  "customer": {"name": "Bert", "vip": true},"retries":2
+}`
+	if string(result) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, result)
+	}
+	if !json.Valid(result) {
+		t.Fatalf("Expected valid JSON, got '%s'", result)
+	}
+}
+
+func TestPassthroughRemovesEveryMember(t *testing.T) {
+	w := WebhookData{}
+	p, err := NewPassthrough([]byte(`{"customer":{"name":"Bert"},"x":1}`), &w)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	w.Customer = nil
+	w.Overflow = nil
+	result, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{}`
+	if string(result) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, result)
+	}
+}
+
+func TestPassthroughDoesNotAddUnchangedFields(t *testing.T) {
+	w := WebhookData{}
+	body := `{"retries": 1}`
+	p, err := NewPassthrough([]byte(body), &w)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	result, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(result) != body {
+		t.Fatalf("Expected '%s', got '%s'", body, result)
+	}
+
+	w.Status = "paid"
+	result, err = p.Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"retries": 1,"status":"paid"}`
+	if string(result) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, result)
+	}
+}
+
+func TestPassthroughWithWrapper(t *testing.T) {
+	person := Person{}
+	p, err := NewPassthrough([]byte(`{"name": "Bert", "age": 29}`), &person)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	person.Name = "Ernie"
+	result, err := p.Encode()
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := `{"name": "Ernie", "age": 29}`
+	if string(result) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, result)
+	}
+}
+
+func TestPassthroughRejectsNonObjects(t *testing.T) {
+	if _, err := NewPassthrough([]byte(`[1]`), &WebhookData{}); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
@@ -0,0 +1,148 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// UnknownFieldPolicy controls what UnmarshalJSON does with keys that are not
+// named fields of the struct.
+type UnknownFieldPolicy int
+
+const (
+	// AllowUnknown keeps unknown keys in Overflow. This is the default.
+	AllowUnknown UnknownFieldPolicy = iota
+
+	// WarnUnknown keeps unknown keys in Overflow and reports each one to the
+	// function given with WithUnknownFieldWarning, or logs it to the
+	// Logger for warnings (see WithWarningLogger).
+	WarnUnknown
+
+	// CollectUnknown keeps unknown keys in Overflow and appends them to the
+	// report given with WithUnknownFieldReport.
+	CollectUnknown
+
+	// RejectUnknown fails on the first unknown key with an *OverflowError,
+	// suggesting the named field the key is a likely misspelling of.
+	RejectUnknown
+)
+
+// UnknownFieldReport collects the unknown keys found under CollectUnknown.
+// The same report may be passed to several calls to accumulate their keys.
+type UnknownFieldReport struct {
+	mutex sync.Mutex
+	keys  []string
+}
+
+// Returns the keys collected so far, in the order they were found.
+func (r *UnknownFieldReport) Keys() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.keys...)
+}
+
+func (r *UnknownFieldReport) add(keys []string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.keys = append(r.keys, keys...)
+}
+
+// Returns an Option that sets the policy for unknown keys.
+func WithUnknownFields(policy UnknownFieldPolicy) Option {
+	return func(c *config) {
+		c.unknownFields = policy
+	}
+}
+
+// Returns an Option that sets the WarnUnknown policy, reporting each unknown
+// key, in key order, to warn.
+func WithUnknownFieldWarning(warn func(key string, raw json.RawMessage)) Option {
+	return func(c *config) {
+		c.unknownFields = WarnUnknown
+		c.warnUnknown = warn
+	}
+}
+
+// Returns an Option that sets the CollectUnknown policy, appending unknown
+// keys, in key order, to report.
+func WithUnknownFieldReport(report *UnknownFieldReport) Option {
+	return func(c *config) {
+		c.unknownFields = CollectUnknown
+		c.unknownReport = report
+	}
+}
+
+var typeOptionRegistry sync.Map // map[reflect.Type][]Option
+
+// Sets the default options for UnmarshalJSON and MarshalJSON calls on
+// structs of the same type as v, replacing any set before. Options passed to
+// a call are applied after the defaults, so they take precedence.
+//
+// Most options only affect UnmarshalJSON. Those that also change what
+// MarshalJSON writes are WithTypeCodec and the options built on it, such as
+// WithDurationStrings; WithOutputScrubber and WithEncryptedOverflow;
+// WithViewOverflow and WithViewNamespace, for MarshalView; WithBeforeEncode;
+// WithKeyOrder; and WithSurgicalEdit.
+//
+// Configure is normally called from an init function, as
+//
+//	j2n.Configure(AdminRequestData{}, j2n.WithUnknownFields(j2n.RejectUnknown))
+func Configure(v interface{}, opts ...Option) {
+	typeOptionRegistry.Store(structType(v), append([]Option(nil), opts...))
+}
+
+func typeOptions(v interface{}) []Option {
+	if opts, ok := typeOptionRegistry.Load(structType(v)); ok {
+		return opts.([]Option)
+	}
+	return nil
+}
+
+func structType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func (c *config) handleUnknownFields(v interface{}, overflow map[string]*json.RawMessage) error {
+	if c.unknownFields == AllowUnknown || len(overflow) == 0 {
+		return nil
+	}
+
+	keys := Overflow(overflow).sortedKeys()
+
+	switch c.unknownFields {
+	case WarnUnknown:
+		for _, k := range keys {
+			var err error
+			if c.warnUnknown != nil {
+				err = callHook("WithUnknownFieldWarning", k, func() error {
+					c.warnUnknown(k, rawOrNull(overflow[k]))
+					return nil
+				})
+			} else {
+				err = callHook("Logger", k, func() error {
+					c.warningLogger().Warn("Unknown JSON field", "key", k, "type", structType(v).String())
+					return nil
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case CollectUnknown:
+		if c.unknownReport == nil {
+			return errors.New("CollectUnknown requires a report, set with WithUnknownFieldReport")
+		}
+		c.unknownReport.add(keys)
+	case RejectUnknown:
+		suggestion, _ := suggestKey(structType(v), keys[0])
+		return &OverflowError{Key: keys[0], Err: errors.New("Unknown field"), Suggestion: suggestion}
+	}
+
+	return nil
+}
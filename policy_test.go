@@ -0,0 +1,89 @@
+package j2n
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFieldPolicyRejectsUnknownKeys(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","city":"Leeds","age":29}`), &p, WithUnknownFields(RejectUnknown))
+
+	overflowErr, ok := err.(*OverflowError)
+	if !ok || overflowErr.Key != "age" {
+		t.Fatalf("Expected overflow error for 'age', got '%v'", err)
+	}
+}
+
+func TestUnknownFieldPolicyRejectSuggestsNamedField(t *testing.T) {
+	c := RetryConfigData{}
+	err := UnmarshalJSON([]byte(`{"retrys":3}`), &c, WithUnknownFields(RejectUnknown))
+
+	expected := "Invalid overflow field 'retrys': Unknown field (did you mean 'retries'?)"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+	if overflowErr, ok := err.(*OverflowError); !ok || overflowErr.Suggestion != "retries" {
+		t.Fatalf("Expected the suggestion 'retries', got '%v'", err)
+	}
+}
+
+func TestUnknownFieldPolicyWarnsAndKeepsKeys(t *testing.T) {
+	p := PersonData{}
+
+	var warned []string
+	err := UnmarshalJSON([]byte(`{"name":"Bert","city":"Leeds","age":29}`), &p, WithUnknownFieldWarning(func(key string, raw json.RawMessage) {
+		warned = append(warned, key)
+	}))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if !reflect.DeepEqual(warned, []string{"age", "city"}) {
+		t.Fatalf("Expected warnings for 'age' and 'city', got '%v'", warned)
+	}
+
+	if len(p.Overflow) != 2 {
+		t.Fatalf("Expected unknown keys to be kept, got '%v'", p.Overflow)
+	}
+}
+
+func TestUnknownFieldPolicyCollectsAcrossCalls(t *testing.T) {
+	report := &UnknownFieldReport{}
+
+	for _, input := range []string{`{"name":"Bert","age":29}`, `{"name":"Ernie","city":"Leeds"}`} {
+		p := PersonData{}
+		if err := UnmarshalJSON([]byte(input), &p, WithUnknownFieldReport(report)); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+
+	if !reflect.DeepEqual(report.Keys(), []string{"age", "city"}) {
+		t.Fatalf("Expected 'age' and 'city' to be collected, got '%v'", report.Keys())
+	}
+}
+
+type StrictPersonData struct {
+	Name     string                      `json:"name"`
+	Overflow map[string]*json.RawMessage `json:"-"`
+}
+
+func TestConfigureSetsDefaultsForType(t *testing.T) {
+	Configure(StrictPersonData{}, WithUnknownFields(RejectUnknown))
+	defer Configure(StrictPersonData{})
+
+	s := StrictPersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &s); err == nil {
+		t.Fatal("Expected error unmarshaling unknown key into strict type")
+	}
+
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &s, WithUnknownFields(AllowUnknown)); err != nil {
+		t.Fatalf("Expected per-call option to override default, got '%s'", err)
+	}
+
+	p := PersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","age":29}`), &p); err != nil {
+		t.Fatalf("Expected other types to be unaffected, got '%s'", err)
+	}
+}
@@ -0,0 +1,259 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Position is the location of a key in a document. Line and Column start at
+// 1, and Column counts bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// OverflowPositions records where each key in Overflow appeared in the
+// document, so that errors about unexpected keys in configuration files can
+// point at them. It is embedded in the data struct, and filled in by
+// UnmarshalJSON when the WithOverflowPositions option is given:
+//
+//	type ConfigData struct {
+//		j2n.OverflowPositions
+//		Port     int          `json:"port"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+//	for k := range config.Overflow {
+//		p, _ := config.OverflowPosition(k)
+//		fmt.Printf("%s:%s: unknown key '%s'\n", path, p, k)
+//	}
+//
+// OverflowPositions has no exported fields, so it is invisible to
+// encoding/json.
+type OverflowPositions struct {
+	positions map[string]Position
+}
+
+// Returns the position of the key in the document, or false if the key was
+// not in Overflow when the struct was parsed with WithOverflowPositions.
+func (p *OverflowPositions) OverflowPosition(key string) (Position, bool) {
+	position, ok := p.positions[key]
+	return position, ok
+}
+
+var overflowPositionsType = reflect.TypeOf(OverflowPositions{})
+
+// Returns an Option that records the position of each key in Overflow in
+// the OverflowPositions embedded in the struct. Positions are recorded
+// before the overflow validators run, so they are available when the
+// document is rejected. UnmarshalJSON fails if the struct does not embed an
+// OverflowPositions.
+//
+// Positions refer to the document after any migrations have rewritten it.
+// UnmarshalJSONC keeps the positions of the original document.
+func WithOverflowPositions() Option {
+	return func(c *config) {
+		c.overflowPositions = true
+	}
+}
+
+// Returns an Option that gives positions to the validation errors returned
+// by UnmarshalJSON, so that they can point at the offending key in a
+// configuration file:
+//
+//	Invalid overflow field 'retrys' at line 42, column 3: Unknown field (did you mean 'retries'?)
+//
+// The Position of an *OverflowError is set, whether it comes from an
+// overflow validator, a key pattern or the RejectUnknown policy, as is that
+// of a *RuleError from WithRules. Errors from WithValidator, such as those
+// of j2nschema, are given positions if they implement Locator.
+//
+// As with WithOverflowPositions, positions are those of the keys as written,
+// after any migrations. LoadConfig sets the positions of overflow errors
+// without this option.
+func WithErrorPositions() Option {
+	return func(c *config) {
+		c.errorPositions = true
+	}
+}
+
+// Locator is implemented by validation errors that refer to values in the
+// document by JSON Pointer, so that WithErrorPositions can give them
+// positions. Locate is called with a function that returns the position of
+// the value at a pointer: that of its key if it is a member of an object,
+// and otherwise that of the value itself.
+type Locator interface {
+	Locate(position func(pointer string) (Position, bool))
+}
+
+// Returns err with the positions of the values it refers to in data set,
+// with WithErrorPositions.
+func (c *config) locate(err error, data []byte) error {
+	if err == nil || !c.errorPositions || !json.Valid(data) {
+		return err
+	}
+
+	position := func(pointer string) (Position, bool) {
+		return pointerPosition(data, pointer)
+	}
+	walkErrors(err, func(err error) {
+		if l, ok := err.(Locator); ok {
+			l.Locate(position)
+		}
+	})
+	return err
+}
+
+// Calls fn with err and every error it wraps.
+func walkErrors(err error, fn func(err error)) {
+	if err == nil {
+		return
+	}
+	fn(err)
+
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(wrapper.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range wrapper.Unwrap() {
+			walkErrors(wrapped, fn)
+		}
+	}
+}
+
+// Calls fn with each *OverflowError in err.
+func eachOverflowError(err error, fn func(e *OverflowError)) {
+	walkErrors(err, func(err error) {
+		if e, ok := err.(*OverflowError); ok {
+			fn(e)
+		}
+	})
+}
+
+// Returns the JSON Pointer to the top-level member key.
+func keyPointer(key string) string {
+	return "/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// Returns the position of the value at pointer in data, which must be valid
+// JSON: that of its key if it is a member of an object, and otherwise that
+// of the value. A repeated key has the position of its last occurrence.
+func pointerPosition(data []byte, pointer string) (Position, bool) {
+	offset := skipSpace(data, 0)
+	at := offset
+	if pointer != "" {
+		if !strings.HasPrefix(pointer, "/") {
+			return Position{}, false
+		}
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			if offset >= len(data) {
+				return Position{}, false
+			}
+
+			found := false
+			switch data[offset] {
+			case '{':
+				members, _, err := objectMembers(data, offset)
+				if err != nil {
+					return Position{}, false
+				}
+				for _, m := range members {
+					if m.key == token {
+						at, offset, found = m.start, m.valueStart, true
+					}
+				}
+			case '[':
+				index, err := strconv.Atoi(token)
+				if err != nil || index < 0 {
+					return Position{}, false
+				}
+				i := skipSpace(data, offset+1)
+				for n := 0; data[i] != ']'; n++ {
+					if n == index {
+						at, offset, found = i, i, true
+						break
+					}
+					i = skipSpace(data, skipValue(data, i))
+					if data[i] == ',' {
+						i = skipSpace(data, i+1)
+					}
+				}
+			}
+			if !found {
+				return Position{}, false
+			}
+		}
+	}
+	return positionAt(data, at), true
+}
+
+// Returns the position of data[offset].
+func positionAt(data []byte, offset int) Position {
+	line, lineStart := 1, 0
+	for i := 0; i < offset; i++ {
+		if data[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+	return Position{Offset: offset, Line: line, Column: offset - lineStart + 1}
+}
+
+// Returns the OverflowPositions embedded in the struct pointed to by v.
+func overflowPositionsOf(v interface{}) (*OverflowPositions, error) {
+	value, ok := structValue(v)
+	if ok {
+		if field := value.FieldByName("OverflowPositions"); field.IsValid() && field.Type() == overflowPositionsType && field.CanAddr() {
+			return field.Addr().Interface().(*OverflowPositions), nil
+		}
+	}
+	return nil, errors.New("WithOverflowPositions requires the struct to embed j2n.OverflowPositions")
+}
+
+// Returns the positions of the top-level keys of data, which must be valid
+// JSON. A repeated key has the position of its last occurrence, which is
+// the one that encoding/json keeps.
+func keyPositions(data []byte) (map[string]Position, error) {
+	start := skipSpace(data, 0)
+	if data[start] != '{' {
+		return nil, nil
+	}
+
+	members, _, err := objectMembers(data, start)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]Position, len(members))
+	line, lineStart, offset := 1, 0, 0
+	for _, m := range members {
+		for ; offset < m.start; offset++ {
+			if data[offset] == '\n' {
+				line++
+				lineStart = offset + 1
+			}
+		}
+		positions[m.key] = Position{Offset: m.start, Line: line, Column: m.start - lineStart + 1}
+	}
+	return positions, nil
+}
+
+// Keeps the positions of the keys in overflow.
+func (p *OverflowPositions) set(positions map[string]Position, overflow map[string]*json.RawMessage) {
+	p.positions = make(map[string]Position, len(overflow))
+	for k := range overflow {
+		if position, ok := positions[k]; ok {
+			p.positions[k] = position
+		}
+	}
+}
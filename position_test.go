@@ -0,0 +1,153 @@
+package j2n
+
+import (
+	"testing"
+)
+
+type PositionedConfigData struct {
+	OverflowPositions
+	Port     int      `json:"port"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestWithOverflowPositionsRecordsKeyPositions(t *testing.T) {
+	data := "{\n  \"port\": 80,\n  \"hots\": \"a\",\n\t\"prot\": 1, \"hots\": \"b\"\n}"
+
+	c := PositionedConfigData{}
+	if err := UnmarshalJSON([]byte(data), &c, WithOverflowPositions()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if _, ok := c.OverflowPosition("port"); ok {
+		t.Fatalf("Expected no position for a named field")
+	}
+
+	// A repeated key has the position of the value that was kept
+	expected := Position{Offset: 43, Line: 4, Column: 13}
+	if p, ok := c.OverflowPosition("hots"); !ok || p != expected {
+		t.Fatalf("Expected '%v', got '%v'", expected, p)
+	}
+
+	expected = Position{Offset: 32, Line: 4, Column: 2}
+	if p, ok := c.OverflowPosition("prot"); !ok || p != expected {
+		t.Fatalf("Expected '%v', got '%v'", expected, p)
+	}
+	if p, _ := c.OverflowPosition("prot"); p.String() != "line 4, column 2" {
+		t.Fatalf("Expected 'line 4, column 2', got '%s'", p)
+	}
+}
+
+func TestWithOverflowPositionsAreKeptWhenValidationFails(t *testing.T) {
+	c := PositionedConfigData{}
+	err := UnmarshalJSON([]byte(`{"port":80,"x":1}`), &c, WithOverflowPositions(), WithExtensionsOnly())
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	expected := Position{Offset: 11, Line: 1, Column: 12}
+	if p, ok := c.OverflowPosition("x"); !ok || p != expected {
+		t.Fatalf("Expected '%v', got '%v'", expected, p)
+	}
+}
+
+func TestWithErrorPositions(t *testing.T) {
+	data := []byte("{\n  \"timeout\": \"5s\",\n  \"retrys\": 3\n}")
+
+	c := RetryConfigData{}
+	err := UnmarshalJSON(data, &c, WithErrorPositions(), WithUnknownFields(RejectUnknown))
+	expected := "Invalid overflow field 'retrys' at line 3, column 3: Unknown field (did you mean 'retries'?)"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	c = RetryConfigData{}
+	err = UnmarshalJSON(data, &c, WithErrorPositions(), WithExtensionsOnly(), WithCollectOverflowErrors())
+	invalid, ok := err.(OverflowErrors)
+	if !ok || len(invalid) != 1 || invalid[0].Position != (Position{Offset: 23, Line: 3, Column: 3}) {
+		t.Fatalf("Expected the position of 'retrys', got '%v'", err)
+	}
+
+	// Without the option, positions are not worked out
+	c = RetryConfigData{}
+	err = UnmarshalJSON(data, &c, WithUnknownFields(RejectUnknown))
+	if overflowErr, ok := err.(*OverflowError); !ok || overflowErr.Position.Line != 0 {
+		t.Fatalf("Expected no position, got '%v'", err)
+	}
+}
+
+type pointerError struct {
+	pointer  string
+	position Position
+}
+
+func (e *pointerError) Error() string {
+	return "Invalid value at " + e.pointer
+}
+
+func (e *pointerError) Locate(position func(pointer string) (Position, bool)) {
+	e.position, _ = position(e.pointer)
+}
+
+func TestWithErrorPositionsLocatesValidationErrors(t *testing.T) {
+	data := []byte("{\n  \"retries\": 3,\n  \"timeout\": \"5s\", \"id\": \"a\"\n}")
+
+	err := UnmarshalJSON(data, &RetryConfigData{}, WithErrorPositions(), WithRules(MutuallyExclusive("timeout", "id")))
+	expected := "Only one of 'timeout', 'id' may be present, got 'timeout', 'id' at line 3, column 20"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	validator := &pointerError{pointer: "/timeout"}
+	err = UnmarshalJSON(data, &RetryConfigData{}, WithErrorPositions(), WithValidator(func([]byte) error { return validator }))
+	position := Position{Offset: 20, Line: 3, Column: 3}
+	if err != validator || validator.position != position {
+		t.Fatalf("Expected '%#v', got '%#v'", position, validator.position)
+	}
+}
+
+func TestPointerPosition(t *testing.T) {
+	data := []byte("{\"a\": {\"b/c\": [1,\n {\"d\": 2}]}, \"a\": {\"x\": 1}}")
+
+	cases := map[string]Position{
+		"":     {Offset: 0, Line: 1, Column: 1},
+		"/a":   {Offset: 31, Line: 2, Column: 14},
+		"/a/x": {Offset: 37, Line: 2, Column: 20},
+	}
+	for pointer, expected := range cases {
+		if p, ok := pointerPosition(data, pointer); !ok || p != expected {
+			t.Fatalf("Expected '%v' for '%s', got '%v'", expected, pointer, p)
+		}
+	}
+
+	// The first 'a' is not reachable, as encoding/json keeps the last
+	for _, pointer := range []string{"/a/b~1c", "/b", "a", "/a/x/0"} {
+		if p, ok := pointerPosition(data, pointer); ok {
+			t.Fatalf("Expected no position for '%s', got '%v'", pointer, p)
+		}
+	}
+
+	data = []byte("{\"a\": {\"b/c\": [1,\n {\"d\": 2}]}}")
+	cases = map[string]Position{
+		"/a/b~1c":     {Offset: 7, Line: 1, Column: 8},
+		"/a/b~1c/1":   {Offset: 19, Line: 2, Column: 2},
+		"/a/b~1c/1/d": {Offset: 20, Line: 2, Column: 3},
+	}
+	for pointer, expected := range cases {
+		if p, ok := pointerPosition(data, pointer); !ok || p != expected {
+			t.Fatalf("Expected '%v' for '%s', got '%v'", expected, pointer, p)
+		}
+	}
+	if _, ok := pointerPosition(data, "/a/b~1c/2"); ok {
+		t.Fatalf("Expected no position past the end of an array")
+	}
+}
+
+func TestWithOverflowPositionsRequiresEmbedding(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &p, WithOverflowPositions())
+
+	expected := "WithOverflowPositions requires the struct to embed j2n.OverflowPositions"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
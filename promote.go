@@ -0,0 +1,127 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Decodes the overflow entry at key of the struct pointed to by v into the
+// value pointed to by target, and removes it from Overflow, for graduating
+// a member from unknown to known:
+//
+//	var age int
+//	found, err := j2n.Promote(&cat, "age", &age)
+//
+// Either both happen or neither does: if the value cannot be decoded, or an
+// OverflowStore refuses the change, target and Overflow are unchanged.
+// Promote returns false if there is no entry at key. A null entry is decoded
+// like any other, leaving target at its zero value.
+func Promote(v interface{}, key string, target interface{}) (bool, error) {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return false, errors.New("Promote requires a non-nil pointer target")
+	}
+
+	decoded, found, err := decodeOverflowEntry(v, key, ptr.Elem().Type(), false)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := removeOverflowEntry(v, key); err != nil {
+		return false, err
+	}
+	ptr.Elem().Set(decoded)
+	return true, nil
+}
+
+// Decodes the overflow entry at key of the struct pointed to by v into its
+// named field with the JSON key field, and removes it from Overflow, for
+// documents whose member was kept in Overflow before the field was added,
+// or under an older name. As with Promote, either both happen or neither
+// does, and PromoteField returns false if there is no entry at key.
+func PromoteField(v interface{}, key, field string) (bool, error) {
+	value, f, err := namedFieldOf(v, field, "PromoteField")
+	if err != nil {
+		return false, err
+	}
+	target, err := value.FieldByIndexErr(f.index)
+	if err != nil {
+		return false, fmt.Errorf("Cannot promote '%s': %s", printableKey(key), err)
+	}
+
+	codec, err := newConfig(v, nil).codecFor(f)
+	if err != nil {
+		return false, fmt.Errorf("Invalid j2n tag on field '%s': %s", printableKey(field), err)
+	}
+
+	var decoded reflect.Value
+	var found bool
+	if codec != nil {
+		decoded, found, err = decodeCodecEntry(v, key, f.typ, codec)
+	} else {
+		decoded, found, err = decodeOverflowEntry(v, key, f.typ, f.quoted)
+	}
+	if err != nil || !found {
+		return false, err
+	}
+	if err := removeOverflowEntry(v, key); err != nil {
+		return false, err
+	}
+	target.Set(decoded)
+	return true, nil
+}
+
+// Returns the overflow entry at key of v, decoded into a new value of type
+// t. If quoted, the entry is a string holding the JSON value, as for fields
+// tagged with the string option.
+func decodeOverflowEntry(v interface{}, key string, t reflect.Type, quoted bool) (reflect.Value, bool, error) {
+	overflow, err := getOverflowMap(v)
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	entry, ok := overflow[key]
+	if !ok {
+		return reflect.Value{}, false, nil
+	}
+
+	raw := rawOrNull(entry)
+	if quoted {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, false, getterError(key, t.String(), err)
+		}
+		raw = json.RawMessage(s)
+	}
+
+	decoded := reflect.New(t)
+	if err := json.Unmarshal(raw, decoded.Interface()); err != nil {
+		return reflect.Value{}, false, getterError(key, t.String(), err)
+	}
+	return decoded.Elem(), true, nil
+}
+
+// Returns the overflow entry at key of v, decoded with codec into a new
+// value of type t.
+func decodeCodecEntry(v interface{}, key string, t reflect.Type, codec fieldCodec) (reflect.Value, bool, error) {
+	overflow, err := getOverflowMap(v)
+	if err != nil {
+		return reflect.Value{}, false, err
+	}
+	entry, ok := overflow[key]
+	if !ok {
+		return reflect.Value{}, false, nil
+	}
+
+	decoded := reflect.New(t).Elem()
+	if err := decodeCodecField(codec, rawOrNull(entry), decoded); err != nil {
+		return reflect.Value{}, false, getterError(key, t.String(), err)
+	}
+	return decoded, true, nil
+}
+
+func removeOverflowEntry(v interface{}, key string) error {
+	return updateOverflow(v, func(overflow map[string]*json.RawMessage) {
+		delete(overflow, key)
+	})
+}
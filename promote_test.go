@@ -0,0 +1,59 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestPromote(t *testing.T) {
+	data := OverflowPersonData{Name: "bert", Overflow: newTestOverflow(map[string]string{"age": "3", "colour": `"yellow"`})}
+
+	var age int
+	found, err := Promote(&data, "age", &age)
+	if err != nil || !found {
+		t.Fatalf("Expected the key to be found, got %t and '%v'", found, err)
+	}
+	if age != 3 {
+		t.Fatalf("Expected 3, got %d", age)
+	}
+	if _, ok := data.Overflow["age"]; ok || len(data.Overflow) != 1 {
+		t.Fatalf("Expected 'age' to be removed, got %v", data.Overflow)
+	}
+
+	if found, err := Promote(&data, "age", &age); found || err != nil {
+		t.Fatalf("Expected the key not to be found, got %t and '%v'", found, err)
+	}
+}
+
+func TestPromoteLeavesOverflowOnError(t *testing.T) {
+	data := OverflowPersonData{Overflow: newTestOverflow(map[string]string{"age": `"three"`})}
+
+	age := 1
+	_, err := Promote(&data, "age", &age)
+	expected := "Overflow key 'age' is not a valid int: json: cannot unmarshal string into Go value of type int"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+	if age != 1 || data.Overflow["age"] == nil {
+		t.Fatalf("Expected target and overflow to be unchanged, got %d and %v", age, data.Overflow)
+	}
+}
+
+func TestPromoteField(t *testing.T) {
+	type AgedData struct {
+		Age      int64    `json:"age,string"`
+		Overflow Overflow `json:"-"`
+	}
+
+	data := AgedData{Overflow: newTestOverflow(map[string]string{"years": `"3"`})}
+	found, err := PromoteField(&data, "years", "age")
+	if err != nil || !found {
+		t.Fatalf("Expected the key to be found, got %t and '%v'", found, err)
+	}
+	if data.Age != 3 || len(data.Overflow) != 0 {
+		t.Fatalf("Expected age 3 and no overflow, got %+v", data)
+	}
+
+	if _, err := PromoteField(&data, "years", "name"); err == nil || err.Error() != "No named field 'name'" {
+		t.Fatalf("Expected an error for a missing field, got '%v'", err)
+	}
+}
@@ -0,0 +1,98 @@
+package j2n
+
+import (
+	"sort"
+	"strings"
+)
+
+// Provenance records which Source set each value loaded by LoadConfig, for
+// tooling that explains to operators where a setting came from:
+//
+//	var provenance j2n.Provenance
+//	err := j2n.LoadConfig(&config, sources, j2n.WithProvenance(&provenance))
+//
+//	for _, path := range provenance.Paths() {
+//		source, _ := provenance.Source(path)
+//		fmt.Printf("%s: set by %s %s\n", path, source.Kind, source.Name)
+//	}
+//
+// Paths are the dotted paths to each leaf value, as produced by Flatten,
+// except that arrays are leaves, since a source replaces an array as a
+// whole. Unknown keys, which end up in Overflow, are recorded like any
+// other, with the keys as the sources wrote them.
+type Provenance struct {
+	sources map[string]Source
+}
+
+// Returns an Option for LoadConfig that records in p which source set each
+// value, replacing anything recorded before. UnmarshalJSON ignores it.
+func WithProvenance(p *Provenance) Option {
+	return func(c *config) {
+		c.provenance = p
+	}
+}
+
+// Returns the source that set the value at path, or false if no source set
+// it, as for a named field left at its zero value.
+func (p *Provenance) Source(path string) (Source, bool) {
+	source, ok := p.sources[path]
+	return source, ok
+}
+
+// Returns the paths of the values set by the sources, in order.
+func (p *Provenance) Paths() []string {
+	paths := make([]string, 0, len(p.sources))
+	for path := range p.sources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Records source as having set the values in overlay, a decoded JSON
+// object merged as by MergeJSON over the sources recorded before it.
+func (p *Provenance) record(source Source, prefix string, overlay map[string]interface{}) {
+	if p.sources == nil {
+		p.sources = make(map[string]Source)
+	}
+
+	for k, v := range overlay {
+		path := joinFlatKey(prefix, k)
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			// null removes the value, and anything else replaces it
+			p.remove(path)
+			if v != nil {
+				p.sources[path] = source
+			}
+			continue
+		}
+
+		// An object is merged into an object, and replaces anything else
+		delete(p.sources, path)
+		p.record(source, path, object)
+		if !p.hasChildren(path) {
+			// An empty object is a leaf
+			p.sources[path] = source
+		}
+	}
+}
+
+// Forgets the value at path and any values inside it.
+func (p *Provenance) remove(path string) {
+	delete(p.sources, path)
+	for k := range p.sources {
+		if strings.HasPrefix(k, path+".") {
+			delete(p.sources, k)
+		}
+	}
+}
+
+func (p *Provenance) hasChildren(path string) bool {
+	for k := range p.sources {
+		if strings.HasPrefix(k, path+".") {
+			return true
+		}
+	}
+	return false
+}
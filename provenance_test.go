@@ -0,0 +1,68 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithProvenance(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"port": 9000,
+		"database": {"url": "postgres://db", "poolMode": "session"},
+		"tags": ["a", "b"],
+		"featureX": true
+	}`)
+	t.Setenv("APP_DATABASE__MAXCONNS", "20")
+
+	var provenance Provenance
+	err := LoadConfig(&ServerConfig{}, []Source{
+		Defaults(ServerConfig{Host: "localhost", Port: 8080}),
+		File(path),
+		Env("APP_"),
+		Patch("flags", []byte(`{"featureX":null,"database":{"url":"postgres://replica"},"extra":{}}`)),
+	}, WithProvenance(&provenance))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]string{
+		"database.maxConns": "env environment",
+		"database.poolMode": "file " + path,
+		"database.url":      "patch flags",
+		"extra":             "patch flags",
+		"host":              "default defaults",
+		"port":              "file " + path,
+		"tags":              "file " + path,
+	}
+	actual := make(map[string]string)
+	for _, p := range provenance.Paths() {
+		source, _ := provenance.Source(p)
+		actual[p] = source.Kind + " " + source.Name
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, actual)
+	}
+
+	if _, ok := provenance.Source("featureX"); ok {
+		t.Fatalf("Expected no source for a removed key")
+	}
+}
+
+func TestProvenanceReplacesObjects(t *testing.T) {
+	var provenance Provenance
+	err := LoadConfig(&ServerConfig{}, []Source{
+		Patch("first", []byte(`{"database":{"url":"a","maxConns":1},"other":1}`)),
+		Patch("second", []byte(`{"database":"none","other":{"a":{"b":null}}}`)),
+	}, WithProvenance(&provenance))
+	if err == nil {
+		t.Fatalf("Expected an error decoding 'database'")
+	}
+
+	expected := []string{"database", "other.a"}
+	if paths := provenance.Paths(); !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, paths)
+	}
+	if source, _ := provenance.Source("other.a"); source.Name != "second" {
+		t.Fatalf("Expected 'second', got '%s'", source.Name)
+	}
+}
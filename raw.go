@@ -0,0 +1,76 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// RawPayload retains the complete document a struct was parsed from, for
+// debugging and for re-submitting a request exactly as it was received. It
+// is embedded in the data struct, and filled in by UnmarshalJSON when the
+// WithRawPayload option is given:
+//
+//	type ChargeData struct {
+//		j2n.RawPayload
+//		Amount   int          `json:"amount"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+//	j2n.Configure(ChargeData{}, j2n.WithRawPayload())
+//
+// Its Raw method is then promoted to the wrapper type. RawPayload has no
+// exported fields, so it is invisible to encoding/json.
+type RawPayload struct {
+	raw json.RawMessage
+
+	// Kept by WithSurgicalEdit: the document after any rewriting, and the
+	// encoding of the struct once it was parsed
+	layout   []byte
+	snapshot []byte
+}
+
+// Returns the document as it was passed to UnmarshalJSON, before any
+// rewriting, or nil if the struct was not parsed with WithRawPayload. It
+// must not be modified.
+func (p *RawPayload) Raw() json.RawMessage {
+	return p.raw
+}
+
+var rawPayloadType = reflect.TypeOf(RawPayload{})
+
+// Returns an Option that keeps a copy of the document in the RawPayload
+// embedded in the struct, once it has been parsed successfully.
+// UnmarshalJSON fails if the struct does not embed a RawPayload.
+//
+// This doubles the memory held by each struct, so it is best set with
+// Configure only for the types that need it.
+func WithRawPayload() Option {
+	return func(c *config) {
+		c.rawPayload = true
+	}
+}
+
+// Returns the RawPayload embedded in the struct pointed to by v, or an
+// error naming the option that needs it.
+func rawPayloadOf(v interface{}, option string) (*RawPayload, error) {
+	value, ok := structValue(v)
+	if ok {
+		if field := value.FieldByName("RawPayload"); field.IsValid() && field.Type() == rawPayloadType && field.CanAddr() {
+			return field.Addr().Interface().(*RawPayload), nil
+		}
+	}
+	return nil, fmt.Errorf("%s requires the struct to embed j2n.RawPayload", option)
+}
+
+// Returns a copy of the RawPayload embedded in the struct v, or a pointer
+// to one, if there is one.
+func rawPayloadValue(v interface{}) (RawPayload, bool) {
+	value, ok := structValue(v)
+	if ok {
+		if field := value.FieldByName("RawPayload"); field.IsValid() && field.Type() == rawPayloadType {
+			return field.Interface().(RawPayload), true
+		}
+	}
+	return RawPayload{}, false
+}
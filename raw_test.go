@@ -0,0 +1,78 @@
+package j2n
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type ChargeData struct {
+	RawPayload
+	Amount   int      `json:"amount"`
+	Overflow Overflow `json:"-"`
+}
+
+type Charge struct {
+	ChargeData
+}
+
+func (c *Charge) UnmarshalJSON(data []byte) error {
+	return UnmarshalJSON(data, &c.ChargeData, WithRawPayload())
+}
+
+func (c Charge) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(c.ChargeData)
+}
+
+func TestWithRawPayloadRetainsDocument(t *testing.T) {
+	document := []byte(`{ "amount": 100, "currency": "gbp" }`)
+
+	c := Charge{}
+	if err := json.Unmarshal(document, &c); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(c.Raw()) != string(document) {
+		t.Fatalf("Expected '%s', got '%s'", document, c.Raw())
+	}
+
+	// The payload is a copy, and is not encoded
+	document[3] = 'X'
+	if string(c.Raw()) != `{ "amount": 100, "currency": "gbp" }` {
+		t.Fatalf("Expected the payload to be a copy, got '%s'", c.Raw())
+	}
+
+	out, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(out) != `{"amount":100,"currency":"gbp"}` {
+		t.Fatalf("Expected '%s', got '%s'", `{"amount":100,"currency":"gbp"}`, out)
+	}
+}
+
+func TestWithRawPayloadKeepsDocumentBeforeRewriting(t *testing.T) {
+	document := `{"version":"1","total":100}`
+	migrations := NewMigrations("version").Register("1", "2", Rename("total", "amount"))
+
+	c := ChargeData{}
+	if err := UnmarshalJSON([]byte(document), &c, WithMigrations(migrations), WithRawPayload()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if c.Amount != 100 || string(c.Raw()) != document {
+		t.Fatalf("Expected '%s', got '%s'", document, c.Raw())
+	}
+
+	if err := UnmarshalJSON([]byte(`{"amount":"x"}`), &c, WithRawPayload()); err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if string(c.Raw()) != document {
+		t.Fatalf("Expected a failed parse to leave the payload, got '%s'", c.Raw())
+	}
+}
+
+func TestWithRawPayloadRequiresField(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{}`), &p, WithRawPayload())
+	if err == nil || err.Error() != "WithRawPayload requires the struct to embed j2n.RawPayload" {
+		t.Fatalf("Expected a missing field error, got '%v'", err)
+	}
+}
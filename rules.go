@@ -0,0 +1,167 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Rule is a cross-field validation rule, checked against the top-level keys
+// of a document once it has been parsed. Rules see named fields and overflow
+// keys alike, and treat a key whose value is null as absent.
+type Rule struct {
+	check func(document map[string]*json.RawMessage) error
+
+	// Recorded only for Describe
+	kind   string
+	keys   []string
+	values []interface{}
+}
+
+// Returns an Option that checks rules after a successful parse. Every rule
+// is checked, and the failures are returned together, joined with
+// errors.Join.
+func WithRules(rules ...Rule) Option {
+	return func(c *config) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// RuleError is a failure of a Rule.
+type RuleError struct {
+	// The key the failure is at, such as the second of two mutually
+	// exclusive keys, or "" if it is not at any key
+	Key string
+	Err error
+
+	// Where Key is in the document, with WithErrorPositions. Line is 0 if it
+	// is not known.
+	Position Position
+}
+
+func (e *RuleError) Error() string {
+	if e.Position.Line > 0 {
+		return fmt.Sprintf("%s at %s", e.Err, e.Position)
+	}
+	return e.Err.Error()
+}
+
+// Returns the failure.
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// Sets the Position of e to that of its key, for WithErrorPositions.
+func (e *RuleError) Locate(position func(pointer string) (Position, bool)) {
+	if e.Key == "" {
+		return
+	}
+	if p, ok := position(keyPointer(e.Key)); ok {
+		e.Position = p
+	}
+}
+
+// Returns a Rule that requires key whenever other is present. If values are
+// given, key is only required when other is equal to one of them.
+//
+//	j2n.RequiredIf("card_number", "payment_method", "card")
+func RequiredIf(key, other string, values ...interface{}) Rule {
+	return Rule{kind: "requiredIf", keys: []string{key, other}, values: values, check: func(document map[string]*json.RawMessage) error {
+		raw := document[other]
+		if raw == nil || document[key] != nil {
+			return nil
+		}
+
+		if len(values) == 0 {
+			return &RuleError{Key: other, Err: fmt.Errorf("'%s' is required when '%s' is present", key, other)}
+		}
+
+		var actual interface{}
+		if err := json.Unmarshal(*raw, &actual); err != nil {
+			return err
+		}
+		for _, value := range values {
+			if equalJSONValue(actual, value) {
+				return &RuleError{Key: other, Err: fmt.Errorf("'%s' is required when '%s' is %s", key, other, *raw)}
+			}
+		}
+		return nil
+	}}
+}
+
+// Returns a Rule that allows at most one of keys to be present.
+func MutuallyExclusive(keys ...string) Rule {
+	return Rule{kind: "mutuallyExclusive", keys: keys, check: func(document map[string]*json.RawMessage) error {
+		var found []string
+		for _, k := range keys {
+			if document[k] != nil {
+				found = append(found, k)
+			}
+		}
+
+		if len(found) > 1 {
+			return &RuleError{Key: found[1], Err: fmt.Errorf("Only one of %s may be present, got %s", quoteKeys(keys), quoteKeys(found))}
+		}
+		return nil
+	}}
+}
+
+// Returns a Rule that requires at least one of keys to be present.
+func AtLeastOneOf(keys ...string) Rule {
+	return Rule{kind: "atLeastOneOf", keys: keys, check: func(document map[string]*json.RawMessage) error {
+		for _, k := range keys {
+			if document[k] != nil {
+				return nil
+			}
+		}
+		return &RuleError{Err: fmt.Errorf("At least one of %s is required", quoteKeys(keys))}
+	}}
+}
+
+// Returns the document's keys if there are rules to check them against.
+func (c *config) presentKeys(document map[string]*json.RawMessage) map[string]*json.RawMessage {
+	if len(c.rules) == 0 {
+		return nil
+	}
+
+	present := make(map[string]*json.RawMessage, len(document))
+	for k, raw := range document {
+		present[k] = raw
+	}
+	return present
+}
+
+func (c *config) checkRules(document map[string]*json.RawMessage) error {
+	var errs []error
+	for _, rule := range c.rules {
+		if err := rule.check(document); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns whether the decoded JSON value actual equals the Go value
+// expected, once expected has been through a JSON round trip.
+func equalJSONValue(actual, expected interface{}) bool {
+	data, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(actual, decoded)
+}
+
+func quoteKeys(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = "'" + k + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
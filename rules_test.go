@@ -0,0 +1,42 @@
+package j2n
+
+import (
+	"testing"
+)
+
+func TestWithRulesAcceptsValidDocument(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","payment":"card","card_number":"4111","email":"b@example.com"}`), &p, WithRules(
+		RequiredIf("card_number", "payment", "card"),
+		MutuallyExclusive("email", "phone"),
+		AtLeastOneOf("email", "phone"),
+	))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestWithRulesReportsEveryFailure(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","payment":"card","email":"b@example.com","phone":"555"}`), &p, WithRules(
+		RequiredIf("card_number", "payment", "card", "debit"),
+		RequiredIf("iban", "payment", "transfer"),
+		MutuallyExclusive("email", "phone"),
+		AtLeastOneOf("address", "city"),
+	))
+
+	expected := "'card_number' is required when 'payment' is \"card\"\n" +
+		"Only one of 'email', 'phone' may be present, got 'email', 'phone'\n" +
+		"At least one of 'address', 'city' is required"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestWithRulesTreatsNullAsAbsent(t *testing.T) {
+	p := PersonData{}
+	err := UnmarshalJSON([]byte(`{"name":null,"nickname":"Bertie"}`), &p, WithRules(RequiredIf("name", "nickname")))
+	if err == nil {
+		t.Fatal("Expected error when required key is null")
+	}
+}
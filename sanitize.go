@@ -0,0 +1,197 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HazardousKeyPolicy controls what UnmarshalJSON does with keys that are
+// unsafe for consumers of Overflow, such as JavaScript clients: keys with
+// control characters or line separators, reserved names such as
+// "__proto__", and keys longer than the maximum length.
+type HazardousKeyPolicy int
+
+const (
+	// RejectHazardousKeys fails on the first hazardous key, in key order,
+	// with an *OverflowError.
+	RejectHazardousKeys HazardousKeyPolicy = iota
+
+	// DropHazardousKeys silently removes hazardous keys from Overflow.
+	DropHazardousKeys
+
+	// NormalizeHazardousKeys renames hazardous keys: control characters and
+	// line separators are removed, long keys are truncated, and then
+	// reserved names get a "_" suffix, for which a key at the maximum length
+	// loses its last character. A key that becomes empty, or the same as
+	// another key or a named field, is rejected with an *OverflowError.
+	NormalizeHazardousKeys
+)
+
+// DefaultReservedKeys are the keys that WithHazardousKeys treats as
+// hazardous, unless others are given with WithReservedKeys.
+var DefaultReservedKeys = []string{"__proto__", "constructor", "prototype"}
+
+// DefaultMaxKeyLength is the longest key, in bytes, that WithHazardousKeys
+// allows, unless another length is given with WithMaxKeyLength.
+const DefaultMaxKeyLength = 256
+
+type keySafety struct {
+	enabled   bool
+	policy    HazardousKeyPolicy
+	maxLength int
+	reserved  []string
+}
+
+// Returns an Option that applies policy to hazardous keys before they enter
+// Overflow. Named fields are not checked. This happens after the key
+// patterns and before any overflow validators are called:
+//
+//	j2n.Configure(ProfileData{}, j2n.WithHazardousKeys(j2n.DropHazardousKeys))
+func WithHazardousKeys(policy HazardousKeyPolicy) Option {
+	return func(c *config) {
+		c.keySafety.enabled = true
+		c.keySafety.policy = policy
+	}
+}
+
+// Returns an Option that sets the longest key, in bytes, allowed by
+// WithHazardousKeys, enabling it with RejectHazardousKeys if it was not
+// given.
+func WithMaxKeyLength(length int) Option {
+	return func(c *config) {
+		c.keySafety.enabled = true
+		c.keySafety.maxLength = length
+	}
+}
+
+// Returns an Option that replaces DefaultReservedKeys as the names treated
+// as hazardous by WithHazardousKeys, enabling it with RejectHazardousKeys if
+// it was not given.
+func WithReservedKeys(keys ...string) Option {
+	keys = append([]string{}, keys...)
+
+	return func(c *config) {
+		c.keySafety.enabled = true
+		c.keySafety.reserved = keys
+	}
+}
+
+// Applies the hazardous key policy to overflow, in key order. named holds
+// the keys of the named fields, which a normalized key must not clash with.
+func (s *keySafety) sanitize(overflow map[string]*json.RawMessage, named map[string]bool) error {
+	if !s.enabled {
+		return nil
+	}
+
+	for _, k := range Overflow(overflow).sortedKeys() {
+		err := s.check(k)
+		if err == nil {
+			continue
+		}
+
+		switch s.policy {
+		case DropHazardousKeys:
+			delete(overflow, k)
+		case NormalizeHazardousKeys:
+			normalized := s.normalize(k)
+			if normalized == "" {
+				return &OverflowError{Key: k, Err: errors.New("Key is empty once normalized")}
+			}
+			if _, ok := overflow[normalized]; ok {
+				return &OverflowError{Key: k, Err: fmt.Errorf("Normalized key '%s' is already present", normalized)}
+			}
+			if named[normalized] {
+				return &OverflowError{Key: k, Err: fmt.Errorf("Normalized key '%s' is a named field", normalized)}
+			}
+			overflow[normalized] = overflow[k]
+			delete(overflow, k)
+		default:
+			return &OverflowError{Key: k, Err: err}
+		}
+	}
+	return nil
+}
+
+// Returns why k is hazardous, or nil if it is not.
+func (s *keySafety) check(k string) error {
+	if strings.IndexFunc(k, isUnsafeRune) >= 0 {
+		return errors.New("Key has control or invalid characters")
+	}
+	if s.isReserved(k) {
+		return errors.New("Key is reserved")
+	}
+	if max := s.maxKeyLength(); len(k) > max {
+		return fmt.Errorf("Key is longer than %d bytes", max)
+	}
+	return nil
+}
+
+func (s *keySafety) normalize(k string) string {
+	k = strings.Map(func(r rune) rune {
+		if isUnsafeRune(r) {
+			return -1
+		}
+		return r
+	}, k)
+
+	max := s.maxKeyLength()
+	k = truncateKey(k, max)
+
+	// The suffix must fit within the maximum, and if that gives another
+	// reserved name, such as "__proto__" cut to "__proto_" and suffixed,
+	// the key is cut short until it is not reserved
+	suffixed := false
+	for k != "" && s.isReserved(k) {
+		if suffixed {
+			k = truncateKey(k, len(k)-1)
+			continue
+		}
+		k = truncateKey(k, max-1) + "_"
+		suffixed = true
+	}
+	return k
+}
+
+// Returns k cut to at most length bytes, at a rune boundary.
+func truncateKey(k string, length int) string {
+	if len(k) <= length {
+		return k
+	}
+	if length < 0 {
+		return ""
+	}
+	for length > 0 && !utf8.RuneStart(k[length]) {
+		length--
+	}
+	return k[:length]
+}
+
+func (s *keySafety) isReserved(k string) bool {
+	reserved := s.reserved
+	if reserved == nil {
+		reserved = DefaultReservedKeys
+	}
+	for _, r := range reserved {
+		if k == r {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *keySafety) maxKeyLength() int {
+	if s.maxLength > 0 {
+		return s.maxLength
+	}
+	return DefaultMaxKeyLength
+}
+
+// Reports whether r is a control character, an invalid encoding, or a line
+// separator that JavaScript treated as a line break.
+func isUnsafeRune(r rune) bool {
+	return unicode.IsControl(r) || r == utf8.RuneError || r == '\u2028' || r == '\u2029'
+}
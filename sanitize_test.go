@@ -0,0 +1,105 @@
+package j2n
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithHazardousKeysRejects(t *testing.T) {
+	cases := map[string]string{
+		`{"name":"Bert","__proto__":{}}`:                       "Invalid overflow field '__proto__': Key is reserved",
+		`{"name":"Bert","a\u0007b":1}`:                         `Invalid overflow field 'a\ab': Key has control or invalid characters`,
+		`{"name":"Bert","` + strings.Repeat("k", 300) + `":1}`: "Key is longer than 256 bytes",
+	}
+
+	for data, expected := range cases {
+		p := OverflowPersonData{}
+		err := UnmarshalJSON([]byte(data), &p, WithHazardousKeys(RejectHazardousKeys))
+		if err == nil || !strings.HasSuffix(err.Error(), expected) {
+			t.Fatalf("Expected '%s', got '%v'", expected, err)
+		}
+	}
+}
+
+func TestWithHazardousKeysDrops(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","constructor":1,"longer":2,"ok":3}`), &p,
+		WithHazardousKeys(DropHazardousKeys),
+		WithMaxKeyLength(4),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !reflect.DeepEqual(p.Overflow.sortedKeys(), []string{"ok"}) {
+		t.Fatalf("Expected '[ok]', got '%v'", p.Overflow.sortedKeys())
+	}
+}
+
+func TestWithHazardousKeysNormalizes(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","__proto__":1,"a\u2028b":2,"héllo":3,"then":4}`), &p,
+		WithHazardousKeys(NormalizeHazardousKeys),
+		WithMaxKeyLength(2),
+		WithReservedKeys("__proto__", "then"),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// "héllo" is cut before the two bytes of "é"
+	expected := []string{"__", "ab", "h", "th"}
+	if !reflect.DeepEqual(p.Overflow.sortedKeys(), expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, p.Overflow.sortedKeys())
+	}
+}
+
+func TestWithHazardousKeysNormalizesReservedKeysAtTheMaximumLength(t *testing.T) {
+	cases := []struct {
+		key       string
+		maxLength int
+		expected  string
+	}{
+		// The suffix replaces the last character
+		{"constructor", 11, "constructo_"},
+		// A longer key is cut to a reserved name, which is then suffixed
+		{"constructors", 11, "constructo_"},
+		// "__proto_" suffixed is reserved again, so it is cut instead
+		{"__proto__", 9, "__proto_"},
+		{"__proto__x", 9, "__proto_"},
+	}
+
+	for _, c := range cases {
+		p := OverflowPersonData{}
+		err := UnmarshalJSON([]byte(`{"name":"Bert","`+c.key+`":1}`), &p,
+			WithHazardousKeys(NormalizeHazardousKeys),
+			WithMaxKeyLength(c.maxLength),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if !reflect.DeepEqual(p.Overflow.sortedKeys(), []string{c.expected}) {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", c.expected, c.key, p.Overflow.sortedKeys())
+		}
+	}
+}
+
+func TestWithHazardousKeysRejectsCollisions(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","a\u0000":1,"a":2}`), &p, WithHazardousKeys(NormalizeHazardousKeys))
+
+	expected := `Invalid overflow field 'a\x00': Normalized key 'a' is already present`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestWithHazardousKeysRejectsCollisionsWithNamedFields(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","name\u0007":"x"}`), &p, WithHazardousKeys(NormalizeHazardousKeys))
+
+	expected := `Invalid overflow field 'name\a': Normalized key 'name' is a named field`
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
@@ -0,0 +1,164 @@
+package j2n
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	numberType        = reflect.TypeOf(json.Number(""))
+)
+
+// Returns a JSON Schema (draft 2020-12) describing the JSON accepted and
+// produced for v, which must be a struct with an 'Overflow' field.
+//
+// Each named field becomes a property, described from its Go type. Fields
+// holding pointers, slices or maps also allow null, which is how nil values
+// are encoded, unless they are omitempty or omitzero, and so are left out
+// when nil. Because
+// any other key is kept in Overflow, the schema allows additional
+// properties. Types with their own MarshalJSON method are left
+// unconstrained, apart from time.Time, which is described as a date-time
+// string.
+func Schema(v interface{}) ([]byte, error) {
+	return SchemaWithOverflow(v, nil)
+}
+
+// Returns a JSON Schema for v like Schema, but with overflow, if it is not
+// nil, as the additionalProperties schema that unknown keys must match.
+func SchemaWithOverflow(v interface{}, overflow json.RawMessage) ([]byte, error) {
+	if _, err := getOverflowFieldValue(v); err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema := structSchema(t, make(map[reflect.Type]bool))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	if overflow != nil {
+		schema["additionalProperties"] = overflow
+	}
+
+	return json.Marshal(schema)
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := make(map[string]interface{})
+	for _, f := range namedFields(t) {
+		var schema map[string]interface{}
+		if f.quoted && isQuotable(f.typ) {
+			schema = map[string]interface{}{"type": "string"}
+		} else {
+			schema = typeSchema(f.typ, visiting)
+		}
+		if !f.omitEmpty && !f.omitZero {
+			schema = nullableSchema(f.typ, schema)
+		}
+		properties[f.name] = schema
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": true,
+	}
+}
+
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == numberType:
+		return map[string]interface{}{"type": "number"}
+	case t == rawMessageType || visiting[t]:
+		return map[string]interface{}{}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]interface{}{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": elementSchema(t.Elem(), visiting)}
+	case reflect.Array:
+		return map[string]interface{}{
+			"type":     "array",
+			"items":    elementSchema(t.Elem(), visiting),
+			"minItems": t.Len(),
+			"maxItems": t.Len(),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": elementSchema(t.Elem(), visiting),
+		}
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	}
+
+	return map[string]interface{}{}
+}
+
+// Returns the schema for the elements of an array or the values of a map,
+// which are never left out, and so are null when nil.
+func elementSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	return nullableSchema(t, typeSchema(t, visiting))
+}
+
+// Returns schema, allowing null as well if values of type t can be nil.
+// Schemas without a type already allow it.
+func nullableSchema(t reflect.Type, schema map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+	default:
+		return schema
+	}
+	if typ, ok := schema["type"]; ok {
+		schema["type"] = []interface{}{typ, "null"}
+	}
+	return schema
+}
+
+// Returns whether the ",string" tag option applies to values of type t.
+func isQuotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package j2n
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type SchemaTestAddress struct {
+	City string `json:"city"`
+}
+
+type SchemaTestData struct {
+	Name     string             `json:"name"`
+	Age      uint8              `json:"age,omitempty"`
+	Score    float64            `json:"score,string"`
+	Tags     []string           `json:"tags"`
+	Born     time.Time          `json:"born"`
+	Address  *SchemaTestAddress `json:"address"`
+	Aliases  []*string          `json:"aliases,omitempty"`
+	Overflow Overflow           `json:"-"`
+}
+
+func TestSchemaDescribesNamedFields(t *testing.T) {
+	data, err := Schema(&SchemaTestData{})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"type":                 "object",
+		"additionalProperties": true,
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string"},
+			"age":   map[string]interface{}{"type": "integer", "minimum": float64(0)},
+			"score": map[string]interface{}{"type": "string"},
+			"tags":  map[string]interface{}{"type": []interface{}{"array", "null"}, "items": map[string]interface{}{"type": "string"}},
+			"born":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"address": map[string]interface{}{
+				"type":                 []interface{}{"object", "null"},
+				"additionalProperties": true,
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": "string"},
+				},
+			},
+			"aliases": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": []interface{}{"string", "null"}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, schema)
+	}
+}
+
+func TestSchemaWithOverflowUsesSuppliedSchema(t *testing.T) {
+	data, err := SchemaWithOverflow(PersonData{}, json.RawMessage(`{"type":"string"}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := map[string]interface{}{"type": "string"}
+	if !reflect.DeepEqual(schema["additionalProperties"], expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, schema["additionalProperties"])
+	}
+}
+
+func TestSchemaReturnsErrorWithoutOverflow(t *testing.T) {
+	if _, err := Schema(PersonDataWithoutOverflow{}); err == nil {
+		t.Fatal("Expected error generating schema for struct without overflow")
+	}
+}
@@ -0,0 +1,195 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// Detector finds one kind of secret in strings.
+type Detector struct {
+	// Name identifies the detector in the default placeholder
+	Name    string
+	Pattern *regexp.Regexp
+
+	// Valid, if not nil, is called with each match of Pattern, and the
+	// match is only replaced if it returns true
+	Valid func(match string) bool
+
+	// Placeholder replaces each match. If it is "", "[REDACTED:Name]" is
+	// used.
+	Placeholder string
+}
+
+func (d Detector) placeholder() string {
+	if d.Placeholder != "" {
+		return d.Placeholder
+	}
+	return "[REDACTED:" + d.Name + "]"
+}
+
+var (
+	// EmailDetector finds email addresses.
+	EmailDetector = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	}
+
+	// CardNumberDetector finds payment card numbers of 13 to 19 digits,
+	// optionally separated by spaces or dashes, that pass the Luhn check.
+	CardNumberDetector = Detector{
+		Name:    "card",
+		Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:   luhnValid,
+	}
+
+	// TokenDetector finds bearer tokens, JWTs, and API keys with well-known
+	// prefixes, such as those of AWS, GitHub, Slack and Stripe.
+	TokenDetector = Detector{
+		Name: "token",
+		Pattern: regexp.MustCompile(`(?i:\bbearer\s+[A-Za-z0-9._~+/-]+=*)` +
+			`|\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*` +
+			`|\b(?:AKIA|ASIA)[0-9A-Z]{16}\b` +
+			`|\bgh[pousr]_[A-Za-z0-9]{36,}\b` +
+			`|\bxox[abprs]-[A-Za-z0-9-]{10,}` +
+			`|\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}\b`),
+	}
+)
+
+// Scrubber replaces secrets found by its detectors in overflow values, so
+// that unknown fields cannot smuggle them into logs and storage. Strings
+// are scrubbed at any depth in a value, and so are numbers, which are
+// replaced by the placeholder string if they contain a match.
+type Scrubber struct {
+	detectors []Detector
+}
+
+// Returns a Scrubber with the given detectors, which are applied in order,
+// or with EmailDetector, CardNumberDetector and TokenDetector if there are
+// none.
+func NewScrubber(detectors ...Detector) *Scrubber {
+	if len(detectors) == 0 {
+		detectors = []Detector{EmailDetector, CardNumberDetector, TokenDetector}
+	}
+	return &Scrubber{detectors: append([]Detector(nil), detectors...)}
+}
+
+// Returns s with each secret replaced by its detector's placeholder.
+func (s *Scrubber) ScrubString(str string) string {
+	for _, d := range s.detectors {
+		placeholder := d.placeholder()
+		str = d.Pattern.ReplaceAllStringFunc(str, func(match string) string {
+			if d.Valid != nil && !d.Valid(match) {
+				return match
+			}
+			return placeholder
+		})
+	}
+	return str
+}
+
+// Returns raw with the secrets in its strings and numbers replaced. If
+// nothing was found, raw itself is returned, with its formatting.
+func (s *Scrubber) Scrub(raw json.RawMessage) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	value, changed := s.scrubValue(value)
+	if !changed {
+		return raw, nil
+	}
+	return json.Marshal(value)
+}
+
+func (s *Scrubber) scrubValue(value interface{}) (interface{}, bool) {
+	changed := false
+	switch v := value.(type) {
+	case string:
+		scrubbed := s.ScrubString(v)
+		return scrubbed, scrubbed != v
+	case json.Number:
+		if scrubbed := s.ScrubString(string(v)); scrubbed != string(v) {
+			return scrubbed, true
+		}
+	case []interface{}:
+		for i, e := range v {
+			var c bool
+			if v[i], c = s.scrubValue(e); c {
+				changed = true
+			}
+		}
+	case map[string]interface{}:
+		for k, e := range v {
+			var c bool
+			if v[k], c = s.scrubValue(e); c {
+				changed = true
+			}
+		}
+	}
+	return value, changed
+}
+
+// Scrubs every value in o in place.
+func (s *Scrubber) ScrubOverflow(o Overflow) error {
+	for _, k := range o.sortedKeys() {
+		scrubbed, err := s.Scrub(rawOrNull(o[k]))
+		if err != nil {
+			return &OverflowError{Key: k, Err: err}
+		}
+		o[k] = &scrubbed
+	}
+	return nil
+}
+
+// Returns an Option that scrubs the values that end up in Overflow with s,
+// before they are seen by validators, hooks or metrics, so the struct never
+// holds the secrets:
+//
+//	j2n.Configure(EventData{}, j2n.WithScrubber(j2n.NewScrubber()))
+func WithScrubber(s *Scrubber) Option {
+	return func(c *config) {
+		c.scrubbers = append(c.scrubbers, s)
+	}
+}
+
+// Returns an Option for MarshalJSON that scrubs the Overflow values in its
+// output with s, leaving the struct unchanged. Named fields are not
+// scrubbed.
+func WithOutputScrubber(s *Scrubber) Option {
+	return func(c *config) {
+		c.outputScrubbers = append(c.outputScrubbers, s)
+	}
+}
+
+func scrubOverflow(overflow map[string]*json.RawMessage, scrubbers []*Scrubber) error {
+	for _, s := range scrubbers {
+		if err := s.ScrubOverflow(overflow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reports whether the digits in s pass the Luhn check.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
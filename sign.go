@@ -0,0 +1,215 @@
+package j2n
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// DefaultSignatureKey is the member that Sign and Verify keep the
+// signature in, unless another is given with WithSignatureKey.
+const DefaultSignatureKey = "signature"
+
+// Returns an Option for Sign and Verify that keeps the signature in the
+// member called name.
+func WithSignatureKey(name string) Option {
+	return func(c *config) {
+		c.signatureKey = name
+	}
+}
+
+func (c *config) signatureMember() string {
+	if c.signatureKey != "" {
+		return c.signatureKey
+	}
+	return DefaultSignatureKey
+}
+
+// Returns the JSON encoding of v, including Overflow, in canonical form and
+// signed with an HMAC-SHA256 of key, for relaying webhook payloads so that
+// tampering can be detected with Verify. v is a struct with an Overflow
+// field, encoded with MarshalJSON and opts, or a wrapper type with its own
+// MarshalJSON method.
+//
+// In the canonical form, keys are sorted at every level, there is no
+// whitespace, HTML characters are not escaped, and numbers are kept exactly
+// as written. The signature is the unpadded base64url encoding of the HMAC
+// of that form, added as a string member under DefaultSignatureKey or the
+// key given with WithSignatureKey. Any member already under that key is
+// replaced.
+func Sign(v interface{}, key []byte, opts ...Option) ([]byte, error) {
+	var data []byte
+	var err error
+	if m, ok := v.(json.Marshaler); ok {
+		data, err = m.MarshalJSON()
+	} else {
+		data, err = MarshalJSON(v, opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := newConfig(v, opts)
+	document, err := decodeObject(data)
+	if err != nil {
+		return nil, err
+	}
+	delete(document, c.signatureMember())
+
+	canonical, err := canonicalJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	document[c.signatureMember()] = signature(canonical, key)
+	return canonicalJSON(document)
+}
+
+// Checks that data, a document produced by Sign, has a valid signature for
+// key. Its members may be in any order and formatted in any way, but its
+// strings and numbers must be unchanged.
+func Verify(data []byte, key []byte, opts ...Option) error {
+	c := newConfig(nil, opts)
+	document, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+
+	name := c.signatureMember()
+	signed, ok := document[name].(string)
+	if !ok {
+		return fmt.Errorf("Missing signature '%s'", name)
+	}
+	delete(document, name)
+
+	canonical, err := canonicalJSON(document)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(signed), []byte(signature(canonical, key))) {
+		return errors.New("Invalid signature")
+	}
+	return nil
+}
+
+func signature(canonical, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(canonical)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Returns the JSON object data, decoded with UseNumber. Documents that
+// parsers could read in different ways are rejected: those with invalid
+// UTF-8 or unpaired surrogate escapes, with a key repeated in any object,
+// or with anything but space after the object.
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("Invalid UTF-8 in document")
+	}
+	if err := checkUnambiguous(data); err != nil {
+		return nil, err
+	}
+	if err := checkEscapes(data); err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, errors.New("Expected a JSON object")
+	}
+	return document, nil
+}
+
+// Returns an error if an object in data repeats a key, or if data holds
+// more than one value.
+func checkUnambiguous(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := checkUniqueKeys(decoder); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("Unexpected data after the document")
+	}
+	return nil
+}
+
+// Returns an error if a string in data, which must be valid JSON, has an
+// unpaired surrogate escape. "\ud800" and "\udbff" both decode to U+FFFD,
+// so a signature over one would be valid for the other.
+func checkEscapes(data []byte) error {
+	for i := 0; i < len(data); i++ {
+		if data[i] != '"' {
+			continue
+		}
+		end := skipString(data, i)
+		next := skipSpace(data, end)
+		if err := checkSurrogates(data[i:end], next < len(data) && data[next] == ':'); err != nil {
+			return err
+		}
+		i = end - 1
+	}
+	return nil
+}
+
+// Reads the next value from decoder, checking that its objects have no
+// repeated keys.
+func checkUniqueKeys(decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		keys := make(map[string]bool)
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key := token.(string)
+			if keys[key] {
+				return fmt.Errorf("Repeated key '%s' in document", printableKey(key))
+			}
+			keys[key] = true
+			if err := checkUniqueKeys(decoder); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for decoder.More() {
+			if err := checkUniqueKeys(decoder); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// The closing delimiter
+	_, err = decoder.Token()
+	return err
+}
+
+// Returns the canonical form of a value decoded with UseNumber.
+// encoding/json already sorts the keys of maps.
+func canonicalJSON(value interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
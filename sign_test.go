@@ -0,0 +1,123 @@
+package j2n
+
+import (
+	"strings"
+	"testing"
+)
+
+var signingKey = []byte("webhook secret")
+
+func TestSignAndVerify(t *testing.T) {
+	p := OverflowPersonData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Bert","z":1.50,"a":{"y":"<b>","x":[1]}}`), &p); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	signed, err := Sign(p, signingKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	prefix := `{"a":{"x":[1],"y":"<b>"},"name":"Bert","signature":"`
+	if !strings.HasPrefix(string(signed), prefix) || !strings.HasSuffix(string(signed), `","z":1.50}`) {
+		t.Fatalf("Expected a canonical signed document, got '%s'", signed)
+	}
+
+	if err := Verify(signed, signingKey); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// Reformatting and reordering does not matter
+	reformatted := strings.Replace(string(signed), `{"a":`, "{\n  \"z\": 1.50, \"a\":", 1)
+	reformatted = strings.Replace(reformatted, `,"z":1.50}`, "}", 1)
+	if err := Verify([]byte(reformatted), signingKey); err != nil {
+		t.Fatalf("Expected no error for '%s', got '%s'", reformatted, err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	p := OverflowPersonData{Name: "Bert", Overflow: newTestOverflow(map[string]string{"amount": "10"})}
+	signed, err := Sign(p, signingKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	tampered := strings.Replace(string(signed), `"amount":10`, `"amount":1000`, 1)
+	if err := Verify([]byte(tampered), signingKey); err == nil || err.Error() != "Invalid signature" {
+		t.Fatalf("Expected 'Invalid signature', got '%v'", err)
+	}
+	if err := Verify(signed, []byte("other key")); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
+
+func TestVerifyRejectsAmbiguousDocuments(t *testing.T) {
+	p := OverflowPersonData{Name: "Bert", Overflow: newTestOverflow(map[string]string{"amount": "10", "meta": `{"a":1}`})}
+	signed, err := Sign(p, signingKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	cases := map[string]string{
+		string(signed) + `{"amount":1e9}`:                                "Unexpected data after the document",
+		string(signed) + ` 1`:                                            "Unexpected data after the document",
+		strings.Replace(string(signed), `{`, `{"amount":1e9,`, 1):        "Repeated key 'amount' in document",
+		strings.Replace(string(signed), `"meta":{`, `"meta":{"a":2,`, 1): "Repeated key 'a' in document",
+		strings.Replace(string(signed), `"Bert"`, "\"Bert\xff\"", 1):     "Invalid UTF-8 in document",
+	}
+	for data, expected := range cases {
+		if err := Verify([]byte(data), signingKey); err == nil || err.Error() != expected {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", expected, data, err)
+		}
+	}
+
+	// Trailing space is not data
+	if err := Verify(append(signed, " \n"...), signingKey); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
+
+func TestVerifyRejectsUnpairedSurrogates(t *testing.T) {
+	p := OverflowPersonData{Name: "Bert", Overflow: newTestOverflow(map[string]string{"note": `"\ufffd"`})}
+	signed, err := Sign(p, signingKey)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// Both decode to U+FFFD, as the signed value does
+	for _, escape := range []string{`\ud800`, `\udbff`} {
+		tampered := strings.Replace(string(signed), "\ufffd", escape, 1)
+		if tampered == string(signed) {
+			t.Fatalf("Expected U+FFFD in '%s'", signed)
+		}
+		err := Verify([]byte(tampered), signingKey)
+		expected := "String has an unpaired UTF-16 surrogate"
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected '%s', got '%v'", expected, err)
+		}
+	}
+
+	p.Overflow = newTestOverflow(map[string]string{"note": `"\ud800"`})
+	_, err = Sign(p, signingKey)
+	expected := "String has an unpaired UTF-16 surrogate"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestWithSignatureKey(t *testing.T) {
+	p := OverflowPersonData{Name: "Bert"}
+	signed, err := Sign(p, signingKey, WithSignatureKey("x-sig"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if !strings.Contains(string(signed), `"x-sig":"`) {
+		t.Fatalf("Expected an 'x-sig' member, got '%s'", signed)
+	}
+
+	if err := Verify(signed, signingKey, WithSignatureKey("x-sig")); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := Verify(signed, signingKey); err == nil || err.Error() != "Missing signature 'signature'" {
+		t.Fatalf("Expected 'Missing signature 'signature'', got '%v'", err)
+	}
+}
@@ -0,0 +1,53 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Returns an Option that declares the Go type expected for the overflow key,
+// without adding a field to the struct. If the key ends up in Overflow, its
+// value must unmarshal into a value of the same type as example, or
+// UnmarshalJSON fails with an *OverflowError. A null value is accepted.
+//
+// Declared keys can then be read with the typed getters or Overflow.Decode.
+// Declarations are normally registered for a type with Configure:
+//
+//	j2n.Configure(CatData{},
+//		j2n.WithOverflowType("age", int64(0)),
+//		j2n.WithOverflowType("tags", []string(nil)),
+//	)
+//
+// example must not be a nil interface, which has no type. Options cannot
+// fail when they are built, so the mistake is reported when the option is
+// used instead: UnmarshalJSON fails for every document, whether or not it
+// has the key, with an error naming WithOverflowType.
+func WithOverflowType(key string, example interface{}) Option {
+	t := reflect.TypeOf(example)
+	if t == nil {
+		return WithValidator(func([]byte) error {
+			return fmt.Errorf("WithOverflowType requires an example value for '%s', got nil", printableKey(key))
+		})
+	}
+
+	validate := WithOverflowValidator(func(k string, raw json.RawMessage) error {
+		if k != key {
+			return nil
+		}
+
+		if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+			return fmt.Errorf("Expected %s: %s", t, err)
+		}
+		return nil
+	})
+
+	return func(c *config) {
+		validate(c)
+		if c.overflowTypes == nil {
+			c.overflowTypes = make(map[string]reflect.Type)
+		}
+		c.overflowTypes[key] = t
+		c.describedValidators++
+	}
+}
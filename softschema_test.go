@@ -0,0 +1,54 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithOverflowTypeAcceptsDeclaredTypes(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":29,"tags":["a","b"],"city":null}`), &p,
+		WithOverflowType("age", int64(0)),
+		WithOverflowType("tags", []string(nil)),
+		WithOverflowType("city", ""),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var tags []string
+	if ok, err := p.Overflow.Decode("tags", &tags); !ok || err != nil {
+		t.Fatalf("Expected 'tags' to decode, got %t and '%v'", ok, err)
+	}
+
+	if !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Fatalf("Expected '[a b]', got '%v'", tags)
+	}
+}
+
+func TestWithOverflowTypeRejectsOtherTypes(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert","age":"old"}`), &p, WithOverflowType("age", int64(0)))
+
+	overflowErr, ok := err.(*OverflowError)
+	if !ok || overflowErr.Key != "age" {
+		t.Fatalf("Expected overflow error for 'age', got '%v'", err)
+	}
+}
+
+func TestWithOverflowTypeRequiresExample(t *testing.T) {
+	p := OverflowPersonData{}
+	opt := WithOverflowType("age", nil)
+
+	// The error is deferred to every decode, with or without the key
+	expected := "WithOverflowType requires an example value for 'age', got nil"
+	for _, data := range []string{`{"name":"Bert","age":29}`, `{"name":"Bert"}`} {
+		err := UnmarshalJSON([]byte(data), &p, opt)
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected '%s' for '%s', got '%v'", expected, data, err)
+		}
+	}
+	if _, err := Describe(&p, WithOverflowType("age", nil)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+}
@@ -0,0 +1,113 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// A member is a key/value pair of a JSON object, located by its offsets in
+// the document: the key's opening quote is at start, its value starts at
+// valueStart, and end is just after the value.
+type member struct {
+	key        string
+	start      int
+	valueStart int
+	end        int
+}
+
+// Returns the members of the object whose opening brace is at data[offset],
+// in document order, and the offset of its closing brace. data must be valid
+// JSON.
+func objectMembers(data []byte, offset int) ([]member, int, error) {
+	if offset >= len(data) || data[offset] != '{' {
+		return nil, 0, errors.New("Expected a JSON object")
+	}
+
+	var members []member
+	i := skipSpace(data, offset+1)
+	for data[i] != '}' {
+		start := i
+		end := skipValue(data, i)
+
+		var key string
+		if err := json.Unmarshal(data[start:end], &key); err != nil {
+			return nil, 0, err
+		}
+
+		// Skip the colon
+		i = skipSpace(data, skipSpace(data, end)+1)
+		valueStart := i
+		i = skipValue(data, i)
+		members = append(members, member{key: key, start: start, valueStart: valueStart, end: i})
+
+		i = skipSpace(data, i)
+		if data[i] == ',' {
+			i = skipSpace(data, i+1)
+		}
+	}
+	return members, i, nil
+}
+
+// Returns the offset of the first non-whitespace byte at or after i.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// Returns the offset just after the JSON value that starts at data[i].
+// data must be valid JSON.
+func skipValue(data []byte, i int) int {
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '"':
+				i = skipString(data, i)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	}
+
+	// A number, true, false or null
+	for i < len(data) {
+		switch data[i] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// Returns the offset just after the string whose opening quote is at
+// data[i].
+func skipString(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
@@ -0,0 +1,35 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObjectMembers(t *testing.T) {
+	data := []byte(` { "a" : 1, "b\"c":{"d":[1,"}"]} ,"e":"x\\"}`)
+	members, closing, err := objectMembers(data, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	var values []string
+	for _, m := range members {
+		values = append(values, m.key+"="+string(data[m.valueStart:m.end]))
+	}
+	expected := []string{`a=1`, `b"c={"d":[1,"}"]}`, `e="x\\"`}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("Expected '%v', got '%v'", expected, values)
+	}
+	if string(data[members[1].start:members[1].start+5]) != `"b\"c` {
+		t.Fatalf("Expected the member to start at its key, got '%s'", data[members[1].start:])
+	}
+	if closing != len(data)-1 {
+		t.Fatalf("Expected the closing brace at %d, got %d", len(data)-1, closing)
+	}
+}
+
+func TestObjectMembersRejectsNonObjects(t *testing.T) {
+	if _, _, err := objectMembers([]byte(`[1]`), 0); err == nil {
+		t.Fatalf("Expected an error")
+	}
+}
@@ -0,0 +1,143 @@
+package j2n
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// OverflowStore is implemented by types that hold the overflow entries of a
+// struct in place of a map, such as SyncOverflow. The Overflow field is
+// then a pointer to the store, which UnmarshalJSON allocates if it is nil:
+//
+//	type CatData struct {
+//		Name     string            `json:"name"`
+//		Overflow *j2n.SyncOverflow `json:"-"`
+//	}
+type OverflowStore interface {
+	// Returns the entries for MarshalJSON. The caller must not modify the
+	// map or the values.
+	OverflowEntries() map[string]*json.RawMessage
+
+	// Replaces the entries with those of m, once UnmarshalJSON has removed
+	// the named fields and applied the options that change Overflow. The
+	// store takes ownership of m.
+	ReplaceOverflow(m map[string]*json.RawMessage) error
+}
+
+var overflowStoreType = reflect.TypeOf((*OverflowStore)(nil)).Elem()
+
+func isOverflowStore(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Implements(overflowStoreType)
+}
+
+// SyncOverflow is an OverflowStore that is safe for concurrent use, for
+// structs that are read from many goroutines while another updates them,
+// for example by decoding a fresher document into the same struct. The
+// zero value is empty and ready to use.
+type SyncOverflow struct {
+	mutex   sync.RWMutex
+	entries Overflow
+	frozen  bool
+}
+
+// Returns a copy of the entries.
+func (s *SyncOverflow) OverflowEntries() map[string]*json.RawMessage {
+	return s.Snapshot()
+}
+
+func (s *SyncOverflow) ReplaceOverflow(m map[string]*json.RawMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.frozen {
+		return ErrFrozen
+	}
+	s.entries = m
+	return nil
+}
+
+// Makes s read-only.
+func (s *SyncOverflow) Freeze() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.frozen = true
+}
+
+// Reports whether s is read-only.
+func (s *SyncOverflow) Frozen() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.frozen
+}
+
+// Returns a copy of the entries, which can be read with the typed getters.
+// The raw values are shared, and must not be modified.
+func (s *SyncOverflow) Snapshot() Overflow {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshot := make(Overflow, len(s.entries))
+	for k, raw := range s.entries {
+		snapshot[k] = raw
+	}
+	return snapshot
+}
+
+// Calls fn with the entries, holding the read lock, to read several of them
+// consistently without copying. fn must not modify or retain o, or call
+// any method of s that changes it.
+func (s *SyncOverflow) Read(fn func(o Overflow)) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	fn(s.entries)
+}
+
+// Returns the raw value at key, which must not be modified.
+func (s *SyncOverflow) Get(key string) (json.RawMessage, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	raw, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return rawOrNull(raw), true
+}
+
+// Returns the number of entries.
+func (s *SyncOverflow) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.entries)
+}
+
+// Sets the value at key to a copy of raw, which must be valid JSON.
+func (s *SyncOverflow) Set(key string, raw json.RawMessage) error {
+	if !json.Valid(raw) {
+		return fmt.Errorf("Invalid JSON for overflow key '%s'", printableKey(key))
+	}
+	copied := append(json.RawMessage(nil), raw...)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.frozen {
+		return ErrFrozen
+	}
+	if s.entries == nil {
+		s.entries = make(Overflow)
+	}
+	s.entries[key] = &copied
+	return nil
+}
+
+// Removes the value at key, if there is one.
+func (s *SyncOverflow) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.frozen {
+		return ErrFrozen
+	}
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,80 @@
+package j2n
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+type SyncCatData struct {
+	Name     string        `json:"name"`
+	Overflow *SyncOverflow `json:"-"`
+}
+
+func TestSyncOverflowRoundTrip(t *testing.T) {
+	c := SyncCatData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Tom","age":3,"colour":"grey"}`), &c); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if c.Overflow.Len() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", c.Overflow.Len())
+	}
+	if age, _, err := c.Overflow.Snapshot().GetInt64("age"); err != nil || age != 3 {
+		t.Fatalf("Expected '3', got '%d' and '%v'", age, err)
+	}
+
+	if err := c.Overflow.Set("age", json.RawMessage(`4`)); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := c.Overflow.Delete("colour"); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if err := c.Overflow.Set("bad", json.RawMessage(`{`)); err == nil {
+		t.Fatalf("Expected an error")
+	}
+
+	data, err := MarshalJSON(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"age":4,"name":"Tom"}`
+	if string(data) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, data)
+	}
+}
+
+func TestSyncOverflowIsUpdatedInPlace(t *testing.T) {
+	c := SyncCatData{}
+	if err := UnmarshalJSON([]byte(`{"name":"Tom","age":3}`), &c); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	shared := c.Overflow
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				shared.Read(func(o Overflow) {
+					o.GetInt64("age")
+				})
+				shared.Get("age")
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		if err := UnmarshalJSON([]byte(`{"name":"Tom","age":4}`), &c); err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+	}
+	wg.Wait()
+
+	if c.Overflow != shared {
+		t.Fatalf("Expected the store to be reused")
+	}
+	if raw, ok := shared.Get("age"); !ok || string(raw) != "4" {
+		t.Fatalf("Expected '4', got '%s'", raw)
+	}
+}
@@ -0,0 +1,184 @@
+package j2n
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSkip is returned by the function given to Transform to leave a record
+// out of the output.
+var ErrSkip = errors.New("Skip record")
+
+// Reads a stream of JSON documents from r, decodes each into a new T,
+// passes it to fn, and writes the result to w. This is the building block
+// for migration jobs:
+//
+//	err := j2n.Transform(os.Stdin, os.Stdout, func(c *CatData) error {
+//		c.Name = strings.TrimSpace(c.Name)
+//		return nil
+//	})
+//
+// The stream is a single document, a sequence of documents such as NDJSON,
+// or a JSON array of documents. An array is written as an array, and
+// anything else as NDJSON, with one document per line.
+//
+// T is a struct with an Overflow field, decoded with UnmarshalJSON and
+// opts, or a wrapper type with its own UnmarshalJSON and MarshalJSON
+// methods, in which case opts are ignored. If fn returns ErrSkip, the record
+// is left out; any other error stops the transform, and is returned with
+// the number of the record, counting from 0.
+func Transform[T any](r io.Reader, w io.Writer, fn func(*T) error, opts ...Option) error {
+	records, err := newRecordReader(r)
+	if err != nil {
+		return err
+	}
+	output := newRecordWriter(w, records.array)
+
+	for i := 0; ; i++ {
+		raw, _, err := records.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("Record %d: %w", i, err)
+		}
+
+		result, err := transformRecord(raw, fn, opts)
+		if errors.Is(err, ErrSkip) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("Record %d: %w", i, err)
+		}
+		if err := output.write(result); err != nil {
+			return err
+		}
+	}
+	return output.close()
+}
+
+// Decodes raw into a new T, passes it to fn, and returns its encoding.
+func transformRecord[T any](raw json.RawMessage, fn func(*T) error, opts []Option) ([]byte, error) {
+	v := new(T)
+	if u, ok := interface{}(v).(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(raw); err != nil {
+			return nil, err
+		}
+	} else if err := UnmarshalJSON(raw, v, opts...); err != nil {
+		return nil, err
+	}
+
+	if err := fn(v); err != nil {
+		return nil, err
+	}
+
+	if m, ok := interface{}(v).(json.Marshaler); ok {
+		return m.MarshalJSON()
+	}
+	return MarshalJSON(v, opts...)
+}
+
+// A recordReader reads the documents of a stream, which is either a JSON
+// array of them or a sequence of them.
+type recordReader struct {
+	decoder *json.Decoder
+	array   bool
+
+	// The number of bytes skipped before the decoder started
+	skipped int64
+}
+
+func newRecordReader(r io.Reader) (*recordReader, error) {
+	buffered := bufio.NewReader(r)
+
+	var skipped int64
+	for {
+		b, err := buffered.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			buffered.UnreadByte()
+			break
+		}
+		skipped++
+	}
+
+	records := &recordReader{decoder: json.NewDecoder(buffered), skipped: skipped}
+	if b, err := buffered.Peek(1); err == nil && b[0] == '[' {
+		records.array = true
+		if _, err := records.decoder.Token(); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Returns the next document and the offset in the stream just after it,
+// or io.EOF at the end of the stream.
+func (r *recordReader) next() (json.RawMessage, int64, error) {
+	if r.array && !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return nil, 0, err
+		}
+		r.array = false
+		return nil, 0, io.EOF
+	}
+
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return nil, 0, err
+	}
+	return raw, r.skipped + r.decoder.InputOffset(), nil
+}
+
+// A recordWriter writes documents as a JSON array or as NDJSON.
+type recordWriter struct {
+	w       io.Writer
+	array   bool
+	written int
+}
+
+func newRecordWriter(w io.Writer, array bool) *recordWriter {
+	return &recordWriter{w: w, array: array}
+}
+
+func (r *recordWriter) write(data []byte) error {
+	var prefix, suffix string
+	switch {
+	case !r.array:
+		suffix = "\n"
+	case r.written == 0:
+		prefix = "["
+	default:
+		prefix = ","
+	}
+	r.written++
+
+	if _, err := io.WriteString(r.w, prefix); err != nil {
+		return err
+	}
+	if _, err := r.w.Write(data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(r.w, suffix)
+	return err
+}
+
+func (r *recordWriter) close() error {
+	if !r.array {
+		return nil
+	}
+	if r.written == 0 {
+		_, err := io.WriteString(r.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(r.w, "]\n")
+	return err
+}
@@ -0,0 +1,76 @@
+package j2n
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func upperCaseNames(p *OverflowPersonData) error {
+	if p.Name == "skip" {
+		return ErrSkip
+	}
+	p.Name = strings.ToUpper(p.Name)
+	return nil
+}
+
+func TestTransformNDJSON(t *testing.T) {
+	input := "{\"name\":\"bert\",\"age\":3}\n{\"name\":\"skip\"}\n\n{\"name\":\"ernie\"}\n"
+
+	var output bytes.Buffer
+	if err := Transform(strings.NewReader(input), &output, upperCaseNames); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "{\"age\":3,\"name\":\"BERT\"}\n{\"name\":\"ERNIE\"}\n"
+	if output.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output.String())
+	}
+}
+
+func TestTransformArray(t *testing.T) {
+	input := ` [ {"name":"bert","x":[1]}, {"name":"ernie"} ]`
+
+	var output bytes.Buffer
+	if err := Transform(strings.NewReader(input), &output, upperCaseNames); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "[{\"name\":\"BERT\",\"x\":[1]},{\"name\":\"ERNIE\"}]\n"
+	if output.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output.String())
+	}
+
+	output.Reset()
+	if err := Transform(strings.NewReader(`[]`), &output, upperCaseNames); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if output.String() != "[]\n" {
+		t.Fatalf("Expected '[]', got '%s'", output.String())
+	}
+}
+
+func TestTransformWrapperTypes(t *testing.T) {
+	var output bytes.Buffer
+	err := Transform(strings.NewReader(`{"name":"bert","age":3}`), &output, func(p *Person) error {
+		p.Name = "Bert"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	expected := "{\"age\":3,\"name\":\"Bert\"}\n"
+	if output.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output.String())
+	}
+}
+
+func TestTransformReportsRecord(t *testing.T) {
+	input := "{\"name\":\"bert\"}\n{\"name\":3}\n"
+
+	err := Transform(strings.NewReader(input), &bytes.Buffer{}, upperCaseNames)
+	if err == nil || !strings.HasPrefix(err.Error(), "Record 1: ") {
+		t.Fatalf("Expected an error for record 1, got '%v'", err)
+	}
+}
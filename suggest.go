@@ -0,0 +1,65 @@
+package j2n
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Returns the JSON key of the named field of t that key was most likely
+// meant to be, or false if none is close enough, for unknown keys that are
+// misspellings such as "retrys" for "retries".
+//
+// Keys are compared case-insensitively, by the number of single-character
+// insertions, deletions, substitutions and transpositions between them;
+// at most one edit is allowed for every three characters of key.
+func suggestKey(t reflect.Type, key string) (string, bool) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	limit := len(key) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	best, bestDistance := "", limit+1
+	for _, f := range namedFields(t) {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(f.name)); d < bestDistance {
+			best, bestDistance = f.name, d
+		}
+	}
+	return best, best != ""
+}
+
+// Returns the optimal string alignment distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	// Three rows of the matrix are enough to allow for transpositions
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := previous[j-1] + cost
+			if previous[j]+1 < d {
+				d = previous[j] + 1
+			}
+			if current[j-1]+1 < d {
+				d = current[j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && previous2[j-2]+1 < d {
+				d = previous2[j-2] + 1
+			}
+			current[j] = d
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,55 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+type RetryConfigData struct {
+	Retries  int      `json:"retries"`
+	Timeout  string   `json:"timeout"`
+	ID       string   `json:"id"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestSuggestKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"retrys", "retries"},
+		{"timout", "timeout"},
+		{"tiemout", "timeout"},
+		{"Timeouts", "timeout"},
+		{"ip", "id"},
+		{"retry_count", ""},
+		{"colour", ""},
+	}
+
+	configType := reflect.TypeOf(RetryConfigData{})
+	for _, test := range tests {
+		suggestion, ok := suggestKey(configType, test.key)
+		if suggestion != test.expected || ok != (test.expected != "") {
+			t.Fatalf("Expected '%s' for '%s', got '%s'", test.expected, test.key, suggestion)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"retrys", "retries", 2},
+		{"ab", "ba", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, test := range tests {
+		if d := editDistance(test.a, test.b); d != test.expected {
+			t.Fatalf("Expected %d between '%s' and '%s', got %d", test.expected, test.a, test.b, d)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package j2n
+
+// Returns an Option for UnmarshalJSON and MarshalJSON that edits documents
+// in place. UnmarshalJSON keeps the document in the RawPayload embedded in
+// the struct, and MarshalJSON then returns that document with only the
+// members that have changed since spliced in. Everything else keeps its
+// exact bytes: whitespace, key order, number formatting, escaping and
+// unknown keys. This gives minimal diffs when a program edits a JSON file
+// that people also maintain:
+//
+//	type SettingsData struct {
+//		j2n.RawPayload
+//		Theme    string       `json:"theme"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+//	j2n.Configure(SettingsData{}, j2n.WithSurgicalEdit())
+//
+// Changed members are replaced, removed members are deleted, and new
+// members are appended in key order, as by Passthrough. UnmarshalJSON fails
+// if the struct does not embed a RawPayload. MarshalJSON encodes as usual
+// if it has not been parsed with this option.
+func WithSurgicalEdit() Option {
+	return func(c *config) {
+		c.surgicalEdit = true
+	}
+}
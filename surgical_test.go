@@ -0,0 +1,76 @@
+package j2n
+
+import (
+	"testing"
+)
+
+type SettingsData struct {
+	RawPayload
+	Theme    string   `json:"theme"`
+	Size     int      `json:"size,omitempty"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestWithSurgicalEditKeepsLayout(t *testing.T) {
+	data := "{\n  \"zoom\": 1.50,\n  \"theme\": \"dark\",\n  \"size\": 12,\n  \"path\": \"a\\/b\"\n}\n"
+
+	s := SettingsData{}
+	if err := UnmarshalJSON([]byte(data), &s, WithSurgicalEdit()); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// Unchanged, the document is returned as it was
+	unchanged, err := MarshalJSON(s, WithSurgicalEdit())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(unchanged) != data {
+		t.Fatalf("Expected '%s', got '%s'", data, unchanged)
+	}
+
+	s.Theme = "light"
+	s.Size = 0
+	edited, err := MarshalJSON(&s, WithSurgicalEdit())
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := "{\n  \"zoom\": 1.50,\n  \"theme\": \"light\",\n  \"path\": \"a\\/b\"\n}\n"
+	if string(edited) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, edited)
+	}
+}
+
+func TestWithSurgicalEditWithConfigure(t *testing.T) {
+	Configure(SettingsData{}, WithSurgicalEdit())
+	defer Configure(SettingsData{})
+
+	s := SettingsData{}
+	if err := UnmarshalJSON([]byte(`{ "theme" : "dark" }`), &s); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	// Decoding again replaces the layout
+	if err := UnmarshalJSON([]byte(`{"theme": "dark",  "x": 1}`), &s); err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	s.Size = 3
+
+	edited, err := MarshalJSON(s)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"theme": "dark",  "x": 1,"size":3}`
+	if string(edited) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, edited)
+	}
+}
+
+func TestWithSurgicalEditRequiresRawPayload(t *testing.T) {
+	p := OverflowPersonData{}
+	err := UnmarshalJSON([]byte(`{"name":"Bert"}`), &p, WithSurgicalEdit())
+
+	expected := "WithSurgicalEdit requires the struct to embed j2n.RawPayload"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
@@ -0,0 +1,29 @@
+package j2n
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Returns the settings in the j2n tag of a field. Settings are separated by
+// semicolons, and each is a name, optionally followed by "=" and a value:
+//
+//	Email string `json:"email" j2n:"view=admin,internal"`
+//
+// A setting without a value maps to the empty string.
+func j2nTag(tag reflect.StructTag) map[string]string {
+	value, ok := tag.Lookup("j2n")
+	if !ok {
+		return nil
+	}
+
+	settings := make(map[string]string)
+	for _, setting := range strings.Split(value, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(setting), "=")
+		if name == "" {
+			continue
+		}
+		settings[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return settings
+}
@@ -0,0 +1,18 @@
+package j2n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJ2NTag(t *testing.T) {
+	settings := j2nTag(reflect.StructTag(`json:"x" j2n:"view=public, admin ; required;;"`))
+	expected := map[string]string{"view": "public, admin", "required": ""}
+	if !reflect.DeepEqual(settings, expected) {
+		t.Fatalf("Expected %v, got %v", expected, settings)
+	}
+
+	if settings := j2nTag(reflect.StructTag(`json:"x"`)); settings != nil {
+		t.Fatalf("Expected no settings, got %v", settings)
+	}
+}
@@ -0,0 +1,82 @@
+package j2n
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Returns a compact summary of o for logs, naming each key and the size of its
+// value, such as
+//
+//	overflow(2 keys: age=2B, tags=5B)
+//
+// Values are never included, so logging a struct does not leak the contents
+// of fields it does not know about.
+func (o Overflow) String() string {
+	var b strings.Builder
+	b.WriteString("overflow(")
+	b.WriteString(strconv.Itoa(len(o)))
+	if len(o) == 1 {
+		b.WriteString(" key")
+	} else {
+		b.WriteString(" keys")
+	}
+
+	for i, k := range o.sortedKeys() {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(summaryKey(k))
+		b.WriteByte('=')
+		b.WriteString(strconv.Itoa(len(rawOrNull(o[k]))))
+		b.WriteByte('B')
+	}
+
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Returns the summary given by String, so that text-based loggers print it in
+// place of the map of pointers.
+func (o Overflow) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// Returns the JSON object holding the entries of o. This takes precedence over
+// MarshalText, so encoding an Overflow as JSON still gives its contents.
+func (o Overflow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]*json.RawMessage(o))
+}
+
+// Returns a summary of o for structured logging with log/slog: the number of
+// keys, the keys themselves, and the total size of their values.
+func (o Overflow) LogValue() slog.Value {
+	keys := o.sortedKeys()
+	size := 0
+	for _, k := range keys {
+		size += len(rawOrNull(o[k]))
+	}
+
+	return slog.GroupValue(
+		slog.Int("count", len(keys)),
+		slog.Any("keys", keys),
+		slog.Int("bytes", size),
+	)
+}
+
+// Quotes keys that would make the summary ambiguous or unreadable.
+func summaryKey(k string) string {
+	for _, r := range k {
+		if r <= ' ' || r == '=' || r == ',' || r == '(' || r == ')' || r == '"' || !strconv.IsPrint(r) {
+			return strconv.Quote(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
@@ -0,0 +1,54 @@
+package j2n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestStringSummarizesKeysWithoutValues(t *testing.T) {
+	o := newTestOverflow(map[string]string{"tags": `["a"]`, "token": `"secret"`, "a b": `1`})
+	o["spouse"] = nil
+
+	expected := `overflow(4 keys: "a b"=1B, spouse=4B, tags=5B, token=8B)`
+	if o.String() != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, o.String())
+	}
+
+	if formatted := fmt.Sprintf("%v", o); strings.Contains(formatted, "secret") {
+		t.Fatalf("Expected value to be left out, got '%s'", formatted)
+	}
+}
+
+func TestMarshalJSONStillEncodesEntries(t *testing.T) {
+	o := newTestOverflow(map[string]string{"age": `29`})
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+
+	if string(data) != `{"age":29}` {
+		t.Fatalf("Expected '{\"age\":29}', got '%s'", data)
+	}
+}
+
+func TestLogValueSummarizesOverflow(t *testing.T) {
+	o := newTestOverflow(map[string]string{"age": `29`, "token": `"secret"`})
+
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, nil))
+	logger.Info("decoded", "overflow", o)
+
+	output := buffer.String()
+	if !strings.Contains(output, "overflow.count=2") || !strings.Contains(output, "overflow.bytes=10") {
+		t.Fatalf("Expected summary attributes, got '%s'", output)
+	}
+
+	if strings.Contains(output, "secret") {
+		t.Fatalf("Expected value to be left out, got '%s'", output)
+	}
+}
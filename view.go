@@ -0,0 +1,134 @@
+package j2n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ViewOverflow says whether MarshalView outputs Overflow in a view.
+type ViewOverflow int
+
+const (
+	// IncludeOverflow outputs Overflow as MarshalJSON does.
+	IncludeOverflow ViewOverflow = iota
+
+	// StripOverflow leaves Overflow out.
+	StripOverflow
+
+	// NamespaceOverflow outputs Overflow as an object under a single key,
+	// DefaultViewNamespace unless another is given with WithViewNamespace,
+	// so that clients can tell extension data from the documented fields.
+	// The key is left out if Overflow is empty.
+	NamespaceOverflow
+)
+
+// DefaultViewNamespace is the key that NamespaceOverflow outputs Overflow
+// under by default.
+const DefaultViewNamespace = "extensions"
+
+// Returns an Option for MarshalView that declares view, and sets whether
+// and how Overflow is output in it.
+func WithViewOverflow(view string, policy ViewOverflow) Option {
+	return func(c *config) {
+		if c.viewOverflow == nil {
+			c.viewOverflow = make(map[string]ViewOverflow)
+		}
+		c.viewOverflow[view] = policy
+	}
+}
+
+// Returns an Option for MarshalView that declares view, and outputs
+// Overflow in it as an object under key, as for NamespaceOverflow.
+func WithViewNamespace(view, key string) Option {
+	return func(c *config) {
+		WithViewOverflow(view, NamespaceOverflow)(c)
+		if c.viewNamespace == nil {
+			c.viewNamespace = make(map[string]string)
+		}
+		c.viewNamespace[view] = key
+	}
+}
+
+// Returns the encoding of v as MarshalJSON would, but with only the named
+// fields that belong to view, so that one struct can serve several
+// audiences. A field belongs to the views listed in its j2n tag, or to
+// every view if it has none:
+//
+//	type UserData struct {
+//		Name     string       `json:"name"`
+//		Email    string       `json:"email" j2n:"view=self,admin"`
+//		Notes    string       `json:"notes" j2n:"view=admin"`
+//		Overflow j2n.Overflow `json:"-"`
+//	}
+//
+//	j2n.Configure(UserData{},
+//		j2n.WithViewOverflow("public", j2n.StripOverflow),
+//		j2n.WithViewOverflow("self", j2n.StripOverflow),
+//		j2n.WithViewOverflow("admin", j2n.IncludeOverflow),
+//	)
+//	data, err := j2n.MarshalView(user, "public")
+//
+// Only the top-level fields are filtered; nested structs are encoded in
+// full. Whether Overflow is output, and how, is set for each view with
+// WithViewOverflow or WithViewNamespace, so that public views do not leak
+// the extension data that internal views need. Every view must be declared
+// by one of them: MarshalView fails for any other view, so that a
+// misspelled view is an error rather than a view of everything.
+func MarshalView(v interface{}, view string, opts ...Option) ([]byte, error) {
+	if view == "" {
+		return nil, errors.New("MarshalView requires a view name")
+	}
+	return MarshalJSON(v, append(opts[:len(opts):len(opts)], func(c *config) {
+		c.view = view
+	})...)
+}
+
+// Removes the named fields of v that do not belong to the view being
+// output from result, and returns the encoded overflow as the view's policy
+// has it.
+func (c *config) applyView(v interface{}, result, overflow map[string]*json.RawMessage) (map[string]*json.RawMessage, error) {
+	for _, f := range namedFields(structType(v)) {
+		if !inView(f, c.view) {
+			delete(result, f.name)
+		}
+	}
+
+	policy, ok := c.viewOverflow[c.view]
+	if !ok {
+		return nil, fmt.Errorf("Undeclared view '%s', declare it with WithViewOverflow", c.view)
+	}
+
+	switch policy {
+	case StripOverflow:
+		return nil, nil
+	case NamespaceOverflow:
+		if len(overflow) == 0 {
+			return nil, nil
+		}
+		nested, err := json.Marshal(overflow)
+		if err != nil {
+			return nil, err
+		}
+		key := DefaultViewNamespace
+		if k, ok := c.viewNamespace[c.view]; ok {
+			key = k
+		}
+		return map[string]*json.RawMessage{key: (*json.RawMessage)(&nested)}, nil
+	}
+	return overflow, nil
+}
+
+func inView(f field, view string) bool {
+	views, ok := j2nTag(f.tag)["view"]
+	if !ok {
+		return true
+	}
+	for _, name := range strings.Split(views, ",") {
+		if strings.TrimSpace(name) == view {
+			return true
+		}
+	}
+	return false
+}
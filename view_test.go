@@ -0,0 +1,111 @@
+package j2n
+
+import (
+	"testing"
+)
+
+type ViewUserData struct {
+	Name     string   `json:"name"`
+	Email    string   `json:"email" j2n:"view=self,admin"`
+	Notes    string   `json:"notes,omitempty" j2n:"view=admin"`
+	Overflow Overflow `json:"-"`
+}
+
+func TestMarshalView(t *testing.T) {
+	user := ViewUserData{Name: "bert", Email: "bert@example.com", Notes: "n", Overflow: newTestOverflow(map[string]string{"x-team": `"muppets"`})}
+
+	cases := map[string]string{
+		"public": `{"name":"bert","x-team":"muppets"}`,
+		"self":   `{"email":"bert@example.com","name":"bert","x-team":"muppets"}`,
+		"admin":  `{"email":"bert@example.com","name":"bert","notes":"n","x-team":"muppets"}`,
+	}
+	declared := []Option{
+		WithViewOverflow("public", IncludeOverflow),
+		WithViewOverflow("self", IncludeOverflow),
+		WithViewOverflow("admin", IncludeOverflow),
+	}
+	for view, expected := range cases {
+		output, err := MarshalView(&user, view, declared...)
+		if err != nil {
+			t.Fatalf("Expected no error, got '%s'", err)
+		}
+		if string(output) != expected {
+			t.Fatalf("Expected '%s' for view '%s', got '%s'", expected, view, output)
+		}
+	}
+
+	output, err := MarshalJSON(&user)
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(output) != cases["admin"] {
+		t.Fatalf("Expected MarshalJSON to output every field, got '%s'", output)
+	}
+
+	_, err = MarshalView(&user, "pubic", declared...)
+	expected := "Undeclared view 'pubic', declare it with WithViewOverflow"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestMarshalViewStripsOverflow(t *testing.T) {
+	Configure(ViewUserData{}, WithViewOverflow("public", StripOverflow))
+	defer Configure(ViewUserData{})
+
+	user := ViewUserData{Name: "bert", Overflow: newTestOverflow(map[string]string{"x-team": `"muppets"`})}
+	output, err := MarshalView(&user, "public")
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"name":"bert"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+
+	output, err = MarshalView(&user, "admin", WithViewOverflow("admin", IncludeOverflow))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"email":"","name":"bert","x-team":"muppets"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+}
+
+func TestMarshalViewNamespacesOverflow(t *testing.T) {
+	user := ViewUserData{Name: "bert", Overflow: newTestOverflow(map[string]string{"x-team": `"muppets"`})}
+
+	output, err := MarshalView(&user, "public", WithViewOverflow("public", NamespaceOverflow))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected := `{"extensions":{"x-team":"muppets"},"name":"bert"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+
+	output, err = MarshalView(&user, "public", WithViewNamespace("public", "ext"))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	expected = `{"ext":{"x-team":"muppets"},"name":"bert"}`
+	if string(output) != expected {
+		t.Fatalf("Expected '%s', got '%s'", expected, output)
+	}
+
+	_, err = MarshalView(&user, "public", WithViewNamespace("public", "name"))
+	expected = "Named field present in overflow: 'name'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected '%s', got '%v'", expected, err)
+	}
+
+	user.Overflow = nil
+	output, err = MarshalView(&user, "public", WithViewOverflow("public", NamespaceOverflow))
+	if err != nil {
+		t.Fatalf("Expected no error, got '%s'", err)
+	}
+	if string(output) != `{"name":"bert"}` {
+		t.Fatalf("Expected no namespace for empty overflow, got '%s'", output)
+	}
+}